@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"order-validation-v2/internal/usecase/imports"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//importOrders creates the orders of a CSV or JSON file in one transaction,
+//e.g.
+//
+//	order-validation import -dry-run orders.csv
+//
+//The format follows the file's extension unless -format is given. Every
+//invalid row is listed and nothing is created when there is one.
+func importOrders(i imports.UseCase, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	formatFlag := flags.String("format", "", "csv or json, defaults to the file's extension")
+	dryRun := flags.Bool("dry-run", false, "only report what would be created")
+	author := flags.String("author", "", "ID of the user the revisions are recorded for, none by default")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import [-format csv|json] [-dry-run] [-author id] file")
+	}
+	path := flags.Arg(0)
+	if *formatFlag == "" {
+		*formatFlag = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	format, ok := imports.ParseFormat(*formatFlag)
+	if !ok {
+		return fmt.Errorf("unknown format %q, use -format csv or -format json", *formatFlag)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := i.Import(format, f, *dryRun, *author)
+	if err != nil {
+		return err
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, e.Error())
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d errors in %s, nothing was imported", len(report.Errors), path)
+	}
+	for _, o := range report.Orders {
+		fmt.Printf("row %d: %s (%s, %s) with %d requirements\n", o.Row, o.Order.Title, o.Order.Status, o.Order.Priority, len(o.Requirements))
+	}
+	if report.DryRun {
+		fmt.Printf("Dry run: %d orders with %d requirements would be imported\n", len(report.Orders), report.RequirementCount())
+		return nil
+	}
+	fmt.Printf("Imported %d orders with %d requirements\n", len(report.Orders), report.RequirementCount())
+	return nil
+}
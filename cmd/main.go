@@ -4,12 +4,33 @@ import (
 	"database/sql"
 	"order-validation-v2/internal/controller"
 	"order-validation-v2/internal/infrastructure/repository"
+	"order-validation-v2/internal/usecase/apikeys"
+	"order-validation-v2/internal/usecase/attachments"
+	"order-validation-v2/internal/usecase/certificates"
+	"order-validation-v2/internal/usecase/comments"
+	"order-validation-v2/internal/usecase/customers"
+	"order-validation-v2/internal/usecase/exports"
+	"order-validation-v2/internal/usecase/imports"
+	"order-validation-v2/internal/usecase/labels"
+	"order-validation-v2/internal/usecase/loginguard"
 	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/policy"
 	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/revisions"
+	"order-validation-v2/internal/usecase/roles"
+	"order-validation-v2/internal/usecase/sessions"
+	"order-validation-v2/internal/usecase/sla"
+	"order-validation-v2/internal/usecase/sso"
 	"order-validation-v2/internal/usecase/submissions"
 	"order-validation-v2/internal/usecase/tasks"
+	"order-validation-v2/internal/usecase/templates"
+	"order-validation-v2/internal/usecase/tokens"
 	"order-validation-v2/internal/usecase/user"
+	"order-validation-v2/pkg/blobstore"
+	"order-validation-v2/pkg/keys"
 	"order-validation-v2/pkg/logger"
+	"order-validation-v2/pkg/mailer"
+	"order-validation-v2/pkg/oidc"
 	"os"
 
 	_ "github.com/lib/pq"
@@ -27,6 +48,23 @@ func main() {
 	orderRepo := repository.NewOrdersPSQL(db)
 	requirementRepo := repository.NewRequirementsPSQL(db)
 	userRepo := repository.NewUserPSQL(db)
+	tokenRepo := repository.NewTokenPSQL(db)
+	sessionRepo := repository.NewSessionPSQL(db)
+	roleRepo := repository.NewRolesPSQL(db)
+	policyRepo := repository.NewPolicyPSQL(db)
+	loginGuardRepo := repository.NewLoginGuardPSQL(db)
+	apiKeyRepo := repository.NewAPIKeyPSQL(db)
+	ssoRepo := repository.NewSSOPSQL(db)
+	templateRepo := repository.NewTemplatePSQL(db)
+	revisionRepo := repository.NewRevisionPSQL(db)
+	customerRepo := repository.NewCustomerPSQL(db)
+	slaRepo := repository.NewSLAPSQL(db)
+	labelsRepo := repository.NewLabelsPSQL(db)
+	attachmentRepo := repository.NewAttachmentPSQL(db)
+	importRepo := repository.NewImportPSQL(db)
+	exportRepo := repository.NewExportPSQL(db)
+	certificateRepo := repository.NewCertificatePSQL(db)
+	commentRepo := repository.NewCommentPSQL(db)
 	/*
 		db, err := sql.Open("mysql", "root:ergo@tcp(localhost:3306)/testers?parseTime=true")
 		if err != nil {
@@ -37,14 +75,93 @@ func main() {
 		orderRepo := repository.NewOrdersMySQL(db)
 		requirementRepo := repository.NewRequirementsMySQL(db)
 		userRepo := repository.NewUserMySQL(db)
+		tokenRepo := repository.NewTokenMySQL(db)
+		sessionRepo := repository.NewSessionMySQL(db)
+		roleRepo := repository.NewRolesMySQL(db)
+		policyRepo := repository.NewPolicyMySQL(db)
+		loginGuardRepo := repository.NewLoginGuardMySQL(db)
+		apiKeyRepo := repository.NewAPIKeyMySQL(db)
+		ssoRepo := repository.NewSSOMySQL(db)
+		templateRepo := repository.NewTemplateMySQL(db)
+		revisionRepo := repository.NewRevisionMySQL(db)
+		customerRepo := repository.NewCustomerMySQL(db)
+		slaRepo := repository.NewSLAMySQL(db)
+		labelsRepo := repository.NewLabelsMySQL(db)
+		attachmentRepo := repository.NewAttachmentMySQL(db)
+		importRepo := repository.NewImportMySQL(db)
+		exportRepo := repository.NewExportMySQL(db)
+		certificateRepo := repository.NewCertificateMySQL(db)
+		commentRepo := repository.NewCommentMySQL(db)
 	*/
-	orderService := orders.NewService(orderRepo)
 	requirementService := requirements.NewService(requirementRepo)
-	userService := user.NewService(userRepo)
+	passwordPolicy, err := user.LoadPasswordPolicy()
+	if err != nil {
+		panic(err)
+	}
+	userService := user.NewService(userRepo, user.NewPasswordHasher(os.Getenv("PASSWORD_HASHER")), passwordPolicy)
 	taskService := tasks.NewService(taskRepo)
+	orderService := orders.NewService(orderRepo, requirementService, taskService)
+	blobs, err := blobstore.LoadFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	attachmentService := attachments.NewService(attachmentRepo, blobs)
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		err = purge(orderService, attachmentService, os.Args[2:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	customerService := customers.NewService(customerRepo, orderService)
+	slaService := sla.NewService(slaRepo)
+	revisionService := revisions.NewService(revisionRepo, orderService, requirementService)
+	labelService := labels.NewService(labelsRepo)
+	importService := imports.NewService(importRepo, slaService, customerService, labelService)
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err = importOrders(importService, os.Args[2:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	templateService := templates.NewService(templateRepo, orderService, requirementService, taskService, userService)
+	exportService := exports.NewService(exportRepo)
+	commentService := comments.NewService(commentRepo, userService)
 	submissionService := submissions.NewService(submissionRepo)
+	sessionService := sessions.NewService(sessionRepo, tokens.DefaultRefreshTTL)
+	tokenService := tokens.NewService(tokenRepo, sessionService, tokens.DefaultRefreshTTL)
+	roleService := roles.NewService(roleRepo)
+	policyService := policy.NewService(policyRepo, roleService)
+	loginGuardService := loginguard.NewService(loginGuardRepo, loginguard.DefaultPolicy)
+	apiKeyService := apikeys.NewService(apiKeyRepo)
+	groupRoles, err := sso.ParseGroupRoles(os.Getenv("OIDC_ROLE_MAPPING"))
+	if err != nil {
+		panic(err)
+	}
+	ssoService := sso.NewService(ssoRepo, userService, roleService, sso.RoleMapping{Groups: groupRoles, DefaultRole: os.Getenv("OIDC_DEFAULT_ROLE")}, sso.Options{
+		LinkByEmail: os.Getenv("OIDC_LINK_BY_EMAIL") == "true",
+		SyncRoles:   os.Getenv("OIDC_SYNC_ROLES") == "true",
+	})
+	oidcProvider, err := oidc.LoadFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	keyManager, err := keys.LoadFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	documentKeys, err := keys.LoadDocumentKeysFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	certificateService := certificates.NewService(certificateRepo, orderService, documentKeys)
+	mail, err := mailer.LoadFromEnv()
+	if err != nil {
+		panic(err)
+	}
 	c := controller.NewController(orderService, userService, requirementService,
-		taskService, submissionService, logger)
+		taskService, submissionService, templateService, revisionService, customerService, slaService, labelService, attachmentService, importService, exportService, certificateService, commentService, tokenService, sessionService, roleService, policyService, loginGuardService, apiKeyService, ssoService, oidcProvider, keyManager, documentKeys, mail, logger)
 	c.RegisterHandler()
 	c.Start()
 
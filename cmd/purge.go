@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"order-validation-v2/internal/usecase/attachments"
+	"order-validation-v2/internal/usecase/orders"
+	"os"
+	"time"
+)
+
+//purge permanently removes orders, requirements, tasks and submissions
+//that were deleted longer ago than the retention period, along with the
+//attachments of the purged orders and requirements, e.g.
+//
+//	order-validation purge -retention 720h
+//
+//The retention defaults to PURGE_RETENTION, or 30 days when that is unset.
+func purge(o orders.UseCase, a attachments.UseCase, args []string) error {
+	retention := orders.DefaultRetention
+	if env := os.Getenv("PURGE_RETENTION"); env != "" {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return fmt.Errorf("PURGE_RETENTION: %w", err)
+		}
+		retention = d
+	}
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	flags.DurationVar(&retention, "retention", retention, "how long deleted records are kept")
+	flags.Parse(args)
+
+	purged, err := o.Purge(retention)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Purged %d rows deleted before %s\n", purged, time.Now().Add(-retention).Format("2/Jan/2006 15:04:05"))
+	removed, err := a.PurgeOrphans()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d attachments of purged orders and requirements\n", removed)
+	return nil
+}
@@ -9,4 +9,5 @@ require (
 	github.com/gorilla/mux v1.6.2
 	github.com/lib/pq v1.10.4
 	github.com/rs/cors v1.8.0
+	golang.org/x/crypto v0.10.0
 )
@@ -10,6 +10,31 @@
 -- CREATE TABLE requirements(id SERIAL PRIMARY KEY,request varchar(50),expectedoutcome varchar(50),orderid varchar(37),userid varchar(37),status bool,FOREIGN KEY(orderid) REFERENCES orders(id),FOREIGN KEY (userid) references users(id));
 
 -- CREATE TABLE users(id varchar(37) PRIMARY KEY, username varchar(50),email varchar(50),pswd varchar (100));
+drop table if exists refresh_tokens;
+drop table if exists sessions;
+drop table if exists revoked_tokens;
+drop table if exists token_revocations;
+drop table if exists login_attempts;
+drop table if exists login_counters;
+drop table if exists recovery_codes;
+drop table if exists password_history;
+drop table if exists api_key_permissions;
+drop table if exists api_keys;
+drop table if exists user_identities;
+drop table if exists revisions;
+drop table if exists comment_mentions;
+drop table if exists comment_edits;
+drop table if exists comments;
+drop table if exists certificates;
+drop table if exists attachments;
+drop table if exists resource_fields;
+drop table if exists resource_tags;
+drop table if exists custom_fields;
+drop table if exists share_links;
+drop table if exists template_task_prerequisites;
+drop table if exists template_tasks;
+drop table if exists template_requirements;
+drop table if exists order_templates;
 drop table if exists image_submissions;
 drop table if exists submissions;
 drop table if exists tasks;
@@ -17,21 +42,80 @@ drop table if exists tasks;
 
 drop table if exists requirements ;
 DROP table if exists orders;
+drop table if exists customer_contacts;
+drop table if exists customers;
+drop table if exists sla_policies;
 DROP table if exists users;
+drop table if exists role_permissions;
+drop table if exists roles;
+
+CREATE TABLE customers(
+    id varchar(37) PRIMARY KEY,
+    name varchar(100),
+    created_at timestamp
+);
+
+CREATE TABLE customer_contacts(
+    customer_id varchar(37),
+    position int,
+    name varchar(100),
+    email varchar(100),
+    phone varchar(30),
+    PRIMARY KEY (customer_id, position),
+    FOREIGN KEY (customer_id) REFERENCES customers(id)
+);
+
+-- targets are in seconds, 0 isn't tracked
+CREATE TABLE sla_policies(
+    name varchar(50) PRIMARY KEY,
+    description varchar(255),
+    target_start int DEFAULT 0,
+    target_completion int DEFAULT 0,
+    review_turnaround int DEFAULT 0
+);
 
 CREATE TABLE orders(
     id varchar(37) PRIMARY KEY,
     title varchar(50),
     description varchar(255),
-    deadline timestamp
+    deadline timestamp,
+    status varchar(20) DEFAULT 'open',
+    priority varchar(10) DEFAULT 'normal',
+    sla_policy varchar(50) NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    customer_id varchar(37) NULL,
+    deleted_at timestamp NULL,
+    FOREIGN KEY (customer_id) REFERENCES customers(id),
+    FOREIGN KEY (sla_policy) REFERENCES sla_policies(name)
+);
+
+CREATE TABLE roles(
+    name varchar(50) PRIMARY KEY,
+    description varchar(255),
+    builtin bool DEFAULT false,
+    require_2fa bool DEFAULT false
+);
+
+CREATE TABLE role_permissions(
+    role_name varchar(50),
+    permission varchar(50),
+    PRIMARY KEY (role_name, permission),
+    FOREIGN KEY (role_name) REFERENCES roles(name)
 );
 
 CREATE TABLE users(
 	id varchar(37) PRIMARY KEY,
     username varchar(50),
     pswd varchar (256),
-    email varchar(30),
-    user_role varchar(7)
+    email varchar(255),
+    user_role varchar(50),
+    disabled bool DEFAULT false,
+    service_account bool DEFAULT false,
+    totp_secret varchar(64),
+    totp_enabled bool DEFAULT false,
+    totp_last_step bigint DEFAULT 0,
+    password_changed_at timestamp DEFAULT now(),
+    FOREIGN KEY (user_role) REFERENCES roles(name)
 );
 CREATE TABLE requirements(
     id SERIAL PRIMARY KEY,
@@ -39,6 +123,7 @@ CREATE TABLE requirements(
     expected_outcome varchar(50),
     order_id varchar(37),
     status smallint,
+    deleted_at timestamp NULL,
     FOREIGN KEY(order_id) REFERENCES orders(id)
 );
 
@@ -53,6 +138,9 @@ CREATE TABLE tasks(
     num_of_prerequisite int,
     deadline timestamp,
     total_reviewer smallint,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at timestamp NULL,
+    deleted_at timestamp NULL,
     FOREIGN KEY (requirement_id) REFERENCES requirements(id),
     FOREIGN KEY (user_id) REFERENCES users(id),
     FOREIGN KEY (assigner_id) REFERENCES users(id)
@@ -66,9 +154,13 @@ CREATE TABLE forwarded_review(
 );
 
 
+-- resolved_at is set once the prerequisite is submitted, the edge is kept
+-- so templates made from the order keep the plan
 CREATE TABLE prerequisite(
 	task_id varchar(37),
-    prerequisite varchar(37)
+    prerequisite varchar(37),
+    resolved_at timestamp NULL,
+    deleted_at timestamp NULL
 );
 
 CREATE TABLE submissions(
@@ -76,6 +168,7 @@ CREATE TABLE submissions(
     submit_time timestamp,
     message varchar(255),
     task_id varchar(37),
+    deleted_at timestamp NULL,
     FOREIGN KEY (task_id) REFERENCES tasks(id)
 );
 
@@ -90,11 +183,287 @@ CREATE TABLE review_messages(
     task_id varchar(37),
     user_id varchar(37),
     message varchar(1024),
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (task_id) REFERENCES tasks(id),
     FOREIGN KEY (user_id) REFERENCES users(id)
 );
 
+CREATE TABLE refresh_tokens(
+    id varchar(37) PRIMARY KEY,
+    family_id varchar(37),
+    user_id varchar(37),
+    token_hash varchar(64) UNIQUE,
+    issued_at timestamp,
+    expires_at timestamp,
+    used bool DEFAULT false,
+    revoked bool DEFAULT false,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX refresh_tokens_family ON refresh_tokens(family_id);
+
+CREATE TABLE sessions(
+    id varchar(37) PRIMARY KEY,
+    user_id varchar(37),
+    user_agent varchar(255),
+    ip varchar(45),
+    created_at timestamp,
+    last_seen_at timestamp,
+    revoked bool DEFAULT false,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE revoked_tokens(
+    jti varchar(37) PRIMARY KEY,
+    expires_at timestamp
+);
+
+CREATE TABLE token_revocations(
+    user_id varchar(37) PRIMARY KEY,
+    revoked_at timestamp,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE recovery_codes(
+    user_id varchar(37),
+    code_hash varchar(64),
+    used_at timestamp NULL,
+    PRIMARY KEY (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE password_history(
+    user_id varchar(37),
+    pswd varchar(256),
+    replaced_at timestamp,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
 
+CREATE TABLE api_keys(
+    id varchar(37) PRIMARY KEY,
+    name varchar(50),
+    user_id varchar(37),
+    prefix varchar(20),
+    key_hash varchar(64) UNIQUE,
+    created_by varchar(37),
+    created_at timestamp,
+    expires_at timestamp NULL,
+    last_used_at timestamp NULL,
+    revoked bool DEFAULT false,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE api_key_permissions(
+    key_id varchar(37),
+    permission varchar(50),
+    PRIMARY KEY (key_id, permission),
+    FOREIGN KEY (key_id) REFERENCES api_keys(id)
+);
+
+CREATE TABLE user_identities(
+    issuer varchar(255),
+    subject varchar(255),
+    user_id varchar(37),
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE order_templates(
+    id varchar(37) PRIMARY KEY,
+    name varchar(50),
+    title varchar(50),
+    description varchar(255),
+    deadline_offset bigint DEFAULT 0,
+    created_by varchar(37),
+    created_at timestamp
+);
+
+CREATE TABLE template_requirements(
+    template_id varchar(37),
+    position int,
+    request varchar(50),
+    expected_outcome varchar(50),
+    PRIMARY KEY (template_id, position),
+    FOREIGN KEY (template_id) REFERENCES order_templates(id)
+);
+
+CREATE TABLE template_tasks(
+    template_id varchar(37),
+    task_key varchar(37),
+    requirement_position int,
+    user_id varchar(37),
+    note varchar(200),
+    deadline_offset bigint DEFAULT 0,
+    PRIMARY KEY (template_id, task_key),
+    FOREIGN KEY (template_id) REFERENCES order_templates(id)
+);
+
+CREATE TABLE template_task_prerequisites(
+    template_id varchar(37),
+    task_key varchar(37),
+    prerequisite_key varchar(37),
+    PRIMARY KEY (template_id, task_key, prerequisite_key),
+    FOREIGN KEY (template_id) REFERENCES order_templates(id)
+);
+
+CREATE TABLE revisions(
+    id varchar(37) PRIMARY KEY,
+    resource_type varchar(20),
+    resource_id varchar(37),
+    number int,
+    author_id varchar(37),
+    created_at timestamp,
+    snapshot text,
+    changes text,
+    restored_of int DEFAULT 0,
+    UNIQUE (resource_type, resource_id, number)
+);
+
+CREATE TABLE share_links(
+    id varchar(37) PRIMARY KEY,
+    customer_id varchar(37),
+    order_id varchar(37),
+    created_by varchar(37),
+    created_at timestamp,
+    expires_at timestamp,
+    revoked bool DEFAULT false,
+    FOREIGN KEY (customer_id) REFERENCES customers(id),
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX share_links_order ON share_links(order_id);
+
+-- options and resources are comma separated, no resources means all of them
+CREATE TABLE custom_fields(
+    name varchar(50) PRIMARY KEY,
+    type varchar(10),
+    options varchar(500),
+    resources varchar(100)
+);
+
+CREATE TABLE resource_tags(
+    resource_type varchar(20),
+    resource_id varchar(37),
+    tag varchar(50),
+    PRIMARY KEY (resource_type, resource_id, tag)
+);
+
+CREATE INDEX resource_tags_tag ON resource_tags (resource_type, tag);
+
+CREATE TABLE resource_fields(
+    resource_type varchar(20),
+    resource_id varchar(37),
+    field varchar(50),
+    value varchar(255),
+    PRIMARY KEY (resource_type, resource_id, field),
+    FOREIGN KEY (field) REFERENCES custom_fields(name)
+);
+
+CREATE INDEX resource_fields_value ON resource_fields (resource_type, field, value);
+
+-- the content of an attachment is in blob storage under its id
+CREATE TABLE attachments(
+    id varchar(37) PRIMARY KEY,
+    resource_type varchar(20),
+    resource_id varchar(37),
+    filename varchar(255),
+    mime_type varchar(255),
+    size bigint,
+    checksum char(64),
+    uploaded_by varchar(37),
+    uploaded_at timestamp
+);
+
+CREATE INDEX attachments_resource ON attachments (resource_type, resource_id);
+
+-- content is the JSON of what the certificate attests, token the JWT signing its digest
+CREATE TABLE certificates(
+    id varchar(37) PRIMARY KEY,
+    order_id varchar(37),
+    issued_at timestamp,
+    content text,
+    digest char(64),
+    token text,
+    document_checksum char(64),
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+
+CREATE INDEX certificates_order ON certificates (order_id, issued_at);
+
+-- a comment starts a thread when thread_id is null, body is Markdown. Users are
+-- deleted outright, so authors and mentions don't reference them with foreign keys.
+CREATE TABLE comments(
+    id varchar(37) PRIMARY KEY,
+    resource_type varchar(20),
+    resource_id varchar(37),
+    thread_id varchar(37) NULL,
+    author_id varchar(37),
+    body text,
+    created_at timestamp,
+    edited_at timestamp NULL,
+    deleted_at timestamp NULL,
+    deleted_by varchar(37) NULL,
+    FOREIGN KEY (thread_id) REFERENCES comments(id)
+);
+
+CREATE INDEX comments_resource ON comments (resource_type, resource_id, created_at);
+CREATE INDEX comments_thread ON comments (thread_id);
+
+-- the body a comment had before each edit, and before it was deleted
+CREATE TABLE comment_edits(
+    id SERIAL PRIMARY KEY,
+    comment_id varchar(37),
+    body text,
+    edited_by varchar(37),
+    edited_at timestamp,
+    FOREIGN KEY (comment_id) REFERENCES comments(id)
+);
+
+CREATE TABLE comment_mentions(
+    comment_id varchar(37),
+    user_id varchar(37),
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments(id)
+);
+
+CREATE TABLE login_counters(
+    counter_key varchar(100) PRIMARY KEY,
+    failures int,
+    last_failure timestamp,
+    locked bool DEFAULT false
+);
+
+CREATE TABLE login_attempts(
+    id SERIAL PRIMARY KEY,
+    username varchar(50),
+    user_id varchar(37),
+    ip varchar(45),
+    user_agent varchar(255),
+    outcome varchar(20),
+    suspicious bool,
+    attempted_at timestamp
+);
+
+
+
+INSERT INTO roles (name, description, builtin) VALUES
+('Admin', 'Full access, always holds every permission', true),
+('Manager', 'Creates orders and assigns tasks', true),
+('Reviewer', 'Reviews submitted tasks', true),
+('Worker', 'Works on assigned tasks', true),
+('Viewer', 'Read-only access to orders and tasks', true);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+('Manager', 'order:read'),
+('Manager', 'order:write'),
+('Manager', 'task:read'),
+('Manager', 'task:assign'),
+('Manager', 'submission:review'),
+('Manager', 'user:read'),
+('Reviewer', 'order:read'),
+('Reviewer', 'task:read'),
+('Reviewer', 'submission:review'),
+('Worker', 'task:work'),
+('Viewer', 'order:read'),
+('Viewer', 'task:read');
 
 INSERT INTO users 
 (id, username, pswd, email, user_role)
@@ -102,6 +471,7 @@ VALUES ('cd75bf2e-0876-46b4-a7a2-355ba2e8e034', 'elloy', sha256('100300'), 'ello
 
 INSERT INTO users 
 (id, username, pswd, email, user_role)
-VALUES ('10b16316-ec54-4fdf-9a30-8deded11f633', 'jorich', sha256('100300'), 'jorich@elloy.com', 'User');
-
+VALUES ('10b16316-ec54-4fdf-9a30-8deded11f633', 'jorich', sha256('100300'), 'jorich@elloy.com', 'Worker');
 
+-- passwords set before password_changed_at was tracked start their max age now
+UPDATE users SET password_changed_at = now() WHERE password_changed_at IS NULL AND pswd <> '';
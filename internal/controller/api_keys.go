@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) NewServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var account models.NewServiceAccount
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &account)
+	if err != nil || account.Username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	_, err = c.roles.GetRole(account.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf("Unknown role %s", account.Role)))
+		return
+	}
+	exists, err := c.user.ValidateUsername(account.Username)
+	if exists {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("Username Exists"))
+		return
+	}
+	id, err := c.user.CreateServiceAccount(account.Username, account.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while creating service account: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("Service account %s has been added with id %s\n", account.Username, id)))
+}
+
+func (c *Controller) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: userID}) {
+		return
+	}
+	var form models.NewAPIKey
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil || form.Name == "" || form.ExpiresInDays < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	account, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("User Not Found"))
+		return
+	}
+	if !account.ServiceAccount {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("API keys can only be issued to service accounts"))
+		return
+	}
+	role, err := c.roles.GetRole(account.UserRole)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving role: ", err.Error())
+		return
+	}
+	for _, p := range form.Permissions {
+		if !role.Has(p) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Role %s lacks %s", role.Name, p)))
+			return
+		}
+	}
+	creator := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	ttl := time.Duration(form.ExpiresInDays) * 24 * time.Hour
+	key, k, err := c.apikeys.CreateKey(account.ID, form.Name, form.Permissions, creator, ttl)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		c.logger.ErrorLogger.Println("Error creating API key: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.BuildCreatedAPIKeyPayload(key, k))
+}
+
+func (c *Controller) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermUserRead, entity.Resource{Type: entity.ResourceUser, ID: userID}) {
+		return
+	}
+	keys, err := c.apikeys.ListKeys(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving API keys: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildAPIKeyPayload(keys))
+}
+
+func (c *Controller) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	k, err := c.apikeys.GetKey(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("API Key Not Found"))
+		return
+	}
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: k.UserID}) {
+		return
+	}
+	err = c.apikeys.RevokeKey(k.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking API key: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("API key %s has been revoked", k.Name)))
+}
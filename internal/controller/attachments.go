@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/attachments"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetOrderAttachments(w http.ResponseWriter, r *http.Request) {
+	c.listAttachments(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) GetRequirementAttachments(w http.ResponseWriter, r *http.Request) {
+	c.listAttachments(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) UploadOrderAttachment(w http.ResponseWriter, r *http.Request) {
+	c.uploadAttachment(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) UploadRequirementAttachment(w http.ResponseWriter, r *http.Request) {
+	c.uploadAttachment(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	a, err := c.attachments.GetAttachment(mux.Vars(r)["id"])
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderRead, a.Resource) {
+		return
+	}
+	c.serveAttachment(w, a.ID)
+}
+
+func (c *Controller) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	a, err := c.attachments.GetAttachment(mux.Vars(r)["id"])
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderWrite, a.Resource) {
+		return
+	}
+	if !c.ensureResourceOpen(w, a.Resource) {
+		return
+	}
+	err = c.attachments.DeleteAttachment(a.ID)
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Attachment Deleted"))
+}
+
+//DownloadTaskAttachment lets the worker on a task download the attachments
+//of the task's requirement
+func (c *Controller) DownloadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermTaskWork, entity.Resource{Type: entity.ResourceTask, ID: taskID}) {
+		return
+	}
+	task, err := c.task.Get(taskID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Task Not Found"))
+		return
+	}
+	a, err := c.attachments.GetAttachment(mux.Vars(r)["attachment"])
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	if a.Resource != (entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(task.RequirementID)}) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(attachments.ErrNotFound.Error()))
+		return
+	}
+	c.serveAttachment(w, a.ID)
+}
+
+func (c *Controller) listAttachments(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderRead, resource) {
+		return
+	}
+	if !c.ensureResource(w, resource) {
+		return
+	}
+	list, err := c.attachments.ListAttachments(resource)
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildAttachments(list))
+}
+
+//uploadAttachment reads the file from the "file" part of a multipart form
+//and streams it to storage without buffering the whole request
+func (c *Controller) uploadAttachment(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderWrite, resource) {
+		return
+	}
+	if !c.ensureResourceOpen(w, resource) {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, expected a multipart form"))
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request, missing file"))
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+		a, err := c.attachments.Upload(resource, part.FileName(), part.Header.Get("Content-Type"), adminID, part)
+		part.Close()
+		if c.writeAttachmentError(w, err) {
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.BuildAttachmentPayload(a))
+		return
+	}
+}
+
+func (c *Controller) serveAttachment(w http.ResponseWriter, id string) {
+	a, content, err := c.attachments.Open(id)
+	if c.writeAttachmentError(w, err) {
+		return
+	}
+	defer content.Close()
+	w.Header().Set("Content-Type", a.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+a.Checksum+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, content)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error sending attachment %s: %s\n", a.ID, err.Error())
+	}
+}
+
+//ensureResourceOpen writes the error and returns false when files can't be
+//attached to the resource because it doesn't exist or its order is closed
+func (c *Controller) ensureResourceOpen(w http.ResponseWriter, resource entity.Resource) bool {
+	if resource.Type == entity.ResourceRequirement {
+		id, err := strconv.Atoi(resource.ID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request, Requirement Does Not Exist"))
+			return false
+		}
+		return c.ensureRequirementOpen(w, id)
+	}
+	return c.ensureOrderOpen(w, resource.ID)
+}
+
+//addTaskAttachments adds the attachments of their requirements to the
+//tasks of the payload
+func (c *Controller) addTaskAttachments(response []*models.TaskWithDetail, list []*entity.TaskWithDetails) error {
+	var requirementIDs []int
+	for _, t := range list {
+		requirementIDs = append(requirementIDs, t.RequirementID)
+	}
+	grouped, err := c.attachments.ListRequirementAttachments(requirementIDs)
+	if err != nil {
+		return err
+	}
+	byTask := map[string][]*entity.Attachment{}
+	for _, t := range list {
+		byTask[t.ID] = grouped[t.RequirementID]
+	}
+	models.AddTaskAttachments(response, byTask)
+	return nil
+}
+
+func (c *Controller) writeAttachmentError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, attachments.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, attachments.ErrInvalidAttachment):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, attachments.ErrTooLarge):
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling attachment: ", err.Error())
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/certificates"
+	"order-validation-v2/internal/usecase/orders"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+//maxCertificateSize bounds the documents uploaded to /verify
+const maxCertificateSize = 5 << 20
+
+func (c *Controller) GetOrderCertificates(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	if !c.ensureResource(w, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	list, err := c.certificates.ListCertificates(orderID)
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCertificates(list))
+}
+
+//DownloadOrderCertificate sends the order's latest certificate as a PDF
+func (c *Controller) DownloadOrderCertificate(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	cert, err := c.certificates.GetLatest(orderID)
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	c.serveCertificate(w, cert)
+}
+
+func (c *Controller) DownloadCertificate(w http.ResponseWriter, r *http.Request) {
+	cert, err := c.certificates.GetCertificate(mux.Vars(r)["id"])
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: cert.Content.OrderID}) {
+		return
+	}
+	c.serveCertificate(w, cert)
+}
+
+//IssueOrderCertificate certifies a validated order on demand, for orders
+//validated before certificates were issued automatically. The latest
+//certificate is returned while the evidence hasn't changed.
+func (c *Controller) IssueOrderCertificate(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	cert, err := c.certificates.Issue(orderID)
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCertificatePayload(cert))
+}
+
+//VerifyCertificate is public. It takes the certificate's token as ?token=
+//or, when posted, the certificate document itself. A certificate that
+//doesn't verify is still answered with 200 and the checks that failed.
+func (c *Controller) VerifyCertificate(w http.ResponseWriter, r *http.Request) {
+	var verification *entity.CertificateVerification
+	var err error
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxCertificateSize)
+		document, readErr := ioutil.ReadAll(r.Body)
+		if readErr != nil || !bytes.HasPrefix(document, []byte("%PDF-")) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request, expected a certificate PDF"))
+			return
+		}
+		verification, err = c.certificates.VerifyDocument(document)
+	} else {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request, missing token"))
+			return
+		}
+		verification, err = c.certificates.Verify(token)
+	}
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCertificateVerificationPayload(verification))
+}
+
+//issueCertificate certifies the order once it is validated. An order whose
+//certificate failed stays validated and can be certified again by hand.
+func (c *Controller) issueCertificate(o *entity.Orders) {
+	if o.Status != entity.OrderValidated {
+		return
+	}
+	_, err := c.certificates.Issue(o.ID)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error issuing certificate of order %s: %s\n", o.ID, err.Error())
+	}
+}
+
+func (c *Controller) serveCertificate(w http.ResponseWriter, cert *entity.Certificate) {
+	var document bytes.Buffer
+	err := c.certificates.WritePDF(cert, &document)
+	if c.writeCertificateError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "certificate-" + cert.ID + ".pdf"}))
+	w.Header().Set("ETag", `"`+cert.DocumentChecksum+`"`)
+	w.WriteHeader(http.StatusOK)
+	document.WriteTo(w)
+}
+
+func (c *Controller) writeCertificateError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, certificates.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Certificate Not Found"))
+	case errors.Is(err, orders.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Order Not Found"))
+	case errors.Is(err, certificates.ErrNotValidated):
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling certificate: ", err.Error())
+	}
+	return true
+}
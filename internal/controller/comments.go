@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/comments"
+	"order-validation-v2/pkg/mailer"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetOrderComments(w http.ResponseWriter, r *http.Request) {
+	c.listComments(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) GetRequirementComments(w http.ResponseWriter, r *http.Request) {
+	c.listComments(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) PostOrderComment(w http.ResponseWriter, r *http.Request) {
+	c.postComment(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) PostRequirementComment(w http.ResponseWriter, r *http.Request) {
+	c.postComment(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) ReplyToComment(w http.ResponseWriter, r *http.Request) {
+	parent, err := c.comments.GetComment(mux.Vars(r)["id"])
+	if c.writeCommentError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderRead, parent.Resource) {
+		return
+	}
+	form, ok := readCommentForm(w, r)
+	if !ok {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	comment, err := c.comments.Reply(parent.ID, adminID, form.Body)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	c.notifyMentions(comment, comment.Mentions)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.BuildCommentPayload(comment))
+}
+
+//EditComment is only open to the comment's author. The previous body is
+//kept in the history and only users the edit newly mentions are notified.
+func (c *Controller) EditComment(w http.ResponseWriter, r *http.Request) {
+	comment, err := c.comments.GetComment(mux.Vars(r)["id"])
+	if c.writeCommentError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderRead, comment.Resource) {
+		return
+	}
+	form, ok := readCommentForm(w, r)
+	if !ok {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	comment, added, err := c.comments.Edit(comment.ID, adminID, form.Body)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	c.notifyMentions(comment, added)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCommentPayload(comment))
+}
+
+//DeleteComment lets authors delete their comments and anyone who can
+//change the order moderate the others
+func (c *Controller) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, err := c.comments.GetComment(mux.Vars(r)["id"])
+	if c.writeCommentError(w, err) {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	perm := entity.PermOrderWrite
+	if comment.AuthorID == adminID {
+		perm = entity.PermOrderRead
+	}
+	if !c.authorize(w, r, perm, comment.Resource) {
+		return
+	}
+	err = c.comments.Delete(comment.ID, adminID)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Comment Deleted"))
+}
+
+func (c *Controller) GetCommentHistory(w http.ResponseWriter, r *http.Request) {
+	comment, err := c.comments.GetComment(mux.Vars(r)["id"])
+	if c.writeCommentError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderRead, comment.Resource) {
+		return
+	}
+	edits, err := c.comments.History(comment.ID)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCommentHistory(edits))
+}
+
+//listComments pages through the threads of the resource, newest first,
+//with ?limit= and ?offset=
+func (c *Controller) listComments(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderRead, resource) {
+		return
+	}
+	if !c.ensureResource(w, resource) {
+		return
+	}
+	query := r.URL.Query()
+	limit, offset := 0, 0
+	var err error
+	if query.Get("limit") != "" {
+		limit, err = strconv.Atoi(query.Get("limit"))
+	}
+	if err == nil && query.Get("offset") != "" {
+		offset, err = strconv.Atoi(query.Get("offset"))
+	}
+	if err != nil || limit < 0 || offset < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, limit and offset must be positive numbers"))
+		return
+	}
+	threads, total, err := c.comments.ListThreads(resource, limit, offset)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCommentPage(threads, total, comments.PageLimit(limit), offset))
+}
+
+func (c *Controller) postComment(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderRead, resource) {
+		return
+	}
+	form, ok := readCommentForm(w, r)
+	if !ok {
+		return
+	}
+	if !c.ensureResource(w, resource) {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	comment, err := c.comments.Post(resource, adminID, form.Body)
+	if c.writeCommentError(w, err) {
+		return
+	}
+	c.notifyMentions(comment, comment.Mentions)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.BuildCommentPayload(comment))
+}
+
+func readCommentForm(w http.ResponseWriter, r *http.Request) (models.CommentForm, bool) {
+	var form models.CommentForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return form, false
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return form, false
+	}
+	return form, true
+}
+
+//notifyMentions emails the mentioned users, except the author. The email
+//only links to the comment, so nothing is disclosed to users who can't
+//read the order. The comment stands whether or not the emails go out.
+func (c *Controller) notifyMentions(comment *entity.Comment, mentions []entity.Mention) {
+	if len(mentions) == 0 {
+		return
+	}
+	link, err := c.commentLink(comment)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error linking comment %s: %s\n", comment.ID, err.Error())
+		return
+	}
+	for _, m := range mentions {
+		if m.UserID == comment.AuthorID {
+			continue
+		}
+		u, err := c.user.GetUserbyID(m.UserID)
+		if err != nil || u.Disabled || u.Email == "" {
+			continue
+		}
+		err = c.mailer.Send(mailer.Message{
+			To:      u.Email,
+			Subject: fmt.Sprintf("%s mentioned you in a comment", comment.AuthorName),
+			Body: fmt.Sprintf("Hello %s,\n\n"+
+				"%s mentioned you in a comment on %s %s:\n\n%s\n",
+				u.Username, comment.AuthorName, comment.Resource.Type, comment.Resource.ID, link),
+		})
+		if err != nil {
+			c.logger.ErrorLogger.Printf("Error notifying %s of comment %s: %s\n", u.Username, comment.ID, err.Error())
+		}
+	}
+}
+
+//commentLink links to the comment on its order's page
+func (c *Controller) commentLink(comment *entity.Comment) (string, error) {
+	orderID := comment.Resource.ID
+	if comment.Resource.Type == entity.ResourceRequirement {
+		id, err := strconv.Atoi(comment.Resource.ID)
+		if err != nil {
+			return "", err
+		}
+		req, err := c.requirements.GetRequirementbyID(id)
+		if err != nil {
+			return "", err
+		}
+		orderID = req.OrderID
+	}
+	return appURL("/orders/" + orderID + "#comment-" + comment.ID), nil
+}
+
+func (c *Controller) writeCommentError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, comments.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Comment Not Found"))
+	case errors.Is(err, comments.ErrInvalidComment):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, comments.ErrNotAuthor):
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, comments.ErrDeleted):
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling comment: ", err.Error())
+	}
+	return true
+}
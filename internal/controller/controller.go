@@ -2,12 +2,33 @@ package controller
 
 import (
 	"net/http"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/apikeys"
+	"order-validation-v2/internal/usecase/attachments"
+	"order-validation-v2/internal/usecase/certificates"
+	"order-validation-v2/internal/usecase/comments"
+	"order-validation-v2/internal/usecase/customers"
+	"order-validation-v2/internal/usecase/exports"
+	"order-validation-v2/internal/usecase/imports"
+	"order-validation-v2/internal/usecase/labels"
+	"order-validation-v2/internal/usecase/loginguard"
 	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/policy"
 	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/revisions"
+	"order-validation-v2/internal/usecase/roles"
+	"order-validation-v2/internal/usecase/sessions"
+	"order-validation-v2/internal/usecase/sla"
+	"order-validation-v2/internal/usecase/sso"
 	"order-validation-v2/internal/usecase/submissions"
 	"order-validation-v2/internal/usecase/tasks"
+	"order-validation-v2/internal/usecase/templates"
+	"order-validation-v2/internal/usecase/tokens"
 	"order-validation-v2/internal/usecase/user"
+	"order-validation-v2/pkg/keys"
 	"order-validation-v2/pkg/logger"
+	"order-validation-v2/pkg/mailer"
+	"order-validation-v2/pkg/oidc"
 	"os"
 
 	"github.com/rs/cors"
@@ -22,52 +43,182 @@ type Controller struct {
 	task         tasks.UseCase
 	submissions  submissions.UseCase
 	requirements requirements.UseCase
+	templates    templates.UseCase
+	revisions    revisions.UseCase
+	customers    customers.UseCase
+	sla          sla.UseCase
+	labels       labels.UseCase
+	attachments  attachments.UseCase
+	imports      imports.UseCase
+	exports      exports.UseCase
+	certificates certificates.UseCase
+	comments     comments.UseCase
+	tokens       tokens.UseCase
+	sessions     sessions.UseCase
+	roles        roles.UseCase
+	policy       policy.UseCase
+	guard        loginguard.UseCase
+	apikeys      apikeys.UseCase
+	sso          sso.UseCase
+	oidc         *oidc.Provider
+	keys         *keys.Manager
+	documents    *keys.Manager
+	mailer       mailer.Mailer
 	logger       *logger.LoggerInstance
 }
 
-func NewController(o orders.UseCase, u user.UseCase, r requirements.UseCase, t tasks.UseCase, s submissions.UseCase, l *logger.LoggerInstance) *Controller {
+func NewController(o orders.UseCase, u user.UseCase, r requirements.UseCase, t tasks.UseCase, s submissions.UseCase, tp templates.UseCase, rv revisions.UseCase, cu customers.UseCase, sl sla.UseCase, lb labels.UseCase, at attachments.UseCase, im imports.UseCase, ex exports.UseCase, ce certificates.UseCase, cm comments.UseCase, tk tokens.UseCase, se sessions.UseCase, ro roles.UseCase, p policy.UseCase, g loginguard.UseCase, ak apikeys.UseCase, ss sso.UseCase, op *oidc.Provider, k *keys.Manager, dk *keys.Manager, m mailer.Mailer, l *logger.LoggerInstance) *Controller {
 	router := mux.NewRouter().StrictSlash(true)
-	controller := &Controller{router: router, order: o, user: u, requirements: r, task: t, submissions: s, logger: l}
+	controller := &Controller{router: router, order: o, user: u, requirements: r, task: t, submissions: s, templates: tp, revisions: rv, customers: cu, sla: sl, labels: lb, attachments: at, imports: im, exports: ex, certificates: ce, comments: cm, tokens: tk, sessions: se, roles: ro, policy: p, guard: g, apikeys: ak, sso: ss, oidc: op, keys: k, documents: dk, mailer: m, logger: l}
 	return controller
 }
 
 func (c *Controller) RegisterHandler() {
+	c.router.HandleFunc("/.well-known/jwks.json", c.GetJWKS).Methods("GET")
+
 	login := c.router.PathPrefix("/login").Subrouter()
 	login.HandleFunc("/", c.Login).Methods("POST")
+	login.HandleFunc("/refresh", c.RefreshLogin).Methods("POST")
+	login.HandleFunc("/2fa", c.LoginSecondFactor).Methods("POST")
+	login.HandleFunc("/2fa/enroll", c.LoginEnrollSecondFactor).Methods("POST")
+	login.HandleFunc("/password", c.ChangeExpiredPassword).Methods("POST")
+	if c.oidc != nil {
+		login.HandleFunc("/oidc", c.StartOIDCLogin).Methods("GET")
+		login.HandleFunc("/oidc/callback", c.OIDCCallback).Methods("GET")
+	}
+
+	c.router.HandleFunc("/invitation/accept", c.AcceptInvitation).Methods("POST")
+	c.router.HandleFunc("/password/forgot", c.ForgotPassword).Methods("POST")
+	c.router.HandleFunc("/password/reset", c.ResetPassword).Methods("POST")
+	c.router.HandleFunc("/share", c.ViewSharedOrder).Methods("GET")
+	c.router.HandleFunc("/verify", c.VerifyCertificate).Methods("GET", "POST")
+
+	logout := c.router.PathPrefix("/logout").Subrouter()
+	logout.Use(c.validateUserJWT)
+	logout.HandleFunc("/", c.Logout).Methods("POST")
 
 	userapp := c.router.PathPrefix("/orders").Subrouter()
 	userapp.Use(c.validateUserJWT)
-	userapp.HandleFunc("/", c.GetTasks).Methods("GET")
+	userapp.HandleFunc("/", c.require(entity.PermTaskWork, c.GetTasks)).Methods("GET")
 	userapp.HandleFunc("/profile", c.GetUserProfile).Methods("GET")
-	userapp.HandleFunc("/profile/passwordchange", c.ChangePassword).Methods("POST")
-	userapp.HandleFunc("/profile/usernamechange", c.ChangeUsername).Methods("POST")
-	userapp.HandleFunc("/task={id}", c.GetSubmission).Methods("GET")
-	userapp.HandleFunc("/submission", c.PostSubmission).Methods("POST")
-	userapp.HandleFunc("/submission/id={id}", c.UpdateSubmission).Methods("POST")
+	userapp.HandleFunc("/profile/passwordchange", c.userOnly(c.ChangePassword)).Methods("POST")
+	userapp.HandleFunc("/profile/usernamechange", c.userOnly(c.ChangeUsername)).Methods("POST")
+	userapp.HandleFunc("/profile/sessions", c.userOnly(c.GetOwnSessions)).Methods("GET")
+	userapp.HandleFunc("/profile/sessions", c.userOnly(c.RevokeOtherSessions)).Methods("DELETE")
+	userapp.HandleFunc("/profile/sessions/id={id}", c.userOnly(c.RevokeOwnSession)).Methods("DELETE")
+	userapp.HandleFunc("/profile/2fa/enroll", c.userOnly(c.EnrollTOTP)).Methods("POST")
+	userapp.HandleFunc("/profile/2fa/confirm", c.userOnly(c.ConfirmTOTP)).Methods("POST")
+	userapp.HandleFunc("/profile/2fa/recoverycodes", c.userOnly(c.RegenerateRecoveryCodes)).Methods("POST")
+	userapp.HandleFunc("/profile/2fa/disable", c.userOnly(c.DisableTOTP)).Methods("POST")
+	userapp.HandleFunc("/task={id}", c.require(entity.PermTaskWork, c.GetSubmission)).Methods("GET")
+	userapp.HandleFunc("/task={id}/attachments/id={attachment}", c.require(entity.PermTaskWork, c.DownloadTaskAttachment)).Methods("GET")
+	userapp.HandleFunc("/submission", c.require(entity.PermTaskWork, c.PostSubmission)).Methods("POST")
+	userapp.HandleFunc("/submission/id={id}", c.require(entity.PermTaskWork, c.UpdateSubmission)).Methods("POST")
 
 	admin := c.router.PathPrefix("/admin").Subrouter()
-	admin.Use(c.validateAdminJWT)
-	admin.HandleFunc("/orders", c.GetAllUncompletedOrders).Methods("GET")
-	admin.HandleFunc("/orders", c.AddNewOrder).Methods("POST")
+	admin.Use(c.validateUserJWT)
+	admin.HandleFunc("/orders", c.require(entity.PermOrderRead, c.GetAllUncompletedOrders)).Methods("GET")
+	admin.HandleFunc("/orders", c.require(entity.PermOrderWrite, c.AddNewOrder)).Methods("POST")
+	admin.HandleFunc("/orders/import", c.require(entity.PermOrderWrite, c.ImportOrders)).Methods("POST")
+	admin.HandleFunc("/orders/export", c.require(entity.PermOrderRead, c.ExportOrders)).Methods("GET")
+	admin.HandleFunc("/orders/deleted", c.require(entity.PermOrderRead, c.GetDeletedOrders)).Methods("GET")
 
-	admin.HandleFunc("/orders/id={id}", c.GetStatusOfOrder).Methods("GET")
-	admin.HandleFunc("/orders/id={id}", c.DeleteOrder).Methods("DELETE")
-	admin.HandleFunc("/orders/id={id}", c.ModifyOrder).Methods("PATCH")
-	admin.HandleFunc("/orders/id={id}/newrequirement", c.AddNewRequirement).Methods("POST")
+	admin.HandleFunc("/orders/id={id}", c.require(entity.PermOrderRead, c.GetStatusOfOrder)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}", c.require(entity.PermOrderWrite, c.DeleteOrder)).Methods("DELETE")
+	admin.HandleFunc("/orders/id={id}", c.require(entity.PermOrderWrite, c.ModifyOrder)).Methods("PATCH")
+	admin.HandleFunc("/orders/id={id}/restore", c.require(entity.PermOrderWrite, c.RestoreOrder)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/newrequirement", c.require(entity.PermOrderWrite, c.AddNewRequirement)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/status", c.require(entity.PermOrderWrite, c.TransitionOrder)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/export", c.require(entity.PermOrderRead, c.ExportOrder)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/certificate", c.require(entity.PermOrderRead, c.DownloadOrderCertificate)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/certificate", c.require(entity.PermOrderWrite, c.IssueOrderCertificate)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/certificates", c.require(entity.PermOrderRead, c.GetOrderCertificates)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/comments", c.require(entity.PermOrderRead, c.GetOrderComments)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/comments", c.require(entity.PermOrderRead, c.PostOrderComment)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/progress", c.require(entity.PermOrderRead, c.GetOrderProgress)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/template", c.require(entity.PermOrderWrite, c.SaveOrderAsTemplate)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/clone", c.require(entity.PermOrderWrite, c.CloneOrder)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/revisions", c.require(entity.PermOrderRead, c.GetOrderRevisions)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/revisions/diff", c.require(entity.PermOrderRead, c.GetOrderRevisionDiff)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/revisions/number={number}/restore", c.require(entity.PermOrderWrite, c.RestoreOrderRevision)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/sla", c.require(entity.PermOrderRead, c.GetOrderSLA)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/attachments", c.require(entity.PermOrderRead, c.GetOrderAttachments)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/attachments", c.require(entity.PermOrderWrite, c.UploadOrderAttachment)).Methods("POST")
+	admin.HandleFunc("/orders/id={id}/labels", c.require(entity.PermOrderWrite, c.SetOrderLabels)).Methods("PUT")
+	admin.HandleFunc("/orders/id={id}/sharelinks", c.require(entity.PermOrderRead, c.GetShareLinks)).Methods("GET")
+	admin.HandleFunc("/orders/id={id}/sharelinks", c.require(entity.PermOrderWrite, c.CreateShareLink)).Methods("POST")
+	admin.HandleFunc("/sharelinks/id={id}", c.require(entity.PermOrderWrite, c.RevokeShareLink)).Methods("DELETE")
+	admin.HandleFunc("/certificates/id={id}", c.require(entity.PermOrderRead, c.DownloadCertificate)).Methods("GET")
+	admin.HandleFunc("/comments/id={id}", c.require(entity.PermOrderRead, c.EditComment)).Methods("PATCH")
+	admin.HandleFunc("/comments/id={id}", c.require(entity.PermOrderRead, c.DeleteComment)).Methods("DELETE")
+	admin.HandleFunc("/comments/id={id}/replies", c.require(entity.PermOrderRead, c.ReplyToComment)).Methods("POST")
+	admin.HandleFunc("/comments/id={id}/history", c.require(entity.PermOrderRead, c.GetCommentHistory)).Methods("GET")
+	admin.HandleFunc("/sla/breaches", c.require(entity.PermOrderRead, c.GetSLABreaches)).Methods("GET")
+	admin.HandleFunc("/sla/policies", c.require(entity.PermOrderRead, c.GetSLAPolicies)).Methods("GET")
+	admin.HandleFunc("/sla/policies", c.require(entity.PermOrderWrite, c.AddNewSLAPolicy)).Methods("POST")
+	admin.HandleFunc("/sla/policies/name={name}", c.require(entity.PermOrderRead, c.GetSLAPolicy)).Methods("GET")
+	admin.HandleFunc("/sla/policies/name={name}", c.require(entity.PermOrderWrite, c.ModifySLAPolicy)).Methods("PUT")
+	admin.HandleFunc("/sla/policies/name={name}", c.require(entity.PermOrderWrite, c.DeleteSLAPolicy)).Methods("DELETE")
+	admin.HandleFunc("/attachments/id={id}", c.require(entity.PermOrderRead, c.DownloadAttachment)).Methods("GET")
+	admin.HandleFunc("/attachments/id={id}", c.require(entity.PermOrderWrite, c.DeleteAttachment)).Methods("DELETE")
+	admin.HandleFunc("/fields", c.require(entity.PermOrderRead, c.GetCustomFields)).Methods("GET")
+	admin.HandleFunc("/fields", c.require(entity.PermOrderWrite, c.AddNewCustomField)).Methods("POST")
+	admin.HandleFunc("/fields/name={name}", c.require(entity.PermOrderWrite, c.DeleteCustomField)).Methods("DELETE")
+	admin.HandleFunc("/customers", c.require(entity.PermOrderRead, c.GetCustomers)).Methods("GET")
+	admin.HandleFunc("/customers", c.require(entity.PermOrderWrite, c.AddNewCustomer)).Methods("POST")
+	admin.HandleFunc("/customers/id={id}", c.require(entity.PermOrderRead, c.GetCustomer)).Methods("GET")
+	admin.HandleFunc("/customers/id={id}", c.require(entity.PermOrderWrite, c.ModifyCustomer)).Methods("PUT")
+	admin.HandleFunc("/customers/id={id}/orders", c.require(entity.PermOrderRead, c.GetCustomerOrders)).Methods("GET")
+	admin.HandleFunc("/templates", c.require(entity.PermOrderRead, c.GetTemplates)).Methods("GET")
+	admin.HandleFunc("/templates", c.require(entity.PermOrderWrite, c.AddNewTemplate)).Methods("POST")
+	admin.HandleFunc("/templates/id={id}", c.require(entity.PermOrderRead, c.GetTemplate)).Methods("GET")
+	admin.HandleFunc("/templates/id={id}", c.require(entity.PermOrderWrite, c.DeleteTemplate)).Methods("DELETE")
+	admin.HandleFunc("/templates/id={id}/instantiate", c.require(entity.PermOrderWrite, c.InstantiateTemplate)).Methods("POST")
 
-	admin.HandleFunc("/requirements", c.ModifyRequirements).Methods("PATCH")
-	admin.HandleFunc("/orders/search:{query}", c.SearchOrders).Methods("GET")
-	admin.HandleFunc("/user", c.NewUser).Methods("POST")
-	admin.HandleFunc("/user", c.GetAllUsers).Methods("GET")
-	admin.HandleFunc("/user/id={id}", c.DeleteUser).Methods("DELETE")
-	admin.HandleFunc("/user/id={id}/tasks", c.GetTasksOfUser).Methods("GET")
-	admin.HandleFunc("/tasks", c.GetAllAssignedTasks).Methods("GET")
-	admin.HandleFunc("/tasks", c.AddNewTask).Methods("POST")
-	admin.HandleFunc("/tasks/id={id}", c.DeleteTask).Methods("DELETE")
-	admin.HandleFunc("/tasks/bulk", c.BulkAssignTasks).Methods("POST")
-	admin.HandleFunc("/tasks/order={id}", c.GetTasksOnSpecificOrder).Methods("GET")
-	admin.HandleFunc("/tasks/submitted", c.GetTaskstoReview).Methods("GET")
-	admin.HandleFunc("/submission={id}/review", c.ReviewSubmission).Methods("POST")
+	admin.HandleFunc("/requirements", c.require(entity.PermOrderWrite, c.ModifyRequirements)).Methods("PATCH")
+	admin.HandleFunc("/requirements/id={id}", c.require(entity.PermOrderWrite, c.DeleteRequirement)).Methods("DELETE")
+	admin.HandleFunc("/requirements/id={id}/attachments", c.require(entity.PermOrderRead, c.GetRequirementAttachments)).Methods("GET")
+	admin.HandleFunc("/requirements/id={id}/attachments", c.require(entity.PermOrderWrite, c.UploadRequirementAttachment)).Methods("POST")
+	admin.HandleFunc("/requirements/id={id}/comments", c.require(entity.PermOrderRead, c.GetRequirementComments)).Methods("GET")
+	admin.HandleFunc("/requirements/id={id}/comments", c.require(entity.PermOrderRead, c.PostRequirementComment)).Methods("POST")
+	admin.HandleFunc("/requirements/id={id}/labels", c.require(entity.PermOrderWrite, c.SetRequirementLabels)).Methods("PUT")
+	admin.HandleFunc("/requirements/id={id}/restore", c.require(entity.PermOrderWrite, c.RestoreRequirement)).Methods("POST")
+	admin.HandleFunc("/requirements/id={id}/revisions", c.require(entity.PermOrderRead, c.GetRequirementRevisions)).Methods("GET")
+	admin.HandleFunc("/requirements/id={id}/revisions/diff", c.require(entity.PermOrderRead, c.GetRequirementRevisionDiff)).Methods("GET")
+	admin.HandleFunc("/requirements/id={id}/revisions/number={number}/restore", c.require(entity.PermOrderWrite, c.RestoreRequirementRevision)).Methods("POST")
+	admin.HandleFunc("/orders/search:{query}", c.require(entity.PermOrderRead, c.SearchOrders)).Methods("GET")
+	admin.HandleFunc("/user", c.require(entity.PermUserWrite, c.NewUser)).Methods("POST")
+	admin.HandleFunc("/user", c.require(entity.PermUserRead, c.GetAllUsers)).Methods("GET")
+	admin.HandleFunc("/user/id={id}", c.require(entity.PermUserWrite, c.DeleteUser)).Methods("DELETE")
+	admin.HandleFunc("/user/id={id}/disable", c.require(entity.PermUserWrite, c.DisableUser)).Methods("POST")
+	admin.HandleFunc("/user/id={id}/enable", c.require(entity.PermUserWrite, c.EnableUser)).Methods("POST")
+	admin.HandleFunc("/serviceaccounts", c.require(entity.PermUserWrite, c.NewServiceAccount)).Methods("POST")
+	admin.HandleFunc("/user/id={id}/apikeys", c.require(entity.PermUserRead, c.GetAPIKeys)).Methods("GET")
+	admin.HandleFunc("/user/id={id}/apikeys", c.require(entity.PermUserWrite, c.CreateAPIKey)).Methods("POST")
+	admin.HandleFunc("/apikeys/id={id}", c.require(entity.PermUserWrite, c.RevokeAPIKey)).Methods("DELETE")
+	admin.HandleFunc("/user/id={id}/invite", c.require(entity.PermUserWrite, c.ResendInvitation)).Methods("POST")
+	admin.HandleFunc("/user/id={id}/sessions", c.require(entity.PermUserRead, c.GetUserSessions)).Methods("GET")
+	admin.HandleFunc("/user/id={id}/sessions", c.require(entity.PermUserWrite, c.RevokeUserSessions)).Methods("DELETE")
+	admin.HandleFunc("/sessions/id={id}", c.require(entity.PermUserWrite, c.RevokeUserSession)).Methods("DELETE")
+	admin.HandleFunc("/user/id={id}/unlock", c.require(entity.PermUserWrite, c.UnlockUser)).Methods("POST")
+	admin.HandleFunc("/user/id={id}/2fa/reset", c.require(entity.PermUserWrite, c.ResetTOTP)).Methods("POST")
+	admin.HandleFunc("/logins", c.require(entity.PermUserRead, c.GetLoginAttempts)).Methods("GET")
+	admin.HandleFunc("/user/id={id}/tasks", c.require(entity.PermTaskRead, c.GetTasksOfUser)).Methods("GET")
+	admin.HandleFunc("/tasks", c.require(entity.PermTaskRead, c.GetAllAssignedTasks)).Methods("GET")
+	admin.HandleFunc("/tasks", c.require(entity.PermTaskAssign, c.AddNewTask)).Methods("POST")
+	admin.HandleFunc("/tasks/id={id}", c.require(entity.PermTaskAssign, c.DeleteTask)).Methods("DELETE")
+	admin.HandleFunc("/tasks/id={id}/labels", c.require(entity.PermTaskAssign, c.SetTaskLabels)).Methods("PUT")
+	admin.HandleFunc("/tasks/id={id}/restore", c.require(entity.PermTaskAssign, c.RestoreTask)).Methods("POST")
+	admin.HandleFunc("/tasks/bulk", c.require(entity.PermTaskAssign, c.BulkAssignTasks)).Methods("POST")
+	admin.HandleFunc("/tasks/order={id}", c.require(entity.PermTaskRead, c.GetTasksOnSpecificOrder)).Methods("GET")
+	admin.HandleFunc("/tasks/submitted", c.require(entity.PermSubmissionReview, c.GetTaskstoReview)).Methods("GET")
+	admin.HandleFunc("/submission={id}/review", c.require(entity.PermSubmissionReview, c.ReviewSubmission)).Methods("POST")
+	admin.HandleFunc("/roles", c.require(entity.PermRoleManage, c.GetAllRoles)).Methods("GET")
+	admin.HandleFunc("/roles", c.require(entity.PermRoleManage, c.AddNewRole)).Methods("POST")
+	admin.HandleFunc("/roles/name={name}", c.require(entity.PermRoleManage, c.ModifyRole)).Methods("PATCH")
+	admin.HandleFunc("/roles/name={name}", c.require(entity.PermRoleManage, c.DeleteRole)).Methods("DELETE")
+	admin.HandleFunc("/roles/name={name}/2fa", c.require(entity.PermRoleManage, c.SetRoleRequire2FA)).Methods("PUT")
+	admin.HandleFunc("/permissions", c.require(entity.PermRoleManage, c.GetAllPermissions)).Methods("GET")
 }
 
 func (c *Controller) Start() {
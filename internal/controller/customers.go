@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/customers"
+	"order-validation-v2/internal/usecase/orders"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+//tokenUseShare marks share link tokens. The token only names the link,
+//whether it still grants access is decided by the stored link.
+const tokenUseShare = "share"
+
+func (c *Controller) GetCustomers(w http.ResponseWriter, r *http.Request) {
+	list, err := c.customers.ListCustomers()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving customers: ", err.Error())
+		return
+	}
+	response := []models.Customer{}
+	for _, customer := range list {
+		response = append(response, models.BuildCustomerPayload(customer))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customer, err := c.customers.GetCustomer(mux.Vars(r)["id"])
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildCustomerPayload(customer))
+}
+
+func (c *Controller) AddNewCustomer(w http.ResponseWriter, r *http.Request) {
+	var form models.Customer
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	id, err := c.customers.CreateCustomer(form.Name, models.DecodeContacts(form.Contacts))
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("Customer '%s' has been added with id %s", form.Name, id)))
+}
+
+//ModifyCustomer replaces the name and the contacts of the customer
+func (c *Controller) ModifyCustomer(w http.ResponseWriter, r *http.Request) {
+	var form models.Customer
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	customer, err := c.customers.GetCustomer(mux.Vars(r)["id"])
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	customer.Name = form.Name
+	customer.Contacts = models.DecodeContacts(form.Contacts)
+	err = c.customers.UpdateCustomer(customer)
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Customer Modified"))
+}
+
+func (c *Controller) GetCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	matches, ok := c.matchLabels(w, r, entity.ResourceOrder)
+	if !ok {
+		return
+	}
+	list, err := c.customers.ListCustomerOrders(mux.Vars(r)["id"])
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	response := models.BuildPayload(filterOrders(list, matches))
+	if response == nil {
+		response = []*models.Orders{}
+	}
+	err = c.addOrderLabels(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving labels from database: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) GetShareLinks(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	links, err := c.customers.ListShareLinks(orderID)
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	response := []models.ShareLink{}
+	for _, l := range links {
+		response = append(response, models.BuildShareLinkPayload(l))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+//CreateShareLink returns the only copy of the link, the token in it isn't
+//stored
+func (c *Controller) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	var form models.NewShareLink
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if len(req) > 0 {
+		err = json.Unmarshal(req, &form)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request"))
+			return
+		}
+	}
+	link, err := c.customers.CreateShareLink(orderID, adminID, time.Duration(form.ExpiresInDays)*24*time.Hour)
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	token, err := c.documents.Sign(jwt.MapClaims{
+		"token_use": tokenUseShare,
+		"jti":       link.ID,
+		"order_id":  link.OrderID,
+	}, time.Until(link.ExpiresAt))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error signing share link: ", err.Error())
+		return
+	}
+	response := models.BuildShareLinkPayload(link)
+	response.URL = appLink("/share", token)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	link, err := c.customers.GetShareLink(mux.Vars(r)["id"])
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: link.OrderID}) {
+		return
+	}
+	err = c.customers.RevokeShareLink(link.ID)
+	if c.writeCustomerError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Share Link Revoked"))
+}
+
+//ViewSharedOrder is the customer portal. It answers 404 for every link
+//that doesn't grant access, so links can't be probed.
+func (c *Controller) ViewSharedOrder(w http.ResponseWriter, r *http.Request) {
+	claims, err := c.documents.Parse(r.URL.Query().Get("token"))
+	if err != nil || claims["token_use"] != tokenUseShare {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Link Not Found"))
+		return
+	}
+	linkID, _ := claims["jti"].(string)
+	link, err := c.customers.ResolveShareLink(linkID)
+	if err == nil && claims["order_id"] != link.OrderID {
+		err = customers.ErrLinkInactive
+	}
+	if errors.Is(err, customers.ErrLinkInactive) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Link Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error resolving share link: ", err.Error())
+		return
+	}
+	shared, err := c.sharedOrder(link)
+	if errors.Is(err, orders.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Link Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error building shared order %s: %s\n", link.OrderID, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shared)
+}
+
+//sharedOrder gathers the customer's view of the order. Evidence is only
+//taken from approved tasks, work still in progress or rejected stays
+//internal.
+func (c *Controller) sharedOrder(link *entity.ShareLink) (models.SharedOrder, error) {
+	o, err := c.order.GetOrder(link.OrderID)
+	if err != nil {
+		return models.SharedOrder{}, err
+	}
+	if o.CustomerID != link.CustomerID {
+		//the order was moved to another customer since the link was made
+		return models.SharedOrder{}, orders.ErrNotFound
+	}
+	customer, err := c.customers.GetCustomer(link.CustomerID)
+	if err != nil {
+		return models.SharedOrder{}, err
+	}
+	progress, err := c.order.GetProgress(o.ID)
+	if err != nil {
+		return models.SharedOrder{}, err
+	}
+	requirements, err := c.requirements.GetRequirementsbyOrderId(o.ID)
+	if err != nil {
+		return models.SharedOrder{}, err
+	}
+	tasks, err := c.task.GetTasksOnSpecificOrder(o.ID)
+	if err != nil {
+		return models.SharedOrder{}, err
+	}
+	evidence := map[int][]*entity.Submission{}
+	for _, t := range tasks {
+		if t.Status != entity.Finished {
+			continue
+		}
+		submissions, err := c.submissions.GetSubmissionByTaskID(t.ID)
+		if err != nil {
+			return models.SharedOrder{}, err
+		}
+		evidence[t.RequirementID] = append(evidence[t.RequirementID], submissions...)
+	}
+	return models.BuildSharedOrderPayload(customer, o, progress, link, requirements, tasks, evidence), nil
+}
+
+//ensureCustomer writes 400 and returns false when the customer doesn't exist
+func (c *Controller) ensureCustomer(w http.ResponseWriter, customerID string) bool {
+	_, err := c.customers.GetCustomer(customerID)
+	if err == customers.ErrNotFound {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, Customer Does Not Exist"))
+		return false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving customer: ", err.Error())
+		return false
+	}
+	return true
+}
+
+func (c *Controller) writeCustomerError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, customers.ErrNotFound), errors.Is(err, orders.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, customers.ErrInvalidCustomer), errors.Is(err, customers.ErrInvalidTTL),
+		errors.Is(err, customers.ErrNoCustomer):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling customer request: ", err.Error())
+	}
+	return true
+}
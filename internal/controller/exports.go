@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/exports"
+	"order-validation-v2/internal/usecase/orders"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+//ExportOrder streams an order as ?format=csv, json or html, json being the
+//default
+func (c *Controller) ExportOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	order, err := c.order.GetOrder(orderID)
+	if errors.Is(err, orders.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Order Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error retrieving order %s from database: %s\n", orderID, err.Error())
+		return
+	}
+	c.writeExport(w, format, "order-"+order.ID, []*entity.Orders{order})
+}
+
+//ExportOrders streams the orders matching ?status=, ?customer_id= and the
+//label filters. Unlike the order list it includes every status by default.
+func (c *Controller) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	statuses, ok := parseOrderStatuses(w, r, nil)
+	if !ok {
+		return
+	}
+	matches, ok := c.matchLabels(w, r, entity.ResourceOrder)
+	if !ok {
+		return
+	}
+	list, err := c.order.ListOrders(statuses)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving orders from database: ", err.Error())
+		return
+	}
+	list = filterOrders(list, matches)
+	if customerID := r.URL.Query().Get("customer_id"); customerID != "" {
+		var filtered []*entity.Orders
+		for _, o := range list {
+			if o.CustomerID == customerID {
+				filtered = append(filtered, o)
+			}
+		}
+		list = filtered
+	}
+	c.writeExport(w, format, "orders-"+time.Now().Format("20060102-150405"), list)
+}
+
+func exportFormat(w http.ResponseWriter, r *http.Request) (exports.Format, bool) {
+	param := r.URL.Query().Get("format")
+	if param == "" {
+		return exports.FormatJSON, true
+	}
+	format, ok := exports.ParseFormat(param)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, format must be csv, json or html"))
+		return "", false
+	}
+	return format, true
+}
+
+//writeExport sends CSV and JSON as downloads and shows the print view in
+//the browser. Once the export has started errors can only be logged.
+func (c *Controller) writeExport(w http.ResponseWriter, format exports.Format, name string, list []*entity.Orders) {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if format != exports.FormatHTML {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	}
+	w.WriteHeader(http.StatusOK)
+	err := c.exports.Export(format, list, w)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error exporting %s: %s\n", name, err.Error())
+	}
+}
@@ -2,8 +2,10 @@ package controller
 
 import (
 	"fmt"
+	"net/http"
 	"order-validation-v2/internal/controller/models"
 	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
 	"sync"
 )
 
@@ -29,6 +31,7 @@ func (c *Controller) updateTaskStatus(taskID string, wg *sync.WaitGroup, status
 		c.logger.ErrorLogger.Println("Error updating task status: ", err.Error())
 		panic(err)
 	}
+	c.syncOrderOfRequirement(task.RequirementID)
 	wg.Done()
 
 }
@@ -93,9 +96,53 @@ func (c *Controller) updateRequirementStatus(requirementID int, wg *sync.WaitGro
 	}
 	req.SetStatus(status)
 	c.requirements.UpdateRequirement(req)
+	c.syncOrderOfRequirement(requirementID)
 	wg.Done()
 }
 
+//syncOrderOfRequirement moves the order of a requirement along after one
+//of its tasks changed
+func (c *Controller) syncOrderOfRequirement(requirementID int) {
+	req, err := c.requirements.GetRequirementbyID(requirementID)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error Getting requirement: ", err.Error())
+		return
+	}
+	o, err := c.order.SyncStatus(req.OrderID)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error syncing status of order %s: %s\n", req.OrderID, err.Error())
+		return
+	}
+	c.issueCertificate(o)
+}
+
+//ensureRequirementOpen writes 409 and returns false when the requirement's
+//order is cancelled or archived
+func (c *Controller) ensureRequirementOpen(w http.ResponseWriter, requirementID int) bool {
+	req, err := c.requirements.GetRequirementbyID(requirementID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, Requirement Does Not Exist"))
+		return false
+	}
+	return c.ensureOrderOpen(w, req.OrderID)
+}
+
+func (c *Controller) ensureOrderOpen(w http.ResponseWriter, orderID string) bool {
+	err := c.order.EnsureNotClosed(orderID)
+	if err == orders.ErrOrderClosed {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, Order Does Not Exist"))
+		return false
+	}
+	return true
+}
+
 func (c *Controller) deletePrerequisite(prerequisiteTaskID string, wg *sync.WaitGroup) {
 	c.logger.InfoLogger.Println("Removing Prerequisite: ", prerequisiteTaskID)
 	affectedTasks, err := c.task.RemovePrerequisite(prerequisiteTaskID)
@@ -128,10 +175,19 @@ func (c *Controller) updateAffectedTasks(affectedTask *entity.Task, wg *sync.Wai
 	wg.Done()
 }
 
-func (c *Controller) assignPrerequiste(task *entity.Task, assignedID map[string]string, wg *sync.WaitGroup) {
+//assignPrerequiste saves the task once its prerequisites point at the new
+//task IDs and sets saved when it was stored
+func (c *Controller) assignPrerequiste(task *entity.Task, assignedID map[string]string, saved *bool, wg *sync.WaitGroup) {
 	for count, prerequiste := range task.Prerequisites {
 		task.Prerequisites[count] = assignedID[prerequiste]
 	}
-	c.task.SaveTask(task)
+	_, err := c.task.SaveTask(task)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error saving task: ", err.Error())
+		wg.Done()
+		return
+	}
+	*saved = true
+	c.syncOrderOfRequirement(task.RequirementID)
 	wg.Done()
 }
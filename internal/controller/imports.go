@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/usecase/imports"
+	"strings"
+)
+
+//maxImportSize is the largest import file accepted, in bytes
+const maxImportSize = 20 << 20
+
+//ImportOrders creates the orders of a CSV or JSON file in one transaction.
+//The format is read from ?format=, or else from the Content-Type, and
+//?dry_run=true only reports what would be created. Invalid rows are
+//reported with 422 and nothing is created.
+func (c *Controller) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Query().Get("format")
+	if param == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		param = string(imports.FormatCSV)
+	} else if param == "" {
+		param = string(imports.FormatJSON)
+	}
+	format, ok := imports.ParseFormat(param)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, format must be csv or json"))
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := c.imports.Import(format, r.Body, dryRun, fmt.Sprintf("%v", r.Context().Value(ctxKey{})))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error importing orders: ", err.Error())
+		return
+	}
+	switch {
+	case len(report.Errors) > 0:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case report.Loaded():
+		c.logger.InfoLogger.Printf("Imported %d orders with %d requirements\n", len(report.Orders), report.RequirementCount())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(models.BuildImportReportPayload(report))
+}
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/loginguard"
+	"order-validation-v2/pkg/mailer"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	tokenUseInvite = "invite"
+	tokenUseReset  = "password_reset"
+	inviteTTL      = 72 * time.Hour
+	resetTTL       = 30 * time.Minute
+)
+
+//appURL builds a link to a page of the frontend (APP_URL)
+func appURL(path string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return base + path
+}
+
+//appLink builds a link to the frontend carrying a token
+func appLink(path string, token string) string {
+	return appURL(path) + "?token=" + url.QueryEscape(token)
+}
+
+func (c *Controller) sendInvitation(u *entity.User) error {
+	token, err := c.generateUserToken(u.ID, tokenUseInvite, inviteTTL)
+	if err != nil {
+		return err
+	}
+	return c.mailer.Send(mailer.Message{
+		To:      u.Email,
+		Subject: "You have been invited to Order Validation",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"An account has been created for you. Choose your password here:\n\n%s\n\n"+
+			"The link expires in %s and can only be used once.\n",
+			u.Username, appLink("/invitation", token), inviteTTL),
+	})
+}
+
+func (c *Controller) sendPasswordReset(u *entity.User) error {
+	token, err := c.generateUserToken(u.ID, tokenUseReset, resetTTL)
+	if err != nil {
+		return err
+	}
+	return c.mailer.Send(mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your Order Validation password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"A password reset was requested for your account. Choose a new password here:\n\n%s\n\n"+
+			"The link expires in %s and can only be used once. "+
+			"If you didn't request it you can ignore this email.\n",
+			u.Username, appLink("/password/reset", token), resetTTL),
+	})
+}
+
+func (c *Controller) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	u, err := c.user.GetUserbyID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.logger.ErrorLogger.Println("Error while retrieving user : ", err.Error())
+		return
+	}
+	if u.Password != "" || u.ServiceAccount {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("User has already accepted the invitation"))
+		return
+	}
+	err = c.sendInvitation(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error sending invitation: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("Invitation sent to %s", u.Email)))
+}
+
+func (c *Controller) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var form models.SetPasswordForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil || form.Password == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, claims, err := c.parseUserToken(form.Token, tokenUseInvite)
+	if err == nil && u.Password != "" {
+		err = errInvalidToken
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(errInvalidToken.Error()))
+		c.logger.WarningLogger.Println("Rejected invitation: ", err.Error())
+		return
+	}
+	err = c.user.ValidatePassword(u, form.Password)
+	if c.writePasswordErrors(w, "password", err) {
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while validating password : ", err.Error())
+		return
+	}
+	if !c.consumeUserToken(w, claims) {
+		return
+	}
+	err = c.user.SetPassword(u, form.Password)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while updating user : ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Password has been set, you can now log in"))
+}
+
+//ForgotPassword always answers the same way and mails in the background,
+//so neither the response nor its timing reveals whether the user exists
+func (c *Controller) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var form models.ForgotPasswordForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil || form.Username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = c.guard.AllowReset(form.Username, clientIP(r))
+	var throttled *loginguard.ThrottledError
+	if errors.As(err, &throttled) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("Too many reset requests, try again later"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error checking password reset throttle ", err.Error())
+		return
+	}
+	go func() {
+		u, err := c.user.GetUserbyUsername(form.Username)
+		if err != nil || u.Disabled || u.Password == "" || u.Email == "" {
+			return
+		}
+		err = c.sendPasswordReset(u)
+		if err != nil {
+			c.logger.ErrorLogger.Println("Error sending password reset: ", err.Error())
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("If the account exists, a reset link has been sent to its email"))
+}
+
+func (c *Controller) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var form models.SetPasswordForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil || form.Password == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, claims, err := c.parseUserToken(form.Token, tokenUseReset)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(errInvalidToken.Error()))
+		c.logger.WarningLogger.Println("Rejected password reset: ", err.Error())
+		return
+	}
+	err = c.user.ValidatePassword(u, form.Password)
+	if c.writePasswordErrors(w, "password", err) {
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while validating password : ", err.Error())
+		return
+	}
+	if !c.consumeUserToken(w, claims) {
+		return
+	}
+	err = c.user.SetPassword(u, form.Password)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while updating user : ", err.Error())
+		return
+	}
+	//ends every session and voids other reset links sent before this one
+	err = c.revokeUserAccess(u.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while revoking tokens : ", err.Error())
+		return
+	}
+	err = c.guard.Unlock(u.Username)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error while unlocking user : ", err.Error())
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Password has been reset"))
+}
+
+//inviteUser creates the user without a password and mails an invitation
+//to choose one
+func (c *Controller) inviteUser(w http.ResponseWriter, newUser models.NewUser) {
+	if newUser.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Email is required to invite a user"))
+		return
+	}
+	id, err := c.user.InviteUser(newUser.Username, newUser.Email, newUser.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while creating new user: ", err.Error())
+		return
+	}
+	u, err := c.user.GetUserbyID(id)
+	if err == nil {
+		err = c.sendInvitation(u)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error sending invitation: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("User %s has been invited with id %s\n", newUser.Username, id)))
+}
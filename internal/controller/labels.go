@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/labels"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetCustomFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := c.labels.ListFields()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving custom fields: ", err.Error())
+		return
+	}
+	response := []models.CustomField{}
+	for _, f := range fields {
+		response = append(response, models.BuildCustomFieldPayload(f))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) AddNewCustomField(w http.ResponseWriter, r *http.Request) {
+	var form models.CustomField
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = c.labels.CreateField(models.DecodeCustomField(form))
+	if c.writeLabelsError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("Custom field '%s' has been added", form.Name)))
+}
+
+//DeleteCustomField also removes every value set for the field
+func (c *Controller) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	err := c.labels.DeleteField(mux.Vars(r)["name"])
+	if c.writeLabelsError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Custom Field Deleted"))
+}
+
+func (c *Controller) SetOrderLabels(w http.ResponseWriter, r *http.Request) {
+	c.setLabels(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]}, entity.PermOrderWrite)
+}
+
+func (c *Controller) SetRequirementLabels(w http.ResponseWriter, r *http.Request) {
+	c.setLabels(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]}, entity.PermOrderWrite)
+}
+
+func (c *Controller) SetTaskLabels(w http.ResponseWriter, r *http.Request) {
+	c.setLabels(w, r, entity.Resource{Type: entity.ResourceTask, ID: mux.Vars(r)["id"]}, entity.PermTaskAssign)
+}
+
+//setLabels replaces the tags and field values of an existing resource
+func (c *Controller) setLabels(w http.ResponseWriter, r *http.Request, resource entity.Resource, perm entity.Permission) {
+	if !c.authorize(w, r, perm, resource) {
+		return
+	}
+	var form models.Labels
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if !c.ensureResource(w, resource) {
+		return
+	}
+	err = c.labels.SetLabels(resource, models.DecodeLabels(form.Tags, form.Fields))
+	if c.writeLabelsError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Labels Updated"))
+}
+
+//ensureResource writes 404 and returns false when the resource to label
+//doesn't exist
+func (c *Controller) ensureResource(w http.ResponseWriter, resource entity.Resource) bool {
+	var err error
+	switch resource.Type {
+	case entity.ResourceOrder:
+		_, err = c.order.GetOrder(resource.ID)
+	case entity.ResourceRequirement:
+		var id int
+		id, err = strconv.Atoi(resource.ID)
+		if err == nil {
+			_, err = c.requirements.GetRequirementbyID(id)
+		}
+	case entity.ResourceTask:
+		_, err = c.task.Get(resource.ID)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(fmt.Sprintf("%s Not Found", strings.Title(string(resource.Type)))))
+		return false
+	}
+	return true
+}
+
+//labelFilter reads ?tag= and ?field.<name>= from the query, every one of
+//them has to match
+func labelFilter(r *http.Request) entity.LabelFilter {
+	filter := entity.LabelFilter{Fields: map[string]string{}}
+	for key, values := range r.URL.Query() {
+		switch {
+		case key == "tag":
+			filter.Tags = append(filter.Tags, values...)
+		case strings.HasPrefix(key, "field.") && len(values) > 0:
+			filter.Fields[strings.TrimPrefix(key, "field.")] = values[0]
+		}
+	}
+	return filter
+}
+
+//matchLabels returns the ids of the resources of type t matching the
+//request's label filter, or nil when it has none. It writes the error and
+//returns false when the filter is invalid.
+func (c *Controller) matchLabels(w http.ResponseWriter, r *http.Request, t entity.ResourceType) (map[string]bool, bool) {
+	filter := labelFilter(r)
+	if filter.Empty() {
+		return nil, true
+	}
+	matches, err := c.labels.Match(t, filter)
+	if c.writeLabelsError(w, err) {
+		return nil, false
+	}
+	return matches, true
+}
+
+func filterOrders(list []*entity.Orders, matches map[string]bool) []*entity.Orders {
+	if matches == nil {
+		return list
+	}
+	var filtered []*entity.Orders
+	for _, o := range list {
+		if matches[o.ID] {
+			filtered = append(filtered, o)
+		}
+	}
+	return filtered
+}
+
+func filterTasks(list []*entity.TaskWithDetails, matches map[string]bool) []*entity.TaskWithDetails {
+	if matches == nil {
+		return list
+	}
+	var filtered []*entity.TaskWithDetails
+	for _, t := range list {
+		if matches[t.ID] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+//addOrderLabels labels the orders of the payload and their requirements
+func (c *Controller) addOrderLabels(response []*models.Orders) error {
+	var orderIDs, requirementIDs []string
+	for _, o := range response {
+		orderIDs = append(orderIDs, o.ID)
+		for _, req := range o.Requirements {
+			requirementIDs = append(requirementIDs, strconv.Itoa(req.Id))
+		}
+	}
+	orderLabels, err := c.labels.GetLabels(entity.ResourceOrder, orderIDs)
+	if err != nil {
+		return err
+	}
+	requirementLabels, err := c.labels.GetLabels(entity.ResourceRequirement, requirementIDs)
+	if err != nil {
+		return err
+	}
+	for _, o := range response {
+		o.AddLabels(orderLabels[o.ID])
+		o.AddRequirementLabels(requirementLabels)
+	}
+	return nil
+}
+
+//buildTasks filters the tasks by the request's label filter and builds
+//their labelled payload
+func (c *Controller) buildTasks(w http.ResponseWriter, r *http.Request, list []*entity.TaskWithDetails) ([]*models.TaskWithDetail, bool) {
+	matches, ok := c.matchLabels(w, r, entity.ResourceTask)
+	if !ok {
+		return nil, false
+	}
+	list = filterTasks(list, matches)
+	var ids []string
+	for _, t := range list {
+		ids = append(ids, t.ID)
+	}
+	taskLabels, err := c.labels.GetLabels(entity.ResourceTask, ids)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving task labels: ", err.Error())
+		return nil, false
+	}
+	response := models.BuildTasks(list)
+	models.AddTaskLabels(response, taskLabels)
+	return response, true
+}
+
+//validateLabels writes 400 and returns false when labels aren't valid for
+//a resource of type t
+func (c *Controller) validateLabels(w http.ResponseWriter, t entity.ResourceType, l *entity.Labels) bool {
+	return !c.writeLabelsError(w, c.labels.Validate(t, l))
+}
+
+//saveLabels sets labels validated before the resource was created, the
+//resource is kept when they can't be saved
+func (c *Controller) saveLabels(resource entity.Resource, l *entity.Labels) {
+	if len(l.Tags) == 0 && len(l.Fields) == 0 {
+		return
+	}
+	err := c.labels.SetLabels(resource, l)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error saving labels of %s %s: %s\n", resource.Type, resource.ID, err.Error())
+	}
+}
+
+func (c *Controller) writeLabelsError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, labels.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, labels.ErrInvalidField), errors.Is(err, labels.ErrInvalidLabel):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, labels.ErrFieldExists):
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling labels: ", err.Error())
+	}
+	return true
+}
@@ -2,9 +2,19 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
 	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/loginguard"
+	"order-validation-v2/internal/usecase/tokens"
+	"order-validation-v2/internal/usecase/user"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -28,44 +38,247 @@ func (c *Controller) Login(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Invalid Request"))
 		return
 	}
-	ID, role, ok, err := c.user.Login(form.Username, form.Password)
-	if err != nil {
+	ip := clientIP(r)
+	userAgent := r.UserAgent()
+	if !c.allowLogin(w, form.Username, ip, userAgent) {
+		return
+	}
+	ID, _, ok, err := c.user.Login(form.Username, form.Password)
+	switch {
+	case err == user.ErrUserDisabled:
+		c.recordLogin(entity.NewLoginAttempt(form.Username, ip, userAgent, entity.LoginDisabled))
+	case err == user.ErrWrongCredentials, err == nil && !ok:
+		c.failLogin(form.Username, ip, userAgent)
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while logging in: ", err.Error())
+		return
+	}
+	if err != nil || !ok {
 		w.WriteHeader(http.StatusUnauthorized)
-		c.logger.ErrorLogger.Println("Error while logging in ", err.Error())
+		w.Write([]byte("Username or Password is wrong"))
 		return
 	}
-	if ok {
-		var response models.Token
-		token, err := c.generateJWT(ID, role)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			c.logger.ErrorLogger.Println("Error generating jwt ", err.Error())
-			return
-		}
-		response.Token = token
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
+	u, err := c.user.GetUserbyID(ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving user info: ", err.Error())
+		return
+	}
+	if c.challengeSecondFactor(w, u) {
+		return
+	}
+	if c.requirePasswordChange(w, u, nil) {
+		return
+	}
+	c.completeLogin(w, r, u, nil)
 
-	} else {
-		w.WriteHeader(http.StatusNotAcceptable)
-		w.Write([]byte("Username or Password is wrong"))
+}
+
+//allowLogin writes the throttled or locked response and returns false when
+//the guard refuses the attempt
+func (c *Controller) allowLogin(w http.ResponseWriter, username string, ip string, userAgent string) bool {
+	err := c.guard.Allow(username, ip)
+	if err == nil {
+		return true
+	}
+	var throttled *loginguard.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.recordLogin(entity.NewLoginAttempt(username, ip, userAgent, entity.LoginThrottled))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("Too many failed attempts, try again later"))
+	case err == loginguard.ErrAccountLocked:
+		c.recordLogin(entity.NewLoginAttempt(username, ip, userAgent, entity.LoginLocked))
+		w.WriteHeader(http.StatusLocked)
+		w.Write([]byte("Account is locked, contact an administrator"))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error checking login throttle ", err.Error())
+	}
+	return false
+}
+
+func (c *Controller) failLogin(username string, ip string, userAgent string) {
+	c.recordLogin(entity.NewLoginAttempt(username, ip, userAgent, entity.LoginBadCredentials))
+	if err := c.guard.Fail(username, ip); err != nil {
+		c.logger.ErrorLogger.Println("Error recording failed login ", err.Error())
+	}
+}
+
+//completeLogin resets the throttle, audits the login and issues the access
+//and refresh tokens
+func (c *Controller) completeLogin(w http.ResponseWriter, r *http.Request, u *entity.User, recoveryCodes []string) {
+	ip := clientIP(r)
+	if err := c.guard.Succeed(u.Username, ip); err != nil {
+		c.logger.ErrorLogger.Println("Error resetting login throttle ", err.Error())
+	}
+	attempt := entity.NewLoginAttempt(u.Username, ip, r.UserAgent(), entity.LoginSucceeded)
+	attempt.UserID = u.ID
+	c.recordLogin(attempt)
+
+	session, err := c.sessions.StartSession(u.ID, ip, r.UserAgent())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error starting session ", err.Error())
+		return
+	}
+	var response models.Token
+	token, err := c.generateJWT(u.ID, u.UserRole, session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error generating jwt ", err.Error())
+		return
+	}
+	response.Token = token
+	response.RefreshToken, err = c.tokens.IssueRefreshToken(u.ID, session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error issuing refresh token ", err.Error())
 		return
 	}
+	response.RecoveryCodes = recoveryCodes
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
 
+func (c *Controller) recordLogin(attempt *entity.LoginAttempt) {
+	err := c.guard.Record(attempt)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error recording login attempt ", err.Error())
+		return
+	}
+	if attempt.Suspicious {
+		c.logger.WarningLogger.Printf("Suspicious login attempt for %s from %s: %s\n", attempt.Username, attempt.IP, attempt.Outcome)
+	}
 }
 
-func (c *Controller) generateJWT(userid string, role string) (string, error) {
-	var err error
+//clientIP uses the address appended last to X-Forwarded-For when running
+//behind a proxy (TRUST_PROXY=true), since earlier entries are client supplied
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") == "true" {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addresses := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addresses[len(addresses)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (c *Controller) generateJWT(userid string, role string, sessionID string) (string, error) {
 	atClaims := jwt.MapClaims{}
 	atClaims["authorized"] = true
+	atClaims["token_use"] = tokenUseAccess
+	atClaims["sub"] = userid
 	atClaims["user_id"] = userid
 	atClaims["authorization"] = role
-	atClaims["exp"] = time.Now().Add(time.Minute * 15)
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-	token, err := at.SignedString([]byte("SUPERSECRETPASSWORD"))
+	atClaims["jti"] = entity.NewUUID().String()
+	atClaims["sid"] = sessionID
+	token, err := c.keys.Sign(atClaims, time.Minute*15)
 	if err != nil {
 		return "", err
 	}
 	return token, nil
 
 }
+
+func (c *Controller) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(c.keys.JWKS())
+}
+
+func (c *Controller) RefreshLogin(w http.ResponseWriter, r *http.Request) {
+	var form models.RefreshForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.logger.ErrorLogger.Println("Error while refreshing token: ", err.Error())
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil || form.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	refreshToken, consumed, err := c.tokens.RotateRefreshToken(form.RefreshToken)
+	if err == tokens.ErrRefreshTokenReused {
+		c.logger.WarningLogger.Println("Refresh token reuse detected, session and token family revoked")
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.logger.ErrorLogger.Println("Error while refreshing token: ", err.Error())
+		return
+	}
+	user, err := c.user.GetUserbyID(consumed.UserID)
+	if err != nil || user.Disabled {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	//a role that started requiring 2FA sends unenrolled members back
+	//through Login instead of refreshing them indefinitely
+	required, err := c.roleRequires2FA(user.UserRole)
+	if err != nil || (required && !user.TOTPEnabled) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	//an expired password is changed before the session goes on, the
+	//change token logs the user back in
+	if c.requirePasswordChange(w, user, nil) {
+		return
+	}
+	token, err := c.generateJWT(user.ID, user.UserRole, consumed.FamilyID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error generating jwt ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.Token{Token: token, RefreshToken: refreshToken})
+}
+
+func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value(apiKeyKey{}) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("API keys are revoked by an administrator"))
+		return
+	}
+	claims := r.Context().Value(claimsKey{}).(jwt.MapClaims)
+	var form models.RefreshForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err == nil && len(req) > 0 {
+		json.Unmarshal(req, &form)
+	}
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	err = c.tokens.RevokeAccessToken(jti, time.Unix(int64(exp), 0))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking access token: ", err.Error())
+		return
+	}
+	sid, _ := claims["sid"].(string)
+	err = c.sessions.RevokeSession(sid)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking session: ", err.Error())
+		return
+	}
+	if form.RefreshToken != "" {
+		err = c.tokens.RevokeRefreshToken(form.RefreshToken)
+		if err != nil && err != tokens.ErrInvalidRefreshToken {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.logger.ErrorLogger.Println("Error revoking refresh token: ", err.Error())
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Logged out"))
+}
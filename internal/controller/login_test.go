@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/loginguard"
+	"order-validation-v2/internal/usecase/roles"
+	"order-validation-v2/internal/usecase/sessions"
+	"order-validation-v2/internal/usecase/sso"
+	"order-validation-v2/internal/usecase/tokens"
+	"order-validation-v2/internal/usecase/user"
+	"order-validation-v2/pkg/keys"
+	"order-validation-v2/pkg/logger"
+	"order-validation-v2/pkg/oidc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type fakeUsers struct {
+	user.UseCase
+	u       *entity.User
+	expired bool
+}
+
+func (f fakeUsers) Login(username string, password string) (string, string, bool, error) {
+	return f.u.ID, f.u.UserRole, true, nil
+}
+
+func (f fakeUsers) GetUserbyID(id string) (*entity.User, error) {
+	return f.u, nil
+}
+
+func (f fakeUsers) PasswordExpired(u *entity.User) bool {
+	return f.expired
+}
+
+type fakeGuard struct {
+	loginguard.UseCase
+}
+
+func (fakeGuard) Allow(username string, ip string) error {
+	return nil
+}
+
+func (fakeGuard) Succeed(username string, ip string) error {
+	return nil
+}
+
+func (fakeGuard) Record(attempt *entity.LoginAttempt) error {
+	return nil
+}
+
+type fakeRoles struct {
+	roles.UseCase
+	require2FA bool
+}
+
+func (f fakeRoles) GetRole(name string) (*entity.Role, error) {
+	return &entity.Role{Name: name, Require2FA: f.require2FA}, nil
+}
+
+type fakeSessions struct {
+	sessions.UseCase
+}
+
+func (fakeSessions) StartSession(userID string, ip string, userAgent string) (*entity.Session, error) {
+	return &entity.Session{ID: "s1", UserID: userID}, nil
+}
+
+type fakeTokens struct {
+	tokens.UseCase
+}
+
+func (fakeTokens) IssueRefreshToken(userID string, sessionID string) (string, error) {
+	return "refresh-1", nil
+}
+
+func (fakeTokens) RotateRefreshToken(token string) (string, *entity.RefreshToken, error) {
+	return "refresh-2", &entity.RefreshToken{ID: "t1", FamilyID: "f1", UserID: "u1"}, nil
+}
+
+func (fakeTokens) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	return true, nil
+}
+
+type fakeSSO struct {
+	sso.UseCase
+	u *entity.User
+}
+
+func (f fakeSSO) Resolve(identity entity.ExternalIdentity) (*entity.User, error) {
+	return f.u, nil
+}
+
+//newStandInIdP serves discovery, JWKS and a token endpoint answering with
+//an ID token for the nonce of the last authorization request
+func newStandInIdP(t *testing.T) (*httptest.Server, *string) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keys.NewKey(private, "idp-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var server *httptest.Server
+	nonce := new(string)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(keys.JWKSet{Keys: []keys.JWK{key.JWK()}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		token := jwt.NewWithClaims(key.Method, jwt.MapClaims{
+			"iss":   server.URL,
+			"sub":   "subject-1",
+			"aud":   "client-1",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": *nonce,
+		})
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(key.Private)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, nonce
+}
+
+//loginWithPassword posts the credentials to Login
+func loginWithPassword(t *testing.T, c *Controller) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c.Login(w, httptest.NewRequest(http.MethodPost, "/login/", strings.NewReader(`{"username": "alice", "password": "secret"}`)))
+	return w
+}
+
+//loginWithSSO goes through StartOIDCLogin and back to OIDCCallback with
+//the flow cookie and the state it handed out
+func loginWithSSO(nonce *string) func(t *testing.T, c *Controller) *httptest.ResponseRecorder {
+	return func(t *testing.T, c *Controller) *httptest.ResponseRecorder {
+		start := httptest.NewRecorder()
+		c.StartOIDCLogin(start, httptest.NewRequest(http.MethodGet, "/login/oidc", nil))
+		location, err := url.Parse(start.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		*nonce = location.Query().Get("nonce")
+		r := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?code=code-1&state="+url.QueryEscape(location.Query().Get("state")), nil)
+		for _, cookie := range start.Result().Cookies() {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		c.OIDCCallback(w, r)
+		return w
+	}
+}
+
+//TestSecondFactorOnEveryLoginPath checks that neither the password nor
+//the SSO login issues a token before the second factor when the user
+//enrolled or their role requires it
+func TestSecondFactorOnEveryLoginPath(t *testing.T) {
+	idp, nonce := newStandInIdP(t)
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: idp.URL, ClientID: "client-1", RedirectURL: "https://app/login/oidc/callback"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := keys.NewManager(key, "issuer", "audience", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]func(t *testing.T, c *Controller) *httptest.ResponseRecorder{
+		"password": loginWithPassword,
+		"sso":      loginWithSSO(nonce),
+	}
+	tests := []struct {
+		name           string
+		enrolled       bool
+		roleRequires   bool
+		wantChallenge  bool
+		wantEnrollment bool
+	}{
+		{name: "no second factor"},
+		{name: "enrolled user", enrolled: true, wantChallenge: true},
+		{name: "role requires it", roleRequires: true, wantChallenge: true, wantEnrollment: true},
+		{name: "enrolled user in a role requiring it", enrolled: true, roleRequires: true, wantChallenge: true},
+	}
+	for path, login := range paths {
+		for _, tt := range tests {
+			t.Run(path+"/"+tt.name, func(t *testing.T) {
+				u := &entity.User{ID: "u1", Username: "alice", UserRole: "worker", TOTPEnabled: tt.enrolled}
+				c := &Controller{
+					user:     fakeUsers{u: u},
+					guard:    fakeGuard{},
+					roles:    fakeRoles{require2FA: tt.roleRequires},
+					sessions: fakeSessions{},
+					tokens:   fakeTokens{},
+					sso:      fakeSSO{u: u},
+					oidc:     provider,
+					keys:     manager,
+					logger:   logger.NewLogger(),
+				}
+
+				w := login(t, c)
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+				}
+				var body struct {
+					models.MFAChallenge
+					Token string `json:"token"`
+				}
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body.MFARequired != tt.wantChallenge {
+					t.Fatalf("mfa_required = %v, want %v", body.MFARequired, tt.wantChallenge)
+				}
+				if tt.wantChallenge && body.Token != "" {
+					t.Errorf("a token was issued before the second factor")
+				}
+				if !tt.wantChallenge && body.Token == "" {
+					t.Errorf("no token issued")
+				}
+				if body.EnrollmentRequired != tt.wantEnrollment {
+					t.Errorf("enrollment_required = %v, want %v", body.EnrollmentRequired, tt.wantEnrollment)
+				}
+			})
+		}
+	}
+}
+
+//TestRefreshLoginWithExpiredPassword checks that a refresh token doesn't
+//outlive the password's max age
+func TestRefreshLoginWithExpiredPassword(t *testing.T) {
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := keys.NewManager(key, "issuer", "audience", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expired := range []bool{false, true} {
+		u := &entity.User{ID: "u1", Username: "alice", UserRole: "worker"}
+		c := &Controller{
+			user:   fakeUsers{u: u, expired: expired},
+			roles:  fakeRoles{},
+			tokens: fakeTokens{},
+			keys:   manager,
+			logger: logger.NewLogger(),
+		}
+
+		w := httptest.NewRecorder()
+		c.RefreshLogin(w, httptest.NewRequest(http.MethodPost, "/refresh/", strings.NewReader(`{"refresh_token": "refresh-1"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expired %v: status = %d, body %s", expired, w.Code, w.Body.String())
+		}
+		var change models.PasswordChangeRequired
+		var login models.Token
+		err := json.Unmarshal(w.Body.Bytes(), &change)
+		if err == nil {
+			err = json.Unmarshal(w.Body.Bytes(), &login)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if change.PasswordChangeRequired != expired || (change.PasswordChangeToken != "") != expired {
+			t.Errorf("expired %v: password change = %+v", expired, change)
+		}
+		if (login.Token == "") != expired {
+			t.Errorf("expired %v: token issued = %v", expired, login.Token != "")
+		}
+	}
+}
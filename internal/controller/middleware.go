@@ -4,60 +4,122 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/pkg/keys"
+	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type ctxKey struct{}
 
+//claimsKey holds the verified jwt.MapClaims of the request
+type claimsKey struct{}
+
+//apiKeyKey holds the *entity.APIKey of requests authenticated by API key
+type apiKeyKey struct{}
+
+//authenticate accepts either a JWT header or an "Authorization: ApiKey"
+//header. API key requests get claims shaped like an access token so
+//handlers don't need to tell them apart.
+func (c *Controller) authenticate(r *http.Request) (jwt.MapClaims, *entity.APIKey, bool) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "ApiKey ") {
+		claims, ok := c.parseJWT(r)
+		return claims, nil, ok
+	}
+	key, err := c.apikeys.Authenticate(strings.TrimSpace(strings.TrimPrefix(authorization, "ApiKey ")))
+	if err != nil {
+		c.logger.WarningLogger.Println("Rejected API key: ", err.Error())
+		return nil, nil, false
+	}
+	account, err := c.user.GetUserbyID(key.UserID)
+	if err != nil || account.Disabled || !account.ServiceAccount {
+		return nil, nil, false
+	}
+	claims := jwt.MapClaims{
+		"sub":           account.ID,
+		"user_id":       account.ID,
+		"authorization": account.UserRole,
+		"api_key":       key.ID,
+	}
+	return claims, key, true
+}
+
+func (c *Controller) parseJWT(r *http.Request) (jwt.MapClaims, bool) {
+	authorization := r.Header.Get("JWT")
+	if authorization == "" {
+		return nil, false
+	}
+	claims, err := c.keys.Parse(authorization)
+	if err != nil {
+		c.logger.WarningLogger.Println("Rejected JWT: ", err.Error())
+		return nil, false
+	}
+	if claims["token_use"] != tokenUseAccess {
+		return nil, false
+	}
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["user_id"].(string)
+	revoked, err := c.tokens.IsRevoked(jti, userID, keys.IssuedAt(claims))
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error checking token revocation: ", err.Error())
+		return nil, false
+	}
+	if revoked {
+		return nil, false
+	}
+	sid, _ := claims["sid"].(string)
+	active, err := c.sessions.ValidateSession(sid, userID)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error checking session: ", err.Error())
+		return nil, false
+	}
+	if !active {
+		return nil, false
+	}
+	return claims, true
+}
+
 func (c *Controller) validateUserJWT(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authorization := r.Header.Get("JWT")
-		if authorization == "" {
+		claims, key, ok := c.authenticate(r)
+		if !ok {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		claims := jwt.MapClaims{}
-		_, err := jwt.ParseWithClaims(authorization, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte("SUPERSECRETPASSWORD"), nil
-		})
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			c.logger.ErrorLogger.Println("Error processing JWT: ", err.Error())
-			return
-		}
 		id := fmt.Sprintf("%v", claims["user_id"])
 		ctx := context.WithValue(r.Context(), ctxKey{}, id)
+		ctx = context.WithValue(ctx, claimsKey{}, claims)
+		if key != nil {
+			ctx = context.WithValue(ctx, apiKeyKey{}, key)
+		}
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 
 	})
 }
-func (c *Controller) validateAdminJWT(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authorization := r.Header.Get("JWT")
-		if authorization == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
-		}
-		claims := jwt.MapClaims{}
-		_, err := jwt.ParseWithClaims(authorization, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte("SUPERSECRETPASSWORD"), nil
-		})
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			c.logger.ErrorLogger.Println("Error processing JWT: ", err.Error())
+
+//require wraps a handler behind a role permission check. It must run after
+//validateUserJWT has stored the claims.
+func (c *Controller) require(p entity.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !c.authorize(w, r, p, entity.Resource{}) {
 			return
 		}
-		id := fmt.Sprintf("%v", claims["user_id"])
-		role := fmt.Sprintf("%v", claims["authorization"])
-		if role != "Admin" {
-			w.WriteHeader(http.StatusUnauthorized)
+		next(w, r)
+	}
+}
+
+//userOnly keeps API keys away from routes managing the account itself,
+//which no permission scope covers
+func (c *Controller) userOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(apiKeyKey{}) != nil {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("API keys can't manage the account"))
 			return
 		}
-		ctx := context.WithValue(r.Context(), ctxKey{}, id)
-		r = r.WithContext(ctx)
-		next.ServeHTTP(w, r)
-
-	})
+		next(w, r)
+	}
 }
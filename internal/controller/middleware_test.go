@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"order-validation-v2/internal/entity"
+	"testing"
+)
+
+func TestUserOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		key  *entity.APIKey
+		want int
+	}{
+		{name: "user session", want: http.StatusOK},
+		{name: "API key", key: &entity.APIKey{ID: "k1", Permissions: entity.AllPermissions}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{}
+			handler := c.userOnly(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			r := httptest.NewRequest(http.MethodPut, "/profile/password", nil)
+			ctx := context.WithValue(r.Context(), ctxKey{}, "u1")
+			if tt.key != nil {
+				ctx = context.WithValue(ctx, apiKeyKey{}, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, r.WithContext(ctx))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type NewServiceAccount struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type NewAPIKey struct {
+	Name          string              `json:"name"`
+	Permissions   []entity.Permission `json:"permissions"`
+	ExpiresInDays int                 `json:"expires_in_days"`
+}
+
+type APIKey struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Prefix      string              `json:"prefix"`
+	Permissions []entity.Permission `json:"permissions"`
+	CreatedBy   string              `json:"created_by"`
+	CreatedAt   string              `json:"created_at"`
+	ExpiresAt   string              `json:"expires_at,omitempty"`
+	LastUsedAt  string              `json:"last_used_at,omitempty"`
+	Revoked     bool                `json:"revoked"`
+}
+
+//CreatedAPIKey is the only response that carries the key itself
+type CreatedAPIKey struct {
+	Key string `json:"key"`
+	APIKey
+}
+
+func BuildAPIKeyPayload(K []*entity.APIKey) []APIKey {
+	var keys []APIKey
+	for _, k := range K {
+		keys = append(keys, buildAPIKey(k))
+	}
+	return keys
+}
+
+func BuildCreatedAPIKeyPayload(key string, k *entity.APIKey) CreatedAPIKey {
+	return CreatedAPIKey{Key: key, APIKey: buildAPIKey(k)}
+}
+
+func buildAPIKey(k *entity.APIKey) APIKey {
+	key := APIKey{
+		ID:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		Permissions: k.Permissions,
+		CreatedBy:   k.CreatedBy,
+		CreatedAt:   k.CreatedAt.Format("2/Jan/2006 15:04:05"),
+		Revoked:     k.Revoked,
+	}
+	if !k.ExpiresAt.IsZero() {
+		key.ExpiresAt = k.ExpiresAt.Format("2/Jan/2006 15:04:05")
+	}
+	if !k.LastUsedAt.IsZero() {
+		key.LastUsedAt = k.LastUsedAt.Format("2/Jan/2006 15:04:05")
+	}
+	return key
+}
@@ -0,0 +1,43 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type Attachment struct {
+	ID         string `json:"id"`
+	Filename   string `json:"filename"`
+	MimeType   string `json:"mime_type"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"sha256"`
+	UploadedBy string `json:"uploaded_by,omitempty"`
+	UploadedAt string `json:"uploaded_at"`
+}
+
+func BuildAttachmentPayload(a *entity.Attachment) Attachment {
+	return Attachment{
+		ID:         a.ID,
+		Filename:   a.Filename,
+		MimeType:   a.MimeType,
+		Size:       a.Size,
+		Checksum:   a.Checksum,
+		UploadedBy: a.UploadedBy,
+		UploadedAt: a.UploadedAt.Format("2/Jan/2006 15:04:05"),
+	}
+}
+
+func BuildAttachments(list []*entity.Attachment) []Attachment {
+	attachments := []Attachment{}
+	for _, a := range list {
+		attachments = append(attachments, BuildAttachmentPayload(a))
+	}
+	return attachments
+}
+
+//AddTaskAttachments adds the attachments of the tasks' requirements,
+//attachments is keyed by task id
+func AddTaskAttachments(tasks []*TaskWithDetail, attachments map[string][]*entity.Attachment) {
+	for _, t := range tasks {
+		if list, ok := attachments[t.Id]; ok {
+			t.Attachments = BuildAttachments(list)
+		}
+	}
+}
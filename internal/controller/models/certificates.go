@@ -0,0 +1,103 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type Certificate struct {
+	ID               string                 `json:"id"`
+	OrderID          string                 `json:"order_id"`
+	OrderTitle       string                 `json:"order_title"`
+	IssuedAt         string                 `json:"issued_at"`
+	Digest           string                 `json:"digest"`
+	DocumentChecksum string                 `json:"document_sha256"`
+	Requirements     []CertifiedRequirement `json:"requirements"`
+}
+
+type CertifiedRequirement struct {
+	ID              int                 `json:"id"`
+	Request         string              `json:"request"`
+	ExpectedOutcome string              `json:"expected_outcome"`
+	ApprovedAt      string              `json:"approved_at,omitempty"`
+	Reviewers       []Reviewer          `json:"reviewers"`
+	Evidence        []CertifiedEvidence `json:"evidence"`
+}
+
+type Reviewer struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+type CertifiedEvidence struct {
+	TaskID       string `json:"task_id"`
+	SubmissionID string `json:"submission_id"`
+	SubmittedAt  string `json:"submitted_at"`
+	SHA256       string `json:"sha256"`
+}
+
+type CertificateCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+type CertificateVerification struct {
+	Valid       bool               `json:"valid"`
+	Certificate *Certificate       `json:"certificate,omitempty"`
+	Checks      []CertificateCheck `json:"checks"`
+}
+
+func BuildCertificatePayload(c *entity.Certificate) Certificate {
+	response := Certificate{
+		ID:               c.ID,
+		OrderID:          c.Content.OrderID,
+		OrderTitle:       c.Content.OrderTitle,
+		IssuedAt:         c.IssuedAt.Format("2/Jan/2006 15:04:05"),
+		Digest:           c.Digest,
+		DocumentChecksum: c.DocumentChecksum,
+		Requirements:     []CertifiedRequirement{},
+	}
+	for _, req := range c.Content.Requirements {
+		certified := CertifiedRequirement{
+			ID:              req.ID,
+			Request:         req.Request,
+			ExpectedOutcome: req.ExpectedOutcome,
+			Reviewers:       []Reviewer{},
+			Evidence:        []CertifiedEvidence{},
+		}
+		if !req.ApprovedAt.IsZero() {
+			certified.ApprovedAt = req.ApprovedAt.Format("2/Jan/2006 15:04:05")
+		}
+		for _, r := range req.Reviewers {
+			certified.Reviewers = append(certified.Reviewers, Reviewer{UserID: r.UserID, Username: r.Username})
+		}
+		for _, e := range req.Evidence {
+			certified.Evidence = append(certified.Evidence, CertifiedEvidence{
+				TaskID:       e.TaskID,
+				SubmissionID: e.SubmissionID,
+				SubmittedAt:  e.SubmittedAt.Format("2/Jan/2006 15:04:05"),
+				SHA256:       e.SHA256,
+			})
+		}
+		response.Requirements = append(response.Requirements, certified)
+	}
+	return response
+}
+
+func BuildCertificates(list []*entity.Certificate) []Certificate {
+	certificates := []Certificate{}
+	for _, c := range list {
+		certificates = append(certificates, BuildCertificatePayload(c))
+	}
+	return certificates
+}
+
+func BuildCertificateVerificationPayload(v *entity.CertificateVerification) CertificateVerification {
+	response := CertificateVerification{Valid: v.Valid(), Checks: []CertificateCheck{}}
+	if v.Certificate != nil {
+		c := BuildCertificatePayload(v.Certificate)
+		response.Certificate = &c
+	}
+	for _, check := range v.Checks {
+		response.Checks = append(response.Checks, CertificateCheck{Name: check.Name, Passed: check.Passed, Detail: check.Detail})
+	}
+	return response
+}
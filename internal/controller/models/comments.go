@@ -0,0 +1,88 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+//CommentForm body is Markdown, @username mentions notify the user
+type CommentForm struct {
+	Body string `json:"body"`
+}
+
+type Mention struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+//Comment body is empty once the comment is deleted
+type Comment struct {
+	ID        string    `json:"id"`
+	ThreadID  string    `json:"thread_id,omitempty"`
+	AuthorID  string    `json:"author_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Mentions  []Mention `json:"mentions"`
+	CreatedAt string    `json:"created_at"`
+	EditedAt  string    `json:"edited_at,omitempty"`
+	Deleted   bool      `json:"deleted"`
+}
+
+type CommentThread struct {
+	Comment Comment   `json:"comment"`
+	Replies []Comment `json:"replies"`
+}
+
+type CommentPage struct {
+	Total   int             `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	Threads []CommentThread `json:"threads"`
+}
+
+type CommentEdit struct {
+	Body     string `json:"body"`
+	EditedBy string `json:"edited_by"`
+	EditedAt string `json:"edited_at"`
+}
+
+func BuildCommentPayload(c *entity.Comment) Comment {
+	response := Comment{
+		ID:        c.ID,
+		ThreadID:  c.ThreadID,
+		AuthorID:  c.AuthorID,
+		Author:    c.AuthorName,
+		Body:      c.Body,
+		Mentions:  []Mention{},
+		CreatedAt: c.CreatedAt.Format("2/Jan/2006 15:04:05"),
+		Deleted:   c.Deleted(),
+	}
+	if !c.EditedAt.IsZero() {
+		response.EditedAt = c.EditedAt.Format("2/Jan/2006 15:04:05")
+	}
+	for _, m := range c.Mentions {
+		response.Mentions = append(response.Mentions, Mention{UserID: m.UserID, Username: m.Username})
+	}
+	return response
+}
+
+func BuildCommentPage(threads []*entity.CommentThread, total int, limit int, offset int) CommentPage {
+	page := CommentPage{Total: total, Limit: limit, Offset: offset, Threads: []CommentThread{}}
+	for _, t := range threads {
+		thread := CommentThread{Comment: BuildCommentPayload(t.Comment), Replies: []Comment{}}
+		for _, reply := range t.Replies {
+			thread.Replies = append(thread.Replies, BuildCommentPayload(reply))
+		}
+		page.Threads = append(page.Threads, thread)
+	}
+	return page
+}
+
+func BuildCommentHistory(edits []*entity.CommentEdit) []CommentEdit {
+	response := []CommentEdit{}
+	for _, e := range edits {
+		response = append(response, CommentEdit{
+			Body:     e.Body,
+			EditedBy: e.EditedBy,
+			EditedAt: e.EditedAt.Format("2/Jan/2006 15:04:05"),
+		})
+	}
+	return response
+}
@@ -0,0 +1,150 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type Customer struct {
+	ID        string    `json:"id,omitempty"`
+	Name      string    `json:"name"`
+	Contacts  []Contact `json:"contacts"`
+	CreatedAt string    `json:"created_at,omitempty"`
+}
+
+type Contact struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type NewShareLink struct {
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+//ShareLink carries the URL only when the link is created, it can't be
+//recovered afterwards
+type ShareLink struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	OrderID    string `json:"order_id"`
+	CreatedBy  string `json:"created_by"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Revoked    bool   `json:"revoked"`
+	URL        string `json:"url,omitempty"`
+}
+
+//SharedOrder is what a customer sees through a share link. It names no
+//worker or reviewer and leaves out review messages.
+type SharedOrder struct {
+	Customer        string              `json:"customer"`
+	Title           string              `json:"title"`
+	Description     string              `json:"description"`
+	Deadline        string              `json:"deadline"`
+	Status          string              `json:"status"`
+	PercentComplete float64             `json:"percent_complete"`
+	Requirements    []SharedRequirement `json:"requirements"`
+	LinkExpiresAt   string              `json:"link_expires_at"`
+}
+
+//SharedRequirement Outcome is "approved", "in_review" or "pending"
+type SharedRequirement struct {
+	Request         string     `json:"request"`
+	ExpectedOutcome string     `json:"expected_outcome"`
+	Outcome         string     `json:"outcome"`
+	ApprovedTasks   int        `json:"approved_tasks"`
+	InReviewTasks   int        `json:"in_review_tasks"`
+	PendingTasks    int        `json:"pending_tasks"`
+	Evidence        []Evidence `json:"evidence"`
+}
+
+//Evidence is a submission of an approved task
+type Evidence struct {
+	SubmittedAt string   `json:"submitted_at"`
+	Message     string   `json:"message"`
+	Images      []string `json:"images"`
+}
+
+func BuildCustomerPayload(c *entity.Customer) Customer {
+	customer := Customer{
+		ID:        c.ID,
+		Name:      c.Name,
+		Contacts:  []Contact{},
+		CreatedAt: c.CreatedAt.Format("2/Jan/2006 15:04:05"),
+	}
+	for _, contact := range c.Contacts {
+		customer.Contacts = append(customer.Contacts, Contact{Name: contact.Name, Email: contact.Email, Phone: contact.Phone})
+	}
+	return customer
+}
+
+func DecodeContacts(contacts []Contact) []entity.Contact {
+	var decoded []entity.Contact
+	for _, contact := range contacts {
+		decoded = append(decoded, entity.Contact{Name: contact.Name, Email: contact.Email, Phone: contact.Phone})
+	}
+	return decoded
+}
+
+func BuildShareLinkPayload(l *entity.ShareLink) ShareLink {
+	return ShareLink{
+		ID:         l.ID,
+		CustomerID: l.CustomerID,
+		OrderID:    l.OrderID,
+		CreatedBy:  l.CreatedBy,
+		CreatedAt:  l.CreatedAt.Format("2/Jan/2006 15:04:05"),
+		ExpiresAt:  l.ExpiresAt.Format("2/Jan/2006 15:04:05"),
+		Revoked:    l.Revoked,
+	}
+}
+
+//BuildSharedOrderPayload only takes the evidence of approved tasks, keyed by
+//requirement
+func BuildSharedOrderPayload(customer *entity.Customer, o *entity.Orders, progress *entity.OrderProgress, link *entity.ShareLink,
+	requirements []*entity.Requirements, tasks []*entity.TaskWithDetails, evidence map[int][]*entity.Submission) SharedOrder {
+	shared := SharedOrder{
+		Customer:        customer.Name,
+		Title:           o.Title,
+		Description:     o.Description,
+		Deadline:        o.Deadline.Format("2/Jan/2006 15:04:05"),
+		Status:          string(o.Status),
+		PercentComplete: progress.PercentComplete,
+		Requirements:    []SharedRequirement{},
+		LinkExpiresAt:   link.ExpiresAt.Format("2/Jan/2006 15:04:05"),
+	}
+	for _, r := range requirements {
+		requirement := SharedRequirement{
+			Request:         r.Request,
+			ExpectedOutcome: r.ExpectedOutcome,
+			Evidence:        []Evidence{},
+		}
+		for _, t := range tasks {
+			if t.RequirementID != r.Id {
+				continue
+			}
+			switch t.Status {
+			case entity.Finished:
+				requirement.ApprovedTasks++
+			case entity.InReview:
+				requirement.InReviewTasks++
+			default:
+				requirement.PendingTasks++
+			}
+		}
+		switch {
+		case r.Status == entity.AssignedAndFinished:
+			requirement.Outcome = "approved"
+		case requirement.InReviewTasks > 0:
+			requirement.Outcome = "in_review"
+		default:
+			requirement.Outcome = "pending"
+		}
+		for _, s := range evidence[r.Id] {
+			e := Evidence{SubmittedAt: s.SubmissionTime.Format("2/Jan/2006 15:04:05"), Message: s.Message, Images: []string{}}
+			for _, image := range s.Images {
+				e.Images = append(e.Images, image.Image)
+			}
+			requirement.Evidence = append(requirement.Evidence, e)
+		}
+		shared.Requirements = append(shared.Requirements, requirement)
+	}
+	return shared
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"order-validation-v2/internal/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSharedOrderPayload(t *testing.T) {
+	deadline := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	requirements := []*entity.Requirements{
+		{Id: 1, Request: "Paint the fence", ExpectedOutcome: "White fence", Status: entity.AssignedAndFinished},
+		{Id: 2, Request: "Fix the gate", ExpectedOutcome: "Gate closes"},
+	}
+	tasks := []*entity.TaskWithDetails{
+		{ID: "t1", RequirementID: 1, Status: entity.Finished, UserID: "worker-id", Username: "worker-name",
+			AssignedBy: "reviewer-id", Note: "internal note", Messages: []entity.Message{{UserID: "reviewer-id", Username: "reviewer-name", Message: "review remark"}}},
+		{ID: "t2", RequirementID: 2, Status: entity.InReview, UserID: "worker-id", Username: "worker-name", AssignedBy: "reviewer-id"},
+		{ID: "t3", RequirementID: 2, Status: entity.Unfinished, UserID: "worker-id", Username: "worker-name", AssignedBy: "reviewer-id"},
+	}
+	evidence := map[int][]*entity.Submission{
+		1: {{ID: "s1", TaskID: "t1", SubmissionTime: deadline, Message: "done",
+			Images: []entity.SubmissionImage{{Image: "fence.png", SubmissionID: "s1"}}}},
+	}
+	shared := BuildSharedOrderPayload(&entity.Customer{Name: "Acme"},
+		&entity.Orders{ID: "o1", Title: "Garden", Deadline: deadline, Status: entity.OrderInProgress},
+		&entity.OrderProgress{PercentComplete: 50}, &entity.ShareLink{ExpiresAt: deadline},
+		requirements, tasks, evidence)
+
+	if len(shared.Requirements) != 2 {
+		t.Fatalf("got %d requirements, want 2", len(shared.Requirements))
+	}
+	approved, open := shared.Requirements[0], shared.Requirements[1]
+	if approved.Outcome != "approved" || approved.ApprovedTasks != 1 || len(approved.Evidence) != 1 {
+		t.Errorf("approved requirement = %+v", approved)
+	}
+	if e := approved.Evidence[0]; e.Message != "done" || len(e.Images) != 1 || e.Images[0] != "fence.png" {
+		t.Errorf("evidence = %+v", e)
+	}
+	if open.Outcome != "in_review" || open.InReviewTasks != 1 || open.PendingTasks != 1 || len(open.Evidence) != 0 {
+		t.Errorf("open requirement = %+v", open)
+	}
+
+	body, err := json.Marshal(shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, internal := range []string{"worker-id", "worker-name", "reviewer-id", "reviewer-name", "review remark", "internal note", "t1", "s1"} {
+		if strings.Contains(string(body), internal) {
+			t.Errorf("shared order leaks %q: %s", internal, body)
+		}
+	}
+}
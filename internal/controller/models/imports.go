@@ -0,0 +1,51 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type ImportReport struct {
+	DryRun           bool            `json:"dry_run"`
+	Loaded           bool            `json:"loaded"`
+	OrderCount       int             `json:"order_count"`
+	RequirementCount int             `json:"requirement_count"`
+	Orders           []ImportedOrder `json:"orders"`
+	Errors           []ImportError   `json:"errors"`
+}
+
+//ImportedOrder is an order of the file as it is or would be created. IDs
+//are only set once the orders are loaded.
+type ImportedOrder struct {
+	Row int `json:"row"`
+	Orders
+}
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func BuildImportReportPayload(report *entity.ImportReport) ImportReport {
+	payload := ImportReport{
+		DryRun:           report.DryRun,
+		Loaded:           report.Loaded(),
+		OrderCount:       len(report.Orders),
+		RequirementCount: report.RequirementCount(),
+		Orders:           []ImportedOrder{},
+		Errors:           []ImportError{},
+	}
+	for _, imported := range report.Orders {
+		order := BuildPayload([]*entity.Orders{imported.Order})[0]
+		order.AddRequirements(imported.Requirements)
+		if !payload.Loaded {
+			order.ID = ""
+			for i := range order.Requirements {
+				order.Requirements[i].OrderID = ""
+			}
+		}
+		payload.Orders = append(payload.Orders, ImportedOrder{Row: imported.Row, Orders: *order})
+	}
+	for _, e := range report.Errors {
+		payload.Errors = append(payload.Errors, ImportError{Row: e.Row, Field: e.Field, Message: e.Message})
+	}
+	return payload
+}
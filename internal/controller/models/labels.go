@@ -0,0 +1,82 @@
+package models
+
+import (
+	"order-validation-v2/internal/entity"
+	"strconv"
+)
+
+//CustomField types are text, number, date or enum. Resources are order,
+//requirement and task, all of them when left out.
+type CustomField struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Options   []string `json:"options,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+//Labels replace all tags and field values of a resource. Date fields are
+//written as 2006-01-02.
+type Labels struct {
+	Tags   []string          `json:"tags"`
+	Fields map[string]string `json:"fields"`
+}
+
+func BuildCustomFieldPayload(f *entity.CustomField) CustomField {
+	field := CustomField{
+		Name:    f.Name,
+		Type:    string(f.Type),
+		Options: f.Options,
+	}
+	for _, t := range f.Resources {
+		field.Resources = append(field.Resources, string(t))
+	}
+	return field
+}
+
+func DecodeCustomField(field CustomField) *entity.CustomField {
+	f := &entity.CustomField{
+		Name:    field.Name,
+		Type:    entity.FieldType(field.Type),
+		Options: field.Options,
+	}
+	for _, t := range field.Resources {
+		f.Resources = append(f.Resources, entity.ResourceType(t))
+	}
+	return f
+}
+
+func DecodeLabels(tags []string, fields map[string]string) *entity.Labels {
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	return &entity.Labels{Tags: tags, Fields: fields}
+}
+
+func (o *Orders) AddLabels(l *entity.Labels) {
+	if l == nil {
+		return
+	}
+	o.Tags = l.Tags
+	o.Fields = l.Fields
+}
+
+//AddRequirementLabels labels the order's requirements, labels is keyed by
+//requirement id
+func (o *Orders) AddRequirementLabels(labels map[string]*entity.Labels) {
+	for i := range o.Requirements {
+		if l, ok := labels[strconv.Itoa(o.Requirements[i].Id)]; ok {
+			o.Requirements[i].Tags = l.Tags
+			o.Requirements[i].Fields = l.Fields
+		}
+	}
+}
+
+//AddTaskLabels labels the tasks, labels is keyed by task id
+func AddTaskLabels(tasks []*TaskWithDetail, labels map[string]*entity.Labels) {
+	for _, t := range tasks {
+		if l, ok := labels[t.Id]; ok {
+			t.Tags = l.Tags
+			t.Fields = l.Fields
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type LoginAttempt struct {
+	Username    string              `json:"username"`
+	UserID      string              `json:"user_id,omitempty"`
+	IP          string              `json:"ip"`
+	UserAgent   string              `json:"user_agent"`
+	Outcome     entity.LoginOutcome `json:"outcome"`
+	Suspicious  bool                `json:"suspicious"`
+	AttemptedAt string              `json:"attempted_at"`
+}
+
+func BuildLoginAttemptPayload(A []*entity.LoginAttempt) []LoginAttempt {
+	var attempts []LoginAttempt
+	for _, a := range A {
+		attempts = append(attempts, LoginAttempt{
+			Username:    a.Username,
+			UserID:      a.UserID,
+			IP:          a.IP,
+			UserAgent:   a.UserAgent,
+			Outcome:     a.Outcome,
+			Suspicious:  a.Suspicious,
+			AttemptedAt: a.AttemptedAt.Format("2/Jan/2006 15:04:05"),
+		})
+	}
+	return attempts
+}
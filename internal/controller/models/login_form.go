@@ -6,7 +6,14 @@ type LoginForm struct {
 }
 
 type Token struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	//RecoveryCodes is only set when 2FA enrollment completes during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type RefreshForm struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type ChangePasswordForm struct {
@@ -18,3 +25,12 @@ type ChangeUsernameForm struct {
 	NewUsername string `json:"new_username"`
 	Password    string `json:"password"`
 }
+
+type SetPasswordForm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ForgotPasswordForm struct {
+	Username string `json:"username"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type RequirementProgress struct {
+	Id            int           `json:"id"`
+	Request       string        `json:"request"`
+	Status        entity.Status `json:"status"`
+	TotalTasks    int           `json:"total_tasks"`
+	FinishedTasks int           `json:"finished_tasks"`
+}
+
+type TaskCounts struct {
+	Unfinished int `json:"unfinished"`
+	InReview   int `json:"in_review"`
+	Finished   int `json:"finished"`
+}
+
+type BlockedTask struct {
+	TaskID    string   `json:"task_id"`
+	WaitingOn []string `json:"waiting_on"`
+	Depth     int      `json:"chain_depth"`
+}
+
+type OrderProgress struct {
+	OrderID          string                `json:"order_id"`
+	Status           string                `json:"status"`
+	PercentComplete  float64               `json:"percent_complete"`
+	Requirements     []RequirementProgress `json:"requirements"`
+	TaskCounts       TaskCounts            `json:"task_counts"`
+	OpenReviewRounds int                   `json:"open_review_rounds"`
+	PendingReviewers int                   `json:"pending_reviewers"`
+	OverdueTasks     []string              `json:"overdue_tasks"`
+	BlockedTasks     []BlockedTask         `json:"blocked_tasks"`
+}
+
+func BuildOrderProgressPayload(p *entity.OrderProgress) OrderProgress {
+	response := OrderProgress{
+		OrderID:         p.OrderID,
+		Status:          string(p.Status),
+		PercentComplete: p.PercentComplete,
+		TaskCounts: TaskCounts{
+			Unfinished: p.TaskCounts[entity.Unfinished],
+			InReview:   p.TaskCounts[entity.InReview],
+			Finished:   p.TaskCounts[entity.Finished],
+		},
+		OpenReviewRounds: p.OpenReviewRounds,
+		PendingReviewers: p.PendingReviewers,
+		Requirements:     []RequirementProgress{},
+		OverdueTasks:     []string{},
+		BlockedTasks:     []BlockedTask{},
+	}
+	for _, r := range p.Requirements {
+		response.Requirements = append(response.Requirements, RequirementProgress{
+			Id:            r.ID,
+			Request:       r.Request,
+			Status:        r.Status,
+			TotalTasks:    r.TotalTasks,
+			FinishedTasks: r.FinishedTasks,
+		})
+	}
+	response.OverdueTasks = append(response.OverdueTasks, p.OverdueTasks...)
+	for _, b := range p.BlockedTasks {
+		response.BlockedTasks = append(response.BlockedTasks, BlockedTask{
+			TaskID:    b.TaskID,
+			WaitingOn: b.WaitingOn,
+			Depth:     b.Depth,
+		})
+	}
+	return response
+}
@@ -3,11 +3,19 @@ package models
 import "order-validation-v2/internal/entity"
 
 type Orders struct {
-	ID           string         `json:"id,omitempty"`
-	Title        string         `json:"title"`
-	Description  string         `json:"description"`
-	Deadline     string         `json:"deadline"`
-	Requirements []Requirements `json:"requirements"`
+	ID           string            `json:"id,omitempty"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	Deadline     string            `json:"deadline"`
+	Status       string            `json:"status,omitempty"`
+	Priority     string            `json:"priority,omitempty"`
+	SLAPolicy    string            `json:"sla_policy,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	CustomerID   string            `json:"customer_id,omitempty"`
+	DeletedAt    string            `json:"deleted_at,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	Requirements []Requirements    `json:"requirements"`
 }
 
 type OrderPatch struct {
@@ -15,6 +23,17 @@ type OrderPatch struct {
 	Title       *string `json:"new_title"`
 	Description *string `json:"new_description"`
 	Deadline    *string `json:"new_deadline"`
+	Priority    *string `json:"new_priority"`
+	SLAPolicy   *string `json:"new_sla_policy"`
+	CustomerID  *string `json:"new_customer_id"`
+	//NewTags replaces the tags, NewFields sets the given fields and removes
+	//those set to an empty value
+	NewTags   *[]string         `json:"new_tags"`
+	NewFields map[string]string `json:"new_fields"`
+}
+
+type OrderTransition struct {
+	Status string `json:"status"`
 }
 
 func BuildPayload(O []*entity.Orders) []*Orders {
@@ -25,6 +44,16 @@ func BuildPayload(O []*entity.Orders) []*Orders {
 			Description: o.Description,
 			Title:       o.Title,
 			Deadline:    o.Deadline.Format("2 Jan 2006"),
+			Status:      string(o.Status),
+			Priority:    string(o.Priority),
+			SLAPolicy:   o.SLAPolicy,
+			CustomerID:  o.CustomerID,
+		}
+		if !o.CreatedAt.IsZero() {
+			r.CreatedAt = o.CreatedAt.Format("2/Jan/2006 15:04:05")
+		}
+		if !o.DeletedAt.IsZero() {
+			r.DeletedAt = o.DeletedAt.Format("2/Jan/2006 15:04:05")
 		}
 
 		response = append(response, &r)
@@ -0,0 +1,23 @@
+package models
+
+type PasswordChangeRequired struct {
+	PasswordChangeRequired bool   `json:"password_change_required"`
+	PasswordChangeToken    string `json:"password_change_token"`
+	//RecoveryCodes is only set when 2FA enrollment completes during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type ExpiredPasswordForm struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationErrors struct {
+	Errors []FieldError `json:"errors"`
+}
@@ -3,11 +3,13 @@ package models
 import "order-validation-v2/internal/entity"
 
 type Requirements struct {
-	Id              int           `json:"id,omitempty"`
-	Request         string        `json:"request"`
-	ExpectedOutcome string        `json:"outcome"`
-	Status          entity.Status `json:"status"`
-	OrderID         string        `json:"order_id,omitempty"`
+	Id              int               `json:"id,omitempty"`
+	Request         string            `json:"request"`
+	ExpectedOutcome string            `json:"outcome"`
+	Status          entity.Status     `json:"status"`
+	OrderID         string            `json:"order_id,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Fields          map[string]string `json:"fields,omitempty"`
 }
 
 type RequirementPatch struct {
@@ -18,4 +20,7 @@ type Patch struct {
 	Id              int     `json:"id"`
 	ExpectedOutcome *string `json:"new_outcome"`
 	Request         *string `json:"new_request"`
+	//NewTags and NewFields work as on orders
+	NewTags   *[]string         `json:"new_tags"`
+	NewFields map[string]string `json:"new_fields"`
 }
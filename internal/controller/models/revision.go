@@ -0,0 +1,49 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+//Revision author is empty for the baseline of records changed before
+//history was kept
+type Revision struct {
+	Number     int               `json:"number"`
+	AuthorID   string            `json:"author_id"`
+	CreatedAt  string            `json:"created_at"`
+	Changes    []FieldChange     `json:"changes"`
+	Snapshot   map[string]string `json:"snapshot"`
+	RestoredOf int               `json:"restored_of,omitempty"`
+}
+
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+func BuildRevisionPayload(revisions []*entity.Revision) []Revision {
+	response := []Revision{}
+	for _, r := range revisions {
+		response = append(response, Revision{
+			Number:     r.Number,
+			AuthorID:   r.AuthorID,
+			CreatedAt:  r.CreatedAt.Format("2/Jan/2006 15:04:05"),
+			Changes:    BuildFieldChangePayload(r.Changes),
+			Snapshot:   r.Snapshot,
+			RestoredOf: r.RestoredOf,
+		})
+	}
+	return response
+}
+
+func BuildFieldChangePayload(changes []entity.FieldChange) []FieldChange {
+	response := []FieldChange{}
+	for _, change := range changes {
+		response = append(response, FieldChange{Field: change.Field, From: change.From, To: change.To})
+	}
+	return response
+}
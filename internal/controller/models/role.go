@@ -0,0 +1,34 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type Role struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Permissions []entity.Permission `json:"permissions"`
+	BuiltIn     bool                `json:"builtin"`
+	Require2FA  bool                `json:"require_2fa"`
+}
+
+type Require2FAForm struct {
+	Required bool `json:"required"`
+}
+
+type RolePatch struct {
+	Description *string              `json:"new_description"`
+	Permissions *[]entity.Permission `json:"new_permissions"`
+}
+
+func BuildRolePayload(R []*entity.Role) []Role {
+	var roles []Role
+	for _, r := range R {
+		roles = append(roles, Role{
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: r.Permissions,
+			BuiltIn:     r.BuiltIn,
+			Require2FA:  r.Require2FA,
+		})
+	}
+	return roles
+}
@@ -0,0 +1,29 @@
+package models
+
+import "order-validation-v2/internal/entity"
+
+type Session struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+//BuildSessionPayload flags the session with ID current, pass "" when
+//listing another user's sessions
+func BuildSessionPayload(S []*entity.Session, current string) []Session {
+	var sessions []Session
+	for _, s := range S {
+		sessions = append(sessions, Session{
+			ID:         s.ID,
+			Device:     s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt.Format("2/Jan/2006 15:04:05"),
+			LastSeenAt: s.LastSeenAt.Format("2/Jan/2006 15:04:05"),
+			Current:    s.ID == current,
+		})
+	}
+	return sessions
+}
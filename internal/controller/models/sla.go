@@ -0,0 +1,107 @@
+package models
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+//SLAPolicy targets are durations such as "4h" or "72h30m", an empty target
+//isn't tracked
+type SLAPolicy struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	TargetStart      string `json:"target_start"`
+	TargetCompletion string `json:"target_completion"`
+	ReviewTurnaround string `json:"review_turnaround"`
+}
+
+type SLAClock struct {
+	Name      string  `json:"name"`
+	TaskID    string  `json:"task_id,omitempty"`
+	Target    string  `json:"target"`
+	StartedAt string  `json:"started_at"`
+	StoppedAt string  `json:"stopped_at,omitempty"`
+	Elapsed   string  `json:"elapsed"`
+	Risk      float64 `json:"risk"`
+	Breached  bool    `json:"breached"`
+}
+
+type OrderSLA struct {
+	OrderID  string     `json:"order_id"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Priority string     `json:"priority"`
+	Policy   string     `json:"sla_policy,omitempty"`
+	Clocks   []SLAClock `json:"clocks"`
+}
+
+func BuildSLAPolicyPayload(p *entity.SLAPolicy) SLAPolicy {
+	policy := SLAPolicy{
+		Name:        p.Name,
+		Description: p.Description,
+	}
+	if p.TargetStart > 0 {
+		policy.TargetStart = p.TargetStart.String()
+	}
+	if p.TargetCompletion > 0 {
+		policy.TargetCompletion = p.TargetCompletion.String()
+	}
+	if p.ReviewTurnaround > 0 {
+		policy.ReviewTurnaround = p.ReviewTurnaround.String()
+	}
+	return policy
+}
+
+func DecodeSLAPolicy(policy SLAPolicy) (*entity.SLAPolicy, error) {
+	p := &entity.SLAPolicy{
+		Name:        policy.Name,
+		Description: policy.Description,
+	}
+	var err error
+	p.TargetStart, err = parseOffset(policy.TargetStart)
+	if err != nil {
+		return nil, err
+	}
+	p.TargetCompletion, err = parseOffset(policy.TargetCompletion)
+	if err != nil {
+		return nil, err
+	}
+	p.ReviewTurnaround, err = parseOffset(policy.ReviewTurnaround)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//BuildOrderSLAPayload reports the given clocks of the order as of now
+func BuildOrderSLAPayload(o *entity.OrderSLA, clocks []entity.SLAClock, now time.Time) OrderSLA {
+	response := OrderSLA{
+		OrderID:  o.OrderID,
+		Title:    o.Title,
+		Status:   string(o.Status),
+		Priority: string(o.Priority),
+		Clocks:   []SLAClock{},
+	}
+	if o.Policy != nil {
+		response.Policy = o.Policy.Name
+	}
+	for _, c := range clocks {
+		if c.Target <= 0 {
+			continue
+		}
+		clock := SLAClock{
+			Name:      c.Name,
+			TaskID:    c.TaskID,
+			Target:    c.Target.String(),
+			StartedAt: c.StartedAt.Format("2/Jan/2006 15:04:05"),
+			Elapsed:   c.Elapsed(now).Round(time.Second).String(),
+			Risk:      c.Risk(now),
+			Breached:  c.Breached(now),
+		}
+		if !c.Running() {
+			clock.StoppedAt = c.StoppedAt.Format("2/Jan/2006 15:04:05")
+		}
+		response.Clocks = append(response.Clocks, clock)
+	}
+	return response
+}
@@ -9,19 +9,22 @@ type ReviewForm struct {
 }
 
 type TaskWithDetail struct {
-	Id               string        `json:"id"`
-	Note             string        `json:"note"`
-	User             string        `json:"assigned_user,omitempty"`
-	Username         string        `json:"assigned_username,omitempty"`
-	Request          string        `json:"task"`
-	ExpectedOutcome  string        `json:"outcome,omitempty"`
-	Prerequisites    []string      `json:"prerequisites,omitempty"`
-	Status           entity.Status `json:"status"`
-	TaskDeadline     string        `json:"deadline,omitempty"`
-	OrderTitle       string        `json:"order_title,omitempty"`
-	OrderDescription string        `json:"order_description,omitempty"`
-	OrderDeadline    string        `json:"order_deadline,omitempty"`
-	Feedbacks        []Feedback    `json:"feedbacks"`
+	Id               string            `json:"id"`
+	Note             string            `json:"note"`
+	User             string            `json:"assigned_user,omitempty"`
+	Username         string            `json:"assigned_username,omitempty"`
+	Request          string            `json:"task"`
+	ExpectedOutcome  string            `json:"outcome,omitempty"`
+	Prerequisites    []string          `json:"prerequisites,omitempty"`
+	Status           entity.Status     `json:"status"`
+	TaskDeadline     string            `json:"deadline,omitempty"`
+	OrderTitle       string            `json:"order_title,omitempty"`
+	OrderDescription string            `json:"order_description,omitempty"`
+	OrderDeadline    string            `json:"order_deadline,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	Fields           map[string]string `json:"fields,omitempty"`
+	Attachments      []Attachment      `json:"attachments,omitempty"`
+	Feedbacks        []Feedback        `json:"feedbacks"`
 }
 
 type Feedback struct {
@@ -30,12 +33,14 @@ type Feedback struct {
 }
 
 type NewTask struct {
-	Num           string   `json:"num,omitempty"`
-	RequirementID int      `json:"requirement_id"`
-	Note          string   `json:"note"`
-	UserID        string   `json:"user_id"`
-	Prerequisite  []string `json:"prerequisite"`
-	Deadline      string   `json:"deadline"`
+	Num           string            `json:"num,omitempty"`
+	RequirementID int               `json:"requirement_id"`
+	Note          string            `json:"note"`
+	UserID        string            `json:"user_id"`
+	Prerequisite  []string          `json:"prerequisite"`
+	Deadline      string            `json:"deadline"`
+	Tags          []string          `json:"tags"`
+	Fields        map[string]string `json:"fields"`
 }
 
 type BulkAddedTasks struct {
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+//OrderTemplate offsets are Go durations such as "720h" or "-48h"
+type OrderTemplate struct {
+	ID             string                `json:"id,omitempty"`
+	Name           string                `json:"name"`
+	Title          string                `json:"title"`
+	Description    string                `json:"description"`
+	DeadlineOffset string                `json:"deadline_offset"`
+	Requirements   []TemplateRequirement `json:"requirements,omitempty"`
+	CreatedBy      string                `json:"created_by,omitempty"`
+	CreatedAt      string                `json:"created_at,omitempty"`
+}
+
+type TemplateRequirement struct {
+	Request         string         `json:"request"`
+	ExpectedOutcome string         `json:"outcome"`
+	Tasks           []TemplateTask `json:"tasks,omitempty"`
+}
+
+type TemplateTask struct {
+	Key            string   `json:"key"`
+	UserID         string   `json:"user_id"`
+	Note           string   `json:"note"`
+	DeadlineOffset string   `json:"deadline_offset"`
+	Prerequisites  []string `json:"prerequisites,omitempty"`
+}
+
+type SaveAsTemplateForm struct {
+	Name string `json:"name"`
+}
+
+//OrderOverrides replace template values, empty fields keep them.
+//Assignees maps task keys, or for a clone the IDs of the order's tasks, to
+//user IDs.
+type OrderOverrides struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Deadline    string            `json:"deadline"`
+	Status      string            `json:"status"`
+	Priority    string            `json:"priority"`
+	SLAPolicy   string            `json:"sla_policy"`
+	CustomerID  string            `json:"customer_id"`
+	Assignees   map[string]string `json:"assignees"`
+}
+
+func BuildTemplatePayload(t *entity.OrderTemplate) OrderTemplate {
+	template := OrderTemplate{
+		ID:             t.ID,
+		Name:           t.Name,
+		Title:          t.Title,
+		Description:    t.Description,
+		DeadlineOffset: t.DeadlineOffset.String(),
+		CreatedBy:      t.CreatedBy,
+		CreatedAt:      t.CreatedAt.Format("2/Jan/2006 15:04:05"),
+	}
+	for _, r := range t.Requirements {
+		requirement := TemplateRequirement{Request: r.Request, ExpectedOutcome: r.ExpectedOutcome}
+		for _, task := range r.Tasks {
+			requirement.Tasks = append(requirement.Tasks, TemplateTask{
+				Key:            task.Key,
+				UserID:         task.UserID,
+				Note:           task.Note,
+				DeadlineOffset: task.DeadlineOffset.String(),
+				Prerequisites:  task.Prerequisites,
+			})
+		}
+		template.Requirements = append(template.Requirements, requirement)
+	}
+	return template
+}
+
+func DecodeTemplatePayload(template OrderTemplate, createdBy string) (*entity.OrderTemplate, error) {
+	offset, err := parseOffset(template.DeadlineOffset)
+	if err != nil {
+		return nil, err
+	}
+	t := entity.NewOrderTemplate(template.Name, template.Title, template.Description, offset, createdBy)
+	for _, r := range template.Requirements {
+		requirement := entity.TemplateRequirement{Request: r.Request, ExpectedOutcome: r.ExpectedOutcome}
+		for _, task := range r.Tasks {
+			offset, err := parseOffset(task.DeadlineOffset)
+			if err != nil {
+				return nil, err
+			}
+			requirement.Tasks = append(requirement.Tasks, entity.TemplateTask{
+				Key:            task.Key,
+				UserID:         task.UserID,
+				Note:           task.Note,
+				DeadlineOffset: offset,
+				Prerequisites:  task.Prerequisites,
+			})
+		}
+		t.Requirements = append(t.Requirements, requirement)
+	}
+	return t, nil
+}
+
+func parseOffset(offset string) (time.Duration, error) {
+	if offset == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(offset)
+}
@@ -0,0 +1,30 @@
+package models
+
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	ChallengeToken     string `json:"challenge_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+type SecondFactorForm struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OTPForm struct {
+	Code string `json:"code"`
+}
+
+type DisableTOTPForm struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
@@ -10,18 +10,22 @@ type NewUser struct {
 }
 
 type RetrievedUser struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	Disabled       bool   `json:"disabled"`
+	ServiceAccount bool   `json:"service_account"`
 }
 
 func BuildUserProfile(user *entity.User) RetrievedUser {
 	return RetrievedUser{
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Role:     user.UserRole,
+		UserID:         user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Role:           user.UserRole,
+		Disabled:       user.Disabled,
+		ServiceAccount: user.ServiceAccount,
 	}
 
 }
@@ -2,24 +2,39 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"order-validation-v2/internal/controller/models"
 	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/requirements"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
+//GetAllUncompletedOrders lists drafts, open and in progress orders unless
+//?status= names other states, comma separated, or is "all"
 func (c *Controller) GetAllUncompletedOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := c.order.ListOrders()
+	statuses, ok := parseOrderStatuses(w, r, entity.UncompletedOrderStatuses)
+	if !ok {
+		return
+	}
+	matches, ok := c.matchLabels(w, r, entity.ResourceOrder)
+	if !ok {
+		return
+	}
+	orders, err := c.order.ListOrders(statuses)
 	if err != nil {
 		c.logger.ErrorLogger.Println("Error retrieving orders from database: ", err.Error())
 		return
 	}
-	response := models.BuildPayload(orders)
+	response := models.BuildPayload(filterOrders(orders, matches))
 	for _, order := range response {
 		requirements, err := c.requirements.GetRequirementsbyOrderId(order.ID)
 		if err != nil {
@@ -28,6 +43,11 @@ func (c *Controller) GetAllUncompletedOrders(w http.ResponseWriter, r *http.Requ
 		}
 		order.AddRequirements(requirements)
 	}
+	err = c.addOrderLabels(response)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error retrieving labels from database: ", err.Error())
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 
 }
@@ -35,12 +55,20 @@ func (c *Controller) GetAllUncompletedOrders(w http.ResponseWriter, r *http.Requ
 func (c *Controller) SearchOrders(w http.ResponseWriter, r *http.Request) {
 	request := mux.Vars(r)
 	query := request["query"]
-	orders, err := c.order.SearchOrders(query)
+	statuses, ok := parseOrderStatuses(w, r, nil)
+	if !ok {
+		return
+	}
+	matches, ok := c.matchLabels(w, r, entity.ResourceOrder)
+	if !ok {
+		return
+	}
+	orders, err := c.order.SearchOrders(query, statuses)
 	if err != nil {
 		c.logger.ErrorLogger.Printf("Error processing query %s : %s\n", query, err.Error())
 		return
 	}
-	response := models.BuildPayload(orders)
+	response := models.BuildPayload(filterOrders(orders, matches))
 	for _, order := range response {
 		requirements, err := c.requirements.GetRequirementsbyOrderId(order.ID)
 		if err != nil {
@@ -51,6 +79,11 @@ func (c *Controller) SearchOrders(w http.ResponseWriter, r *http.Request) {
 		order.AddRequirements(requirements)
 
 	}
+	err = c.addOrderLabels(response)
+	if err != nil {
+		c.logger.ErrorLogger.Println("Error retrieving labels from database: ", err.Error())
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 func (c *Controller) AddNewOrder(w http.ResponseWriter, r *http.Request) {
@@ -69,23 +102,51 @@ func (c *Controller) AddNewOrder(w http.ResponseWriter, r *http.Request) {
 		c.logger.ErrorLogger.Println("Invalid Request, Can't unmarshal :", err.Error())
 		return
 	}
+	if order.CustomerID != "" && !c.ensureCustomer(w, order.CustomerID) {
+		return
+	}
+	if order.SLAPolicy != "" && !c.ensureSLAPolicy(w, order.SLAPolicy) {
+		return
+	}
+	orderLabels := models.DecodeLabels(order.Tags, order.Fields)
+	if !c.validateLabels(w, entity.ResourceOrder, orderLabels) {
+		return
+	}
+	for _, requirement := range order.Requirements {
+		if !c.validateLabels(w, entity.ResourceRequirement, models.DecodeLabels(requirement.Tags, requirement.Fields)) {
+			return
+		}
+	}
 	deadline, _ := time.Parse("2/Jan/2006 15:04:05", order.Deadline)
-	id, err := c.order.NewOrder(order.Title, order.Description, deadline)
+	id, err := c.order.NewOrder(order.Title, order.Description, deadline, entity.OrderStatus(order.Status),
+		entity.Priority(order.Priority), order.SLAPolicy, order.CustomerID)
+	if errors.Is(err, orders.ErrInvalidTransition) || errors.Is(err, orders.ErrInvalidPriority) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
 		c.logger.ErrorLogger.Println("Can't add new order into database : ", err.Error())
+		return
 	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	c.recordOrder(nil, id, adminID)
+	c.saveLabels(entity.Resource{Type: entity.ResourceOrder, ID: id}, orderLabels)
 	var wg sync.WaitGroup
 	for _, requirement := range order.Requirements {
 		wg.Add(1)
 		go func(wg *sync.WaitGroup, requirement models.Requirements) {
-			_, err := c.requirements.CreateRequirement(requirement.Request, requirement.ExpectedOutcome, id)
+			requirementID, err := c.requirements.CreateRequirement(requirement.Request, requirement.ExpectedOutcome, id)
 			if err != nil {
 				c.logger.ErrorLogger.Println("Can't add requirements into database : ", err.Error())
 				wg.Done()
 				return
 			}
+			c.recordRequirement(nil, requirementID, adminID)
+			c.saveLabels(entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(requirementID)},
+				models.DecodeLabels(requirement.Tags, requirement.Fields))
 			wg.Done()
 		}(&wg, requirement)
 	}
@@ -98,6 +159,9 @@ func (c *Controller) AddNewOrder(w http.ResponseWriter, r *http.Request) {
 func (c *Controller) GetStatusOfOrder(w http.ResponseWriter, r *http.Request) {
 	request := mux.Vars(r)
 	uuid := request["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: uuid}) {
+		return
+	}
 	order, err := c.order.GetOrder(uuid)
 
 	if err != nil {
@@ -113,59 +177,178 @@ func (c *Controller) GetStatusOfOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Println(requirements[0].Status)
 	response[0].AddRequirements(requirements)
+	err = c.addOrderLabels(response)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error retrieving labels for order %s from database: %s\n", uuid, err.Error())
+		return
+	}
 
 	json.NewEncoder(w).Encode(response)
 
 }
 
+func (c *Controller) GetOrderProgress(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	progress, err := c.order.GetProgress(orderID)
+	if err == orders.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Order Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error retrieving progress of order %s : %s\n", orderID, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildOrderProgressPayload(progress))
+}
+
+//DeleteOrder soft deletes the order with its requirements, tasks and
+//submissions. It can be restored until the purge command removes it.
 func (c *Controller) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	request := mux.Vars(r)
 	uuid := request["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: uuid}) {
+		return
+	}
+	err := c.order.DeleteOrder(uuid)
+	if err == orders.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Order Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error deleting order %s : %s\n", uuid, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Order Deleted"))
+}
 
-	requirements, err := c.requirements.GetRequirementsbyOrderId(uuid)
+func (c *Controller) GetDeletedOrders(w http.ResponseWriter, r *http.Request) {
+	deleted, err := c.order.ListDeletedOrders()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving deleted orders: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildPayload(deleted))
+}
+
+func (c *Controller) RestoreOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
 		return
 	}
-	if len(requirements) == 0 {
+	err := c.order.RestoreOrder(orderID)
+	if err == orders.ErrNotFound {
 		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Deleted Order Not Found"))
 		return
 	}
-	for _, requirement := range requirements {
-		err = c.requirements.DeleteRequirement(requirement.Id)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error restoring order %s : %s\n", orderID, err.Error())
+		return
 	}
-	err = c.order.DeleteOrder(uuid)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Order Restored"))
+}
 
+func (c *Controller) DeleteRequirement(w http.ResponseWriter, r *http.Request) {
+	requirementID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(requirementID)}) {
+		return
+	}
+	req, err := c.requirements.GetRequirementbyID(requirementID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Requirement Not Found"))
+		return
+	}
+	if !c.ensureOrderOpen(w, req.OrderID) {
+		return
+	}
+	err = c.requirements.DeleteRequirement(requirementID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error deleting requirement %d : %s\n", requirementID, err.Error())
 		return
 	}
+	//the remaining requirements may all be validated now
+	o, err := c.order.SyncStatus(req.OrderID)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error syncing status of order %s: %s\n", req.OrderID, err.Error())
+	} else {
+		c.issueCertificate(o)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Requirement Deleted"))
+}
 
+func (c *Controller) RestoreRequirement(w http.ResponseWriter, r *http.Request) {
+	requirementID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(requirementID)}) {
+		return
+	}
+	err = c.requirements.RestoreRequirement(requirementID)
+	if err == requirements.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Deleted Requirement Not Found, Or Its Order Is Deleted"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error restoring requirement %d : %s\n", requirementID, err.Error())
+		return
+	}
+	c.syncOrderOfRequirement(requirementID)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Requirement Restored"))
 }
 
 func (c *Controller) ModifyOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
 	var patch models.OrderPatch
 	req, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
+		return
 	}
 	err = json.Unmarshal(req, &patch)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
+		return
 	}
 	orderDetail, err := c.order.GetOrder(orderID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.logger.ErrorLogger.Println("Error while retrieving order : ", err.Error())
 		w.Write([]byte("Internal Server Error"))
+		return
 	}
+	before := *orderDetail
 	if patch.Deadline != nil {
 		orderDetail.Deadline, err = time.Parse("2/Jan/2006 15:04:05", *patch.Deadline)
 		if err != nil {
@@ -182,11 +365,51 @@ func (c *Controller) ModifyOrder(w http.ResponseWriter, r *http.Request) {
 		orderDetail.Title = *patch.Title
 	}
 
+	//an empty customer id detaches the order from its customer
+	if patch.CustomerID != nil {
+		if *patch.CustomerID != "" && !c.ensureCustomer(w, *patch.CustomerID) {
+			return
+		}
+		orderDetail.CustomerID = *patch.CustomerID
+	}
+
+	if patch.Priority != nil {
+		priority, ok := entity.ParsePriority(*patch.Priority)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Priority"))
+			return
+		}
+		orderDetail.Priority = priority
+	}
+
+	//an empty policy name takes the order off its SLA
+	if patch.SLAPolicy != nil {
+		if *patch.SLAPolicy != "" && !c.ensureSLAPolicy(w, *patch.SLAPolicy) {
+			return
+		}
+		orderDetail.SLAPolicy = *patch.SLAPolicy
+	}
+
+	resource := entity.Resource{Type: entity.ResourceOrder, ID: orderID}
+	var orderLabels *entity.Labels
+	if patch.NewTags != nil || len(patch.NewFields) > 0 {
+		orderLabels, err = c.labels.PatchedLabels(resource, patch.NewTags, patch.NewFields)
+		if c.writeLabelsError(w, err) {
+			return
+		}
+	}
+
 	err = c.order.UpdateOrder(orderDetail)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("Internal Server Error"))
 		c.logger.ErrorLogger.Println("Error while modifying order : ", err.Error())
+		return
+	}
+	c.recordOrder(&before, orderID, fmt.Sprintf("%v", r.Context().Value(ctxKey{})))
+	if orderLabels != nil && c.writeLabelsError(w, c.labels.SetLabels(resource, orderLabels)) {
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Order Modified"))
@@ -199,19 +422,33 @@ func (c *Controller) ModifyRequirements(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
+		return
 	}
 	err = json.Unmarshal(req, &patches)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
+		return
 	}
 	for _, patch := range patches.Patches {
+		if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(patch.Id)}) {
+			return
+		}
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	//every patch is checked before anything is written, the labels go last
+	reqs := make([]*entity.Requirements, len(patches.Patches))
+	befores := make([]entity.Requirements, len(patches.Patches))
+	reqLabels := make([]*entity.Labels, len(patches.Patches))
+	for i, patch := range patches.Patches {
 		r, err := c.requirements.GetRequirementbyID(patch.Id)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			w.Write([]byte("Invalid Request"))
 			c.logger.ErrorLogger.Println("Error retrieving requirement : ", err.Error())
+			return
 		}
+		befores[i] = *r
 
 		if patch.ExpectedOutcome != nil {
 			r.ExpectedOutcome = *patch.ExpectedOutcome
@@ -221,12 +458,31 @@ func (c *Controller) ModifyRequirements(w http.ResponseWriter, r *http.Request)
 			r.Request = *patch.Request
 		}
 
+		if patch.NewTags != nil || len(patch.NewFields) > 0 {
+			reqLabels[i], err = c.labels.PatchedLabels(entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(r.Id)}, patch.NewTags, patch.NewFields)
+			if c.writeLabelsError(w, err) {
+				return
+			}
+		}
+		reqs[i] = r
+	}
+	for i, r := range reqs {
 		err = c.requirements.UpdateRequirement(r)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			c.logger.ErrorLogger.Println("Error updating requirement : ", err.Error())
 			return
 		}
+		c.recordRequirement(&befores[i], r.Id, adminID)
+	}
+	for i, r := range reqs {
+		if reqLabels[i] == nil {
+			continue
+		}
+		err = c.labels.SetLabels(entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(r.Id)}, reqLabels[i])
+		if c.writeLabelsError(w, err) {
+			return
+		}
 	}
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Requirements Modified"))
@@ -235,6 +491,9 @@ func (c *Controller) ModifyRequirements(w http.ResponseWriter, r *http.Request)
 
 func (c *Controller) AddNewRequirement(w http.ResponseWriter, r *http.Request) {
 	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
 	var newRequirement models.Requirements
 	req, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -246,20 +505,95 @@ func (c *Controller) AddNewRequirement(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Invalid Request"))
 	}
-	_, err = c.order.GetOrder(orderID)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid Request, Order Does Not Exist"))
+	if !c.ensureOrderOpen(w, orderID) {
+		return
+	}
+	requirementLabels := models.DecodeLabels(newRequirement.Tags, newRequirement.Fields)
+	if !c.validateLabels(w, entity.ResourceRequirement, requirementLabels) {
+		return
 	}
-	_, err = c.requirements.CreateRequirement(newRequirement.Request, newRequirement.ExpectedOutcome, orderID)
+	requirementID, err := c.requirements.CreateRequirement(newRequirement.Request, newRequirement.ExpectedOutcome, orderID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("Unexpected Error"))
+		return
+	}
+	c.recordRequirement(nil, requirementID, fmt.Sprintf("%v", r.Context().Value(ctxKey{})))
+	c.saveLabels(entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(requirementID)}, requirementLabels)
+	//a validated order has work again
+	_, err = c.order.SyncStatus(orderID)
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error syncing status of order %s: %s\n", orderID, err.Error())
 	}
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte("Requirement Added"))
 }
 
+//TransitionOrder drafts, publishes, cancels or archives an order
+func (c *Controller) TransitionOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderWrite, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	var form models.OrderTransition
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	status, ok := entity.ParseOrderStatus(form.Status)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf("Unknown order status %s", form.Status)))
+		return
+	}
+	order, err := c.order.TransitionOrder(orderID, status)
+	if errors.Is(err, orders.ErrInvalidTransition) || err == orders.ErrStatusChanged {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while changing order status : ", err.Error())
+		return
+	}
+	//reopening an order whose requirements are all finished validates it
+	c.issueCertificate(order)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildPayload([]*entity.Orders{order})[0])
+}
+
+//parseOrderStatuses reads the comma separated ?status= filter, falling
+//back to fallback when it is missing. "all" lifts the filter.
+func parseOrderStatuses(w http.ResponseWriter, r *http.Request, fallback []entity.OrderStatus) ([]entity.OrderStatus, bool) {
+	param := r.URL.Query().Get("status")
+	if param == "" {
+		return fallback, true
+	}
+	if param == "all" {
+		return nil, true
+	}
+	var statuses []entity.OrderStatus
+	for _, s := range strings.Split(param, ",") {
+		status, ok := entity.ParseOrderStatus(strings.TrimSpace(s))
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Unknown order status %s", s)))
+			return nil, false
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, true
+}
+
 /*
 func (c *Controller) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	err := c.order.DeleteOrder(mux.Vars(r)["id"])
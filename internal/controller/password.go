@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/user"
+	"time"
+)
+
+const (
+	tokenUsePasswordChange = "password_change"
+	passwordChangeTTL      = 10 * time.Minute
+)
+
+//writePasswordErrors answers 422 with one entry per policy violation for
+//field and returns false when err is not a policy error
+func (c *Controller) writePasswordErrors(w http.ResponseWriter, field string, err error) bool {
+	var policyErr *user.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	var response models.ValidationErrors
+	for _, v := range policyErr.Violations {
+		response.Errors = append(response.Errors, models.FieldError{Field: field, Code: v.Code, Message: v.Message})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(response)
+	return true
+}
+
+//requirePasswordChange hands out a password change token instead of a
+//login when the password has expired. It is only called once every other
+//factor has passed, so the token completes the login.
+func (c *Controller) requirePasswordChange(w http.ResponseWriter, u *entity.User, recoveryCodes []string) bool {
+	if !c.user.PasswordExpired(u) {
+		return false
+	}
+	token, err := c.generateUserToken(u.ID, tokenUsePasswordChange, passwordChangeTTL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error generating password change token ", err.Error())
+		return true
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.PasswordChangeRequired{
+		PasswordChangeRequired: true,
+		PasswordChangeToken:    token,
+		RecoveryCodes:          recoveryCodes,
+	})
+	return true
+}
+
+//ChangeExpiredPassword sets a new password with the token handed out for an
+//expired one and logs the user in
+func (c *Controller) ChangeExpiredPassword(w http.ResponseWriter, r *http.Request) {
+	var form models.ExpiredPasswordForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, claims, err := c.parseUserToken(form.Token, tokenUsePasswordChange)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(errInvalidToken.Error()))
+		c.logger.WarningLogger.Println("Rejected password change: ", err.Error())
+		return
+	}
+	//validated before the token is consumed, so a rejected password can be
+	//corrected with the same token
+	err = c.user.ValidatePassword(u, form.NewPassword)
+	if c.writePasswordErrors(w, "new_password", err) {
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while validating password : ", err.Error())
+		return
+	}
+	if !c.consumeUserToken(w, claims) {
+		return
+	}
+	err = c.user.SetPassword(u, form.NewPassword)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while updating user : ", err.Error())
+		return
+	}
+	err = c.revokeUserAccess(u.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while revoking tokens : ", err.Error())
+		return
+	}
+	c.completeLogin(w, r, u, nil)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"order-validation-v2/internal/entity"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func actorFromRequest(r *http.Request) entity.Actor {
+	claims, _ := r.Context().Value(claimsKey{}).(jwt.MapClaims)
+	actor := entity.Actor{
+		UserID: fmt.Sprintf("%v", r.Context().Value(ctxKey{})),
+		Role:   fmt.Sprintf("%v", claims["authorization"]),
+	}
+	if key, ok := r.Context().Value(apiKeyKey{}).(*entity.APIKey); ok {
+		actor.Scopes = key.Permissions
+	}
+	return actor
+}
+
+//authorize asks the policy layer whether the caller may perform action on
+//resource and answers 403 when it may not
+func (c *Controller) authorize(w http.ResponseWriter, r *http.Request, action entity.Permission, resource entity.Resource) bool {
+	actor := actorFromRequest(r)
+	err := c.policy.Authorize(actor, action, resource)
+	if err != nil {
+		c.logger.WarningLogger.Printf("Denied %s on %s %s for user %s: %s\n",
+			action, resource.Type, resource.ID, actor.UserID, err.Error())
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Forbidden"))
+		return false
+	}
+	return true
+}
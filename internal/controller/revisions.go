@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/revisions"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetOrderRevisions(w http.ResponseWriter, r *http.Request) {
+	c.listRevisions(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) GetRequirementRevisions(w http.ResponseWriter, r *http.Request) {
+	c.listRevisions(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) GetOrderRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	c.diffRevisions(w, r, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) GetRequirementRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	c.diffRevisions(w, r, entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]})
+}
+
+func (c *Controller) RestoreOrderRevision(w http.ResponseWriter, r *http.Request) {
+	resource := entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]}
+	if !c.authorize(w, r, entity.PermOrderWrite, resource) || !c.ensureOrderOpen(w, resource.ID) {
+		return
+	}
+	c.restoreRevision(w, r, resource)
+}
+
+func (c *Controller) RestoreRequirementRevision(w http.ResponseWriter, r *http.Request) {
+	resource := entity.Resource{Type: entity.ResourceRequirement, ID: mux.Vars(r)["id"]}
+	if !c.authorize(w, r, entity.PermOrderWrite, resource) {
+		return
+	}
+	requirementID, err := strconv.Atoi(resource.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if !c.ensureRequirementOpen(w, requirementID) {
+		return
+	}
+	c.restoreRevision(w, r, resource)
+}
+
+func (c *Controller) listRevisions(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderRead, resource) {
+		return
+	}
+	list, err := c.revisions.ListRevisions(resource)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error retrieving revisions of %s %s : %s\n", resource.Type, resource.ID, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildRevisionPayload(list))
+}
+
+//diffRevisions compares the revisions given as ?from= and ?to=
+func (c *Controller) diffRevisions(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	if !c.authorize(w, r, entity.PermOrderRead, resource) {
+		return
+	}
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, from must be a revision number"))
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, to must be a revision number"))
+		return
+	}
+	changes, err := c.revisions.Diff(resource, from, to)
+	if c.writeRevisionError(w, resource, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.RevisionDiff{From: from, To: to, Changes: models.BuildFieldChangePayload(changes)})
+}
+
+func (c *Controller) restoreRevision(w http.ResponseWriter, r *http.Request, resource entity.Resource) {
+	number, err := strconv.Atoi(mux.Vars(r)["number"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	rev, err := c.revisions.Restore(resource, number, adminID)
+	if c.writeRevisionError(w, resource, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildRevisionPayload([]*entity.Revision{rev})[0])
+}
+
+func (c *Controller) writeRevisionError(w http.ResponseWriter, resource entity.Resource, err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == revisions.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Revision Not Found"))
+		return true
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("Internal Server Error"))
+	c.logger.ErrorLogger.Printf("Error handling revisions of %s %s : %s\n", resource.Type, resource.ID, err.Error())
+	return true
+}
+
+//recordOrder stores the current state of the order as a revision, before
+//is nil for new orders. History is best effort and never fails the edit.
+func (c *Controller) recordOrder(before *entity.Orders, orderID string, authorID string) {
+	after, err := c.order.GetOrder(orderID)
+	if err == nil {
+		err = c.revisions.RecordOrder(before, after, authorID)
+	}
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error recording revision of order %s: %s\n", orderID, err.Error())
+	}
+}
+
+func (c *Controller) recordRequirement(before *entity.Requirements, requirementID int, authorID string) {
+	after, err := c.requirements.GetRequirementbyID(requirementID)
+	if err == nil {
+		err = c.revisions.RecordRequirement(before, after, authorID)
+	}
+	if err != nil {
+		c.logger.ErrorLogger.Printf("Error recording revision of requirement %d: %s\n", requirementID, err.Error())
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/roles"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.roles.ListRoles()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving roles: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildRolePayload(roles))
+}
+
+func (c *Controller) GetAllPermissions(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entity.AllPermissions)
+}
+
+func (c *Controller) AddNewRole(w http.ResponseWriter, r *http.Request) {
+	var role models.Role
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &role)
+	if err != nil || role.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	_, err = c.roles.CreateRole(role.Name, role.Description, role.Permissions)
+	if err == roles.ErrRoleExists {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("Role Exists"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		c.logger.ErrorLogger.Println("Error creating role: ", err.Error())
+		return
+	}
+	if role.Require2FA {
+		err = c.roles.SetRequire2FA(role.Name, true)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.logger.ErrorLogger.Println("Error requiring 2FA on role: ", err.Error())
+			return
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("Role %s has been added", role.Name)))
+}
+
+func (c *Controller) ModifyRole(w http.ResponseWriter, r *http.Request) {
+	var patch models.RolePatch
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &patch)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	current, err := c.roles.GetRole(mux.Vars(r)["name"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Role Not Found"))
+		return
+	}
+	role := entity.Role{
+		Name:        current.Name,
+		Description: current.Description,
+		Permissions: current.Permissions,
+		BuiltIn:     current.BuiltIn,
+		Require2FA:  current.Require2FA,
+	}
+	if patch.Description != nil {
+		role.Description = *patch.Description
+	}
+	if patch.Permissions != nil {
+		role.Permissions = *patch.Permissions
+	}
+	err = c.roles.UpdateRole(&role)
+	if err == roles.ErrBuiltInRole {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		c.logger.ErrorLogger.Println("Error modifying role: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Role Modified"))
+}
+
+func (c *Controller) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	err := c.roles.DeleteRole(mux.Vars(r)["name"])
+	if err == roles.ErrBuiltInRole {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error deleting role: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) SetRoleRequire2FA(w http.ResponseWriter, r *http.Request) {
+	var form models.Require2FAForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = c.roles.SetRequire2FA(mux.Vars(r)["name"], form.Required)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Role Not Found"))
+		c.logger.ErrorLogger.Println("Error requiring 2FA on role: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Role Modified"))
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+//revokeUserAccess ends every session of the user and invalidates the
+//tokens issued to them so far
+func (c *Controller) revokeUserAccess(userID string) error {
+	err := c.sessions.RevokeSessions(userID, "")
+	if err != nil {
+		return err
+	}
+	return c.tokens.RevokeUser(userID)
+}
+
+func currentSession(r *http.Request) string {
+	claims, _ := r.Context().Value(claimsKey{}).(jwt.MapClaims)
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+func (c *Controller) GetOwnSessions(w http.ResponseWriter, r *http.Request) {
+	userID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	sessions, err := c.sessions.ListSessions(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving sessions: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildSessionPayload(sessions, currentSession(r)))
+}
+
+func (c *Controller) RevokeOwnSession(w http.ResponseWriter, r *http.Request) {
+	userID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	session, err := c.sessions.GetSession(mux.Vars(r)["id"])
+	if err != nil || session.UserID != userID {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Session Not Found"))
+		return
+	}
+	err = c.sessions.RevokeSession(session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking session: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Session has been revoked"))
+}
+
+//RevokeOtherSessions signs the user out everywhere but the current session
+func (c *Controller) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	err := c.sessions.RevokeSessions(userID, currentSession(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking sessions: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Other sessions have been revoked"))
+}
+
+func (c *Controller) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermUserRead, entity.Resource{Type: entity.ResourceUser, ID: userID}) {
+		return
+	}
+	sessions, err := c.sessions.ListSessions(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving sessions: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildSessionPayload(sessions, ""))
+}
+
+func (c *Controller) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: userID}) {
+		return
+	}
+	err := c.revokeUserAccess(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking sessions: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("All sessions have been revoked"))
+}
+
+func (c *Controller) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	session, err := c.sessions.GetSession(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Session Not Found"))
+		return
+	}
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: session.UserID}) {
+		return
+	}
+	err = c.sessions.RevokeSession(session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error revoking session: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Session has been revoked"))
+}
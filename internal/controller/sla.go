@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/sla"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetSLAPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := c.sla.ListPolicies()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving SLA policies: ", err.Error())
+		return
+	}
+	response := []models.SLAPolicy{}
+	for _, p := range policies {
+		response = append(response, models.BuildSLAPolicyPayload(p))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) GetSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	p, err := c.sla.GetPolicy(mux.Vars(r)["name"])
+	if c.writeSLAError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildSLAPolicyPayload(p))
+}
+
+func (c *Controller) AddNewSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := c.readSLAPolicy(w, r)
+	if !ok {
+		return
+	}
+	err := c.sla.CreatePolicy(p)
+	if c.writeSLAError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("SLA policy '%s' has been added", p.Name)))
+}
+
+func (c *Controller) ModifySLAPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := c.readSLAPolicy(w, r)
+	if !ok {
+		return
+	}
+	p.Name = mux.Vars(r)["name"]
+	err := c.sla.UpdatePolicy(p)
+	if c.writeSLAError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("SLA Policy Modified"))
+}
+
+func (c *Controller) DeleteSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	err := c.sla.DeletePolicy(mux.Vars(r)["name"])
+	if c.writeSLAError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("SLA Policy Deleted"))
+}
+
+//GetOrderSLA reports every clock of the order's SLA, running or stopped
+func (c *Controller) GetOrderSLA(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	o, err := c.sla.GetOrderSLA(orderID)
+	if c.writeSLAError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildOrderSLAPayload(o, o.Clocks(), time.Now()))
+}
+
+//GetSLABreaches reports the orders past an SLA target with only the
+//breached clocks
+func (c *Controller) GetSLABreaches(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	list, err := c.sla.Breaches(now)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error building SLA breach report: ", err.Error())
+		return
+	}
+	response := []models.OrderSLA{}
+	for _, o := range list {
+		response = append(response, models.BuildOrderSLAPayload(o, o.Breaches(now), now))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) readSLAPolicy(w http.ResponseWriter, r *http.Request) (*entity.SLAPolicy, bool) {
+	var form models.SLAPolicy
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return nil, false
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return nil, false
+	}
+	p, err := models.DecodeSLAPolicy(form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Target Duration Format"))
+		return nil, false
+	}
+	return p, true
+}
+
+//ensureSLAPolicy writes 400 and returns false when the policy doesn't exist
+func (c *Controller) ensureSLAPolicy(w http.ResponseWriter, name string) bool {
+	_, err := c.sla.GetPolicy(name)
+	if err == sla.ErrNotFound {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request, SLA Policy Does Not Exist"))
+		return false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving SLA policy: ", err.Error())
+		return false
+	}
+	return true
+}
+
+func (c *Controller) writeSLAError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, sla.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, sla.ErrInvalidPolicy):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, sla.ErrPolicyExists), errors.Is(err, sla.ErrPolicyInUse):
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+		c.logger.ErrorLogger.Println("Error handling SLA request: ", err.Error())
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"net/http"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/sso"
+	"order-validation-v2/internal/usecase/user"
+	"order-validation-v2/pkg/oidc"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	tokenUseOIDCFlow = "oidc_flow"
+	oidcFlowTTL      = 10 * time.Minute
+	oidcFlowCookie   = "oidc_flow"
+)
+
+//StartOIDCLogin redirects to the identity provider. The state, nonce and
+//PKCE verifier travel in a signed cookie so the callback can check them
+//without server side storage.
+func (c *Controller) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var values [3]string
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.logger.ErrorLogger.Println("Error starting SSO login: ", err.Error())
+			return
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+	flow, err := c.keys.Sign(jwt.MapClaims{
+		"token_use": tokenUseOIDCFlow,
+		"jti":       entity.NewUUID().String(),
+		"state":     state,
+		"nonce":     nonce,
+		"verifier":  verifier,
+	}, oidcFlowTTL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error starting SSO login: ", err.Error())
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    flow,
+		Path:     "/login/oidc",
+		MaxAge:   int(oidcFlowTTL / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, c.oidc.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier)), http.StatusFound)
+}
+
+func (c *Controller) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Sign in was cancelled or refused by the identity provider"))
+		c.logger.WarningLogger.Println("SSO login failed at the provider: ", providerError, query.Get("error_description"))
+		return
+	}
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcFlowCookie, Path: "/login/oidc", MaxAge: -1})
+	flow, err := c.keys.Parse(cookie.Value)
+	if err != nil || flow["token_use"] != tokenUseOIDCFlow || flow["state"] != query.Get("state") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		c.logger.WarningLogger.Println("Rejected SSO callback with an invalid state")
+		return
+	}
+	if !c.consumeUserToken(w, flow) {
+		return
+	}
+	verifier, _ := flow["verifier"].(string)
+	nonce, _ := flow["nonce"].(string)
+	idToken, err := c.oidc.Exchange(query.Get("code"), verifier)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		c.logger.ErrorLogger.Println("Error exchanging SSO code: ", err.Error())
+		return
+	}
+	claims, err := c.oidc.VerifyIDToken(idToken, nonce)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.logger.WarningLogger.Println("Rejected ID token: ", err.Error())
+		return
+	}
+	u, err := c.sso.Resolve(c.externalIdentity(claims))
+	switch err {
+	case nil:
+	case sso.ErrNoMappedRole, sso.ErrNotLinkable, sso.ErrAmbiguousLink, sso.ErrPrivilegedLink:
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error resolving SSO user: ", err.Error())
+		return
+	}
+	if u.Disabled {
+		c.recordLogin(entity.NewLoginAttempt(u.Username, clientIP(r), r.UserAgent(), entity.LoginDisabled))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(user.ErrUserDisabled.Error()))
+		return
+	}
+	if c.challengeSecondFactor(w, u) {
+		return
+	}
+	c.completeLogin(w, r, u, nil)
+}
+
+//externalIdentity reads the standard claims and the configured groups
+//claim, which providers send either as a list or a single string
+func (c *Controller) externalIdentity(claims jwt.MapClaims) entity.ExternalIdentity {
+	identity := entity.ExternalIdentity{Issuer: c.oidc.Issuer()}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Username, _ = claims["preferred_username"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	switch groups := claims[c.oidc.GroupsClaim()].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = []string{groups}
+	}
+	return identity
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -14,6 +15,9 @@ import (
 func (c *Controller) ReviewSubmission(w http.ResponseWriter, r *http.Request) {
 	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
 	submissionID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermSubmissionReview, entity.Resource{Type: entity.ResourceSubmission, ID: submissionID}) {
+		return
+	}
 	var reviewForm models.ReviewForm
 	req, err := ioutil.ReadAll(r.Body)
 	if err != nil {
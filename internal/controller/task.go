@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -25,13 +26,25 @@ func (c *Controller) GetTasks(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("No Tasks Present"))
 		return
 	}
-	response := models.BuildTasks(tasks)
+	response, ok := c.buildTasks(w, r, tasks)
+	if !ok {
+		return
+	}
+	err = c.addTaskAttachments(response, tasks)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while getting attachments: ", err.Error())
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
 func (c *Controller) GetSubmission(w http.ResponseWriter, r *http.Request) {
 	request := mux.Vars(r)
 	id := request["id"]
+	if !c.authorize(w, r, entity.PermTaskWork, entity.Resource{Type: entity.ResourceTask, ID: id}) {
+		return
+	}
 	submission, err := c.submissions.GetSubmissionByTaskID(id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -60,6 +73,9 @@ func (c *Controller) PostSubmission(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Invalid Request"))
 		return
 	}
+	if !c.authorize(w, r, entity.PermTaskWork, entity.Resource{Type: entity.ResourceTask, ID: submission.TaskID}) {
+		return
+	}
 	task, err := c.task.Get(submission.TaskID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -78,5 +94,8 @@ func (c *Controller) PostSubmission(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c *Controller) UpdateSubmission(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermTaskWork, entity.Resource{Type: entity.ResourceSubmission, ID: mux.Vars(r)["id"]}) {
+		return
+	}
 
 }
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"order-validation-v2/internal/controller/models"
 	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/tasks"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,6 +30,17 @@ func (c *Controller) BulkAssignTasks(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Invalid Request"))
 		return
 	}
+	for _, task := range newTasks.Tasks {
+		if !c.authorize(w, r, entity.PermTaskAssign, entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(task.RequirementID)}) {
+			return
+		}
+		if !c.ensureRequirementOpen(w, task.RequirementID) {
+			return
+		}
+		if !c.validateLabels(w, entity.ResourceTask, models.DecodeLabels(task.Tags, task.Fields)) {
+			return
+		}
+	}
 	assignedID := map[string]string{}
 	var tasks []*entity.Task
 	for _, task := range newTasks.Tasks {
@@ -39,11 +52,19 @@ func (c *Controller) BulkAssignTasks(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(fmt.Sprintf("%d Task has been added", len(tasks))))
 	var wg sync.WaitGroup
-	for _, task := range tasks {
+	saved := make([]bool, len(tasks))
+	for i, task := range tasks {
 		wg.Add(1)
-		go c.assignPrerequiste(task, assignedID, &wg)
+		go c.assignPrerequiste(task, assignedID, &saved[i], &wg)
 	}
 	wg.Wait()
+	for i, task := range tasks {
+		if !saved[i] {
+			continue
+		}
+		c.saveLabels(entity.Resource{Type: entity.ResourceTask, ID: task.ID},
+			models.DecodeLabels(newTasks.Tasks[i].Tags, newTasks.Tasks[i].Fields))
+	}
 
 }
 func (c *Controller) AddNewTask(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +82,16 @@ func (c *Controller) AddNewTask(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Invalid Request"))
 		return
 	}
+	if !c.authorize(w, r, entity.PermTaskAssign, entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(newTask.RequirementID)}) {
+		return
+	}
+	if !c.ensureRequirementOpen(w, newTask.RequirementID) {
+		return
+	}
+	taskLabels := models.DecodeLabels(newTask.Tags, newTask.Fields)
+	if !c.validateLabels(w, entity.ResourceTask, taskLabels) {
+		return
+	}
 	deadline, _ := time.Parse("2/Jan/2006 15:04:05", newTask.Deadline)
 	id, err := c.task.CreateTask(adminID, newTask.RequirementID, newTask.UserID, newTask.Note, newTask.Prerequisite, deadline)
 	if err != nil {
@@ -68,6 +99,7 @@ func (c *Controller) AddNewTask(w http.ResponseWriter, r *http.Request) {
 		c.logger.ErrorLogger.Println("Error creating new task: ", err.Error())
 		return
 	}
+	c.saveLabels(entity.Resource{Type: entity.ResourceTask, ID: id}, taskLabels)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go c.updateRequirementStatus(newTask.RequirementID, &wg, 1)
@@ -91,7 +123,10 @@ func (c *Controller) GetAllAssignedTasks(w http.ResponseWriter, r *http.Request)
 			task.Prerequisites = prerequisites
 		}
 	}
-	response := models.BuildTasks(tasks)
+	response, ok := c.buildTasks(w, r, tasks)
+	if !ok {
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -102,11 +137,17 @@ func (c *Controller) GetTaskstoReview(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.logger.ErrorLogger.Println("Error retrieving all tasks: ", err.Error())
 	}
-	response := models.BuildTasks(tasks)
+	response, ok := c.buildTasks(w, r, tasks)
+	if !ok {
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
 func (c *Controller) GetTasksOfUser(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermTaskRead, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
 	tasks, err := c.task.GetTasksofUser(mux.Vars(r)["id"])
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -118,11 +159,17 @@ func (c *Controller) GetTasksOfUser(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("No Tasks Present"))
 		return
 	}
-	response := models.BuildTasks(tasks)
+	response, ok := c.buildTasks(w, r, tasks)
+	if !ok {
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
 func (c *Controller) GetTasksOnSpecificOrder(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermTaskRead, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]}) {
+		return
+	}
 	tasks, err := c.task.GetTasksOnSpecificOrder(mux.Vars(r)["id"])
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -134,17 +181,53 @@ func (c *Controller) GetTasksOnSpecificOrder(w http.ResponseWriter, r *http.Requ
 		w.Write([]byte("No Tasks Present"))
 		return
 	}
-	response := models.BuildTasks(tasks)
+	response, ok := c.buildTasks(w, r, tasks)
+	if !ok {
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
 func (c *Controller) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	err := c.task.DeleteTask(mux.Vars(r)["id"])
+	if !c.authorize(w, r, entity.PermTaskAssign, entity.Resource{Type: entity.ResourceTask, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	task, err := c.task.Get(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	err = c.task.DeleteTask(task.ID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.logger.ErrorLogger.Println("Error while deleting task : ", err.Error())
 		return
 	}
+	c.syncOrderOfRequirement(task.RequirementID)
 	w.WriteHeader(http.StatusOK)
 	return
 }
+
+func (c *Controller) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermTaskAssign, entity.Resource{Type: entity.ResourceTask, ID: taskID}) {
+		return
+	}
+	err := c.task.RestoreTask(taskID)
+	if err == tasks.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Deleted Task Not Found, Or Its Requirement Is Deleted"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Printf("Error restoring task %s : %s\n", taskID, err.Error())
+		return
+	}
+	task, err := c.task.Get(taskID)
+	if err == nil {
+		c.syncOrderOfRequirement(task.RequirementID)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task Restored"))
+}
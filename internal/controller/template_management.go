@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/templates"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	list, err := c.templates.ListTemplates()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving templates: ", err.Error())
+		return
+	}
+	response := []models.OrderTemplate{}
+	for _, t := range list {
+		response = append(response, models.BuildTemplatePayload(t))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Controller) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := c.templates.GetTemplate(mux.Vars(r)["id"])
+	if err == templates.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Template Not Found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving template: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildTemplatePayload(t))
+}
+
+func (c *Controller) AddNewTemplate(w http.ResponseWriter, r *http.Request) {
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	var payload models.OrderTemplate
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &payload)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	t, err := models.DecodeTemplatePayload(payload, adminID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Deadline Offset"))
+		return
+	}
+	id, err := c.templates.CreateTemplate(t)
+	if c.writeTemplateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("Template '%s' has been added with id %s", t.Name, id)))
+}
+
+func (c *Controller) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	err := c.templates.DeleteTemplate(mux.Vars(r)["id"])
+	if c.writeTemplateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) SaveOrderAsTemplate(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: orderID}) {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	var form models.SaveAsTemplateForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	id, err := c.templates.SaveOrderAsTemplate(orderID, form.Name, adminID)
+	if c.writeTemplateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("Template '%s' has been added with id %s", form.Name, id)))
+}
+
+func (c *Controller) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	c.createOrderFrom(w, r, c.templates.Instantiate)
+}
+
+func (c *Controller) CloneOrder(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermOrderRead, entity.Resource{Type: entity.ResourceOrder, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	c.createOrderFrom(w, r, c.templates.CloneOrder)
+}
+
+//createOrderFrom reads the overrides and creates an order from the
+//template or order named in the route. The new order comes with its
+//tasks assigned, so the caller must be allowed to assign tasks too.
+func (c *Controller) createOrderFrom(w http.ResponseWriter, r *http.Request, create func(string, templates.Overrides, string) (string, error)) {
+	if !c.authorize(w, r, entity.PermTaskAssign, entity.Resource{}) {
+		return
+	}
+	adminID := fmt.Sprintf("%v", r.Context().Value(ctxKey{}))
+	var form models.OrderOverrides
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	if len(req) > 0 {
+		err = json.Unmarshal(req, &form)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Request"))
+			return
+		}
+	}
+	overrides := templates.Overrides{
+		Title:       form.Title,
+		Description: form.Description,
+		Status:      entity.OrderStatus(form.Status),
+		Priority:    entity.Priority(form.Priority),
+		SLAPolicy:   form.SLAPolicy,
+		CustomerID:  form.CustomerID,
+		Assignees:   form.Assignees,
+	}
+	if form.CustomerID != "" && !c.ensureCustomer(w, form.CustomerID) {
+		return
+	}
+	if form.SLAPolicy != "" && !c.ensureSLAPolicy(w, form.SLAPolicy) {
+		return
+	}
+	if form.Deadline != "" {
+		overrides.Deadline, err = time.Parse("2/Jan/2006 15:04:05", form.Deadline)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid Deadline Datetime Format"))
+			return
+		}
+	}
+	id, err := create(mux.Vars(r)["id"], overrides, adminID)
+	if c.writeTemplateError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(fmt.Sprintf("201 - Order has been added, keep track on your order here at /orders/id=%s", id)))
+}
+
+//writeTemplateError maps template and order errors to a response and
+//returns false when there was none
+func (c *Controller) writeTemplateError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, templates.ErrNotFound), errors.Is(err, orders.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, templates.ErrInvalidTemplate), errors.Is(err, templates.ErrDeadlineRequired),
+		errors.Is(err, templates.ErrUnassignedTask), errors.Is(err, templates.ErrUnknownAssignee),
+		errors.Is(err, orders.ErrInvalidTransition),
+		errors.Is(err, orders.ErrInvalidPriority):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while processing template : ", err.Error())
+	}
+	return true
+}
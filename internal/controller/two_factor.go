@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/user"
+	"order-validation-v2/pkg/keys"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+//token_use separates access tokens from the single-purpose tokens signed
+//with the same keys, like the challenge handed out between the password
+//and the OTP step
+const (
+	tokenUseAccess    = "access"
+	tokenUseChallenge = "mfa_challenge"
+	challengeTTL      = 5 * time.Minute
+)
+
+var errInvalidToken = errors.New("token is invalid or has already been used")
+
+//generateUserToken signs a token that is only accepted for one purpose
+func (c *Controller) generateUserToken(userid string, use string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{}
+	claims["sub"] = userid
+	claims["user_id"] = userid
+	claims["token_use"] = use
+	claims["jti"] = entity.NewUUID().String()
+	return c.keys.Sign(claims, ttl)
+}
+
+//parseUserToken verifies a token issued for one purpose (token_use) and
+//loads its user. Such tokens are consumed once used, so each one
+//completes a single login, invitation or reset.
+func (c *Controller) parseUserToken(tokenString string, use string) (*entity.User, jwt.MapClaims, error) {
+	claims, err := c.keys.Parse(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claims["token_use"] != use {
+		return nil, nil, errInvalidToken
+	}
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["user_id"].(string)
+	revoked, err := c.tokens.IsRevoked(jti, userID, keys.IssuedAt(claims))
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, errInvalidToken
+	}
+	u, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Disabled {
+		return nil, nil, user.ErrUserDisabled
+	}
+	return u, claims, nil
+}
+
+//consumeUserToken marks the token as used and writes 401 when a
+//concurrent request already used it
+func (c *Controller) consumeUserToken(w http.ResponseWriter, claims jwt.MapClaims) bool {
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	consumed, err := c.tokens.ConsumeToken(jti, time.Unix(int64(exp), 0))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error consuming token: ", err.Error())
+		return false
+	}
+	if !consumed {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(errInvalidToken.Error()))
+		return false
+	}
+	return true
+}
+
+func (c *Controller) roleRequires2FA(roleName string) (bool, error) {
+	role, err := c.roles.GetRole(roleName)
+	if err != nil {
+		return false, err
+	}
+	return role.Require2FA, nil
+}
+
+//challengeSecondFactor hands out a 2FA challenge instead of a login when
+//the user enrolled or their role requires a second factor. Every login
+//path, password or SSO, goes through it before completeLogin.
+func (c *Controller) challengeSecondFactor(w http.ResponseWriter, u *entity.User) bool {
+	required, err := c.roleRequires2FA(u.UserRole)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving role: ", err.Error())
+		return true
+	}
+	if !u.TOTPEnabled && !required {
+		return false
+	}
+	//the throttle is only reset once the second factor passes, otherwise
+	//knowing the password would allow unlimited guesses at the code
+	challenge, err := c.generateUserToken(u.ID, tokenUseChallenge, challengeTTL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error generating 2FA challenge ", err.Error())
+		return true
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.MFAChallenge{
+		MFARequired:        true,
+		ChallengeToken:     challenge,
+		EnrollmentRequired: !u.TOTPEnabled,
+	})
+	return true
+}
+
+func (c *Controller) LoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	var form models.SecondFactorForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, claims, err := c.parseUserToken(form.ChallengeToken, tokenUseChallenge)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.logger.WarningLogger.Println("Rejected 2FA challenge: ", err.Error())
+		return
+	}
+	ip := clientIP(r)
+	userAgent := r.UserAgent()
+	if !c.allowLogin(w, u.Username, ip, userAgent) {
+		return
+	}
+	var ok bool
+	var recoveryCodes []string
+	switch {
+	case u.TOTPEnabled:
+		ok, err = c.user.VerifySecondFactor(u, form.Code)
+	case u.TOTPSecret != "":
+		//first code after enrollment through the challenge confirms it
+		recoveryCodes, err = c.user.ConfirmTOTP(u, form.Code)
+		ok = err == nil
+		if err == user.ErrInvalidOTP {
+			err = nil
+		}
+	default:
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Two-factor enrollment required"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error verifying second factor: ", err.Error())
+		return
+	}
+	if !ok {
+		c.failLogin(u.Username, ip, userAgent)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Invalid code"))
+		return
+	}
+	if !c.consumeUserToken(w, claims) {
+		return
+	}
+	if c.requirePasswordChange(w, u, recoveryCodes) {
+		return
+	}
+	c.completeLogin(w, r, u, recoveryCodes)
+}
+
+//LoginEnrollSecondFactor lets members of a role that requires 2FA enroll
+//with their challenge token before their first full login
+func (c *Controller) LoginEnrollSecondFactor(w http.ResponseWriter, r *http.Request) {
+	var form models.SecondFactorForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, _, err := c.parseUserToken(form.ChallengeToken, tokenUseChallenge)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.logger.WarningLogger.Println("Rejected 2FA challenge: ", err.Error())
+		return
+	}
+	c.enrollTOTP(w, u)
+}
+
+func (c *Controller) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Context().Value(ctxKey{})
+	userID := fmt.Sprintf("%v", auth)
+	u, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving user info: ", err.Error())
+		return
+	}
+	c.enrollTOTP(w, u)
+}
+
+func (c *Controller) enrollTOTP(w http.ResponseWriter, u *entity.User) {
+	secret, uri, err := c.user.EnrollTOTP(u, c.keys.Issuer())
+	if err == user.ErrTOTPAlreadyEnabled {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error enrolling TOTP: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.TOTPEnrollment{Secret: secret, ProvisioningURI: uri})
+}
+
+func (c *Controller) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Context().Value(ctxKey{})
+	userID := fmt.Sprintf("%v", auth)
+	var form models.OTPForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving user info: ", err.Error())
+		return
+	}
+	codes, err := c.user.ConfirmTOTP(u, form.Code)
+	switch err {
+	case nil:
+	case user.ErrInvalidOTP:
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Invalid code"))
+		return
+	case user.ErrTOTPAlreadyEnabled, user.ErrTOTPNotEnrolled:
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error confirming TOTP: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.RecoveryCodes{RecoveryCodes: codes})
+}
+
+func (c *Controller) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	auth := r.Context().Value(ctxKey{})
+	userID := fmt.Sprintf("%v", auth)
+	var form models.OTPForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	u, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving user info: ", err.Error())
+		return
+	}
+	ok, err := c.user.VerifySecondFactor(u, form.Code)
+	if err == user.ErrTOTPNotEnrolled {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error verifying second factor: ", err.Error())
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Invalid code"))
+		return
+	}
+	codes, err := c.user.RegenerateRecoveryCodes(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error generating recovery codes: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.RecoveryCodes{RecoveryCodes: codes})
+}
+
+func (c *Controller) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Context().Value(ctxKey{})
+	userID := fmt.Sprintf("%v", auth)
+	var form models.DisableTOTPForm
+	req, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	err = json.Unmarshal(req, &form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid Request"))
+		return
+	}
+	authorize, u, err := c.user.ValidateAndRetrieveUser(userID, form.Password)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while validating user : ", err.Error())
+		return
+	}
+	if !authorize {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Invalid Password"))
+		return
+	}
+	required, err := c.roleRequires2FA(u.UserRole)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while retrieving role: ", err.Error())
+		return
+	}
+	if required {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Two-factor authentication is required for your role"))
+		return
+	}
+	ok, err := c.user.VerifySecondFactor(u, form.Code)
+	if err == user.ErrTOTPNotEnrolled {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error verifying second factor: ", err.Error())
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Invalid code"))
+		return
+	}
+	err = c.user.DisableTOTP(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error disabling TOTP: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Two-factor authentication has been disabled"))
+}
+
+//ResetTOTP clears the second factor of a user who lost their device and
+//their recovery codes. Their sessions are revoked.
+func (c *Controller) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	u, err := c.user.GetUserbyID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.logger.ErrorLogger.Println("Error while retrieving user : ", err.Error())
+		return
+	}
+	err = c.user.DisableTOTP(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error disabling TOTP: ", err.Error())
+		return
+	}
+	err = c.revokeUserAccess(u.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while revoking tokens : ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("Two-factor authentication of %s has been reset", u.Username)))
+}
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -53,13 +52,21 @@ func (c *Controller) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if authorize {
-		user.Password = fmt.Sprintf("\\x%x", sha256.Sum256([]byte(form.NewPassword)))
-		err := c.user.UpdateUser(user)
+		err := c.user.SetPassword(user, form.NewPassword)
+		if c.writePasswordErrors(w, "new_password", err) {
+			return
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			c.logger.ErrorLogger.Println("Error while updating user : ", err.Error())
 			return
 		}
+		err = c.revokeUserAccess(userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.logger.ErrorLogger.Println("Error while revoking tokens : ", err.Error())
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Password has been changed"))
 		return
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"order-validation-v2/internal/controller/models"
+	"order-validation-v2/internal/entity"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
@@ -26,13 +28,26 @@ func (c *Controller) NewUser(w http.ResponseWriter, r *http.Request) {
 		c.logger.ErrorLogger.Println("Invalid Request, Can't unmarshal :", err.Error())
 		return
 	}
+	_, err = c.roles.GetRole(newUser.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf("Unknown role %s", newUser.Role)))
+		return
+	}
 	exists, err := c.user.ValidateUsername(newUser.Username)
 	if exists {
 		w.WriteHeader(http.StatusConflict)
 		w.Write([]byte("Username Exists"))
 		return
 	}
+	if newUser.Password == "" {
+		c.inviteUser(w, newUser)
+		return
+	}
 	id, err := c.user.CreateUser(newUser.Username, newUser.Email, newUser.Password, newUser.Role)
+	if c.writePasswordErrors(w, "password", err) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.logger.ErrorLogger.Println("Error while creating new user: ", err.Error())
@@ -58,6 +73,11 @@ func (c *Controller) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c *Controller) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	//the sessions and refresh tokens go with the account, which also ends
+	//every access token issued to it
 	err := c.user.DeleteUser(mux.Vars(r)["id"])
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -68,3 +88,90 @@ func (c *Controller) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	return
 
 }
+
+func (c *Controller) DisableUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: userID}) {
+		return
+	}
+	user, err := c.user.GetUserbyID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.logger.ErrorLogger.Println("Error while retrieving user : ", err.Error())
+		return
+	}
+	user.Disable()
+	err = c.user.UpdateUser(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while disabling user : ", err.Error())
+		return
+	}
+	err = c.revokeUserAccess(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while revoking tokens : ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("User %s has been disabled", user.Username)))
+}
+
+func (c *Controller) EnableUser(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	user, err := c.user.GetUserbyID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.logger.ErrorLogger.Println("Error while retrieving user : ", err.Error())
+		return
+	}
+	user.Enable()
+	err = c.user.UpdateUser(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while enabling user : ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("User %s has been enabled", user.Username)))
+}
+
+func (c *Controller) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r, entity.PermUserWrite, entity.Resource{Type: entity.ResourceUser, ID: mux.Vars(r)["id"]}) {
+		return
+	}
+	user, err := c.user.GetUserbyID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.logger.ErrorLogger.Println("Error while retrieving user : ", err.Error())
+		return
+	}
+	err = c.guard.Unlock(user.Username)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error while unlocking user : ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf("User %s has been unlocked", user.Username)))
+}
+
+func (c *Controller) GetLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := entity.LoginAttemptFilter{
+		Username:   query.Get("username"),
+		Outcome:    entity.LoginOutcome(query.Get("outcome")),
+		Suspicious: query.Get("suspicious") == "true",
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	attempts, err := c.guard.ListAttempts(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.logger.ErrorLogger.Println("Error retrieving login attempts: ", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.BuildLoginAttemptPayload(attempts))
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+)
+
+//APIKey authenticates a service account. Permissions narrow what the
+//account's role allows, a key never grants more than the role.
+type APIKey struct {
+	ID     string
+	Name   string
+	UserID string
+	//Prefix is the start of the key, kept so keys can be told apart in
+	//listings without storing them
+	Prefix      string
+	KeyHash     string
+	Permissions []Permission
+	CreatedBy   string
+	CreatedAt   time.Time
+	//ExpiresAt and LastUsedAt are zero for keys that never expire and
+	//keys that were never used
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	Revoked    bool
+}
+
+func NewAPIKey(userID string, name string, prefix string, keyHash string, permissions []Permission, createdBy string, ttl time.Duration) *APIKey {
+	now := time.Now()
+	k := &APIKey{
+		ID:          NewUUID().String(),
+		Name:        name,
+		UserID:      userID,
+		Prefix:      prefix,
+		KeyHash:     keyHash,
+		Permissions: permissions,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+	}
+	if ttl > 0 {
+		k.ExpiresAt = now.Add(ttl)
+	}
+	return k
+}
+
+func (k *APIKey) Expired() bool {
+	return !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt)
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+)
+
+//Attachment is a file attached to an order or requirement. Its content is
+//kept in blob storage under the attachment's ID, Checksum is its SHA-256 in
+//hex.
+type Attachment struct {
+	ID         string
+	Resource   Resource
+	Filename   string
+	MimeType   string
+	Size       int64
+	Checksum   string
+	UploadedBy string
+	UploadedAt time.Time
+}
+
+func NewAttachment(resource Resource, filename string, mimeType string, uploadedBy string) *Attachment {
+	return &Attachment{
+		ID:         NewUUID().String(),
+		Resource:   resource,
+		Filename:   filename,
+		MimeType:   mimeType,
+		UploadedBy: uploadedBy,
+		UploadedAt: time.Now(),
+	}
+}
@@ -0,0 +1,113 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+//CertificateContent is what a validation certificate attests: the order
+//and, for each of its requirements, the evidence approved for it
+type CertificateContent struct {
+	OrderID      string
+	OrderTitle   string
+	Requirements []CertifiedRequirement
+}
+
+//Digest is the hex SHA-256 of the content's JSON encoding. The content is
+//read back the same way every time, so an unchanged order digests the same.
+func (c *CertificateContent) Digest() (string, error) {
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+//CertifiedRequirement lists the finished tasks of a requirement through
+//their evidence, the users who reviewed them and when the last one was
+//approved
+type CertifiedRequirement struct {
+	ID              int
+	Request         string
+	ExpectedOutcome string
+	ApprovedAt      time.Time
+	Reviewers       []Reviewer
+	Evidence        []Evidence
+}
+
+type Reviewer struct {
+	UserID   string
+	Username string
+}
+
+//Evidence is a submission of a finished task. SHA256 covers the
+//submission's message and images.
+type Evidence struct {
+	TaskID       string
+	SubmissionID string
+	SubmittedAt  time.Time
+	SHA256       string
+}
+
+//EvidenceHash hashes a submission's message followed by the SHA-256 of
+//each of its images, in hex and in upload order
+func EvidenceHash(message string, imageHashes []string) string {
+	hash := sha256.New()
+	hash.Write([]byte(message))
+	for _, image := range imageHashes {
+		hash.Write([]byte("\n" + image))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//Certificate is issued when an order is validated. Token is the signed
+//JWT carrying the certificate's id and digest, DocumentChecksum the hex
+//SHA-256 of the PDF rendered at issue time.
+type Certificate struct {
+	ID               string
+	IssuedAt         time.Time
+	Content          CertificateContent
+	Digest           string
+	Token            string
+	DocumentChecksum string
+}
+
+func NewCertificate(content CertificateContent, digest string) *Certificate {
+	return &Certificate{
+		ID:       NewUUID().String(),
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+		Content:  content,
+		Digest:   digest,
+	}
+}
+
+//CertificateCheck is one step of verifying a certificate
+type CertificateCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+//CertificateVerification is the outcome of verifying a certificate.
+//Certificate is nil when its signature couldn't be verified.
+type CertificateVerification struct {
+	Certificate *Certificate
+	Checks      []CertificateCheck
+}
+
+func (v *CertificateVerification) Add(name string, passed bool, detail string) {
+	v.Checks = append(v.Checks, CertificateCheck{Name: name, Passed: passed, Detail: detail})
+}
+
+//Valid is true when every check passed
+func (v *CertificateVerification) Valid() bool {
+	for _, check := range v.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return len(v.Checks) > 0
+}
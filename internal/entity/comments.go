@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+)
+
+//Comment is a Markdown comment on an order or requirement. A comment
+//starts a thread when ThreadID is empty, replies carry the ID of the
+//comment that started their thread.
+type Comment struct {
+	ID       string
+	Resource Resource
+	ThreadID string
+	AuthorID string
+	//AuthorName is read with the comment and not stored
+	AuthorName string
+	Body       string
+	Mentions   []Mention
+	CreatedAt  time.Time
+	EditedAt   time.Time
+	DeletedAt  time.Time
+}
+
+//Mention is a user named as @username in a comment
+type Mention struct {
+	UserID   string
+	Username string
+}
+
+func NewComment(resource Resource, threadID string, authorID string, body string) *Comment {
+	return &Comment{
+		ID:        NewUUID().String(),
+		Resource:  resource,
+		ThreadID:  threadID,
+		AuthorID:  authorID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+//Deleted comments keep their place in the thread without their body,
+//which stays in the history
+func (c *Comment) Deleted() bool {
+	return !c.DeletedAt.IsZero()
+}
+
+//CommentEdit records the body a comment had before it was edited or
+//deleted
+type CommentEdit struct {
+	CommentID string
+	Body      string
+	EditedBy  string
+	EditedAt  time.Time
+}
+
+//CommentThread is a comment with its replies, oldest first
+type CommentThread struct {
+	Comment *Comment
+	Replies []*Comment
+}
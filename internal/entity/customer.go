@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"time"
+)
+
+//Customer places orders. Contacts are the people at the customer orders
+//are discussed with.
+type Customer struct {
+	ID        string
+	Name      string
+	Contacts  []Contact
+	CreatedAt time.Time
+}
+
+type Contact struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+func NewCustomer(name string, contacts []Contact) *Customer {
+	return &Customer{
+		ID:        NewUUID().String(),
+		Name:      name,
+		Contacts:  contacts,
+		CreatedAt: time.Now(),
+	}
+}
+
+//ShareLink lets a customer follow one of its orders without an account.
+//The link itself is a signed token naming the ShareLink, which is what
+//makes it revocable before it expires.
+type ShareLink struct {
+	ID         string
+	CustomerID string
+	OrderID    string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Revoked    bool
+}
+
+func NewShareLink(customerID string, orderID string, createdBy string, ttl time.Duration) *ShareLink {
+	now := time.Now()
+	return &ShareLink{
+		ID:         NewUUID().String(),
+		CustomerID: customerID,
+		OrderID:    orderID,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+}
+
+func (l *ShareLink) Active() bool {
+	return !l.Revoked && time.Now().Before(l.ExpiresAt)
+}
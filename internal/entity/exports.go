@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+)
+
+//ExportRow is a requirement of an exported order with one of its tasks.
+//Task is nil for requirements without tasks.
+type ExportRow struct {
+	Requirement *Requirements
+	Task        *ExportTask
+}
+
+//ExportTask is a task with its submissions and the review messages left
+//on it
+type ExportTask struct {
+	ID          string
+	UserID      string
+	Username    string
+	Note        string
+	Status      Status
+	Deadline    time.Time
+	CreatedAt   time.Time
+	ReviewedAt  time.Time
+	Submissions []SubmissionMetadata
+	Reviews     []Message
+}
+
+//SubmissionMetadata describes a submission without its images
+type SubmissionMetadata struct {
+	ID          string
+	SubmittedAt time.Time
+	Message     string
+	Images      int
+}
@@ -0,0 +1,12 @@
+package entity
+
+//ExternalIdentity is a user as asserted by an OpenID Connect provider,
+//identified by the issuer and subject pair
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Groups        []string
+}
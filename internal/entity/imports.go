@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"fmt"
+)
+
+//ImportedOrder is an order read from an import file with its requirements.
+//Row is where it starts in the file: the line for CSV, the position in the
+//list for JSON. RequirementLabels[i] and RequirementRevisions[i] belong to
+//Requirements[i]. The revisions are the first ones, loaded with the orders.
+type ImportedOrder struct {
+	Row                  int
+	Order                *Orders
+	Labels               *Labels
+	Revision             *Revision
+	Requirements         []*Requirements
+	RequirementLabels    []*Labels
+	RequirementRevisions []*Revision
+}
+
+//ImportError reports an invalid value in an import file. Row is 0 for
+//errors about the file as a whole.
+type ImportError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+func (e ImportError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d, %s: %s", e.Row, e.Field, e.Message)
+}
+
+//ImportReport is the outcome of an import. Nothing is loaded when it has
+//errors or is a dry run.
+type ImportReport struct {
+	DryRun bool
+	Orders []*ImportedOrder
+	Errors []ImportError
+}
+
+func (r *ImportReport) Loaded() bool {
+	return !r.DryRun && len(r.Errors) == 0
+}
+
+func (r *ImportReport) RequirementCount() int {
+	count := 0
+	for _, o := range r.Orders {
+		count += len(o.Requirements)
+	}
+	return count
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"
+	FieldNumber FieldType = "number"
+	//FieldDate values are written as 2006-01-02
+	FieldDate FieldType = "date"
+	FieldEnum FieldType = "enum"
+)
+
+const (
+	maxTagLength   = 50
+	maxValueLength = 255
+)
+
+var ErrInvalidValue = errors.New("invalid value")
+
+//CustomField is an admin defined field orders, requirements and tasks can
+//carry. Resources lists the kinds it applies to, all three when empty.
+type CustomField struct {
+	Name      string
+	Type      FieldType
+	Options   []string
+	Resources []ResourceType
+}
+
+func ParseFieldType(s string) (FieldType, bool) {
+	switch t := FieldType(s); t {
+	case FieldText, FieldNumber, FieldDate, FieldEnum:
+		return t, true
+	}
+	return "", false
+}
+
+func (f *CustomField) AppliesTo(t ResourceType) bool {
+	if len(f.Resources) == 0 {
+		return t == ResourceOrder || t == ResourceRequirement || t == ResourceTask
+	}
+	for _, resource := range f.Resources {
+		if resource == t {
+			return true
+		}
+	}
+	return false
+}
+
+//Normalize checks value against the field's type and returns it in its
+//canonical form, so equal values compare equal when filtering
+func (f *CustomField) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch f.Type {
+	case FieldNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s expects a number", ErrInvalidValue, f.Name)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case FieldDate:
+		d, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s expects a date as 2006-01-02", ErrInvalidValue, f.Name)
+		}
+		return d.Format("2006-01-02"), nil
+	case FieldEnum:
+		for _, option := range f.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s expects one of %s", ErrInvalidValue, f.Name, strings.Join(f.Options, ", "))
+	}
+	if len(value) > maxValueLength {
+		return "", fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidValue, f.Name, maxValueLength)
+	}
+	return value, nil
+}
+
+//NormalizeTag lowercases and trims a tag. Tags are stored comma joined in
+//some queries, so they can't contain commas.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len(tag) > maxTagLength || strings.Contains(tag, ",") {
+		return "", fmt.Errorf("%w: tag %q must be 1 to %d characters without commas", ErrInvalidValue, tag, maxTagLength)
+	}
+	return tag, nil
+}
+
+//Labels are the tags and custom field values of an order, requirement or
+//task. Fields is keyed by field name.
+type Labels struct {
+	Tags   []string
+	Fields map[string]string
+}
+
+//NormalizeTags normalizes, deduplicates and sorts the tags
+func (l *Labels) NormalizeTags() error {
+	seen := map[string]bool{}
+	var tags []string
+	for _, tag := range l.Tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	l.Tags = tags
+	return nil
+}
+
+//LabelFilter matches resources carrying every tag and every field value
+type LabelFilter struct {
+	Tags   []string
+	Fields map[string]string
+}
+
+func (f LabelFilter) Empty() bool {
+	return len(f.Tags) == 0 && len(f.Fields) == 0
+}
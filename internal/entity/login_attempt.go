@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"time"
+)
+
+type LoginOutcome string
+
+const (
+	LoginSucceeded      LoginOutcome = "success"
+	LoginBadCredentials LoginOutcome = "bad_credentials"
+	LoginThrottled      LoginOutcome = "throttled"
+	LoginLocked         LoginOutcome = "locked"
+	LoginDisabled       LoginOutcome = "disabled"
+)
+
+type LoginAttempt struct {
+	ID          int
+	Username    string
+	UserID      string
+	IP          string
+	UserAgent   string
+	Outcome     LoginOutcome
+	Suspicious  bool
+	AttemptedAt time.Time
+}
+
+type LoginAttemptFilter struct {
+	Username   string
+	Outcome    LoginOutcome
+	Suspicious bool
+	Limit      int
+}
+
+func NewLoginAttempt(username string, ip string, userAgent string, outcome LoginOutcome) *LoginAttempt {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if len(username) > 50 {
+		username = username[:50]
+	}
+	return &LoginAttempt{
+		Username:    username,
+		IP:          ip,
+		UserAgent:   userAgent,
+		Outcome:     outcome,
+		AttemptedAt: time.Now(),
+	}
+}
+
+//LoginCounter tracks consecutive failures for an account or an IP address
+type LoginCounter struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	Locked      bool
+}
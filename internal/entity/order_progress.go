@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+)
+
+//ProgressRow is one task of an order as read for the progress rollup.
+//Requirements without tasks come as a single row with an empty TaskID.
+type ProgressRow struct {
+	RequirementID     int
+	Request           string
+	RequirementStatus Status
+	TaskID            string
+	TaskStatus        Status
+	Deadline          time.Time
+	Allowed           bool
+	//PendingReviewers are the forwarded reviewers yet to review the task,
+	//or the assigner when it wasn't forwarded
+	PendingReviewers int
+	//Prerequisites are the tasks this one still waits for, a prerequisite
+	//is dropped once its task is submitted
+	Prerequisites []string
+}
+
+type RequirementProgress struct {
+	ID            int
+	Request       string
+	Status        Status
+	TotalTasks    int
+	FinishedTasks int
+}
+
+//BlockedTask waits for prerequisites. Depth is the length of the longest
+//chain of unsubmitted tasks in front of it.
+type BlockedTask struct {
+	TaskID    string
+	WaitingOn []string
+	Depth     int
+}
+
+type OrderProgress struct {
+	OrderID      string
+	Status       OrderStatus
+	Requirements []RequirementProgress
+	//TaskCounts is keyed by task status: Unfinished, InReview, Finished
+	TaskCounts       map[Status]int
+	OpenReviewRounds int
+	PendingReviewers int
+	OverdueTasks     []string
+	BlockedTasks     []BlockedTask
+	PercentComplete  float64
+}
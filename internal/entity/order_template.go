@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+)
+
+//OrderTemplate is a reusable order plan. Deadlines are relative: the order
+//is due DeadlineOffset after it is instantiated and each task is due its
+//own DeadlineOffset from the order's deadline, usually a negative one.
+type OrderTemplate struct {
+	ID             string
+	Name           string
+	Title          string
+	Description    string
+	DeadlineOffset time.Duration
+	Requirements   []TemplateRequirement
+	CreatedBy      string
+	CreatedAt      time.Time
+}
+
+type TemplateRequirement struct {
+	Request         string
+	ExpectedOutcome string
+	Tasks           []TemplateTask
+}
+
+//TemplateTask is a planned task. Key identifies it within the template so
+//other tasks can name it as a prerequisite.
+type TemplateTask struct {
+	Key            string
+	UserID         string
+	Note           string
+	DeadlineOffset time.Duration
+	Prerequisites  []string
+}
+
+func NewOrderTemplate(name string, title string, description string, deadlineOffset time.Duration, createdBy string) *OrderTemplate {
+	return &OrderTemplate{
+		ID:             NewUUID().String(),
+		Name:           name,
+		Title:          title,
+		Description:    description,
+		DeadlineOffset: deadlineOffset,
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now(),
+	}
+}
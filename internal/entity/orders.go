@@ -4,11 +4,62 @@ import (
 	"time"
 )
 
+type OrderStatus string
+
+const (
+	OrderDraft      OrderStatus = "draft"
+	OrderOpen       OrderStatus = "open"
+	OrderInProgress OrderStatus = "in_progress"
+	OrderValidated  OrderStatus = "validated"
+	OrderCancelled  OrderStatus = "cancelled"
+	OrderArchived   OrderStatus = "archived"
+)
+
+//orderTransitions lists the states an order may move to from each state.
+//Open, InProgress and Validated follow the work on the requirements,
+//the others are only entered on request.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderDraft:      {OrderOpen, OrderCancelled},
+	OrderOpen:       {OrderDraft, OrderInProgress, OrderValidated, OrderCancelled},
+	OrderInProgress: {OrderOpen, OrderValidated, OrderCancelled},
+	OrderValidated:  {OrderOpen, OrderInProgress, OrderArchived},
+	OrderCancelled:  {OrderArchived},
+	OrderArchived:   {},
+}
+
+//UncompletedOrderStatuses are the states of orders that still need work
+var UncompletedOrderStatuses = []OrderStatus{OrderDraft, OrderOpen, OrderInProgress}
+
+func ParseOrderStatus(s string) (OrderStatus, bool) {
+	status := OrderStatus(s)
+	_, ok := orderTransitions[status]
+	return status, ok
+}
+
+//Tracked reports whether the status follows the requirements' progress
+func (s OrderStatus) Tracked() bool {
+	return s == OrderOpen || s == OrderInProgress || s == OrderValidated
+}
+
+//Closed orders take no new requirements or tasks
+func (s OrderStatus) Closed() bool {
+	return s == OrderCancelled || s == OrderArchived
+}
+
 type Orders struct {
 	ID          string
 	Title       string
 	Description string
 	Deadline    time.Time
+	Status      OrderStatus
+	Priority    Priority
+	//SLAPolicy is the name of the order's SLA policy, empty when it has none
+	SLAPolicy string
+	CreatedAt time.Time
+	//CustomerID is empty for orders not placed by a customer
+	CustomerID string
+	//DeletedAt is only set on orders listed from the trash
+	DeletedAt time.Time
 }
 
 func NewOrder(title string, description string, deadline time.Time) *Orders {
@@ -17,6 +68,18 @@ func NewOrder(title string, description string, deadline time.Time) *Orders {
 		Title:       title,
 		Description: description,
 		Deadline:    deadline,
+		Status:      OrderOpen,
+		Priority:    PriorityNormal,
+		CreatedAt:   time.Now(),
 	}
 	return o
 }
+
+func (o *Orders) CanTransition(to OrderStatus) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package entity
+
+type ResourceType string
+
+const (
+	ResourceOrder       ResourceType = "order"
+	ResourceRequirement ResourceType = "requirement"
+	ResourceTask        ResourceType = "task"
+	ResourceSubmission  ResourceType = "submission"
+	ResourceUser        ResourceType = "user"
+)
+
+//Resource identifies what an action is performed on. An empty ID stands
+//for the whole collection, e.g. listing every order.
+type Resource struct {
+	Type ResourceType
+	ID   string
+}
+
+//Actor is the authenticated caller. Scopes is set when the caller uses an
+//API key and limits the actor to those permissions on top of the role.
+type Actor struct {
+	UserID string
+	Role   string
+	Scopes []Permission
+}
+
+func (a *Actor) InScope(p Permission) bool {
+	if a.Scopes == nil {
+		return true
+	}
+	for _, scope := range a.Scopes {
+		if scope == p {
+			return true
+		}
+	}
+	return false
+}
+
+//TaskRelations are the users tied to a task that policies care about
+type TaskRelations struct {
+	TaskID     string
+	OrderID    string
+	UserID     string
+	AssignerID string
+	Reviewers  []string
+}
+
+func (t *TaskRelations) IsReviewer(userID string) bool {
+	if t.AssignerID == userID {
+		return true
+	}
+	for _, reviewer := range t.Reviewers {
+		if reviewer == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+)
+
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+//NewRefreshToken creates a token in the given family, an empty familyID
+//starts a new family (a new login)
+func NewRefreshToken(userID string, familyID string, tokenHash string, ttl time.Duration) *RefreshToken {
+	id := NewUUID().String()
+	if familyID == "" {
+		familyID = id
+	}
+	now := time.Now()
+	return &RefreshToken{
+		ID:        id,
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: tokenHash,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+func (t *RefreshToken) Expired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
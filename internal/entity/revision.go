@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+//Revision is an immutable snapshot of an order's or requirement's content
+//after a change. Number counts up from 1 per record.
+type Revision struct {
+	ID         string
+	Resource   Resource
+	Number     int
+	AuthorID   string
+	CreatedAt  time.Time
+	Snapshot   map[string]string
+	Changes    []FieldChange
+	RestoredOf int
+}
+
+//FieldChange is one field that differs between two snapshots
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+func NewRevision(resource Resource, number int, authorID string, snapshot map[string]string, previous map[string]string) *Revision {
+	return &Revision{
+		ID:        NewUUID().String(),
+		Resource:  resource,
+		Number:    number,
+		AuthorID:  authorID,
+		CreatedAt: time.Now(),
+		Snapshot:  snapshot,
+		Changes:   DiffSnapshots(previous, snapshot),
+	}
+}
+
+//DiffSnapshots lists the fields that differ, sorted by field name. A nil
+//from stands for the record not existing yet.
+func DiffSnapshots(from map[string]string, to map[string]string) []FieldChange {
+	fields := map[string]bool{}
+	for field := range from {
+		fields[field] = true
+	}
+	for field := range to {
+		fields[field] = true
+	}
+	var changes []FieldChange
+	for field := range fields {
+		if from[field] != to[field] {
+			changes = append(changes, FieldChange{Field: field, From: from[field], To: to[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,84 @@
+package entity
+
+type Permission string
+
+const (
+	PermOrderRead        Permission = "order:read"
+	PermOrderWrite       Permission = "order:write"
+	PermTaskRead         Permission = "task:read"
+	PermTaskAssign       Permission = "task:assign"
+	PermTaskWork         Permission = "task:work"
+	PermSubmissionReview Permission = "submission:review"
+	PermUserRead         Permission = "user:read"
+	PermUserWrite        Permission = "user:write"
+	PermRoleManage       Permission = "role:manage"
+)
+
+//AllPermissions lists every permission a route can require
+var AllPermissions = []Permission{
+	PermOrderRead,
+	PermOrderWrite,
+	PermTaskRead,
+	PermTaskAssign,
+	PermTaskWork,
+	PermSubmissionReview,
+	PermUserRead,
+	PermUserWrite,
+	PermRoleManage,
+}
+
+//Built-in role names. Admin always holds every permission and can't be
+//changed, the others are seeded with defaults that admins may edit.
+const (
+	RoleAdmin    = "Admin"
+	RoleManager  = "Manager"
+	RoleReviewer = "Reviewer"
+	RoleWorker   = "Worker"
+	RoleViewer   = "Viewer"
+)
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []Permission
+	BuiltIn     bool
+	//Require2FA makes members complete a TOTP challenge before a token
+	//is issued, enrolling first if they haven't yet
+	Require2FA bool
+}
+
+func NewRole(name string, description string, permissions []Permission) *Role {
+	return &Role{
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+		BuiltIn:     false,
+	}
+}
+
+func (r *Role) Has(p Permission) bool {
+	if r.Name == RoleAdmin {
+		return true
+	}
+	for _, permission := range r.Permissions {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
+
+//Privileged reports whether members can manage users or roles, and so
+//grant themselves every other permission
+func (r *Role) Privileged() bool {
+	return r.Has(PermUserWrite) || r.Has(PermRoleManage)
+}
+
+func ValidPermission(p Permission) bool {
+	for _, permission := range AllPermissions {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+)
+
+//Session is one login on one device. Its ID is the sid claim of the access
+//tokens and the family ID of the refresh tokens issued for it.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Revoked    bool
+}
+
+func NewSession(userID string, ip string, userAgent string) *Session {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	now := time.Now()
+	return &Session{
+		ID:         NewUUID().String(),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"time"
+)
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+var priorityRanks = map[Priority]int{
+	PriorityLow:    0,
+	PriorityNormal: 1,
+	PriorityHigh:   2,
+	PriorityUrgent: 3,
+}
+
+func ParsePriority(s string) (Priority, bool) {
+	priority := Priority(s)
+	_, ok := priorityRanks[priority]
+	return priority, ok
+}
+
+//Rank orders priorities, higher is more urgent. Unknown priorities rank as
+//normal.
+func (p Priority) Rank() int {
+	rank, ok := priorityRanks[p]
+	if !ok {
+		return priorityRanks[PriorityNormal]
+	}
+	return rank
+}
+
+//SLAPolicy holds the targets an order is measured against. A zero target
+//isn't tracked.
+type SLAPolicy struct {
+	Name        string
+	Description string
+	//TargetStart is the time from the order's creation to its first
+	//submission
+	TargetStart time.Duration
+	//TargetCompletion is the time from the order's creation to the approval
+	//of its last task
+	TargetCompletion time.Duration
+	//ReviewTurnaround is the time from a submission to its review
+	ReviewTurnaround time.Duration
+}
+
+const (
+	ClockStart      = "start"
+	ClockCompletion = "completion"
+	ClockReview     = "review"
+)
+
+//SLAClock measures one target. It runs until StoppedAt is set.
+type SLAClock struct {
+	Name string
+	//TaskID is only set on review clocks
+	TaskID    string
+	Target    time.Duration
+	StartedAt time.Time
+	StoppedAt time.Time
+}
+
+func (c SLAClock) Running() bool {
+	return c.StoppedAt.IsZero()
+}
+
+func (c SLAClock) Elapsed(now time.Time) time.Duration {
+	if c.Running() {
+		return now.Sub(c.StartedAt)
+	}
+	return c.StoppedAt.Sub(c.StartedAt)
+}
+
+//Risk is the share of the target used up, it is above 1 once the target is
+//breached and 0 for untracked targets
+func (c SLAClock) Risk(now time.Time) float64 {
+	if c.Target <= 0 || c.StartedAt.IsZero() {
+		return 0
+	}
+	return float64(c.Elapsed(now)) / float64(c.Target)
+}
+
+//Breached is false for clocks that never started, like Risk
+func (c SLAClock) Breached(now time.Time) bool {
+	return c.Target > 0 && !c.StartedAt.IsZero() && c.Elapsed(now) > c.Target
+}
+
+//TaskTimes are the timestamps of a task the SLA clocks are computed from.
+//Submission times are zero until the task is submitted.
+type TaskTimes struct {
+	TaskID          string
+	Status          Status
+	AssignedAt      time.Time
+	FirstSubmission time.Time
+	LastSubmission  time.Time
+	ReviewedAt      time.Time
+}
+
+//OrderSLA is an order with the timestamps of its tasks. Policy is nil for
+//orders without an SLA policy.
+type OrderSLA struct {
+	OrderID   string
+	Title     string
+	Status    OrderStatus
+	Priority  Priority
+	CreatedAt time.Time
+	Policy    *SLAPolicy
+	Tasks     []TaskTimes
+}
+
+//Clocks computes the order's clocks: start, completion and one review clock
+//per task that is in review or was reviewed after its last submission.
+//Only the latest review round of a task is measured.
+func (o *OrderSLA) Clocks() []SLAClock {
+	if o.Policy == nil {
+		return nil
+	}
+	start := SLAClock{Name: ClockStart, Target: o.Policy.TargetStart, StartedAt: o.CreatedAt}
+	completion := SLAClock{Name: ClockCompletion, Target: o.Policy.TargetCompletion, StartedAt: o.CreatedAt}
+	var reviews []SLAClock
+	for _, t := range o.Tasks {
+		if !t.FirstSubmission.IsZero() && (start.StoppedAt.IsZero() || t.FirstSubmission.Before(start.StoppedAt)) {
+			start.StoppedAt = t.FirstSubmission
+		}
+		if t.LastSubmission.IsZero() {
+			continue
+		}
+		review := SLAClock{Name: ClockReview, TaskID: t.TaskID, Target: o.Policy.ReviewTurnaround, StartedAt: t.LastSubmission}
+		switch {
+		case t.ReviewedAt.After(t.LastSubmission):
+			review.StoppedAt = t.ReviewedAt
+		case t.Status != InReview:
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+	if o.Status == OrderValidated || o.Status == OrderArchived {
+		completion.StoppedAt = o.CreatedAt
+		for _, t := range o.Tasks {
+			if t.ReviewedAt.After(completion.StoppedAt) {
+				completion.StoppedAt = t.ReviewedAt
+			}
+		}
+	}
+	return append([]SLAClock{start, completion}, reviews...)
+}
+
+//Breaches returns the clocks past their target
+func (o *OrderSLA) Breaches(now time.Time) []SLAClock {
+	var breaches []SLAClock
+	for _, clock := range o.Clocks() {
+		if clock.Breached(now) {
+			breaches = append(breaches, clock)
+		}
+	}
+	return breaches
+}
+
+//SLARisk is the risk of the clock the task holds up: its review clock while
+//it is in review, its order's completion clock while it is being worked on
+func (t *TaskWithDetails) SLARisk(now time.Time) float64 {
+	switch t.Status {
+	case InReview:
+		return SLAClock{Target: t.ReviewTarget, StartedAt: t.SubmittedAt}.Risk(now)
+	case Unfinished:
+		return SLAClock{Target: t.CompletionTarget, StartedAt: t.OrderCreatedAt}.Risk(now)
+	}
+	return 0
+}
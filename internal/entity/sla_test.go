@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderSLAClocks(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return created.Add(d) }
+	policy := &SLAPolicy{Name: "standard", TargetStart: time.Hour, TargetCompletion: 24 * time.Hour, ReviewTurnaround: 2 * time.Hour}
+	tt := []struct {
+		name   string
+		status OrderStatus
+		policy *SLAPolicy
+		tasks  []TaskTimes
+		want   []SLAClock
+	}{
+		{
+			name:   "no policy",
+			status: OrderOpen,
+			tasks:  []TaskTimes{{TaskID: "t1", Status: InReview, FirstSubmission: at(time.Hour), LastSubmission: at(time.Hour)}},
+		},
+		{
+			name:   "nothing submitted",
+			status: OrderOpen,
+			policy: policy,
+			tasks:  []TaskTimes{{TaskID: "t1", Status: Unfinished, AssignedAt: created}},
+			want: []SLAClock{
+				{Name: ClockStart, Target: time.Hour, StartedAt: created},
+				{Name: ClockCompletion, Target: 24 * time.Hour, StartedAt: created},
+			},
+		},
+		{
+			name:   "start stops at the first submission",
+			status: OrderInProgress,
+			policy: policy,
+			tasks: []TaskTimes{
+				{TaskID: "t1", Status: InReview, FirstSubmission: at(3 * time.Hour), LastSubmission: at(3 * time.Hour)},
+				{TaskID: "t2", Status: InReview, FirstSubmission: at(2 * time.Hour), LastSubmission: at(2 * time.Hour)},
+			},
+			want: []SLAClock{
+				{Name: ClockStart, Target: time.Hour, StartedAt: created, StoppedAt: at(2 * time.Hour)},
+				{Name: ClockCompletion, Target: 24 * time.Hour, StartedAt: created},
+				{Name: ClockReview, TaskID: "t1", Target: 2 * time.Hour, StartedAt: at(3 * time.Hour)},
+				{Name: ClockReview, TaskID: "t2", Target: 2 * time.Hour, StartedAt: at(2 * time.Hour)},
+			},
+		},
+		{
+			name:   "only the latest review round is measured",
+			status: OrderInProgress,
+			policy: policy,
+			tasks: []TaskTimes{
+				//resubmitted after being sent back
+				{TaskID: "t1", Status: InReview, FirstSubmission: at(time.Hour), LastSubmission: at(5 * time.Hour), ReviewedAt: at(4 * time.Hour)},
+				//sent back and not resubmitted yet
+				{TaskID: "t2", Status: Unfinished, FirstSubmission: at(2 * time.Hour), LastSubmission: at(2 * time.Hour), ReviewedAt: at(3 * time.Hour)},
+			},
+			want: []SLAClock{
+				{Name: ClockStart, Target: time.Hour, StartedAt: created, StoppedAt: at(time.Hour)},
+				{Name: ClockCompletion, Target: 24 * time.Hour, StartedAt: created},
+				{Name: ClockReview, TaskID: "t1", Target: 2 * time.Hour, StartedAt: at(5 * time.Hour)},
+				{Name: ClockReview, TaskID: "t2", Target: 2 * time.Hour, StartedAt: at(2 * time.Hour), StoppedAt: at(3 * time.Hour)},
+			},
+		},
+		{
+			name:   "completion stops at the last approval",
+			status: OrderValidated,
+			policy: policy,
+			tasks: []TaskTimes{
+				{TaskID: "t1", Status: Finished, FirstSubmission: at(time.Hour), LastSubmission: at(time.Hour), ReviewedAt: at(30 * time.Hour)},
+				{TaskID: "t2", Status: Finished, FirstSubmission: at(2 * time.Hour), LastSubmission: at(2 * time.Hour), ReviewedAt: at(3 * time.Hour)},
+			},
+			want: []SLAClock{
+				{Name: ClockStart, Target: time.Hour, StartedAt: created, StoppedAt: at(time.Hour)},
+				{Name: ClockCompletion, Target: 24 * time.Hour, StartedAt: created, StoppedAt: at(30 * time.Hour)},
+				{Name: ClockReview, TaskID: "t1", Target: 2 * time.Hour, StartedAt: at(time.Hour), StoppedAt: at(30 * time.Hour)},
+				{Name: ClockReview, TaskID: "t2", Target: 2 * time.Hour, StartedAt: at(2 * time.Hour), StoppedAt: at(3 * time.Hour)},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &OrderSLA{OrderID: "o1", Status: tc.status, CreatedAt: created, Policy: tc.policy, Tasks: tc.tasks}
+			got := o.Clocks()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Clocks() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSLAClockRisk(t *testing.T) {
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := started.Add(3 * time.Hour)
+	tt := []struct {
+		name     string
+		clock    SLAClock
+		risk     float64
+		breached bool
+	}{
+		{name: "running within target", clock: SLAClock{Target: 4 * time.Hour, StartedAt: started}, risk: 0.75},
+		{name: "running past target", clock: SLAClock{Target: 2 * time.Hour, StartedAt: started}, risk: 1.5, breached: true},
+		{name: "stopped in time", clock: SLAClock{Target: 2 * time.Hour, StartedAt: started, StoppedAt: started.Add(time.Hour)}, risk: 0.5},
+		{name: "stopped late", clock: SLAClock{Target: 2 * time.Hour, StartedAt: started, StoppedAt: started.Add(4 * time.Hour)}, risk: 2, breached: true},
+		{name: "untracked target", clock: SLAClock{StartedAt: started}},
+		{name: "not started", clock: SLAClock{Target: time.Hour}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if risk := tc.clock.Risk(now); risk != tc.risk {
+				t.Errorf("Risk = %v, want %v", risk, tc.risk)
+			}
+			if breached := tc.clock.Breached(now); breached != tc.breached {
+				t.Errorf("Breached = %v, want %v", breached, tc.breached)
+			}
+		})
+	}
+}
@@ -24,6 +24,9 @@ type Task struct {
 	NumOfReviewer     uint8
 	Allowed           bool
 	Deadline          time.Time
+	CreatedAt         time.Time
+	//ReviewedAt is when the last review decision was taken on the task
+	ReviewedAt time.Time
 }
 
 type TaskWithDetails struct {
@@ -43,6 +46,13 @@ type TaskWithDetails struct {
 	NumOfPrerequisite uint8
 	Prerequisites     []string
 	Messages          []Message
+	//The fields below are read for sorting task lists by SLA risk. Targets
+	//are zero when the order has no SLA policy.
+	Priority         Priority
+	OrderCreatedAt   time.Time
+	SubmittedAt      time.Time
+	CompletionTarget time.Duration
+	ReviewTarget     time.Duration
 }
 
 type Message struct {
@@ -64,6 +74,7 @@ func NewTask(assignerID string, requirementID int, userID string, note string, p
 		NumOfReviewer:     1,
 		NumOfPrerequisite: 0,
 		Status:            0,
+		CreatedAt:         time.Now(),
 	}
 	if totalPrerequisite := len(prerequisiteTaskID); totalPrerequisite != 0 {
 		task.Allowed = false
@@ -73,7 +84,11 @@ func NewTask(assignerID string, requirementID int, userID string, note string, p
 	return &task
 }
 
+//SetStatus stamps ReviewedAt when the task leaves review, approved or not
 func (t *Task) SetStatus(newStatus uint8) {
+	if t.Status == InReview && Status(newStatus) != InReview {
+		t.ReviewedAt = time.Now()
+	}
 	t.Status = Status(newStatus)
 }
 
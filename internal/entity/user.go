@@ -1,11 +1,6 @@
 package entity
 
-type Role uint8
-
-const (
-	Admin Role = iota
-	Worker
-)
+import "time"
 
 type User struct {
 	ID       string
@@ -13,6 +8,17 @@ type User struct {
 	Email    string
 	Password string
 	UserRole string
+	Disabled bool
+	//ServiceAccount users have no password and authenticate with API keys
+	ServiceAccount bool
+	//TOTPSecret is set on enrollment and TOTPEnabled once the first code
+	//has been confirmed. TOTPLastStep is the last accepted time step, so
+	//a code can't be used twice.
+	TOTPSecret   string
+	TOTPEnabled  bool
+	TOTPLastStep int64
+	//PasswordChangedAt is zero when unknown, which counts as expired
+	PasswordChangedAt time.Time
 }
 
 func NewUser(email string, username string, password string, Role string) *User {
@@ -25,3 +31,11 @@ func NewUser(email string, username string, password string, Role string) *User
 	}
 	return &u
 }
+
+func (u *User) Disable() {
+	u.Disabled = true
+}
+
+func (u *User) Enable() {
+	u.Disabled = false
+}
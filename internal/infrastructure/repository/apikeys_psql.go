@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type APIKeyPSQL struct {
+	db *sql.DB
+}
+
+func NewAPIKeyPSQL(db *sql.DB) *APIKeyPSQL {
+	return &APIKeyPSQL{
+		db: db,
+	}
+}
+
+func (r *APIKeyPSQL) Create(k *entity.APIKey) (string, error) {
+	var expiresAt sql.NullTime
+	if !k.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: k.ExpiresAt, Valid: true}
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return k.ID, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO api_keys (id, name, user_id, prefix, key_hash, created_by, created_at, expires_at, revoked) 
+		values($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		k.ID, k.Name, k.UserID, k.Prefix, k.KeyHash, k.CreatedBy, k.CreatedAt, expiresAt, k.Revoked)
+	if err != nil {
+		tx.Rollback()
+		return k.ID, err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO api_key_permissions (key_id, permission) values($1,$2)`)
+	if err != nil {
+		tx.Rollback()
+		return k.ID, err
+	}
+	for _, p := range k.Permissions {
+		_, err = stmt.Exec(k.ID, p)
+		if err != nil {
+			tx.Rollback()
+			return k.ID, err
+		}
+	}
+	err = stmt.Close()
+	if err != nil {
+		tx.Rollback()
+		return k.ID, err
+	}
+	return k.ID, tx.Commit()
+}
+
+func (r *APIKeyPSQL) Get(id string) (*entity.APIKey, error) {
+	return r.getOne(`SELECT id, name, user_id, prefix, key_hash, created_by, created_at, expires_at, last_used_at, revoked 
+					 FROM api_keys WHERE id = $1`, id)
+}
+
+func (r *APIKeyPSQL) GetByHash(keyHash string) (*entity.APIKey, error) {
+	return r.getOne(`SELECT id, name, user_id, prefix, key_hash, created_by, created_at, expires_at, last_used_at, revoked 
+					 FROM api_keys WHERE key_hash = $1`, keyHash)
+}
+
+func (r *APIKeyPSQL) ListByUser(userID string) ([]*entity.APIKey, error) {
+	stmt, err := r.db.Prepare(`SELECT id, name, user_id, prefix, key_hash, created_by, created_at, expires_at, last_used_at, revoked 
+							   FROM api_keys WHERE user_id = $1 ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []*entity.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		k.Permissions, err = r.getPermissions(k.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func (r *APIKeyPSQL) Revoke(id string) error {
+	_, err := r.db.Exec("UPDATE api_keys SET revoked = true WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *APIKeyPSQL) TouchLastUsed(id string, at time.Time) error {
+	_, err := r.db.Exec("UPDATE api_keys SET last_used_at = $1 WHERE id = $2", at, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *APIKeyPSQL) getOne(query string, arg string) (*entity.APIKey, error) {
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	k, err := scanAPIKey(stmt.QueryRow(arg))
+	if err != nil {
+		return nil, err
+	}
+	k.Permissions, err = r.getPermissions(k.ID)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (r *APIKeyPSQL) getPermissions(keyID string) ([]entity.Permission, error) {
+	stmt, err := r.db.Prepare(`SELECT permission FROM api_key_permissions WHERE key_id = $1`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var permissions []entity.Permission
+	for rows.Next() {
+		var p entity.Permission
+		err = rows.Scan(&p)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var k entity.APIKey
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.UserID, &k.Prefix, &k.KeyHash, &k.CreatedBy, &k.CreatedAt, &expiresAt, &lastUsedAt, &k.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	k.ExpiresAt = expiresAt.Time
+	k.LastUsedAt = lastUsedAt.Time
+	return &k, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+type AttachmentMySQL struct {
+	db *sql.DB
+}
+
+func NewAttachmentMySQL(db *sql.DB) *AttachmentMySQL {
+	return &AttachmentMySQL{
+		db: db,
+	}
+}
+
+func (r *AttachmentMySQL) Create(a *entity.Attachment) (string, error) {
+	_, err := r.db.Exec(`INSERT INTO attachments (id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at)
+						 values(?,?,?,?,?,?,?,?,?)`,
+		a.ID, a.Resource.Type, a.Resource.ID, a.Filename, a.MimeType, a.Size, a.Checksum, a.UploadedBy, a.UploadedAt)
+	if err != nil {
+		return "", err
+	}
+	return a.ID, nil
+}
+
+func (r *AttachmentMySQL) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM attachments WHERE id = ?`, id)
+	return err
+}
+
+func (r *AttachmentMySQL) Get(id string) (*entity.Attachment, error) {
+	row := r.db.QueryRow(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+						  FROM attachments WHERE id = ?`, id)
+	a, err := scanAttachment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *AttachmentMySQL) List(resource entity.Resource) ([]*entity.Attachment, error) {
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE resource_type = ? AND resource_id = ? ORDER BY uploaded_at`,
+		resource.Type, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func (r *AttachmentMySQL) ListByResources(t entity.ResourceType, ids []string) ([]*entity.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{t}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	in := "(?" + strings.Repeat(",?", len(ids)-1) + ")"
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE resource_type = ? AND resource_id IN `+in+` ORDER BY uploaded_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func (r *AttachmentMySQL) ListOrphans() ([]*entity.Attachment, error) {
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE (resource_type = 'order' AND resource_id NOT IN (SELECT id FROM orders))
+							 OR (resource_type = 'requirement' AND resource_id NOT IN (SELECT CAST(id AS CHAR) FROM requirements))`)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+type AttachmentPSQL struct {
+	db *sql.DB
+}
+
+func NewAttachmentPSQL(db *sql.DB) *AttachmentPSQL {
+	return &AttachmentPSQL{
+		db: db,
+	}
+}
+
+func (r *AttachmentPSQL) Create(a *entity.Attachment) (string, error) {
+	_, err := r.db.Exec(`INSERT INTO attachments (id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at)
+						 values($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		a.ID, a.Resource.Type, a.Resource.ID, a.Filename, a.MimeType, a.Size, a.Checksum, a.UploadedBy, a.UploadedAt)
+	if err != nil {
+		return "", err
+	}
+	return a.ID, nil
+}
+
+func (r *AttachmentPSQL) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM attachments WHERE id = $1`, id)
+	return err
+}
+
+func (r *AttachmentPSQL) Get(id string) (*entity.Attachment, error) {
+	row := r.db.QueryRow(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+						  FROM attachments WHERE id = $1`, id)
+	a, err := scanAttachment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *AttachmentPSQL) List(resource entity.Resource) ([]*entity.Attachment, error) {
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE resource_type = $1 AND resource_id = $2 ORDER BY uploaded_at`,
+		resource.Type, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func (r *AttachmentPSQL) ListByResources(t entity.ResourceType, ids []string) ([]*entity.Attachment, error) {
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE resource_type = $1 AND resource_id = ANY($2) ORDER BY uploaded_at`,
+		t, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func (r *AttachmentPSQL) ListOrphans() ([]*entity.Attachment, error) {
+	rows, err := r.db.Query(`SELECT id, resource_type, resource_id, filename, mime_type, size, checksum, uploaded_by, uploaded_at
+							 FROM attachments WHERE (resource_type = 'order' AND resource_id NOT IN (SELECT id FROM orders))
+							 OR (resource_type = 'requirement' AND resource_id NOT IN (SELECT CAST(id AS varchar(37)) FROM requirements))`)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func scanAttachments(rows *sql.Rows) ([]*entity.Attachment, error) {
+	defer rows.Close()
+	var list []*entity.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func scanAttachment(row rowScanner) (*entity.Attachment, error) {
+	var a entity.Attachment
+	err := row.Scan(&a.ID, &a.Resource.Type, &a.Resource.ID, &a.Filename, &a.MimeType, &a.Size, &a.Checksum, &a.UploadedBy, &a.UploadedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
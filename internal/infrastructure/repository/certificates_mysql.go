@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"order-validation-v2/internal/entity"
+)
+
+type CertificateMySQL struct {
+	db *sql.DB
+}
+
+func NewCertificateMySQL(db *sql.DB) *CertificateMySQL {
+	return &CertificateMySQL{
+		db: db,
+	}
+}
+
+func (r *CertificateMySQL) Create(c *entity.Certificate) error {
+	content, err := json.Marshal(c.Content)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO certificates (id, order_id, issued_at, content, digest, token, document_checksum)
+						values(?,?,?,?,?,?,?)`,
+		c.ID, c.Content.OrderID, c.IssuedAt, string(content), c.Digest, c.Token, c.DocumentChecksum)
+	return err
+}
+
+func (r *CertificateMySQL) Get(id string) (*entity.Certificate, error) {
+	row := r.db.QueryRow(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates WHERE id = ?`, id)
+	c, err := scanCertificate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificateMySQL) GetLatest(orderID string) (*entity.Certificate, error) {
+	row := r.db.QueryRow(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates
+						  WHERE order_id = ? ORDER BY issued_at DESC, id LIMIT 1`, orderID)
+	c, err := scanCertificate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificateMySQL) List(orderID string) ([]*entity.Certificate, error) {
+	rows, err := r.db.Query(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates
+							 WHERE order_id = ? ORDER BY issued_at DESC, id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*entity.Certificate
+	for rows.Next() {
+		c, err := scanCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+//Evidence reads the finished tasks of each requirement and then their
+//reviewers and submissions. The images are hashed by the database so they
+//never leave it.
+func (r *CertificateMySQL) Evidence(orderID string) (*entity.CertificateContent, error) {
+	content := &entity.CertificateContent{OrderID: orderID}
+	err := r.db.QueryRow(`SELECT title FROM orders WHERE id = ? AND deleted_at IS NULL`, orderID).Scan(&content.OrderTitle)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT requirements.id, requirements.request, requirements.expected_outcome,
+							 tasks.id, tasks.reviewed_at, tasks.assigner_id, COALESCE(users.username, '')
+							 FROM requirements LEFT JOIN tasks ON tasks.requirement_id = requirements.id
+							 AND tasks.deleted_at IS NULL AND tasks.fulfillment_status = ?
+							 LEFT JOIN users ON users.id = tasks.assigner_id
+							 WHERE requirements.order_id = ? AND requirements.deleted_at IS NULL
+							 ORDER BY requirements.id, tasks.created_at, tasks.id`, entity.Finished, orderID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := scanCertifiedTasks(rows, content)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		reviewerRows, err := r.db.Query(`SELECT DISTINCT review_messages.user_id, COALESCE(users.username, '')
+										 FROM review_messages LEFT JOIN users ON users.id = review_messages.user_id
+										 WHERE review_messages.task_id = ? ORDER BY review_messages.user_id`, t.id)
+		if err != nil {
+			return nil, err
+		}
+		err = scanReviewers(reviewerRows, t.requirement)
+		if err != nil {
+			return nil, err
+		}
+		evidenceRows, err := r.db.Query(`SELECT submissions.id, submissions.submit_time, COALESCE(submissions.message, ''),
+										 SHA2(image_submissions.image, 256)
+										 FROM submissions LEFT JOIN image_submissions ON image_submissions.submission_id = submissions.id
+										 WHERE submissions.task_id = ? AND submissions.deleted_at IS NULL
+										 ORDER BY submissions.submit_time, submissions.id, image_submissions.id`, t.id)
+		if err != nil {
+			return nil, err
+		}
+		err = scanEvidence(evidenceRows, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"order-validation-v2/internal/entity"
+)
+
+type CertificatePSQL struct {
+	db *sql.DB
+}
+
+func NewCertificatePSQL(db *sql.DB) *CertificatePSQL {
+	return &CertificatePSQL{
+		db: db,
+	}
+}
+
+func (r *CertificatePSQL) Create(c *entity.Certificate) error {
+	content, err := json.Marshal(c.Content)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO certificates (id, order_id, issued_at, content, digest, token, document_checksum)
+						values($1,$2,$3,$4,$5,$6,$7)`,
+		c.ID, c.Content.OrderID, c.IssuedAt, string(content), c.Digest, c.Token, c.DocumentChecksum)
+	return err
+}
+
+func (r *CertificatePSQL) Get(id string) (*entity.Certificate, error) {
+	row := r.db.QueryRow(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates WHERE id = $1`, id)
+	c, err := scanCertificate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificatePSQL) GetLatest(orderID string) (*entity.Certificate, error) {
+	row := r.db.QueryRow(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates
+						  WHERE order_id = $1 ORDER BY issued_at DESC, id LIMIT 1`, orderID)
+	c, err := scanCertificate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificatePSQL) List(orderID string) ([]*entity.Certificate, error) {
+	rows, err := r.db.Query(`SELECT id, issued_at, content, digest, token, document_checksum FROM certificates
+							 WHERE order_id = $1 ORDER BY issued_at DESC, id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*entity.Certificate
+	for rows.Next() {
+		c, err := scanCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+//Evidence reads the finished tasks of each requirement and then their
+//reviewers and submissions. The images are hashed by the database so they
+//never leave it.
+func (r *CertificatePSQL) Evidence(orderID string) (*entity.CertificateContent, error) {
+	content := &entity.CertificateContent{OrderID: orderID}
+	err := r.db.QueryRow(`SELECT title FROM orders WHERE id = $1 AND deleted_at IS NULL`, orderID).Scan(&content.OrderTitle)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT requirements.id, requirements.request, requirements.expected_outcome,
+							 tasks.id, tasks.reviewed_at, tasks.assigner_id, COALESCE(users.username, '')
+							 FROM requirements LEFT JOIN tasks ON tasks.requirement_id = requirements.id
+							 AND tasks.deleted_at IS NULL AND tasks.fulfillment_status = $1
+							 LEFT JOIN users ON users.id = tasks.assigner_id
+							 WHERE requirements.order_id = $2 AND requirements.deleted_at IS NULL
+							 ORDER BY requirements.id, tasks.created_at, tasks.id`, entity.Finished, orderID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := scanCertifiedTasks(rows, content)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		reviewerRows, err := r.db.Query(`SELECT DISTINCT review_messages.user_id, COALESCE(users.username, '')
+										 FROM review_messages LEFT JOIN users ON users.id = review_messages.user_id
+										 WHERE review_messages.task_id = $1 ORDER BY review_messages.user_id`, t.id)
+		if err != nil {
+			return nil, err
+		}
+		err = scanReviewers(reviewerRows, t.requirement)
+		if err != nil {
+			return nil, err
+		}
+		evidenceRows, err := r.db.Query(`SELECT submissions.id, submissions.submit_time, COALESCE(submissions.message, ''),
+										 encode(sha256(image_submissions.image), 'hex')
+										 FROM submissions LEFT JOIN image_submissions ON image_submissions.submission_id = submissions.id
+										 WHERE submissions.task_id = $1 AND submissions.deleted_at IS NULL
+										 ORDER BY submissions.submit_time, submissions.id, image_submissions.id`, t.id)
+		if err != nil {
+			return nil, err
+		}
+		err = scanEvidence(evidenceRows, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+func scanCertificate(row rowScanner) (*entity.Certificate, error) {
+	var c entity.Certificate
+	var content string
+	err := row.Scan(&c.ID, &c.IssuedAt, &content, &c.Digest, &c.Token, &c.DocumentChecksum)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(content), &c.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+//certifiedTask is a finished task whose reviewers and evidence are added
+//to its requirement
+type certifiedTask struct {
+	id          string
+	requirement *entity.CertifiedRequirement
+}
+
+//scanCertifiedTasks adds the requirements to content and returns their
+//finished tasks. The assigner of a task is its first reviewer and the
+//latest review of a requirement's tasks is when it was approved.
+func scanCertifiedTasks(rows *sql.Rows, content *entity.CertificateContent) ([]certifiedTask, error) {
+	defer rows.Close()
+	var ids []string
+	var requirementOf []int
+	for rows.Next() {
+		var req entity.CertifiedRequirement
+		var taskID, assignerID, assigner sql.NullString
+		var reviewedAt sql.NullTime
+		err := rows.Scan(&req.ID, &req.Request, &req.ExpectedOutcome, &taskID, &reviewedAt, &assignerID, &assigner)
+		if err != nil {
+			return nil, err
+		}
+		last := len(content.Requirements) - 1
+		if last < 0 || content.Requirements[last].ID != req.ID {
+			content.Requirements = append(content.Requirements, req)
+			last++
+		}
+		if !taskID.Valid {
+			continue
+		}
+		current := &content.Requirements[last]
+		if reviewedAt.Time.After(current.ApprovedAt) {
+			current.ApprovedAt = reviewedAt.Time
+		}
+		if assignerID.Valid {
+			addReviewer(current, entity.Reviewer{UserID: assignerID.String, Username: assigner.String})
+		}
+		ids = append(ids, taskID.String)
+		requirementOf = append(requirementOf, last)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	//the requirements are only addressed once content stops growing
+	tasks := make([]certifiedTask, len(ids))
+	for i, id := range ids {
+		tasks[i] = certifiedTask{id: id, requirement: &content.Requirements[requirementOf[i]]}
+	}
+	return tasks, nil
+}
+
+func scanReviewers(rows *sql.Rows, req *entity.CertifiedRequirement) error {
+	defer rows.Close()
+	for rows.Next() {
+		var reviewer entity.Reviewer
+		err := rows.Scan(&reviewer.UserID, &reviewer.Username)
+		if err != nil {
+			return err
+		}
+		addReviewer(req, reviewer)
+	}
+	return rows.Err()
+}
+
+func addReviewer(req *entity.CertifiedRequirement, reviewer entity.Reviewer) {
+	for _, r := range req.Reviewers {
+		if r.UserID == reviewer.UserID {
+			return
+		}
+	}
+	req.Reviewers = append(req.Reviewers, reviewer)
+}
+
+//scanEvidence reads a row per image of each submission, or a single row
+//for a submission without images, and hashes each submission
+func scanEvidence(rows *sql.Rows, t certifiedTask) error {
+	defer rows.Close()
+	var current *entity.Evidence
+	var message string
+	var images []string
+	flush := func() {
+		if current != nil {
+			current.SHA256 = entity.EvidenceHash(message, images)
+			t.requirement.Evidence = append(t.requirement.Evidence, *current)
+		}
+	}
+	for rows.Next() {
+		var e entity.Evidence
+		var text string
+		var image sql.NullString
+		err := rows.Scan(&e.SubmissionID, &e.SubmittedAt, &text, &image)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.SubmissionID != e.SubmissionID {
+			flush()
+			e.TaskID = t.id
+			current, message, images = &e, text, nil
+		}
+		if image.Valid {
+			images = append(images, image.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
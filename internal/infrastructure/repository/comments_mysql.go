@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+type CommentMySQL struct {
+	db *sql.DB
+}
+
+func NewCommentMySQL(db *sql.DB) *CommentMySQL {
+	return &CommentMySQL{
+		db: db,
+	}
+}
+
+func (r *CommentMySQL) Create(c *entity.Comment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO comments (id, resource_type, resource_id, thread_id, author_id, body, created_at)
+					  values(?,?,?,?,?,?,?)`,
+		c.ID, c.Resource.Type, c.Resource.ID, nullString(c.ThreadID), c.AuthorID, c.Body, c.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertMentions(tx, c)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentMySQL) Update(c *entity.Comment, edit *entity.CommentEdit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = insertCommentEdit(tx, edit)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`UPDATE comments SET body = ?, edited_at = ? WHERE id = ?`,
+	}, c.Body, edit.EditedAt, c.ID)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{`DELETE FROM comment_mentions WHERE comment_id = ?`}, c.ID)
+	if err != nil {
+		return err
+	}
+	err = insertMentions(tx, c)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentMySQL) Delete(c *entity.Comment, edit *entity.CommentEdit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = insertCommentEdit(tx, edit)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`UPDATE comments SET body = '', deleted_at = ?, deleted_by = ? WHERE id = ?`,
+	}, c.DeletedAt, edit.EditedBy, c.ID)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{`DELETE FROM comment_mentions WHERE comment_id = ?`}, c.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentMySQL) Get(id string) (*entity.Comment, error) {
+	row := r.db.QueryRow(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+						  comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+						  comments.edited_at, comments.deleted_at
+						  FROM comments LEFT JOIN users ON users.id = comments.author_id WHERE comments.id = ?`, id)
+	c, err := scanComment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = r.addMentions([]*entity.Comment{c})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CommentMySQL) ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.Comment, int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM comments WHERE resource_type = ? AND resource_id = ? AND thread_id IS NULL`,
+		resource.Type, resource.ID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+							 comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+							 comments.edited_at, comments.deleted_at
+							 FROM comments LEFT JOIN users ON users.id = comments.author_id
+							 WHERE comments.resource_type = ? AND comments.resource_id = ? AND comments.thread_id IS NULL
+							 ORDER BY comments.created_at DESC, comments.id LIMIT ? OFFSET ?`,
+		resource.Type, resource.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := scanComments(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = r.addMentions(list)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *CommentMySQL) ListReplies(threadIDs []string) ([]*entity.Comment, error) {
+	if len(threadIDs) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{}
+	for _, id := range threadIDs {
+		args = append(args, id)
+	}
+	in := "(?" + strings.Repeat(",?", len(threadIDs)-1) + ")"
+	rows, err := r.db.Query(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+							 comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+							 comments.edited_at, comments.deleted_at
+							 FROM comments LEFT JOIN users ON users.id = comments.author_id
+							 WHERE comments.thread_id IN `+in+` ORDER BY comments.created_at, comments.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanComments(rows)
+	if err != nil {
+		return nil, err
+	}
+	err = r.addMentions(list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CommentMySQL) History(commentID string) ([]*entity.CommentEdit, error) {
+	rows, err := r.db.Query(`SELECT comment_id, body, edited_by, edited_at FROM comment_edits
+							 WHERE comment_id = ? ORDER BY edited_at, id`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var edits []*entity.CommentEdit
+	for rows.Next() {
+		var e entity.CommentEdit
+		err = rows.Scan(&e.CommentID, &e.Body, &e.EditedBy, &e.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, &e)
+	}
+	return edits, rows.Err()
+}
+
+func (r *CommentMySQL) addMentions(list []*entity.Comment) error {
+	if len(list) == 0 {
+		return nil
+	}
+	byID := map[string]*entity.Comment{}
+	args := []interface{}{}
+	for _, c := range list {
+		byID[c.ID] = c
+		args = append(args, c.ID)
+	}
+	in := "(?" + strings.Repeat(",?", len(list)-1) + ")"
+	rows, err := r.db.Query(`SELECT comment_mentions.comment_id, users.id, users.username
+							 FROM comment_mentions INNER JOIN users ON users.id = comment_mentions.user_id
+							 WHERE comment_mentions.comment_id IN `+in+` ORDER BY users.username`, args...)
+	if err != nil {
+		return err
+	}
+	return scanMentions(rows, byID)
+}
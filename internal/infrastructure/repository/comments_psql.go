@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+type CommentPSQL struct {
+	db *sql.DB
+}
+
+func NewCommentPSQL(db *sql.DB) *CommentPSQL {
+	return &CommentPSQL{
+		db: db,
+	}
+}
+
+func (r *CommentPSQL) Create(c *entity.Comment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO comments (id, resource_type, resource_id, thread_id, author_id, body, created_at)
+					  values($1,$2,$3,$4,$5,$6,$7)`,
+		c.ID, c.Resource.Type, c.Resource.ID, nullString(c.ThreadID), c.AuthorID, c.Body, c.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertMentions(tx, c)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentPSQL) Update(c *entity.Comment, edit *entity.CommentEdit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = insertCommentEdit(tx, edit)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`UPDATE comments SET body = $1, edited_at = $2 WHERE id = $3`,
+	}, c.Body, edit.EditedAt, c.ID)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{`DELETE FROM comment_mentions WHERE comment_id = $1`}, c.ID)
+	if err != nil {
+		return err
+	}
+	err = insertMentions(tx, c)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentPSQL) Delete(c *entity.Comment, edit *entity.CommentEdit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = insertCommentEdit(tx, edit)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`UPDATE comments SET body = '', deleted_at = $1, deleted_by = $2 WHERE id = $3`,
+	}, c.DeletedAt, edit.EditedBy, c.ID)
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{`DELETE FROM comment_mentions WHERE comment_id = $1`}, c.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CommentPSQL) Get(id string) (*entity.Comment, error) {
+	row := r.db.QueryRow(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+						  comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+						  comments.edited_at, comments.deleted_at
+						  FROM comments LEFT JOIN users ON users.id = comments.author_id WHERE comments.id = $1`, id)
+	c, err := scanComment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = r.addMentions([]*entity.Comment{c})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CommentPSQL) ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.Comment, int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM comments WHERE resource_type = $1 AND resource_id = $2 AND thread_id IS NULL`,
+		resource.Type, resource.ID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+							 comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+							 comments.edited_at, comments.deleted_at
+							 FROM comments LEFT JOIN users ON users.id = comments.author_id
+							 WHERE comments.resource_type = $1 AND comments.resource_id = $2 AND comments.thread_id IS NULL
+							 ORDER BY comments.created_at DESC, comments.id LIMIT $3 OFFSET $4`,
+		resource.Type, resource.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := scanComments(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = r.addMentions(list)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *CommentPSQL) ListReplies(threadIDs []string) ([]*entity.Comment, error) {
+	rows, err := r.db.Query(`SELECT comments.id, comments.resource_type, comments.resource_id, COALESCE(comments.thread_id, ''),
+							 comments.author_id, COALESCE(users.username, ''), comments.body, comments.created_at,
+							 comments.edited_at, comments.deleted_at
+							 FROM comments LEFT JOIN users ON users.id = comments.author_id
+							 WHERE comments.thread_id = ANY($1) ORDER BY comments.created_at, comments.id`, pq.Array(threadIDs))
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanComments(rows)
+	if err != nil {
+		return nil, err
+	}
+	err = r.addMentions(list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CommentPSQL) History(commentID string) ([]*entity.CommentEdit, error) {
+	rows, err := r.db.Query(`SELECT comment_id, body, edited_by, edited_at FROM comment_edits
+							 WHERE comment_id = $1 ORDER BY edited_at, id`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var edits []*entity.CommentEdit
+	for rows.Next() {
+		var e entity.CommentEdit
+		err = rows.Scan(&e.CommentID, &e.Body, &e.EditedBy, &e.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, &e)
+	}
+	return edits, rows.Err()
+}
+
+func (r *CommentPSQL) addMentions(list []*entity.Comment) error {
+	if len(list) == 0 {
+		return nil
+	}
+	byID := map[string]*entity.Comment{}
+	var ids []string
+	for _, c := range list {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+	rows, err := r.db.Query(`SELECT comment_mentions.comment_id, users.id, users.username
+							 FROM comment_mentions INNER JOIN users ON users.id = comment_mentions.user_id
+							 WHERE comment_mentions.comment_id = ANY($1) ORDER BY users.username`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	return scanMentions(rows, byID)
+}
+
+func insertMentions(tx *sql.Tx, c *entity.Comment) error {
+	for _, m := range c.Mentions {
+		_, err := tx.Exec(`INSERT INTO comment_mentions (comment_id, user_id) values($1,$2)`, c.ID, m.UserID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+func insertCommentEdit(tx *sql.Tx, edit *entity.CommentEdit) error {
+	_, err := tx.Exec(`INSERT INTO comment_edits (comment_id, body, edited_by, edited_at) values($1,$2,$3,$4)`,
+		edit.CommentID, edit.Body, edit.EditedBy, edit.EditedAt)
+	if err != nil {
+		tx.Rollback()
+	}
+	return err
+}
+
+func scanComment(row rowScanner) (*entity.Comment, error) {
+	var c entity.Comment
+	var editedAt, deletedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.Resource.Type, &c.Resource.ID, &c.ThreadID, &c.AuthorID, &c.AuthorName, &c.Body,
+		&c.CreatedAt, &editedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.EditedAt = editedAt.Time
+	c.DeletedAt = deletedAt.Time
+	return &c, nil
+}
+
+func scanComments(rows *sql.Rows) ([]*entity.Comment, error) {
+	defer rows.Close()
+	var list []*entity.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func scanMentions(rows *sql.Rows, comments map[string]*entity.Comment) error {
+	defer rows.Close()
+	for rows.Next() {
+		var commentID string
+		var m entity.Mention
+		err := rows.Scan(&commentID, &m.UserID, &m.Username)
+		if err != nil {
+			return err
+		}
+		if c, ok := comments[commentID]; ok {
+			c.Mentions = append(c.Mentions, m)
+		}
+	}
+	return rows.Err()
+}
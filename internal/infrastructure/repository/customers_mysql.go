@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type CustomerMySQL struct {
+	db *sql.DB
+}
+
+func NewCustomerMySQL(db *sql.DB) *CustomerMySQL {
+	return &CustomerMySQL{
+		db: db,
+	}
+}
+
+func (r *CustomerMySQL) Create(c *entity.Customer) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return c.ID, err
+	}
+	_, err = tx.Exec(`INSERT INTO customers (id, name, created_at) values(?,?,?)`, c.ID, c.Name, c.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return c.ID, err
+	}
+	err = r.insertContacts(tx, c)
+	if err != nil {
+		return c.ID, err
+	}
+	return c.ID, tx.Commit()
+}
+
+func (r *CustomerMySQL) Update(c *entity.Customer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE customers SET name = ? WHERE id = ?", c.Name, c.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM customer_contacts WHERE customer_id = ?", c.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = r.insertContacts(tx, c)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CustomerMySQL) insertContacts(tx *sql.Tx, c *entity.Customer) error {
+	for position, contact := range c.Contacts {
+		_, err := tx.Exec(`INSERT INTO customer_contacts (customer_id, position, name, email, phone) values(?,?,?,?,?)`,
+			c.ID, position, contact.Name, contact.Email, contact.Phone)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *CustomerMySQL) Get(id string) (*entity.Customer, error) {
+	var c entity.Customer
+	err := r.db.QueryRow(`SELECT id, name, created_at FROM customers WHERE id = ?`, id).Scan(&c.ID, &c.Name, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT name, email, phone FROM customer_contacts WHERE customer_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var contact entity.Contact
+		err = rows.Scan(&contact.Name, &contact.Email, &contact.Phone)
+		if err != nil {
+			return nil, err
+		}
+		c.Contacts = append(c.Contacts, contact)
+	}
+	return &c, rows.Err()
+}
+
+//List leaves out contacts, they are loaded with Get
+func (r *CustomerMySQL) List() ([]*entity.Customer, error) {
+	rows, err := r.db.Query(`SELECT id, name, created_at FROM customers ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var customers []*entity.Customer
+	for rows.Next() {
+		var c entity.Customer
+		err = rows.Scan(&c.ID, &c.Name, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, &c)
+	}
+	return customers, rows.Err()
+}
+
+func (r *CustomerMySQL) CreateShareLink(l *entity.ShareLink) (string, error) {
+	_, err := r.db.Exec(`INSERT INTO share_links (id, customer_id, order_id, created_by, created_at, expires_at, revoked) 
+						 values(?,?,?,?,?,?,?)`,
+		l.ID, l.CustomerID, l.OrderID, l.CreatedBy, l.CreatedAt, l.ExpiresAt, l.Revoked)
+	if err != nil {
+		return l.ID, err
+	}
+	return l.ID, nil
+}
+
+func (r *CustomerMySQL) GetShareLink(id string) (*entity.ShareLink, error) {
+	var l entity.ShareLink
+	err := r.db.QueryRow(`SELECT id, customer_id, order_id, created_by, created_at, expires_at, revoked 
+						  FROM share_links WHERE id = ?`, id).
+		Scan(&l.ID, &l.CustomerID, &l.OrderID, &l.CreatedBy, &l.CreatedAt, &l.ExpiresAt, &l.Revoked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *CustomerMySQL) ListShareLinks(orderID string) ([]*entity.ShareLink, error) {
+	rows, err := r.db.Query(`SELECT id, customer_id, order_id, created_by, created_at, expires_at, revoked 
+							 FROM share_links WHERE order_id = ? ORDER BY created_at DESC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var links []*entity.ShareLink
+	for rows.Next() {
+		var l entity.ShareLink
+		err = rows.Scan(&l.ID, &l.CustomerID, &l.OrderID, &l.CreatedBy, &l.CreatedAt, &l.ExpiresAt, &l.Revoked)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, &l)
+	}
+	return links, rows.Err()
+}
+
+func (r *CustomerMySQL) RevokeShareLink(id string) error {
+	_, err := r.db.Exec("UPDATE share_links SET revoked = true WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
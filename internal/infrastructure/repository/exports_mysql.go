@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type ExportMySQL struct {
+	db *sql.DB
+}
+
+func NewExportMySQL(db *sql.DB) *ExportMySQL {
+	return &ExportMySQL{
+		db: db,
+	}
+}
+
+//EachRow reads the rows, the submissions and the reviews of the order with
+//a query each
+func (r *ExportMySQL) EachRow(orderID string, fn func(row *entity.ExportRow) error) error {
+	return eachExportRow(r.db, exportQueries{
+		rows: `SELECT requirements.id, requirements.request, requirements.expected_outcome, requirements.status,
+			   tasks.id, tasks.user_id, users.username, tasks.note, tasks.fulfillment_status, tasks.deadline,
+			   tasks.created_at, tasks.reviewed_at
+			   FROM requirements LEFT JOIN tasks ON tasks.requirement_id = requirements.id AND tasks.deleted_at IS NULL
+			   LEFT JOIN users ON users.id = tasks.user_id
+			   WHERE requirements.order_id = ? AND requirements.deleted_at IS NULL
+			   ORDER BY requirements.id, tasks.created_at, tasks.id`,
+		submissions: `SELECT tasks.id, submissions.id, submissions.submit_time, COALESCE(submissions.message, ''), COUNT(image_submissions.id)
+					  FROM submissions JOIN tasks ON tasks.id = submissions.task_id AND tasks.deleted_at IS NULL
+					  JOIN requirements ON requirements.id = tasks.requirement_id AND requirements.deleted_at IS NULL
+					  LEFT JOIN image_submissions ON image_submissions.submission_id = submissions.id
+					  WHERE requirements.order_id = ? AND submissions.deleted_at IS NULL
+					  GROUP BY requirements.id, tasks.created_at, tasks.id, submissions.id, submissions.submit_time, submissions.message
+					  ORDER BY requirements.id, tasks.created_at, tasks.id, submissions.submit_time`,
+		reviews: `SELECT tasks.id, review_messages.user_id, COALESCE(users.username, ''), review_messages.message
+				  FROM review_messages JOIN tasks ON tasks.id = review_messages.task_id AND tasks.deleted_at IS NULL
+				  JOIN requirements ON requirements.id = tasks.requirement_id AND requirements.deleted_at IS NULL
+				  LEFT JOIN users ON users.id = review_messages.user_id
+				  WHERE requirements.order_id = ?
+				  ORDER BY requirements.id, tasks.created_at, tasks.id, review_messages.created_at`,
+	}, orderID, fn)
+}
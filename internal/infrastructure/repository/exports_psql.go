@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type ExportPSQL struct {
+	db *sql.DB
+}
+
+func NewExportPSQL(db *sql.DB) *ExportPSQL {
+	return &ExportPSQL{
+		db: db,
+	}
+}
+
+//EachRow reads the rows, the submissions and the reviews of the order with
+//a query each
+func (r *ExportPSQL) EachRow(orderID string, fn func(row *entity.ExportRow) error) error {
+	return eachExportRow(r.db, exportQueries{
+		rows: `SELECT requirements.id, requirements.request, requirements.expected_outcome, requirements.status,
+			   tasks.id, tasks.user_id, users.username, tasks.note, tasks.fulfillment_status, tasks.deadline,
+			   tasks.created_at, tasks.reviewed_at
+			   FROM requirements LEFT JOIN tasks ON tasks.requirement_id = requirements.id AND tasks.deleted_at IS NULL
+			   LEFT JOIN users ON users.id = tasks.user_id
+			   WHERE requirements.order_id = $1 AND requirements.deleted_at IS NULL
+			   ORDER BY requirements.id, tasks.created_at, tasks.id`,
+		submissions: `SELECT tasks.id, submissions.id, submissions.submit_time, COALESCE(submissions.message, ''), COUNT(image_submissions.id)
+					  FROM submissions JOIN tasks ON tasks.id = submissions.task_id AND tasks.deleted_at IS NULL
+					  JOIN requirements ON requirements.id = tasks.requirement_id AND requirements.deleted_at IS NULL
+					  LEFT JOIN image_submissions ON image_submissions.submission_id = submissions.id
+					  WHERE requirements.order_id = $1 AND submissions.deleted_at IS NULL
+					  GROUP BY requirements.id, tasks.created_at, tasks.id, submissions.id, submissions.submit_time, submissions.message
+					  ORDER BY requirements.id, tasks.created_at, tasks.id, submissions.submit_time`,
+		reviews: `SELECT tasks.id, review_messages.user_id, COALESCE(users.username, ''), review_messages.message
+				  FROM review_messages JOIN tasks ON tasks.id = review_messages.task_id AND tasks.deleted_at IS NULL
+				  JOIN requirements ON requirements.id = tasks.requirement_id AND requirements.deleted_at IS NULL
+				  LEFT JOIN users ON users.id = review_messages.user_id
+				  WHERE requirements.order_id = $1
+				  ORDER BY requirements.id, tasks.created_at, tasks.id, review_messages.created_at`,
+	}, orderID, fn)
+}
+
+//exportQueries list the rows of an export and the submissions and reviews
+//of its tasks. All three take the order id and list the tasks in the same
+//order, the submissions and reviews start with their task id.
+type exportQueries struct {
+	rows        string
+	submissions string
+	reviews     string
+}
+
+//eachExportRow merges the submissions and reviews into the rows of their
+//tasks as the three queries are read together, which takes three
+//connections from the pool
+func eachExportRow(db *sql.DB, q exportQueries, orderID string, fn func(row *entity.ExportRow) error) error {
+	rows, err := db.Query(q.rows, orderID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	submissionRows, err := db.Query(q.submissions, orderID)
+	if err != nil {
+		return err
+	}
+	defer submissionRows.Close()
+	reviewRows, err := db.Query(q.reviews, orderID)
+	if err != nil {
+		return err
+	}
+	defer reviewRows.Close()
+
+	var submission entity.SubmissionMetadata
+	submissions := &taskRows{rows: submissionRows, scan: func(taskID *string) error {
+		return submissionRows.Scan(taskID, &submission.ID, &submission.SubmittedAt, &submission.Message, &submission.Images)
+	}}
+	var review entity.Message
+	reviews := &taskRows{rows: reviewRows, scan: func(taskID *string) error {
+		return reviewRows.Scan(taskID, &review.UserID, &review.Username, &review.Message)
+	}}
+	for rows.Next() {
+		row, err := scanExportRow(rows)
+		if err != nil {
+			return err
+		}
+		if row.Task != nil {
+			err = submissions.each(row.Task.ID, func() {
+				row.Task.Submissions = append(row.Task.Submissions, submission)
+			})
+			if err != nil {
+				return err
+			}
+			err = reviews.each(row.Task.ID, func() {
+				row.Task.Reviews = append(row.Task.Reviews, review)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+//taskRows reads rows listed in the order of the tasks they belong to
+type taskRows struct {
+	rows *sql.Rows
+	scan func(taskID *string) error
+	//taskID is the task of the row scanned last, pending until it is added
+	taskID  string
+	pending bool
+}
+
+//each calls add for every row of the task. It stops at the first row of a
+//later task, which is kept for it.
+func (t *taskRows) each(taskID string, add func()) error {
+	for {
+		if !t.pending {
+			if !t.rows.Next() {
+				return t.rows.Err()
+			}
+			err := t.scan(&t.taskID)
+			if err != nil {
+				return err
+			}
+			t.pending = true
+		}
+		if t.taskID != taskID {
+			return nil
+		}
+		add()
+		t.pending = false
+	}
+}
+
+func scanExportRow(row rowScanner) (*entity.ExportRow, error) {
+	var req entity.Requirements
+	var taskID, userID, username, note sql.NullString
+	var status sql.NullInt64
+	var deadline, createdAt, reviewedAt sql.NullTime
+	err := row.Scan(&req.Id, &req.Request, &req.ExpectedOutcome, &req.Status,
+		&taskID, &userID, &username, &note, &status, &deadline, &createdAt, &reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	result := &entity.ExportRow{Requirement: &req}
+	if taskID.Valid {
+		result.Task = &entity.ExportTask{
+			ID:         taskID.String,
+			UserID:     userID.String,
+			Username:   username.String,
+			Note:       note.String,
+			Status:     entity.Status(status.Int64),
+			Deadline:   deadline.Time,
+			CreatedAt:  createdAt.Time,
+			ReviewedAt: reviewedAt.Time,
+		}
+	}
+	return result, nil
+}
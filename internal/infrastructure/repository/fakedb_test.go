@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+)
+
+//fakeDB is a database/sql driver that records what the repositories run on
+//it, so the statements can be checked without a server. Queries answer with
+//the rows answer returns for them, or with no rows.
+type fakeDB struct {
+	mu     sync.Mutex
+	log    []string
+	args   [][]driver.Value
+	answer func(query string) ([]string, [][]driver.Value)
+}
+
+func newFakeDB(answer func(query string) ([]string, [][]driver.Value)) (*sql.DB, *fakeDB) {
+	f := &fakeDB{answer: answer}
+	return sql.OpenDB(f), f
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return fakeConn{f}, nil }
+
+//record keeps the statement with its whitespace collapsed
+func (f *fakeDB) record(query string, args []driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, strings.Join(strings.Fields(query), " "))
+	f.args = append(f.args, args)
+}
+
+//statements returns the logged statements starting with part and their args
+func (f *fakeDB) statements(part string) ([]string, [][]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var queries []string
+	var args [][]driver.Value
+	for i, q := range f.log {
+		if strings.HasPrefix(q, part) {
+			queries = append(queries, q)
+			args = append(args, f.args[i])
+		}
+	}
+	return queries, args
+}
+
+//index returns the position of the first logged statement containing part
+func (f *fakeDB) index(part string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, q := range f.log {
+		if strings.Contains(q, part) {
+			return i
+		}
+	}
+	return -1
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return fakeTx(c), nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (t fakeTx) Commit() error {
+	t.db.record("COMMIT", nil)
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query, args)
+	rows := &fakeRows{}
+	if s.db.answer != nil {
+		rows.columns, rows.values = s.db.answer(s.query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+//rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//execAll runs every query with the same arguments inside tx and rolls it
+//back on the first error
+func execAll(tx *sql.Tx, queries []string, args ...interface{}) error {
+	for _, query := range queries {
+		_, err := tx.Exec(query, args...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+//nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+//nullTime stores a zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
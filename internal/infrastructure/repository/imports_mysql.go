@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+//importBatchSize is how many rows each INSERT of an import writes
+const importBatchSize = 500
+
+type ImportMySQL struct {
+	db *sql.DB
+}
+
+func NewImportMySQL(db *sql.DB) *ImportMySQL {
+	return &ImportMySQL{
+		db: db,
+	}
+}
+
+//Load inserts the orders, then the requirements, then their revisions and
+//labels, in batches of importBatchSize rows
+func (r *ImportMySQL) Load(orders []*entity.ImportedOrder) error {
+	var orderRows, requirementRows [][]interface{}
+	ids := make([]interface{}, len(orders))
+	for i, imported := range orders {
+		o := imported.Order
+		ids[i] = o.ID
+		orderRows = append(orderRows, []interface{}{o.ID, o.Title, o.Description, o.Deadline, o.Status, o.Priority,
+			nullString(o.SLAPolicy), o.CreatedAt, nullString(o.CustomerID)})
+		for _, req := range imported.Requirements {
+			requirementRows = append(requirementRows, []interface{}{req.Request, req.ExpectedOutcome, req.OrderID, req.Status})
+		}
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = insertBatches(tx, `INSERT INTO orders (id, title, description, deadline, status, priority, sla_policy, created_at, customer_id) VALUES `, orderRows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertBatches(tx, `INSERT INTO requirements (request, expected_outcome, order_id, status) VALUES `, requirementRows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(ids) > 0 {
+		err = assignRequirementIDs(tx, orders, `SELECT id, order_id FROM requirements WHERE order_id IN (?`+strings.Repeat(",?", len(ids)-1)+`) ORDER BY id`, ids...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	revisions, tags, fields, err := importedRecords(orders)
+	if err == nil {
+		err = insertBatches(tx, `INSERT INTO revisions (id, resource_type, resource_id, number, author_id, created_at, snapshot, changes, restored_of) VALUES `, revisions)
+	}
+	if err == nil {
+		err = insertBatches(tx, `INSERT INTO resource_tags (resource_type, resource_id, tag) VALUES `, tags)
+	}
+	if err == nil {
+		err = insertBatches(tx, `INSERT INTO resource_fields (resource_type, resource_id, field, value) VALUES `, fields)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertBatches(tx *sql.Tx, insert string, rows [][]interface{}) error {
+	for start := 0; start < len(rows); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		placeholders := "(?" + strings.Repeat(",?", len(rows[start])-1) + ")"
+		var values []string
+		var args []interface{}
+		for _, row := range rows[start:end] {
+			values = append(values, placeholders)
+			args = append(args, row...)
+		}
+		_, err := tx.Exec(insert+strings.Join(values, ","), args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"order-validation-v2/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+type ImportPSQL struct {
+	db *sql.DB
+}
+
+func NewImportPSQL(db *sql.DB) *ImportPSQL {
+	return &ImportPSQL{
+		db: db,
+	}
+}
+
+//Load streams the orders, then the requirements, then their revisions and
+//labels, with COPY
+func (r *ImportPSQL) Load(orders []*entity.ImportedOrder) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = copyRows(tx, pq.CopyIn("orders", "id", "title", "description", "deadline", "status", "priority", "sla_policy", "created_at", "customer_id"),
+		func(stmt *sql.Stmt) error {
+			for _, imported := range orders {
+				o := imported.Order
+				_, err := stmt.Exec(o.ID, o.Title, o.Description, o.Deadline, o.Status, o.Priority,
+					nullString(o.SLAPolicy), o.CreatedAt, nullString(o.CustomerID))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = copyRows(tx, pq.CopyIn("requirements", "request", "expected_outcome", "order_id", "status"),
+		func(stmt *sql.Stmt) error {
+			for _, imported := range orders {
+				for _, req := range imported.Requirements {
+					_, err := stmt.Exec(req.Request, req.ExpectedOutcome, req.OrderID, req.Status)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	ids := make([]string, len(orders))
+	for i, imported := range orders {
+		ids[i] = imported.Order.ID
+	}
+	err = assignRequirementIDs(tx, orders, `SELECT id, order_id FROM requirements WHERE order_id = ANY($1) ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	revisions, tags, fields, err := importedRecords(orders)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = copyRows(tx, pq.CopyIn("revisions", "id", "resource_type", "resource_id", "number", "author_id", "created_at", "snapshot", "changes", "restored_of"), execRows(revisions))
+	if err == nil {
+		err = copyRows(tx, pq.CopyIn("resource_tags", "resource_type", "resource_id", "tag"), execRows(tags))
+	}
+	if err == nil {
+		err = copyRows(tx, pq.CopyIn("resource_fields", "resource_type", "resource_id", "field", "value"), execRows(fields))
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//importedRecords lists the rows of the revisions, tags and fields of the
+//imported orders and requirements, once the requirements have their IDs
+func importedRecords(orders []*entity.ImportedOrder) (revisions [][]interface{}, tags [][]interface{}, fields [][]interface{}, err error) {
+	add := func(resource entity.Resource, rev *entity.Revision, l *entity.Labels) error {
+		if rev != nil {
+			snapshot, err := json.Marshal(rev.Snapshot)
+			if err != nil {
+				return err
+			}
+			changes, err := json.Marshal(rev.Changes)
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, []interface{}{rev.ID, string(resource.Type), resource.ID, rev.Number, rev.AuthorID, rev.CreatedAt, string(snapshot), string(changes), rev.RestoredOf})
+		}
+		if l == nil {
+			return nil
+		}
+		for _, tag := range l.Tags {
+			tags = append(tags, []interface{}{string(resource.Type), resource.ID, tag})
+		}
+		for field, value := range l.Fields {
+			fields = append(fields, []interface{}{string(resource.Type), resource.ID, field, value})
+		}
+		return nil
+	}
+	for _, imported := range orders {
+		err = add(entity.Resource{Type: entity.ResourceOrder, ID: imported.Order.ID}, imported.Revision, imported.Labels)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for i, req := range imported.Requirements {
+			var rev *entity.Revision
+			if i < len(imported.RequirementRevisions) {
+				rev = imported.RequirementRevisions[i]
+			}
+			var l *entity.Labels
+			if i < len(imported.RequirementLabels) {
+				l = imported.RequirementLabels[i]
+			}
+			err = add(entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(req.Id)}, rev, l)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	return revisions, tags, fields, nil
+}
+
+//assignRequirementIDs reads back the IDs of the imported requirements.
+//They were inserted in order, so query must list them by ascending id.
+func assignRequirementIDs(tx *sql.Tx, orders []*entity.ImportedOrder, query string, args ...interface{}) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	ids := map[string][]int{}
+	for rows.Next() {
+		var id int
+		var orderID string
+		err = rows.Scan(&id, &orderID)
+		if err != nil {
+			return err
+		}
+		ids[orderID] = append(ids[orderID], id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	for _, imported := range orders {
+		created := ids[imported.Order.ID]
+		if len(created) != len(imported.Requirements) {
+			return fmt.Errorf("order %s has %d requirements, %d were imported", imported.Order.ID, len(created), len(imported.Requirements))
+		}
+		for i, req := range imported.Requirements {
+			req.Id = created[i]
+			if i < len(imported.RequirementRevisions) {
+				imported.RequirementRevisions[i].Resource.ID = strconv.Itoa(req.Id)
+			}
+		}
+	}
+	return nil
+}
+
+//execRows adds rows to a COPY statement
+func execRows(rows [][]interface{}) func(stmt *sql.Stmt) error {
+	return func(stmt *sql.Stmt) error {
+		for _, row := range rows {
+			_, err := stmt.Exec(row...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+//copyRows runs a COPY statement, exec adds the rows to it
+func copyRows(tx *sql.Tx, query string, exec func(stmt *sql.Stmt) error) error {
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	err = exec(stmt)
+	if err != nil {
+		stmt.Close()
+		return err
+	}
+	_, err = stmt.Exec()
+	if err != nil {
+		stmt.Close()
+		return err
+	}
+	return stmt.Close()
+}
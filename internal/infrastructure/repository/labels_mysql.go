@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+type LabelsMySQL struct {
+	db *sql.DB
+}
+
+func NewLabelsMySQL(db *sql.DB) *LabelsMySQL {
+	return &LabelsMySQL{
+		db: db,
+	}
+}
+
+func (r *LabelsMySQL) CreateField(f *entity.CustomField) error {
+	resources := make([]string, len(f.Resources))
+	for i, t := range f.Resources {
+		resources[i] = string(t)
+	}
+	_, err := r.db.Exec(`INSERT INTO custom_fields (name, type, options, resources) values(?,?,?,?)`,
+		f.Name, f.Type, strings.Join(f.Options, ","), strings.Join(resources, ","))
+	return err
+}
+
+func (r *LabelsMySQL) DeleteField(name string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`DELETE FROM resource_fields WHERE field = ?`,
+		`DELETE FROM custom_fields WHERE name = ?`,
+	}, name)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *LabelsMySQL) GetField(name string) (*entity.CustomField, error) {
+	row := r.db.QueryRow(`SELECT name, type, options, resources FROM custom_fields WHERE name = ?`, name)
+	f, err := scanCustomField(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *LabelsMySQL) ListFields() ([]*entity.CustomField, error) {
+	rows, err := r.db.Query(`SELECT name, type, options, resources FROM custom_fields ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fields []*entity.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, rows.Err()
+}
+
+func (r *LabelsMySQL) Set(resource entity.Resource, labels *entity.Labels) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`DELETE FROM resource_tags WHERE resource_type = ? AND resource_id = ?`,
+		`DELETE FROM resource_fields WHERE resource_type = ? AND resource_id = ?`,
+	}, resource.Type, resource.ID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range labels.Tags {
+		_, err = tx.Exec(`INSERT INTO resource_tags (resource_type, resource_id, tag) values(?,?,?)`,
+			resource.Type, resource.ID, tag)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for field, value := range labels.Fields {
+		_, err = tx.Exec(`INSERT INTO resource_fields (resource_type, resource_id, field, value) values(?,?,?,?)`,
+			resource.Type, resource.ID, field, value)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *LabelsMySQL) Get(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error) {
+	labels := map[string]*entity.Labels{}
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	args := []interface{}{t}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	in := "(?" + strings.Repeat(",?", len(ids)-1) + ")"
+	rows, err := r.db.Query(`SELECT resource_id, tag FROM resource_tags WHERE resource_type = ? AND resource_id IN `+in+`
+							 ORDER BY tag`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	err = scanLabels(rows, labels, func(l *entity.Labels, tag string, _ string) {
+		l.Tags = append(l.Tags, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	fieldRows, err := r.db.Query(`SELECT resource_id, field, value FROM resource_fields WHERE resource_type = ? AND resource_id IN `+in,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer fieldRows.Close()
+	err = scanLabels(fieldRows, labels, func(l *entity.Labels, field string, value string) {
+		l.Fields[field] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+//Find intersects the resources carrying each tag and each field value
+func (r *LabelsMySQL) Find(t entity.ResourceType, filter entity.LabelFilter) ([]string, error) {
+	var queries []string
+	var args [][]interface{}
+	for _, tag := range filter.Tags {
+		queries = append(queries, `SELECT resource_id FROM resource_tags WHERE resource_type = ? AND tag = ?`)
+		args = append(args, []interface{}{t, tag})
+	}
+	for field, value := range filter.Fields {
+		queries = append(queries, `SELECT resource_id FROM resource_fields WHERE resource_type = ? AND field = ? AND value = ?`)
+		args = append(args, []interface{}{t, field, value})
+	}
+	return findIntersection(r.db, queries, args)
+}
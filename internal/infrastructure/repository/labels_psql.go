@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+type LabelsPSQL struct {
+	db *sql.DB
+}
+
+func NewLabelsPSQL(db *sql.DB) *LabelsPSQL {
+	return &LabelsPSQL{
+		db: db,
+	}
+}
+
+func (r *LabelsPSQL) CreateField(f *entity.CustomField) error {
+	resources := make([]string, len(f.Resources))
+	for i, t := range f.Resources {
+		resources[i] = string(t)
+	}
+	_, err := r.db.Exec(`INSERT INTO custom_fields (name, type, options, resources) values($1,$2,$3,$4)`,
+		f.Name, f.Type, strings.Join(f.Options, ","), strings.Join(resources, ","))
+	return err
+}
+
+func (r *LabelsPSQL) DeleteField(name string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`DELETE FROM resource_fields WHERE field = $1`,
+		`DELETE FROM custom_fields WHERE name = $1`,
+	}, name)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *LabelsPSQL) GetField(name string) (*entity.CustomField, error) {
+	row := r.db.QueryRow(`SELECT name, type, options, resources FROM custom_fields WHERE name = $1`, name)
+	f, err := scanCustomField(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *LabelsPSQL) ListFields() ([]*entity.CustomField, error) {
+	rows, err := r.db.Query(`SELECT name, type, options, resources FROM custom_fields ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fields []*entity.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, rows.Err()
+}
+
+func (r *LabelsPSQL) Set(resource entity.Resource, labels *entity.Labels) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = execAll(tx, []string{
+		`DELETE FROM resource_tags WHERE resource_type = $1 AND resource_id = $2`,
+		`DELETE FROM resource_fields WHERE resource_type = $1 AND resource_id = $2`,
+	}, resource.Type, resource.ID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range labels.Tags {
+		_, err = tx.Exec(`INSERT INTO resource_tags (resource_type, resource_id, tag) values($1,$2,$3)`,
+			resource.Type, resource.ID, tag)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for field, value := range labels.Fields {
+		_, err = tx.Exec(`INSERT INTO resource_fields (resource_type, resource_id, field, value) values($1,$2,$3,$4)`,
+			resource.Type, resource.ID, field, value)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *LabelsPSQL) Get(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error) {
+	labels := map[string]*entity.Labels{}
+	rows, err := r.db.Query(`SELECT resource_id, tag FROM resource_tags WHERE resource_type = $1 AND resource_id = ANY($2)
+							 ORDER BY tag`, t, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	err = scanLabels(rows, labels, func(l *entity.Labels, tag string, _ string) {
+		l.Tags = append(l.Tags, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	fieldRows, err := r.db.Query(`SELECT resource_id, field, value FROM resource_fields WHERE resource_type = $1 AND resource_id = ANY($2)`,
+		t, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer fieldRows.Close()
+	err = scanLabels(fieldRows, labels, func(l *entity.Labels, field string, value string) {
+		l.Fields[field] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+//Find intersects the resources carrying each tag and each field value
+func (r *LabelsPSQL) Find(t entity.ResourceType, filter entity.LabelFilter) ([]string, error) {
+	var queries []string
+	var args [][]interface{}
+	for _, tag := range filter.Tags {
+		queries = append(queries, `SELECT resource_id FROM resource_tags WHERE resource_type = $1 AND tag = $2`)
+		args = append(args, []interface{}{t, tag})
+	}
+	for field, value := range filter.Fields {
+		queries = append(queries, `SELECT resource_id FROM resource_fields WHERE resource_type = $1 AND field = $2 AND value = $3`)
+		args = append(args, []interface{}{t, field, value})
+	}
+	return findIntersection(r.db, queries, args)
+}
+
+func findIntersection(db *sql.DB, queries []string, args [][]interface{}) ([]string, error) {
+	var matches map[string]bool
+	for i, query := range queries {
+		found := map[string]bool{}
+		rows, err := db.Query(query, args[i]...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			err = rows.Scan(&id)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if matches == nil || matches[id] {
+				found[id] = true
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		matches = found
+	}
+	var ids []string
+	for id := range matches {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+//scanLabels reads rows of resource id, key and an optional value into the
+//labels of each resource
+func scanLabels(rows *sql.Rows, labels map[string]*entity.Labels, add func(l *entity.Labels, key string, value string)) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, key, value string
+		dest := []interface{}{&id, &key, &value}
+		err = rows.Scan(dest[:len(cols)]...)
+		if err != nil {
+			return err
+		}
+		l, ok := labels[id]
+		if !ok {
+			l = &entity.Labels{Fields: map[string]string{}}
+			labels[id] = l
+		}
+		add(l, key, value)
+	}
+	return rows.Err()
+}
+
+func scanCustomField(row rowScanner) (*entity.CustomField, error) {
+	var f entity.CustomField
+	var options, resources string
+	err := row.Scan(&f.Name, &f.Type, &options, &resources)
+	if err != nil {
+		return nil, err
+	}
+	if options != "" {
+		f.Options = strings.Split(options, ",")
+	}
+	if resources != "" {
+		for _, t := range strings.Split(resources, ",") {
+			f.Resources = append(f.Resources, entity.ResourceType(t))
+		}
+	}
+	return &f, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"order-validation-v2/internal/entity"
+	"strings"
+	"time"
+)
+
+type LoginGuardMySQL struct {
+	db *sql.DB
+}
+
+func NewLoginGuardMySQL(db *sql.DB) *LoginGuardMySQL {
+	return &LoginGuardMySQL{
+		db: db,
+	}
+}
+
+func (r *LoginGuardMySQL) GetCounter(key string) (*entity.LoginCounter, error) {
+	stmt, err := r.db.Prepare(`SELECT counter_key, failures, last_failure, locked FROM login_counters WHERE counter_key = ?`)
+	if err != nil {
+		return nil, err
+	}
+	var c entity.LoginCounter
+	row := stmt.QueryRow(key)
+	err = row.Scan(&c.Key, &c.Failures, &c.LastFailure, &c.Locked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *LoginGuardMySQL) IncrementFailures(key string, at time.Time, since time.Time) (*entity.LoginCounter, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO login_counters (counter_key, failures, last_failure, locked) VALUES (?, 1, ?, false)
+		ON DUPLICATE KEY UPDATE 
+			failures = CASE WHEN last_failure < ? THEN 1 ELSE failures + 1 END,
+			last_failure = VALUES(last_failure)`, key, at, since)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetCounter(key)
+}
+
+func (r *LoginGuardMySQL) Lock(key string) error {
+	_, err := r.db.Exec("UPDATE login_counters SET locked = true WHERE counter_key = ?", key)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *LoginGuardMySQL) DeleteCounter(key string) error {
+	_, err := r.db.Exec("DELETE FROM login_counters WHERE counter_key = ?", key)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *LoginGuardMySQL) CreateAttempt(a *entity.LoginAttempt) (int, error) {
+	var userID interface{}
+	if a.UserID != "" {
+		userID = a.UserID
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO login_attempts (username, user_id, ip, user_agent, outcome, suspicious, attempted_at) 
+		values(?,?,?,?,?,?,?)`,
+		a.Username, userID, a.IP, a.UserAgent, a.Outcome, a.Suspicious, a.AttemptedAt)
+	if err != nil {
+		return -1, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+	a.ID = int(id)
+	return a.ID, nil
+}
+
+func (r *LoginGuardMySQL) ListAttempts(filter entity.LoginAttemptFilter) ([]*entity.LoginAttempt, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Username != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, filter.Username)
+	}
+	if filter.Outcome != "" {
+		conditions = append(conditions, "outcome = ?")
+		args = append(args, filter.Outcome)
+	}
+	if filter.Suspicious {
+		conditions = append(conditions, "suspicious = true")
+	}
+	query := `SELECT id, username, COALESCE(user_id, ''), ip, user_agent, outcome, suspicious, attempted_at FROM login_attempts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY attempted_at DESC LIMIT ?"
+	args = append(args, filter.Limit)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var attempts []*entity.LoginAttempt
+	for rows.Next() {
+		var a entity.LoginAttempt
+		err = rows.Scan(&a.ID, &a.Username, &a.UserID, &a.IP, &a.UserAgent, &a.Outcome, &a.Suspicious, &a.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+	return attempts, nil
+}
+
+func (r *LoginGuardMySQL) HasSucceededFrom(username string, ip string) (bool, error) {
+	var exists bool
+	row := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM login_attempts WHERE username = ? AND ip = ? AND outcome = ?)`,
+		username, ip, entity.LoginSucceeded)
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"order-validation-v2/internal/entity"
+	"strings"
+	"time"
+)
+
+type LoginGuardPSQL struct {
+	db *sql.DB
+}
+
+func NewLoginGuardPSQL(db *sql.DB) *LoginGuardPSQL {
+	return &LoginGuardPSQL{
+		db: db,
+	}
+}
+
+func (r *LoginGuardPSQL) GetCounter(key string) (*entity.LoginCounter, error) {
+	stmt, err := r.db.Prepare(`SELECT counter_key, failures, last_failure, locked FROM login_counters WHERE counter_key = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var c entity.LoginCounter
+	row := stmt.QueryRow(key)
+	err = row.Scan(&c.Key, &c.Failures, &c.LastFailure, &c.Locked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *LoginGuardPSQL) IncrementFailures(key string, at time.Time, since time.Time) (*entity.LoginCounter, error) {
+	stmt, err := r.db.Prepare(`
+		INSERT INTO login_counters (counter_key, failures, last_failure, locked) VALUES ($1, 1, $2, false)
+		ON CONFLICT (counter_key) DO UPDATE SET 
+			failures = CASE WHEN login_counters.last_failure < $3 THEN 1 ELSE login_counters.failures + 1 END,
+			last_failure = $2
+		RETURNING counter_key, failures, last_failure, locked`)
+	if err != nil {
+		return nil, err
+	}
+	var c entity.LoginCounter
+	row := stmt.QueryRow(key, at, since)
+	err = row.Scan(&c.Key, &c.Failures, &c.LastFailure, &c.Locked)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *LoginGuardPSQL) Lock(key string) error {
+	_, err := r.db.Exec("UPDATE login_counters SET locked = true WHERE counter_key = $1", key)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *LoginGuardPSQL) DeleteCounter(key string) error {
+	_, err := r.db.Exec("DELETE FROM login_counters WHERE counter_key = $1", key)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *LoginGuardPSQL) CreateAttempt(a *entity.LoginAttempt) (int, error) {
+	var userID interface{}
+	if a.UserID != "" {
+		userID = a.UserID
+	}
+	row := r.db.QueryRow(`
+		INSERT INTO login_attempts (username, user_id, ip, user_agent, outcome, suspicious, attempted_at) 
+		values($1,$2,$3,$4,$5,$6,$7) RETURNING id`,
+		a.Username, userID, a.IP, a.UserAgent, a.Outcome, a.Suspicious, a.AttemptedAt)
+	err := row.Scan(&a.ID)
+	if err != nil {
+		return -1, err
+	}
+	return a.ID, nil
+}
+
+func (r *LoginGuardPSQL) ListAttempts(filter entity.LoginAttemptFilter) ([]*entity.LoginAttempt, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Username != "" {
+		args = append(args, filter.Username)
+		conditions = append(conditions, fmt.Sprintf("username = $%d", len(args)))
+	}
+	if filter.Outcome != "" {
+		args = append(args, filter.Outcome)
+		conditions = append(conditions, fmt.Sprintf("outcome = $%d", len(args)))
+	}
+	if filter.Suspicious {
+		conditions = append(conditions, "suspicious = true")
+	}
+	query := `SELECT id, username, COALESCE(user_id, ''), ip, user_agent, outcome, suspicious, attempted_at FROM login_attempts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY attempted_at DESC LIMIT $%d", len(args))
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var attempts []*entity.LoginAttempt
+	for rows.Next() {
+		var a entity.LoginAttempt
+		err = rows.Scan(&a.ID, &a.Username, &a.UserID, &a.IP, &a.UserAgent, &a.Outcome, &a.Suspicious, &a.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+	return attempts, nil
+}
+
+func (r *LoginGuardPSQL) HasSucceededFrom(username string, ip string) (bool, error) {
+	var exists bool
+	row := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM login_attempts WHERE username = $1 AND ip = $2 AND outcome = $3)`,
+		username, ip, entity.LoginSucceeded)
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
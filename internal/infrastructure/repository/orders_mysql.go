@@ -2,6 +2,8 @@ package repository
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"order-validation-v2/internal/entity"
 )
@@ -18,8 +20,8 @@ func NewOrdersMySQL(db *sql.DB) *OrdersMySQL {
 
 func (r *OrdersMySQL) Create(e *entity.Orders) (string, error) {
 	stmt, err := r.db.Prepare(`
-		INSERT INTO orders (id, title, description, deadline) 
-		values(?,?,?,?)`)
+		INSERT INTO orders (id, title, description, deadline, status, priority, sla_policy, created_at, customer_id) 
+		values(?,?,?,?,?,?,?,?,?)`)
 	if err != nil {
 		return e.ID, err
 	}
@@ -28,6 +30,11 @@ func (r *OrdersMySQL) Create(e *entity.Orders) (string, error) {
 		e.Title,
 		e.Description,
 		e.Deadline,
+		e.Status,
+		e.Priority,
+		nullString(e.SLAPolicy),
+		e.CreatedAt,
+		nullString(e.CustomerID),
 	)
 	if err != nil {
 		return e.ID, err
@@ -40,7 +47,7 @@ func (r *OrdersMySQL) Create(e *entity.Orders) (string, error) {
 }
 
 func (r *OrdersMySQL) Get(id string) (*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders where id = ?`)
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders where id = ? AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +57,10 @@ func (r *OrdersMySQL) Get(id string) (*entity.Orders, error) {
 		return nil, err
 	}
 
-	err = row.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline)
+	err = row.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -59,27 +69,40 @@ func (r *OrdersMySQL) Get(id string) (*entity.Orders, error) {
 }
 
 func (r *OrdersMySQL) Update(e *entity.Orders) error {
-	_, err := r.db.Exec("UPDATE orders SET title = ?, description = ?, deadline = ? where id = '?'",
-		e.Title, e.Description, e.Deadline, e.ID)
+	_, err := r.db.Exec("UPDATE orders SET title = ?, description = ?, deadline = ?, priority = ?, sla_policy = ?, customer_id = ? where id = ?",
+		e.Title, e.Description, e.Deadline, e.Priority, nullString(e.SLAPolicy), nullString(e.CustomerID), e.ID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *OrdersMySQL) Search(query string) ([]*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders WHERE title like ?`)
+func (r *OrdersMySQL) SetStatus(id string, from entity.OrderStatus, to entity.OrderStatus) (bool, error) {
+	res, err := r.db.Exec("UPDATE orders SET status = ? WHERE id = ? AND status = ? AND deleted_at IS NULL", to, id, from)
+	if err != nil {
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+func (r *OrdersMySQL) Search(query string, statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	filter, args := statusFilter(statuses)
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders WHERE title like ? AND deleted_at IS NULL` + filter)
 	if err != nil {
 		return nil, err
 	}
 	var orders []*entity.Orders
-	rows, err := stmt.Query("%" + query + "%")
+	rows, err := stmt.Query(append([]interface{}{"%" + query + "%"}, args...)...)
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var o entity.Orders
-		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline)
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
 		if err != nil {
 			return nil, err
 		}
@@ -89,19 +112,20 @@ func (r *OrdersMySQL) Search(query string) ([]*entity.Orders, error) {
 	return orders, nil
 }
 
-func (r *OrdersMySQL) List() ([]*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders`)
+func (r *OrdersMySQL) List(statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	filter, args := statusFilter(statuses)
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders WHERE deleted_at IS NULL` + filter)
 	if err != nil {
 		return nil, err
 	}
 	var orders []*entity.Orders
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(args...)
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var o entity.Orders
-		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline)
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
 		if err != nil {
 			return nil, err
 		}
@@ -111,11 +135,155 @@ func (r *OrdersMySQL) List() ([]*entity.Orders, error) {
 }
 
 func (r *OrdersMySQL) Delete(id string) error {
-	_, err := r.db.Exec("DELETE FROM orders where id = ?", id)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE orders SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		`UPDATE requirements SET deleted_at = ? WHERE order_id = ? AND deleted_at IS NULL`,
+		`UPDATE tasks SET deleted_at = ? WHERE deleted_at IS NULL 
+		 AND requirement_id IN (SELECT id FROM requirements WHERE order_id = ?)`,
+		`UPDATE submissions SET deleted_at = ? WHERE deleted_at IS NULL AND task_id IN 
+		 (SELECT tasks.id FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id WHERE requirements.order_id = ?)`,
+	}, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//Restore only brings back children stamped with the order's deletion time,
+//those deleted on their own before stay deleted
+func (r *OrdersMySQL) Restore(id string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM orders WHERE id = ? AND deleted_at IS NOT NULL FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = ? AND task_id IN 
+		 (SELECT tasks.id FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id WHERE requirements.order_id = ?)`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = ? 
+		 AND requirement_id IN (SELECT id FROM requirements WHERE order_id = ?)`,
+		`UPDATE requirements SET deleted_at = NULL WHERE deleted_at = ? AND order_id = ?`,
+		`UPDATE orders SET deleted_at = NULL WHERE deleted_at = ? AND id = ?`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+//Purge removes dependent rows first so no foreign key is violated. A child
+//is never deleted later than its parent, so purging by time alone can't
+//leave orphans behind.
+func (r *OrdersMySQL) Purge(before time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, query := range []string{
+		`DELETE FROM image_submissions WHERE submission_id IN (SELECT id FROM submissions WHERE deleted_at < ?)`,
+		`DELETE FROM submissions WHERE deleted_at < ?`,
+		`DELETE FROM forwarded_review WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM review_messages WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM prerequisite WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM prerequisite WHERE prerequisite IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'task' AND resource_id IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'task' AND resource_id IN (SELECT id FROM tasks WHERE deleted_at < ?)`,
+		`DELETE FROM tasks WHERE deleted_at < ?`,
+		`DELETE FROM revisions WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?)`,
+		`DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?))`,
+		`DELETE FROM comment_edits WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?))`,
+		`DELETE FROM comments WHERE thread_id IS NOT NULL AND resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?)`,
+		`DELETE FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS CHAR) FROM requirements WHERE deleted_at < ?)`,
+		`DELETE FROM requirements WHERE deleted_at < ?`,
+		`DELETE FROM revisions WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM share_links WHERE order_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM certificates WHERE order_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?))`,
+		`DELETE FROM comment_edits WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?))`,
+		`DELETE FROM comments WHERE thread_id IS NOT NULL AND resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < ?)`,
+		`DELETE FROM orders WHERE deleted_at < ?`,
+	} {
+		result, err := tx.Exec(query, before)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		purged += n
+	}
+	return purged, tx.Commit()
+}
+
+func (r *OrdersMySQL) ListByCustomer(customerID string) ([]*entity.Orders, error) {
+	rows, err := r.db.Query(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders 
+							 WHERE customer_id = ? AND deleted_at IS NULL ORDER BY deadline`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.Orders
+	for rows.Next() {
+		var o entity.Orders
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+	return orders, rows.Err()
+}
+
+func (r *OrdersMySQL) ListDeleted() ([]*entity.Orders, error) {
+	rows, err := r.db.Query(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, ''), deleted_at FROM orders 
+							 WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.Orders
+	for rows.Next() {
+		var o entity.Orders
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID, &o.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+	return orders, rows.Err()
 }
 
 func (r *OrdersMySQL) CustomQuery(query string) (*sql.Rows, error) {
@@ -125,3 +293,33 @@ func (r *OrdersMySQL) CustomQuery(query string) (*sql.Rows, error) {
 	}
 	return rows, nil
 }
+
+//statusFilter returns the condition restricting orders to statuses, if any
+func statusFilter(statuses []entity.OrderStatus) (string, []interface{}) {
+	if len(statuses) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		args[i] = string(status)
+	}
+	return " AND status IN (?" + strings.Repeat(",?", len(statuses)-1) + ")", args
+}
+
+func (r *OrdersMySQL) GetProgress(orderID string) (entity.OrderStatus, []*entity.ProgressRow, error) {
+	rows, err := r.db.Query(`SELECT orders.status, requirements.id, requirements.request, requirements.status, 
+								tasks.id, tasks.fulfillment_status, tasks.deadline, tasks.allowed, 
+								(SELECT GREATEST(COUNT(DISTINCT forwarded_review.reviewer_id), 1) FROM forwarded_review WHERE forwarded_review.task_id = tasks.id), 
+								COALESCE(GROUP_CONCAT(prerequisite.prerequisite), '') 
+								FROM orders LEFT JOIN requirements ON requirements.order_id = orders.id AND requirements.deleted_at IS NULL 
+								LEFT JOIN tasks ON tasks.requirement_id = requirements.id AND tasks.deleted_at IS NULL 
+								LEFT JOIN prerequisite ON prerequisite.task_id = tasks.id AND prerequisite.resolved_at IS NULL AND prerequisite.deleted_at IS NULL 
+								WHERE orders.id = ? AND orders.deleted_at IS NULL 
+								GROUP BY orders.status, requirements.id, tasks.id 
+								ORDER BY requirements.id`, orderID)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	return scanProgress(rows)
+}
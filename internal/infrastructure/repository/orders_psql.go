@@ -2,8 +2,12 @@ package repository
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"order-validation-v2/internal/entity"
+
+	"github.com/lib/pq"
 )
 
 type OrdersPSQL struct {
@@ -18,8 +22,8 @@ func NewOrdersPSQL(db *sql.DB) *OrdersPSQL {
 
 func (r *OrdersPSQL) Create(e *entity.Orders) (string, error) {
 	stmt, err := r.db.Prepare(`
-		INSERT INTO orders (id, title, description, deadline) 
-		values($1,$2,$3,$4)`)
+		INSERT INTO orders (id, title, description, deadline, status, priority, sla_policy, created_at, customer_id) 
+		values($1,$2,$3,$4,$5,$6,$7,$8,$9)`)
 	if err != nil {
 		return e.ID, err
 	}
@@ -28,6 +32,11 @@ func (r *OrdersPSQL) Create(e *entity.Orders) (string, error) {
 		e.Title,
 		e.Description,
 		e.Deadline,
+		e.Status,
+		e.Priority,
+		nullString(e.SLAPolicy),
+		e.CreatedAt,
+		nullString(e.CustomerID),
 	)
 	if err != nil {
 		return e.ID, err
@@ -40,13 +49,16 @@ func (r *OrdersPSQL) Create(e *entity.Orders) (string, error) {
 }
 
 func (r *OrdersPSQL) Get(id string) (*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders where id = $1`)
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders where id = $1 AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
 	var b entity.Orders
 	row := stmt.QueryRow(id)
-	err = row.Scan(&b.ID, &b.Title, &b.Description, &b.Deadline)
+	err = row.Scan(&b.ID, &b.Title, &b.Description, &b.Deadline, &b.Status, &b.Priority, &b.SLAPolicy, &b.CreatedAt, &b.CustomerID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -54,27 +66,40 @@ func (r *OrdersPSQL) Get(id string) (*entity.Orders, error) {
 }
 
 func (r *OrdersPSQL) Update(e *entity.Orders) error {
-	_, err := r.db.Exec("UPDATE orders SET title = $1, description = $2, deadline = $3 where id = $4",
-		e.Title, e.Description, e.Deadline, e.ID)
+	_, err := r.db.Exec("UPDATE orders SET title = $1, description = $2, deadline = $3, priority = $4, sla_policy = $5, customer_id = $6 where id = $7",
+		e.Title, e.Description, e.Deadline, e.Priority, nullString(e.SLAPolicy), nullString(e.CustomerID), e.ID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *OrdersPSQL) Search(query string) ([]*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders WHERE title like $1`)
+func (r *OrdersPSQL) SetStatus(id string, from entity.OrderStatus, to entity.OrderStatus) (bool, error) {
+	res, err := r.db.Exec("UPDATE orders SET status = $1 WHERE id = $2 AND status = $3 AND deleted_at IS NULL", to, id, from)
+	if err != nil {
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+func (r *OrdersPSQL) Search(query string, statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders 
+								WHERE title like $1 AND (cardinality($2::text[]) = 0 OR status = ANY($2)) AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
 	var orders []*entity.Orders
-	rows, err := stmt.Query("%" + query + "%")
+	rows, err := stmt.Query("%"+query+"%", pq.Array(statusStrings(statuses)))
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var o entity.Orders
-		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline)
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
 		if err != nil {
 			return nil, err
 		}
@@ -84,19 +109,20 @@ func (r *OrdersPSQL) Search(query string) ([]*entity.Orders, error) {
 	return orders, nil
 }
 
-func (r *OrdersPSQL) List() ([]*entity.Orders, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM orders`)
+func (r *OrdersPSQL) List(statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	stmt, err := r.db.Prepare(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders 
+								WHERE (cardinality($1::text[]) = 0 OR status = ANY($1)) AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
 	var orders []*entity.Orders
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(pq.Array(statusStrings(statuses)))
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var o entity.Orders
-		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline)
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
 		if err != nil {
 			return nil, err
 		}
@@ -106,11 +132,155 @@ func (r *OrdersPSQL) List() ([]*entity.Orders, error) {
 }
 
 func (r *OrdersPSQL) Delete(id string) error {
-	_, err := r.db.Exec("DELETE FROM orders where id = $1", id)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE orders SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`,
+		`UPDATE requirements SET deleted_at = $1 WHERE order_id = $2 AND deleted_at IS NULL`,
+		`UPDATE tasks SET deleted_at = $1 WHERE deleted_at IS NULL 
+		 AND requirement_id IN (SELECT id FROM requirements WHERE order_id = $2)`,
+		`UPDATE submissions SET deleted_at = $1 WHERE deleted_at IS NULL AND task_id IN 
+		 (SELECT tasks.id FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id WHERE requirements.order_id = $2)`,
+	}, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//Restore only brings back children stamped with the order's deletion time,
+//those deleted on their own before stay deleted
+func (r *OrdersPSQL) Restore(id string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM orders WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = $1 AND task_id IN 
+		 (SELECT tasks.id FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id WHERE requirements.order_id = $2)`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = $1 
+		 AND requirement_id IN (SELECT id FROM requirements WHERE order_id = $2)`,
+		`UPDATE requirements SET deleted_at = NULL WHERE deleted_at = $1 AND order_id = $2`,
+		`UPDATE orders SET deleted_at = NULL WHERE deleted_at = $1 AND id = $2`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+//Purge removes dependent rows first so no foreign key is violated. A child
+//is never deleted later than its parent, so purging by time alone can't
+//leave orphans behind.
+func (r *OrdersPSQL) Purge(before time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, query := range []string{
+		`DELETE FROM image_submissions WHERE submission_id IN (SELECT id FROM submissions WHERE deleted_at < $1)`,
+		`DELETE FROM submissions WHERE deleted_at < $1`,
+		`DELETE FROM forwarded_review WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM review_messages WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM prerequisite WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM prerequisite WHERE prerequisite IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'task' AND resource_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'task' AND resource_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`,
+		`DELETE FROM tasks WHERE deleted_at < $1`,
+		`DELETE FROM revisions WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1)`,
+		`DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1))`,
+		`DELETE FROM comment_edits WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1))`,
+		`DELETE FROM comments WHERE thread_id IS NOT NULL AND resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1)`,
+		`DELETE FROM comments WHERE resource_type = 'requirement' 
+		 AND resource_id IN (SELECT CAST(id AS varchar(37)) FROM requirements WHERE deleted_at < $1)`,
+		`DELETE FROM requirements WHERE deleted_at < $1`,
+		`DELETE FROM revisions WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM share_links WHERE order_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM certificates WHERE order_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM resource_tags WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM resource_fields WHERE resource_type = 'order' AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1))`,
+		`DELETE FROM comment_edits WHERE comment_id IN (SELECT id FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1))`,
+		`DELETE FROM comments WHERE thread_id IS NOT NULL AND resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM comments WHERE resource_type = 'order' 
+		 AND resource_id IN (SELECT id FROM orders WHERE deleted_at < $1)`,
+		`DELETE FROM orders WHERE deleted_at < $1`,
+	} {
+		result, err := tx.Exec(query, before)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		purged += n
+	}
+	return purged, tx.Commit()
+}
+
+func (r *OrdersPSQL) ListByCustomer(customerID string) ([]*entity.Orders, error) {
+	rows, err := r.db.Query(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, '') FROM orders 
+							 WHERE customer_id = $1 AND deleted_at IS NULL ORDER BY deadline`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.Orders
+	for rows.Next() {
+		var o entity.Orders
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+	return orders, rows.Err()
+}
+
+func (r *OrdersPSQL) ListDeleted() ([]*entity.Orders, error) {
+	rows, err := r.db.Query(`SELECT id, title, description, deadline, status, priority, COALESCE(sla_policy, ''), created_at, COALESCE(customer_id, ''), deleted_at FROM orders 
+							 WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.Orders
+	for rows.Next() {
+		var o entity.Orders
+		err = rows.Scan(&o.ID, &o.Title, &o.Description, &o.Deadline, &o.Status, &o.Priority, &o.SLAPolicy, &o.CreatedAt, &o.CustomerID, &o.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+	return orders, rows.Err()
 }
 
 func (r *OrdersPSQL) CustomQuery(query string) (*sql.Rows, error) {
@@ -120,3 +290,67 @@ func (r *OrdersPSQL) CustomQuery(query string) (*sql.Rows, error) {
 	}
 	return rows, nil
 }
+
+func statusStrings(statuses []entity.OrderStatus) []string {
+	s := make([]string, len(statuses))
+	for i, status := range statuses {
+		s[i] = string(status)
+	}
+	return s
+}
+
+func (r *OrdersPSQL) GetProgress(orderID string) (entity.OrderStatus, []*entity.ProgressRow, error) {
+	rows, err := r.db.Query(`SELECT orders.status, requirements.id, requirements.request, requirements.status, 
+								tasks.id, tasks.fulfillment_status, tasks.deadline, tasks.allowed, 
+								(SELECT GREATEST(COUNT(DISTINCT forwarded_review.reviewer_id), 1) FROM forwarded_review WHERE forwarded_review.task_id = tasks.id), 
+								COALESCE(string_agg(prerequisite.prerequisite, ','), '') 
+								FROM orders LEFT JOIN requirements ON requirements.order_id = orders.id AND requirements.deleted_at IS NULL 
+								LEFT JOIN tasks ON tasks.requirement_id = requirements.id AND tasks.deleted_at IS NULL 
+								LEFT JOIN prerequisite ON prerequisite.task_id = tasks.id AND prerequisite.resolved_at IS NULL AND prerequisite.deleted_at IS NULL 
+								WHERE orders.id = $1 AND orders.deleted_at IS NULL 
+								GROUP BY orders.status, requirements.id, tasks.id 
+								ORDER BY requirements.id`, orderID)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	return scanProgress(rows)
+}
+
+func scanProgress(rows *sql.Rows) (entity.OrderStatus, []*entity.ProgressRow, error) {
+	var status entity.OrderStatus
+	var progress []*entity.ProgressRow
+	for rows.Next() {
+		var requirementID, requirementStatus, taskStatus, reviewers sql.NullInt64
+		var request, taskID sql.NullString
+		var deadline sql.NullTime
+		var allowed sql.NullBool
+		var prerequisites string
+		err := rows.Scan(&status, &requirementID, &request, &requirementStatus,
+			&taskID, &taskStatus, &deadline, &allowed, &reviewers, &prerequisites)
+		if err != nil {
+			return "", nil, err
+		}
+		if !requirementID.Valid {
+			continue
+		}
+		row := &entity.ProgressRow{
+			RequirementID:     int(requirementID.Int64),
+			Request:           request.String,
+			RequirementStatus: entity.Status(requirementStatus.Int64),
+			TaskID:            taskID.String,
+			TaskStatus:        entity.Status(taskStatus.Int64),
+			Deadline:          deadline.Time,
+			Allowed:           allowed.Bool,
+			PendingReviewers:  int(reviewers.Int64),
+		}
+		if prerequisites != "" {
+			row.Prerequisites = strings.Split(prerequisites, ",")
+		}
+		progress = append(progress, row)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+	return status, progress, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+func TestOrdersPurge(t *testing.T) {
+	db, fake := newFakeDB(nil)
+	before := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	purged, err := NewOrdersPSQL(db).Purge(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, args := fake.statements("DELETE FROM")
+	if purged != int64(len(queries)) {
+		t.Errorf("purged = %d, want the %d rows deleted", purged, len(queries))
+	}
+	for i := range args {
+		if len(args[i]) != 1 || args[i][0] != before {
+			t.Errorf("%s: args = %v, want the cutoff", queries[i], args[i])
+		}
+	}
+	if last := fake.log[len(fake.log)-1]; last != "COMMIT" {
+		t.Errorf("last statement = %q, want COMMIT", last)
+	}
+	tt := []struct {
+		child  string
+		parent string
+	}{
+		{child: "DELETE FROM image_submissions", parent: "DELETE FROM submissions"},
+		{child: "DELETE FROM submissions", parent: "DELETE FROM tasks"},
+		{child: "DELETE FROM prerequisite", parent: "DELETE FROM tasks"},
+		{child: "DELETE FROM forwarded_review", parent: "DELETE FROM tasks"},
+		{child: "DELETE FROM tasks", parent: "DELETE FROM requirements"},
+		{child: "DELETE FROM comment_mentions", parent: "DELETE FROM comments"},
+		{child: "DELETE FROM share_links", parent: "DELETE FROM orders"},
+		{child: "DELETE FROM certificates", parent: "DELETE FROM orders"},
+		{child: "DELETE FROM requirements", parent: "DELETE FROM orders"},
+	}
+	for _, tc := range tt {
+		child, parent := fake.index(tc.child), fake.index(tc.parent)
+		if child < 0 || parent < 0 || child > parent {
+			t.Errorf("%q runs at %d, %q at %d", tc.child, child, tc.parent, parent)
+		}
+	}
+}
+
+func TestDeletedRowsHidden(t *testing.T) {
+	db, fake := newFakeDB(nil)
+	orders := NewOrdersPSQL(db)
+	tasks := NewTaskPSQL(db)
+	requirements := NewRequirementsPSQL(db)
+	reads := []func(){
+		func() { orders.Get("o1") },
+		func() { orders.List(nil) },
+		func() { orders.Search("x", []entity.OrderStatus{}) },
+		func() { orders.ListByCustomer("c1") },
+		func() { requirements.Get(1) },
+		func() { requirements.List() },
+		func() { requirements.Search("x") },
+		func() { requirements.GetByOrderID("o1") },
+		func() { tasks.Get("t1") },
+	}
+	//nothing is stored, only the queries matter
+	for _, read := range reads {
+		read()
+	}
+	if len(fake.log) != len(reads) {
+		t.Fatalf("ran %d queries for %d reads", len(fake.log), len(reads))
+	}
+	for _, query := range fake.log {
+		if !strings.Contains(query, "deleted_at IS NULL") {
+			t.Errorf("%q returns deleted rows", query)
+		}
+	}
+}
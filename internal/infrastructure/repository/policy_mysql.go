@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type PolicyMySQL struct {
+	db *sql.DB
+}
+
+func NewPolicyMySQL(db *sql.DB) *PolicyMySQL {
+	return &PolicyMySQL{
+		db: db,
+	}
+}
+
+func (r *PolicyMySQL) GetTaskRelations(taskID string) (*entity.TaskRelations, error) {
+	stmt, err := r.db.Prepare(`SELECT tasks.id, requirements.order_id, tasks.user_id, tasks.assigner_id 
+								FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id 
+								WHERE tasks.id = ?`)
+	if err != nil {
+		return nil, err
+	}
+	var t entity.TaskRelations
+	row := stmt.QueryRow(taskID)
+	err = row.Scan(&t.TaskID, &t.OrderID, &t.UserID, &t.AssignerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT reviewer_id FROM forwarded_review WHERE task_id = ?", taskID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var reviewer string
+		err = rows.Scan(&reviewer)
+		if err != nil {
+			return nil, err
+		}
+		t.Reviewers = append(t.Reviewers, reviewer)
+	}
+	return &t, nil
+}
+
+func (r *PolicyMySQL) GetSubmissionTaskID(submissionID string) (string, error) {
+	var taskID string
+	row := r.db.QueryRow("SELECT task_id FROM submissions WHERE id = ?", submissionID)
+	err := row.Scan(&taskID)
+	if err != nil {
+		return "", err
+	}
+	return taskID, nil
+}
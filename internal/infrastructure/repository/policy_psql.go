@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type PolicyPSQL struct {
+	db *sql.DB
+}
+
+func NewPolicyPSQL(db *sql.DB) *PolicyPSQL {
+	return &PolicyPSQL{
+		db: db,
+	}
+}
+
+func (r *PolicyPSQL) GetTaskRelations(taskID string) (*entity.TaskRelations, error) {
+	stmt, err := r.db.Prepare(`SELECT tasks.id, requirements.order_id, tasks.user_id, tasks.assigner_id 
+								FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id 
+								WHERE tasks.id = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var t entity.TaskRelations
+	row := stmt.QueryRow(taskID)
+	err = row.Scan(&t.TaskID, &t.OrderID, &t.UserID, &t.AssignerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT reviewer_id FROM forwarded_review WHERE task_id = $1", taskID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var reviewer string
+		err = rows.Scan(&reviewer)
+		if err != nil {
+			return nil, err
+		}
+		t.Reviewers = append(t.Reviewers, reviewer)
+	}
+	return &t, nil
+}
+
+func (r *PolicyPSQL) GetSubmissionTaskID(submissionID string) (string, error) {
+	var taskID string
+	row := r.db.QueryRow("SELECT task_id FROM submissions WHERE id = $1", submissionID)
+	err := row.Scan(&taskID)
+	if err != nil {
+		return "", err
+	}
+	return taskID, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"order-validation-v2/internal/entity"
 )
@@ -43,7 +44,7 @@ func (r *RequirementsMySQL) Create(e *entity.Requirements) (int, error) {
 }
 
 func (r *RequirementsMySQL) Get(ID int) (*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM requirements where id = ?`)
+	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, status, order_id FROM requirements where id = ? AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +68,7 @@ func (r *RequirementsMySQL) Update(e *entity.Requirements) error {
 }
 
 func (r *RequirementsMySQL) Search(query string) ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, status FROM requirements WHERE request like ?`)
+	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, status, order_id FROM requirements WHERE request like ? AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +90,7 @@ func (r *RequirementsMySQL) Search(query string) ([]*entity.Requirements, error)
 }
 
 func (r *RequirementsMySQL) List() ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM requirements`)
+	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, status, order_id FROM requirements WHERE deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -110,15 +111,52 @@ func (r *RequirementsMySQL) List() ([]*entity.Requirements, error) {
 }
 
 func (r *RequirementsMySQL) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM requirements where id = ?", id)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE requirements SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		`UPDATE tasks SET deleted_at = ? WHERE requirement_id = ? AND deleted_at IS NULL`,
+		`UPDATE submissions SET deleted_at = ? WHERE deleted_at IS NULL 
+		 AND task_id IN (SELECT id FROM tasks WHERE requirement_id = ?)`,
+	}, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RequirementsMySQL) Restore(id int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM requirements WHERE id = ? AND deleted_at IS NOT NULL 
+					   AND order_id IN (SELECT id FROM orders WHERE deleted_at IS NULL) FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = ? 
+		 AND task_id IN (SELECT id FROM tasks WHERE requirement_id = ?)`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = ? AND requirement_id = ?`,
+		`UPDATE requirements SET deleted_at = NULL WHERE deleted_at = ? AND id = ?`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 func (r *RequirementsMySQL) GetByOrderID(orderID string) ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare("SELECT * FROM requirements where order_id = ?")
+	stmt, err := r.db.Prepare("SELECT id, request, expected_outcome, order_id, status FROM requirements where order_id = ? AND deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"order-validation-v2/internal/entity"
 )
@@ -42,7 +43,7 @@ func (r *RequirementsPSQL) Create(e *entity.Requirements) (int, error) {
 }
 
 func (r *RequirementsPSQL) Get(ID int) (*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT ID, request, expected_outcome, order_id, status FROM requirements where id = $1`)
+	stmt, err := r.db.Prepare(`SELECT ID, request, expected_outcome, order_id, status FROM requirements where id = $1 AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +66,7 @@ func (r *RequirementsPSQL) Update(e *entity.Requirements) error {
 }
 
 func (r *RequirementsPSQL) Search(query string) ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT id, request, expectedoutcome, status FROM requirements WHERE request like $1`)
+	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, order_id, status FROM requirements WHERE request like $1 AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +88,7 @@ func (r *RequirementsPSQL) Search(query string) ([]*entity.Requirements, error)
 }
 
 func (r *RequirementsPSQL) List() ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare(`SELECT * FROM requirements`)
+	stmt, err := r.db.Prepare(`SELECT id, request, expected_outcome, status, order_id FROM requirements WHERE deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -108,15 +109,52 @@ func (r *RequirementsPSQL) List() ([]*entity.Requirements, error) {
 }
 
 func (r *RequirementsPSQL) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM requirements where id = $1", id)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE requirements SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`,
+		`UPDATE tasks SET deleted_at = $1 WHERE requirement_id = $2 AND deleted_at IS NULL`,
+		`UPDATE submissions SET deleted_at = $1 WHERE deleted_at IS NULL 
+		 AND task_id IN (SELECT id FROM tasks WHERE requirement_id = $2)`,
+	}, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RequirementsPSQL) Restore(id int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM requirements WHERE id = $1 AND deleted_at IS NOT NULL 
+					   AND order_id IN (SELECT id FROM orders WHERE deleted_at IS NULL) FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = $1 
+		 AND task_id IN (SELECT id FROM tasks WHERE requirement_id = $2)`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = $1 AND requirement_id = $2`,
+		`UPDATE requirements SET deleted_at = NULL WHERE deleted_at = $1 AND id = $2`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 func (r *RequirementsPSQL) GetByOrderID(orderID string) ([]*entity.Requirements, error) {
-	stmt, err := r.db.Prepare("SELECT * FROM requirements where order_id = $1")
+	stmt, err := r.db.Prepare("SELECT id, request, expected_outcome, order_id, status FROM requirements where order_id = $1 AND deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
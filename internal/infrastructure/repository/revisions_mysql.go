@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"order-validation-v2/internal/entity"
+)
+
+type RevisionMySQL struct {
+	db *sql.DB
+}
+
+func NewRevisionMySQL(db *sql.DB) *RevisionMySQL {
+	return &RevisionMySQL{
+		db: db,
+	}
+}
+
+func (r *RevisionMySQL) Create(rev *entity.Revision) (bool, error) {
+	snapshot, err := json.Marshal(rev.Snapshot)
+	if err != nil {
+		return false, err
+	}
+	changes, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.Exec(`INSERT INTO revisions (id, resource_type, resource_id, number, author_id, created_at, snapshot, changes, restored_of) 
+						values(?,?,?,?,?,?,?,?,?)
+						ON DUPLICATE KEY UPDATE id = id`,
+		rev.ID, string(rev.Resource.Type), rev.Resource.ID, rev.Number, rev.AuthorID, rev.CreatedAt, string(snapshot), string(changes), rev.RestoredOf)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+func (r *RevisionMySQL) List(resource entity.Resource) ([]*entity.Revision, error) {
+	rows, err := r.db.Query(`SELECT id, number, author_id, created_at, snapshot, changes, restored_of FROM revisions 
+							 WHERE resource_type = ? AND resource_id = ? ORDER BY number`, string(resource.Type), resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []*entity.Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows, resource)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *RevisionMySQL) Get(resource entity.Resource, number int) (*entity.Revision, error) {
+	row := r.db.QueryRow(`SELECT id, number, author_id, created_at, snapshot, changes, restored_of FROM revisions 
+						  WHERE resource_type = ? AND resource_id = ? AND number = ?`, string(resource.Type), resource.ID, number)
+	rev, err := scanRevision(row, resource)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rev, err
+}
+
+func (r *RevisionMySQL) Latest(resource entity.Resource) (*entity.Revision, error) {
+	row := r.db.QueryRow(`SELECT id, number, author_id, created_at, snapshot, changes, restored_of FROM revisions 
+						  WHERE resource_type = ? AND resource_id = ? ORDER BY number DESC LIMIT 1`, string(resource.Type), resource.ID)
+	rev, err := scanRevision(row, resource)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rev, err
+}
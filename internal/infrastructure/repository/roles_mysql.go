@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type RolesMySQL struct {
+	db *sql.DB
+}
+
+func NewRolesMySQL(db *sql.DB) *RolesMySQL {
+	return &RolesMySQL{
+		db: db,
+	}
+}
+
+func (r *RolesMySQL) Create(e *entity.Role) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return e.Name, err
+	}
+	_, err = tx.Exec(`INSERT INTO roles (name, description, builtin, require_2fa) values(?,?,?,?)`,
+		e.Name, e.Description, e.BuiltIn, e.Require2FA)
+	if err != nil {
+		tx.Rollback()
+		return e.Name, err
+	}
+	err = r.insertPermissions(tx, e)
+	if err != nil {
+		tx.Rollback()
+		return e.Name, err
+	}
+	return e.Name, tx.Commit()
+}
+
+func (r *RolesMySQL) Get(name string) (*entity.Role, error) {
+	stmt, err := r.db.Prepare(`SELECT name, description, builtin, require_2fa FROM roles WHERE name = ?`)
+	if err != nil {
+		return nil, err
+	}
+	var role entity.Role
+	row := stmt.QueryRow(name)
+	err = row.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.Require2FA)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions, err = r.getPermissions(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RolesMySQL) List() ([]*entity.Role, error) {
+	stmt, err := r.db.Prepare(`SELECT name, description, builtin, require_2fa FROM roles ORDER BY builtin DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	var roles []*entity.Role
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var role entity.Role
+		err = rows.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.Require2FA)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, &role)
+	}
+	for _, role := range roles {
+		role.Permissions, err = r.getPermissions(role.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func (r *RolesMySQL) Update(e *entity.Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE roles SET description = ? WHERE name = ?", e.Description, e.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM role_permissions WHERE role_name = ?", e.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = r.insertPermissions(tx, e)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RolesMySQL) Delete(name string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM role_permissions WHERE role_name = ?", name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM roles WHERE name = ?", name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RolesMySQL) insertPermissions(tx *sql.Tx, e *entity.Role) error {
+	stmt, err := tx.Prepare(`INSERT INTO role_permissions (role_name, permission) values(?,?)`)
+	if err != nil {
+		return err
+	}
+	for _, p := range e.Permissions {
+		_, err = stmt.Exec(e.Name, p)
+		if err != nil {
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func (r *RolesMySQL) getPermissions(name string) ([]entity.Permission, error) {
+	stmt, err := r.db.Prepare(`SELECT permission FROM role_permissions WHERE role_name = ?`)
+	if err != nil {
+		return nil, err
+	}
+	var permissions []entity.Permission
+	rows, err := stmt.Query(name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p entity.Permission
+		err = rows.Scan(&p)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, nil
+}
+
+func (r *RolesMySQL) SetRequire2FA(name string, required bool) error {
+	_, err := r.db.Exec("UPDATE roles SET require_2fa = ? WHERE name = ?", required, name)
+	return err
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+
+	"order-validation-v2/internal/entity"
+)
+
+type RolesPSQL struct {
+	db *sql.DB
+}
+
+func NewRolesPSQL(db *sql.DB) *RolesPSQL {
+	return &RolesPSQL{
+		db: db,
+	}
+}
+
+func (r *RolesPSQL) Create(e *entity.Role) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return e.Name, err
+	}
+	_, err = tx.Exec(`INSERT INTO roles (name, description, builtin, require_2fa) values($1,$2,$3,$4)`,
+		e.Name, e.Description, e.BuiltIn, e.Require2FA)
+	if err != nil {
+		tx.Rollback()
+		return e.Name, err
+	}
+	err = r.insertPermissions(tx, e)
+	if err != nil {
+		tx.Rollback()
+		return e.Name, err
+	}
+	return e.Name, tx.Commit()
+}
+
+func (r *RolesPSQL) Get(name string) (*entity.Role, error) {
+	stmt, err := r.db.Prepare(`SELECT name, description, builtin, require_2fa FROM roles WHERE name = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var role entity.Role
+	row := stmt.QueryRow(name)
+	err = row.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.Require2FA)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions, err = r.getPermissions(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RolesPSQL) List() ([]*entity.Role, error) {
+	stmt, err := r.db.Prepare(`SELECT name, description, builtin, require_2fa FROM roles ORDER BY builtin DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	var roles []*entity.Role
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var role entity.Role
+		err = rows.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.Require2FA)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, &role)
+	}
+	for _, role := range roles {
+		role.Permissions, err = r.getPermissions(role.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func (r *RolesPSQL) Update(e *entity.Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE roles SET description = $1 WHERE name = $2", e.Description, e.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM role_permissions WHERE role_name = $1", e.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = r.insertPermissions(tx, e)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RolesPSQL) Delete(name string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM role_permissions WHERE role_name = $1", name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM roles WHERE name = $1", name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RolesPSQL) insertPermissions(tx *sql.Tx, e *entity.Role) error {
+	stmt, err := tx.Prepare(`INSERT INTO role_permissions (role_name, permission) values($1,$2)`)
+	if err != nil {
+		return err
+	}
+	for _, p := range e.Permissions {
+		_, err = stmt.Exec(e.Name, p)
+		if err != nil {
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func (r *RolesPSQL) getPermissions(name string) ([]entity.Permission, error) {
+	stmt, err := r.db.Prepare(`SELECT permission FROM role_permissions WHERE role_name = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var permissions []entity.Permission
+	rows, err := stmt.Query(name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p entity.Permission
+		err = rows.Scan(&p)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, nil
+}
+
+func (r *RolesPSQL) SetRequire2FA(name string, required bool) error {
+	_, err := r.db.Exec("UPDATE roles SET require_2fa = $1 WHERE name = $2", required, name)
+	return err
+}
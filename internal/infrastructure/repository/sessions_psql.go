@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type SessionPSQL struct {
+	db *sql.DB
+}
+
+func NewSessionPSQL(db *sql.DB) *SessionPSQL {
+	return &SessionPSQL{
+		db: db,
+	}
+}
+
+func (r *SessionPSQL) Create(s *entity.Session) (string, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, revoked) 
+		values($1,$2,$3,$4,$5,$6,$7)`,
+		s.ID, s.UserID, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.Revoked)
+	if err != nil {
+		return s.ID, err
+	}
+	return s.ID, nil
+}
+
+func (r *SessionPSQL) Get(id string) (*entity.Session, error) {
+	stmt, err := r.db.Prepare(`SELECT id, user_id, user_agent, ip, created_at, last_seen_at, revoked 
+							   FROM sessions WHERE id = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var s entity.Session
+	row := stmt.QueryRow(id)
+	err = row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.Revoked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SessionPSQL) ListActive(userID string, since time.Time) ([]*entity.Session, error) {
+	stmt, err := r.db.Prepare(`SELECT id, user_id, user_agent, ip, created_at, last_seen_at, revoked 
+							   FROM sessions WHERE user_id = $1 AND revoked = false AND last_seen_at > $2 
+							   ORDER BY last_seen_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*entity.Session
+	for rows.Next() {
+		var s entity.Session
+		err = rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.Revoked)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &s)
+	}
+	return sessions, nil
+}
+
+func (r *SessionPSQL) Touch(id string, at time.Time) error {
+	_, err := r.db.Exec("UPDATE sessions SET last_seen_at = $1 WHERE id = $2", at, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SessionPSQL) Revoke(id string) error {
+	_, err := r.db.Exec("UPDATE sessions SET revoked = true WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SessionPSQL) RevokeUser(userID string, except string) error {
+	_, err := r.db.Exec("UPDATE sessions SET revoked = true WHERE user_id = $1 AND id <> $2", userID, except)
+	if err != nil {
+		return err
+	}
+	return nil
+}
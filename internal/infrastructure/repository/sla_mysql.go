@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+type SLAMySQL struct {
+	db *sql.DB
+}
+
+func NewSLAMySQL(db *sql.DB) *SLAMySQL {
+	return &SLAMySQL{
+		db: db,
+	}
+}
+
+func (r *SLAMySQL) CreatePolicy(p *entity.SLAPolicy) error {
+	_, err := r.db.Exec(`INSERT INTO sla_policies (name, description, target_start, target_completion, review_turnaround)
+						 values(?,?,?,?,?)`,
+		p.Name, p.Description, int64(p.TargetStart/time.Second), int64(p.TargetCompletion/time.Second), int64(p.ReviewTurnaround/time.Second))
+	return err
+}
+
+func (r *SLAMySQL) UpdatePolicy(p *entity.SLAPolicy) error {
+	_, err := r.db.Exec(`UPDATE sla_policies SET description = ?, target_start = ?, target_completion = ?, review_turnaround = ?
+						 WHERE name = ?`,
+		p.Description, int64(p.TargetStart/time.Second), int64(p.TargetCompletion/time.Second), int64(p.ReviewTurnaround/time.Second), p.Name)
+	return err
+}
+
+func (r *SLAMySQL) DeletePolicy(name string) error {
+	_, err := r.db.Exec(`DELETE FROM sla_policies WHERE name = ?`, name)
+	return err
+}
+
+func (r *SLAMySQL) GetPolicy(name string) (*entity.SLAPolicy, error) {
+	row := r.db.QueryRow(`SELECT name, description, target_start, target_completion, review_turnaround
+						  FROM sla_policies WHERE name = ?`, name)
+	p, err := scanSLAPolicy(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *SLAMySQL) ListPolicies() ([]*entity.SLAPolicy, error) {
+	rows, err := r.db.Query(`SELECT name, description, target_start, target_completion, review_turnaround
+							 FROM sla_policies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var policies []*entity.SLAPolicy
+	for rows.Next() {
+		p, err := scanSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *SLAMySQL) CountOrders(name string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM orders WHERE sla_policy = ?`, name).Scan(&count)
+	return count, err
+}
+
+func (r *SLAMySQL) GetOrder(orderID string) (*entity.OrderSLA, error) {
+	row := r.db.QueryRow(`SELECT orders.id, orders.title, orders.status, orders.priority, orders.created_at,
+						  sla_policies.name, sla_policies.description, sla_policies.target_start,
+						  sla_policies.target_completion, sla_policies.review_turnaround
+						  FROM orders LEFT JOIN sla_policies ON sla_policies.name = orders.sla_policy
+						  WHERE orders.id = ? AND orders.deleted_at IS NULL`, orderID)
+	o, err := scanOrderSLA(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at,
+							 MIN(submissions.submit_time), MAX(submissions.submit_time)
+							 FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id
+							 LEFT JOIN submissions ON submissions.task_id = tasks.id AND submissions.deleted_at IS NULL
+							 WHERE requirements.order_id = ? AND requirements.deleted_at IS NULL AND tasks.deleted_at IS NULL
+							 GROUP BY requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	err = scanTaskTimes(rows, map[string]*entity.OrderSLA{o.OrderID: o})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *SLAMySQL) ListOrders() ([]*entity.OrderSLA, error) {
+	rows, err := r.db.Query(`SELECT orders.id, orders.title, orders.status, orders.priority, orders.created_at,
+							 sla_policies.name, sla_policies.description, sla_policies.target_start,
+							 sla_policies.target_completion, sla_policies.review_turnaround
+							 FROM orders INNER JOIN sla_policies ON sla_policies.name = orders.sla_policy
+							 WHERE orders.deleted_at IS NULL AND orders.status <> 'cancelled' ORDER BY orders.created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.OrderSLA
+	byID := map[string]*entity.OrderSLA{}
+	for rows.Next() {
+		o, err := scanOrderSLA(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+		byID[o.OrderID] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	taskRows, err := r.db.Query(`SELECT requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at,
+								 MIN(submissions.submit_time), MAX(submissions.submit_time)
+								 FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id
+								 INNER JOIN orders ON requirements.order_id = orders.id
+								 LEFT JOIN submissions ON submissions.task_id = tasks.id AND submissions.deleted_at IS NULL
+								 WHERE orders.sla_policy IS NOT NULL AND orders.deleted_at IS NULL AND orders.status <> 'cancelled'
+								 AND requirements.deleted_at IS NULL AND tasks.deleted_at IS NULL
+								 GROUP BY requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer taskRows.Close()
+	err = scanTaskTimes(taskRows, byID)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
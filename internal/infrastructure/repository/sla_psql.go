@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+//taskSLAColumns and taskSLAJoin extend the task list queries with what
+//their SLA risk is computed from. The columns are read by taskSLA.
+const (
+	taskSLAColumns = `orders.priority, orders.created_at, COALESCE(sla_policies.target_completion, 0),
+		COALESCE(sla_policies.review_turnaround, 0), (SELECT MAX(submit_time) FROM submissions
+		WHERE submissions.task_id = tasks.id AND submissions.deleted_at IS NULL)`
+	taskSLAJoin = `LEFT JOIN sla_policies ON sla_policies.name = orders.sla_policy`
+)
+
+type taskSLA struct {
+	completion  int64
+	review      int64
+	submittedAt sql.NullTime
+}
+
+//scan appends the destinations of taskSLAColumns to dest
+func (s *taskSLA) scan(t *entity.TaskWithDetails, dest ...interface{}) []interface{} {
+	return append(dest, &t.Priority, &t.OrderCreatedAt, &s.completion, &s.review, &s.submittedAt)
+}
+
+func (s *taskSLA) apply(t *entity.TaskWithDetails) {
+	t.CompletionTarget = time.Duration(s.completion) * time.Second
+	t.ReviewTarget = time.Duration(s.review) * time.Second
+	t.SubmittedAt = s.submittedAt.Time
+}
+
+type SLAPSQL struct {
+	db *sql.DB
+}
+
+func NewSLAPSQL(db *sql.DB) *SLAPSQL {
+	return &SLAPSQL{
+		db: db,
+	}
+}
+
+func (r *SLAPSQL) CreatePolicy(p *entity.SLAPolicy) error {
+	_, err := r.db.Exec(`INSERT INTO sla_policies (name, description, target_start, target_completion, review_turnaround)
+						 values($1,$2,$3,$4,$5)`,
+		p.Name, p.Description, int64(p.TargetStart/time.Second), int64(p.TargetCompletion/time.Second), int64(p.ReviewTurnaround/time.Second))
+	return err
+}
+
+func (r *SLAPSQL) UpdatePolicy(p *entity.SLAPolicy) error {
+	_, err := r.db.Exec(`UPDATE sla_policies SET description = $1, target_start = $2, target_completion = $3, review_turnaround = $4
+						 WHERE name = $5`,
+		p.Description, int64(p.TargetStart/time.Second), int64(p.TargetCompletion/time.Second), int64(p.ReviewTurnaround/time.Second), p.Name)
+	return err
+}
+
+func (r *SLAPSQL) DeletePolicy(name string) error {
+	_, err := r.db.Exec(`DELETE FROM sla_policies WHERE name = $1`, name)
+	return err
+}
+
+func (r *SLAPSQL) GetPolicy(name string) (*entity.SLAPolicy, error) {
+	row := r.db.QueryRow(`SELECT name, description, target_start, target_completion, review_turnaround
+						  FROM sla_policies WHERE name = $1`, name)
+	p, err := scanSLAPolicy(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *SLAPSQL) ListPolicies() ([]*entity.SLAPolicy, error) {
+	rows, err := r.db.Query(`SELECT name, description, target_start, target_completion, review_turnaround
+							 FROM sla_policies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var policies []*entity.SLAPolicy
+	for rows.Next() {
+		p, err := scanSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *SLAPSQL) CountOrders(name string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM orders WHERE sla_policy = $1`, name).Scan(&count)
+	return count, err
+}
+
+func (r *SLAPSQL) GetOrder(orderID string) (*entity.OrderSLA, error) {
+	row := r.db.QueryRow(`SELECT orders.id, orders.title, orders.status, orders.priority, orders.created_at,
+						  sla_policies.name, sla_policies.description, sla_policies.target_start,
+						  sla_policies.target_completion, sla_policies.review_turnaround
+						  FROM orders LEFT JOIN sla_policies ON sla_policies.name = orders.sla_policy
+						  WHERE orders.id = $1 AND orders.deleted_at IS NULL`, orderID)
+	o, err := scanOrderSLA(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at,
+							 MIN(submissions.submit_time), MAX(submissions.submit_time)
+							 FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id
+							 LEFT JOIN submissions ON submissions.task_id = tasks.id AND submissions.deleted_at IS NULL
+							 WHERE requirements.order_id = $1 AND requirements.deleted_at IS NULL AND tasks.deleted_at IS NULL
+							 GROUP BY requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	err = scanTaskTimes(rows, map[string]*entity.OrderSLA{o.OrderID: o})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *SLAPSQL) ListOrders() ([]*entity.OrderSLA, error) {
+	rows, err := r.db.Query(`SELECT orders.id, orders.title, orders.status, orders.priority, orders.created_at,
+							 sla_policies.name, sla_policies.description, sla_policies.target_start,
+							 sla_policies.target_completion, sla_policies.review_turnaround
+							 FROM orders INNER JOIN sla_policies ON sla_policies.name = orders.sla_policy
+							 WHERE orders.deleted_at IS NULL AND orders.status <> 'cancelled' ORDER BY orders.created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*entity.OrderSLA
+	byID := map[string]*entity.OrderSLA{}
+	for rows.Next() {
+		o, err := scanOrderSLA(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+		byID[o.OrderID] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	taskRows, err := r.db.Query(`SELECT requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at,
+								 MIN(submissions.submit_time), MAX(submissions.submit_time)
+								 FROM tasks INNER JOIN requirements ON tasks.requirement_id = requirements.id
+								 INNER JOIN orders ON requirements.order_id = orders.id
+								 LEFT JOIN submissions ON submissions.task_id = tasks.id AND submissions.deleted_at IS NULL
+								 WHERE orders.sla_policy IS NOT NULL AND orders.deleted_at IS NULL AND orders.status <> 'cancelled'
+								 AND requirements.deleted_at IS NULL AND tasks.deleted_at IS NULL
+								 GROUP BY requirements.order_id, tasks.id, tasks.fulfillment_status, tasks.created_at, tasks.reviewed_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer taskRows.Close()
+	err = scanTaskTimes(taskRows, byID)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func scanSLAPolicy(row rowScanner) (*entity.SLAPolicy, error) {
+	var p entity.SLAPolicy
+	var start, completion, review int64
+	err := row.Scan(&p.Name, &p.Description, &start, &completion, &review)
+	if err != nil {
+		return nil, err
+	}
+	p.TargetStart = time.Duration(start) * time.Second
+	p.TargetCompletion = time.Duration(completion) * time.Second
+	p.ReviewTurnaround = time.Duration(review) * time.Second
+	return &p, nil
+}
+
+func scanOrderSLA(row rowScanner) (*entity.OrderSLA, error) {
+	var o entity.OrderSLA
+	var name, description sql.NullString
+	var start, completion, review sql.NullInt64
+	err := row.Scan(&o.OrderID, &o.Title, &o.Status, &o.Priority, &o.CreatedAt,
+		&name, &description, &start, &completion, &review)
+	if err != nil {
+		return nil, err
+	}
+	if name.Valid {
+		o.Policy = &entity.SLAPolicy{
+			Name:             name.String,
+			Description:      description.String,
+			TargetStart:      time.Duration(start.Int64) * time.Second,
+			TargetCompletion: time.Duration(completion.Int64) * time.Second,
+			ReviewTurnaround: time.Duration(review.Int64) * time.Second,
+		}
+	}
+	return &o, nil
+}
+
+//scanTaskTimes adds each task to its order in orders
+func scanTaskTimes(rows *sql.Rows, orders map[string]*entity.OrderSLA) error {
+	for rows.Next() {
+		var orderID string
+		var t entity.TaskTimes
+		var reviewedAt, firstSubmission, lastSubmission sql.NullTime
+		err := rows.Scan(&orderID, &t.TaskID, &t.Status, &t.AssignedAt, &reviewedAt, &firstSubmission, &lastSubmission)
+		if err != nil {
+			return err
+		}
+		t.ReviewedAt = reviewedAt.Time
+		t.FirstSubmission = firstSubmission.Time
+		t.LastSubmission = lastSubmission.Time
+		if o, ok := orders[orderID]; ok {
+			o.Tasks = append(o.Tasks, t)
+		}
+	}
+	return rows.Err()
+}
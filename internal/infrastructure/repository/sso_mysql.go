@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+type SSOMySQL struct {
+	db *sql.DB
+}
+
+func NewSSOMySQL(db *sql.DB) *SSOMySQL {
+	return &SSOMySQL{
+		db: db,
+	}
+}
+
+func (r *SSOMySQL) GetUserID(issuer string, subject string) (string, error) {
+	stmt, err := r.db.Prepare(`SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?`)
+	if err != nil {
+		return "", err
+	}
+	var userID string
+	err = stmt.QueryRow(issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *SSOMySQL) Link(issuer string, subject string, userID string) error {
+	_, err := r.db.Exec(`INSERT INTO user_identities (issuer, subject, user_id) VALUES (?,?,?)`, issuer, subject, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
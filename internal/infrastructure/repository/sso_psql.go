@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+type SSOPSQL struct {
+	db *sql.DB
+}
+
+func NewSSOPSQL(db *sql.DB) *SSOPSQL {
+	return &SSOPSQL{
+		db: db,
+	}
+}
+
+func (r *SSOPSQL) GetUserID(issuer string, subject string) (string, error) {
+	stmt, err := r.db.Prepare(`SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2`)
+	if err != nil {
+		return "", err
+	}
+	var userID string
+	err = stmt.QueryRow(issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *SSOPSQL) Link(issuer string, subject string, userID string) error {
+	_, err := r.db.Exec(`INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1,$2,$3)`, issuer, subject, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
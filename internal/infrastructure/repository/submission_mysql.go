@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"order-validation-v2/internal/entity"
+	"time"
 )
 
 type SubmissionMySQL struct {
@@ -17,7 +18,7 @@ func NewSubmissionMySQL(db *sql.DB) *SubmissionMySQL {
 }
 
 func (r *SubmissionMySQL) GetByTaskID(taskID string) ([]*entity.Submission, error) {
-	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where task_id = ?`)
+	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where task_id = ? AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +107,7 @@ func (r *SubmissionMySQL) Create(e *entity.Submission) (string, error) {
 }
 
 func (r *SubmissionMySQL) Get(id string) (*entity.Submission, error) {
-	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where id = ?`)
+	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where id = ? AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +158,7 @@ func (r *SubmissionMySQL) Update(e *entity.Submission) error {
 }
 
 func (r *SubmissionMySQL) Delete(id string) error {
-	_, err := r.db.Exec("DELETE FROM submissions where id = ?", id)
+	_, err := r.db.Exec("UPDATE submissions SET deleted_at = ? where id = ? AND deleted_at IS NULL", time.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"order-validation-v2/internal/entity"
+	"time"
 )
 
 type SubmissionPSQL struct {
@@ -66,7 +67,7 @@ func (r *SubmissionPSQL) Create(e *entity.Submission) (string, error) {
 }
 
 func (r *SubmissionPSQL) Get(submissionID string) (*entity.Submission, error) {
-	statement, err := r.db.Prepare(`SELECT submit_time, message, task_id FROM submissions where id = $1`)
+	statement, err := r.db.Prepare(`SELECT submit_time, message, task_id FROM submissions where id = $1 AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +85,7 @@ func (r *SubmissionPSQL) Get(submissionID string) (*entity.Submission, error) {
 }
 
 func (r *SubmissionPSQL) GetByTaskID(taskID string) ([]*entity.Submission, error) {
-	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where task_id = $1`)
+	statement, err := r.db.Prepare(`SELECT id, submit_time, message FROM submissions where task_id = $1 AND deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +139,7 @@ func (r *SubmissionPSQL) Update(e *entity.Submission) error {
 }
 
 func (r *SubmissionPSQL) Delete(id string) error {
-	_, err := r.db.Exec("DELETE FROM submissions where id = $1", id)
+	_, err := r.db.Exec("UPDATE submissions SET deleted_at = $1 where id = $2 AND deleted_at IS NULL", time.Now(), id)
 	if err != nil {
 		return err
 	}
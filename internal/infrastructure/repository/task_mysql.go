@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"order-validation-v2/internal/entity"
+	"strings"
+	"time"
 )
 
 type TaskMySQL struct {
@@ -17,8 +19,8 @@ func NewTaskMySQL(db *sql.DB) *TaskMySQL {
 
 func (r *TaskMySQL) Create(t *entity.Task) (string, error) {
 	stmt, err := r.db.Prepare(`
-		INSERT INTO tasks (assigner_id, ID, user_id, requirement_id, note, fulfillment_status, allowed, deadline, num_of_prerequisite, total_reviewer) 
-		values(?,?,?,?,?,?,?,?,?,?)`)
+		INSERT INTO tasks (assigner_id, ID, user_id, requirement_id, note, fulfillment_status, allowed, deadline, num_of_prerequisite, total_reviewer, created_at) 
+		values(?,?,?,?,?,?,?,?,?,?,?)`)
 
 	if err != nil {
 		return t.ID, err
@@ -35,6 +37,7 @@ func (r *TaskMySQL) Create(t *entity.Task) (string, error) {
 		t.Deadline,
 		t.NumOfPrerequisite,
 		t.NumOfReviewer,
+		t.CreatedAt,
 	)
 	if err != nil {
 		return t.ID, err
@@ -46,7 +49,7 @@ func (r *TaskMySQL) Create(t *entity.Task) (string, error) {
 func (r *TaskMySQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.allowed, tasks.user_id, tasks.fulfillment_status, tasks.num_of_prerequisite, tasks.deadline
 								FROM prerequisite INNER JOIN tasks on tasks.id = prerequisite.task_id
-								 WHERE prerequisite = ?"`)
+								 WHERE prerequisite = ? AND prerequisite.resolved_at IS NULL AND prerequisite.deleted_at IS NULL AND tasks.deleted_at IS NULL`)
 
 	if err != nil {
 		return nil, err
@@ -66,7 +69,7 @@ func (r *TaskMySQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 		}
 		affectedTasks = append(affectedTasks, &t)
 	}
-	_, err = r.db.Exec("DELETE FROM prerequisite WHERE prerequisite=?", taskID)
+	_, err = r.db.Exec("UPDATE prerequisite SET resolved_at = ? WHERE prerequisite = ? AND resolved_at IS NULL AND deleted_at IS NULL", time.Now(), taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,8 +77,8 @@ func (r *TaskMySQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 }
 
 func (r *TaskMySQL) Get(id string) (*entity.Task, error) {
-	stmt, err := r.db.Prepare(`SELECT id, requirement_id, allowed, user_id, fulfillment_status, num_of_prerequisite, deadline 
-								from tasks where id = ?`)
+	stmt, err := r.db.Prepare(`SELECT id, requirement_id, allowed, user_id, fulfillment_status, num_of_prerequisite, deadline, created_at, reviewed_at 
+								from tasks where id = ? AND deleted_at IS NULL`)
 	var task entity.Task
 	if err != nil {
 		return nil, err
@@ -84,21 +87,56 @@ func (r *TaskMySQL) Get(id string) (*entity.Task, error) {
 	if row == nil {
 		return nil, err
 	}
+	var reviewedAt sql.NullTime
 	err = row.Scan(&task.ID, &task.RequirementID, &task.Allowed, &task.UserID,
-		&task.Status, &task.NumOfPrerequisite, &task.Deadline)
+		&task.Status, &task.NumOfPrerequisite, &task.Deadline, &task.CreatedAt, &reviewedAt)
 	if err != nil {
 		return nil, err
 	}
+	task.ReviewedAt = reviewedAt.Time
 	return &task, nil
 
 }
 
+func (r *TaskMySQL) GetByOrderID(orderID string) ([]*entity.TaskWithDetails, error) {
+	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.note, users.username, tasks.deadline, requirements.request, 
+								requirements.expected_outcome,orders.title, tasks.requirement_id, tasks.fulfillment_status, tasks.user_id, 
+								COALESCE((SELECT GROUP_CONCAT(prerequisite) FROM prerequisite WHERE prerequisite.task_id = tasks.id AND prerequisite.deleted_at IS NULL), '') 
+								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
+								INNER JOIN users ON users.id = tasks.user_id
+								INNER JOIN orders ON requirements.order_id = orders.id 
+								WHERE orders.id = ? AND tasks.deleted_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*entity.TaskWithDetails
+	rows, err := stmt.Query(orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var task entity.TaskWithDetails
+		var prerequisites string
+		err = rows.Scan(&task.ID, &task.Note, &task.Username, &task.Deadline, &task.Request, &task.ExpectedOutcome, &task.OrderTitle,
+			&task.RequirementID, &task.Status, &task.UserID, &prerequisites)
+		if err != nil {
+			return nil, err
+		}
+		if prerequisites != "" {
+			task.Prerequisites = strings.Split(prerequisites, ",")
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, rows.Err()
+}
+
 func (r *TaskMySQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.deadline, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline,tasks.fulfillment_status
+								orders.title, orders.description, orders.deadline,tasks.fulfillment_status, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
-								INNER JOIN orders ON requirements.order_id = orders.id 
-								where user_id = ? and tasks.allowed = true`)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								where user_id = ? and tasks.allowed = true AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -109,11 +147,13 @@ func (r *TaskMySQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Deadline, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
-			&t.Status)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Deadline, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
+			&t.Status)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		tasks = append(tasks, &t)
 	}
 
@@ -122,8 +162,8 @@ func (r *TaskMySQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error
 }
 func (r *TaskMySQL) Update(e *entity.Task) error {
 	_, err := r.db.Exec(`UPDATE tasks SET user_id = ?, fulfillment_status = ?, deadline = ?, num_of_prerequisite = ?,
-						 allowed = ?, total_reviewer = ? where id = ?`,
-		e.UserID, e.Status, e.Deadline, e.NumOfPrerequisite, e.Allowed, e.NumOfReviewer, e.ID)
+						 allowed = ?, total_reviewer = ?, reviewed_at = ? where id = ?`,
+		e.UserID, e.Status, e.Deadline, e.NumOfPrerequisite, e.Allowed, e.NumOfReviewer, nullTime(e.ReviewedAt), e.ID)
 	if err != nil {
 		return err
 	}
@@ -132,10 +172,11 @@ func (r *TaskMySQL) Update(e *entity.Task) error {
 
 func (r *TaskMySQL) List() ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.deadline, users.username, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline, tasks.fulfillment_status 
+								orders.title, orders.description, orders.deadline, tasks.fulfillment_status, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
 								INNER JOIN users on users.id = tasks.user_id
-								INNER JOIN orders ON requirements.order_id = orders.id `)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								WHERE tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -146,11 +187,13 @@ func (r *TaskMySQL) List() ([]*entity.TaskWithDetails, error) {
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
-			&t.Status)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
+			&t.Status)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		tasks = append(tasks, &t)
 	}
 	if len(tasks) == 0 {
@@ -163,11 +206,11 @@ func (r *TaskMySQL) List() ([]*entity.TaskWithDetails, error) {
 
 func (r *TaskMySQL) GetTasksToReview() ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.deadline, users.username, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline, tasks.fulfillment_status 
+								orders.title, orders.description, orders.deadline, tasks.fulfillment_status, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
 								INNER JOIN users on users.id = tasks.user_id
-								INNER JOIN orders ON requirements.order_id = orders.id 
-								WHERE tasks.fulfillment_status = 1`)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								WHERE tasks.fulfillment_status = 1 AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -178,11 +221,13 @@ func (r *TaskMySQL) GetTasksToReview() ([]*entity.TaskWithDetails, error) {
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
-			&t.Status)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
+			&t.Status)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		tasks = append(tasks, &t)
 	}
 	if len(tasks) == 0 {
@@ -194,9 +239,50 @@ func (r *TaskMySQL) GetTasksToReview() ([]*entity.TaskWithDetails, error) {
 }
 
 func (r *TaskMySQL) Delete(TaskID string) error {
-	_, err := r.db.Exec("DELETE FROM task where requirement_id = ?", TaskID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE tasks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		`UPDATE submissions SET deleted_at = ? WHERE task_id = ? AND deleted_at IS NULL`,
+		`UPDATE prerequisite SET deleted_at = ? WHERE prerequisite = ? AND deleted_at IS NULL`,
+		`UPDATE tasks SET allowed = num_of_prerequisite <= 1, num_of_prerequisite = num_of_prerequisite - 1 
+		 WHERE id IN (SELECT task_id FROM prerequisite WHERE deleted_at = ? AND prerequisite = ? AND resolved_at IS NULL)`,
+	}, time.Now(), TaskID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *TaskMySQL) Restore(id string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM tasks WHERE id = ? AND deleted_at IS NOT NULL 
+					   AND requirement_id IN (SELECT id FROM requirements WHERE deleted_at IS NULL) FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = ? AND task_id = ?`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = ? AND id = ?`,
+		`UPDATE prerequisite SET resolved_at = deleted_at WHERE deleted_at = ? AND prerequisite = ? AND resolved_at IS NULL
+		 AND task_id IN (SELECT id FROM tasks WHERE fulfillment_status <> 0)`,
+		`UPDATE tasks SET allowed = false, num_of_prerequisite = num_of_prerequisite + 1 
+		 WHERE id IN (SELECT task_id FROM prerequisite WHERE deleted_at = ? AND prerequisite = ? AND resolved_at IS NULL)`,
+		`UPDATE prerequisite SET deleted_at = NULL WHERE deleted_at = ? AND prerequisite = ?`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
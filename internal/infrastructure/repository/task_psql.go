@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"order-validation-v2/internal/entity"
+	"strings"
+	"time"
 )
 
 type TaskPSQL struct {
@@ -30,8 +32,8 @@ func (r *TaskPSQL) AddPrerequisite(taskID string, prerequisite string) error {
 }
 func (r *TaskPSQL) Create(t *entity.Task) (string, error) {
 	stmt, err := r.db.Prepare(`
-		INSERT INTO tasks (assigner_id, ID, user_id, requirement_id, note, fulfillment_status, allowed, deadline, num_of_prerequisite, total_reviewer) 
-		values($1,$2,$3,$4,$5,$6,$7,$8, $9, $10)`)
+		INSERT INTO tasks (assigner_id, ID, user_id, requirement_id, note, fulfillment_status, allowed, deadline, num_of_prerequisite, total_reviewer, created_at) 
+		values($1,$2,$3,$4,$5,$6,$7,$8, $9, $10, $11)`)
 
 	if err != nil {
 		return t.ID, err
@@ -48,6 +50,7 @@ func (r *TaskPSQL) Create(t *entity.Task) (string, error) {
 		t.Deadline,
 		t.NumOfPrerequisite,
 		t.NumOfReviewer,
+		t.CreatedAt,
 	)
 	if err != nil {
 		return t.ID, err
@@ -58,11 +61,12 @@ func (r *TaskPSQL) Create(t *entity.Task) (string, error) {
 
 func (r *TaskPSQL) GetByOrderID(orderID string) ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.note, users.username, tasks.deadline, requirements.request, 
-								requirements.expected_outcome,orders.title 
+								requirements.expected_outcome,orders.title, tasks.requirement_id, tasks.fulfillment_status, tasks.user_id, 
+								COALESCE((SELECT string_agg(prerequisite, ',') FROM prerequisite WHERE prerequisite.task_id = tasks.id AND prerequisite.deleted_at IS NULL), '') 
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
 								INNER JOIN users ON users.id = tasks.user_id
 								INNER JOIN orders ON requirements.order_id = orders.id 
-								WHERE orders.id = $1`)
+								WHERE orders.id = $1 AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -73,10 +77,15 @@ func (r *TaskPSQL) GetByOrderID(orderID string) ([]*entity.TaskWithDetails, erro
 	}
 	for rows.Next() {
 		var task entity.TaskWithDetails
-		err = rows.Scan(&task.ID, &task.Note, &task.Username, &task.Deadline, &task.Request, &task.ExpectedOutcome, &task.OrderTitle)
+		var prerequisites string
+		err = rows.Scan(&task.ID, &task.Note, &task.Username, &task.Deadline, &task.Request, &task.ExpectedOutcome, &task.OrderTitle,
+			&task.RequirementID, &task.Status, &task.UserID, &prerequisites)
 		if err != nil {
 			return nil, err
 		}
+		if prerequisites != "" {
+			task.Prerequisites = strings.Split(prerequisites, ",")
+		}
 		tasks = append(tasks, &task)
 	}
 	return tasks, nil
@@ -87,7 +96,7 @@ func (r *TaskPSQL) GetPrerequisites(taskID string) ([]string, error) {
 	stmt, err := r.db.Prepare(`SELECT requirements.request
 							  	FROM prerequisite INNER JOIN tasks on prerequisite.task_id = tasks.id
 								INNER JOIN requirements ON tasks.requirement_id = requirements.id
-								WHERE prerequisite = $1`)
+								WHERE prerequisite = $1 AND prerequisite.resolved_at IS NULL AND prerequisite.deleted_at IS NULL AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +116,7 @@ func (r *TaskPSQL) GetPrerequisites(taskID string) ([]string, error) {
 func (r *TaskPSQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.allowed, tasks.user_id, tasks.fulfillment_status, tasks.num_of_prerequisite, tasks.deadline
 							  	FROM prerequisite INNER JOIN tasks on prerequisite.task_id = tasks.id
-								WHERE prerequisite = $1`)
+								WHERE prerequisite = $1 AND prerequisite.resolved_at IS NULL AND prerequisite.deleted_at IS NULL AND tasks.deleted_at IS NULL`)
 
 	if err != nil {
 		return nil, err
@@ -127,7 +136,7 @@ func (r *TaskPSQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 		}
 		affectedTasks = append(affectedTasks, &t)
 	}
-	_, err = r.db.Exec("DELETE FROM prerequisite WHERE prerequisite=$1", taskID)
+	_, err = r.db.Exec("UPDATE prerequisite SET resolved_at = $1 WHERE prerequisite = $2 AND resolved_at IS NULL AND deleted_at IS NULL", time.Now(), taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,8 +144,8 @@ func (r *TaskPSQL) RemovePrerequisite(taskID string) ([]*entity.Task, error) {
 }
 
 func (r *TaskPSQL) Get(id string) (*entity.Task, error) {
-	stmt, err := r.db.Prepare(`SELECT id, requirement_id, allowed, user_id, fulfillment_status, num_of_prerequisite, deadline 
-								from tasks where id = $1`)
+	stmt, err := r.db.Prepare(`SELECT id, requirement_id, allowed, user_id, fulfillment_status, num_of_prerequisite, deadline, created_at, reviewed_at 
+								from tasks where id = $1 AND deleted_at IS NULL`)
 	var task entity.Task
 	if err != nil {
 		return nil, err
@@ -146,21 +155,23 @@ func (r *TaskPSQL) Get(id string) (*entity.Task, error) {
 	if row == nil {
 		return nil, err
 	}
+	var reviewedAt sql.NullTime
 	err = row.Scan(&task.ID, &task.RequirementID, &task.Allowed, &task.UserID,
-		&task.Status, &task.NumOfPrerequisite, &task.Deadline)
+		&task.Status, &task.NumOfPrerequisite, &task.Deadline, &task.CreatedAt, &reviewedAt)
 	if err != nil {
 		return nil, err
 	}
+	task.ReviewedAt = reviewedAt.Time
 	return &task, nil
 
 }
 
 func (r *TaskPSQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.note, tasks.deadline, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline,tasks.fulfillment_status
+								orders.title, orders.description, orders.deadline,tasks.fulfillment_status, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
-								INNER JOIN orders ON requirements.order_id = orders.id 
-								where user_id = $1 and tasks.allowed = true`)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								where user_id = $1 and tasks.allowed = true AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -171,11 +182,13 @@ func (r *TaskPSQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error)
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Note, &t.Deadline, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
-			&t.Status)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Note, &t.Deadline, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
+			&t.Status)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		t.Messages, err = r.GetReviewMessages(t.ID)
 		if err != nil {
 			return nil, err
@@ -188,8 +201,8 @@ func (r *TaskPSQL) GetbyUserID(userID string) ([]*entity.TaskWithDetails, error)
 }
 func (r *TaskPSQL) Update(e *entity.Task) error {
 	_, err := r.db.Exec(`UPDATE tasks SET user_id = $1, fulfillment_status = $2, deadline = $3, num_of_prerequisite = $4,
-						 allowed = $5, total_reviewer = $6 where id = $7`,
-		e.UserID, e.Status, e.Deadline, e.NumOfPrerequisite, e.Allowed, e.NumOfReviewer, e.ID)
+						 allowed = $5, total_reviewer = $6, reviewed_at = $7 where id = $8`,
+		e.UserID, e.Status, e.Deadline, e.NumOfPrerequisite, e.Allowed, e.NumOfReviewer, nullTime(e.ReviewedAt), e.ID)
 	if err != nil {
 		return err
 	}
@@ -198,10 +211,11 @@ func (r *TaskPSQL) Update(e *entity.Task) error {
 
 func (r *TaskPSQL) List() ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.note, tasks.deadline, users.username, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline, tasks.fulfillment_status, tasks.num_of_prerequisite 
+								orders.title, orders.description, orders.deadline, tasks.fulfillment_status, tasks.num_of_prerequisite, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
 								INNER JOIN users on users.id = tasks.user_id
-								INNER JOIN orders ON requirements.order_id = orders.id `)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								WHERE tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -212,11 +226,13 @@ func (r *TaskPSQL) List() ([]*entity.TaskWithDetails, error) {
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Note, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle,
-			&t.OrderDescription, &t.OrderDeadline, &t.Status, &t.NumOfPrerequisite)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Note, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle,
+			&t.OrderDescription, &t.OrderDeadline, &t.Status, &t.NumOfPrerequisite)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		t.Messages, err = r.GetReviewMessages(t.ID)
 		if err != nil {
 			return nil, err
@@ -233,12 +249,13 @@ func (r *TaskPSQL) List() ([]*entity.TaskWithDetails, error) {
 
 func (r *TaskPSQL) GetTasksToReview(adminID string) ([]*entity.TaskWithDetails, error) {
 	stmt, err := r.db.Prepare(`SELECT tasks.id, tasks.note, tasks.deadline, users.username, requirements.request, requirements.expected_outcome,  
-								orders.title, orders.description, orders.deadline 
+								orders.title, orders.description, orders.deadline, tasks.fulfillment_status, ` + taskSLAColumns + `
 								FROM tasks INNER JOIN requirements ON tasks.requirement_id=requirements.id 
 								INNER JOIN users ON users.id = tasks.user_id
 								LEFT JOIN forwarded_review ON tasks.id = forwarded_review.task_id
-								INNER JOIN orders ON requirements.order_id = orders.id 
-								WHERE tasks.fulfillment_status = 1 and (tasks.assigner_id = $1 or forwarded_review.reviewer_id = $1)`)
+								INNER JOIN orders ON requirements.order_id = orders.id ` + taskSLAJoin + `
+								WHERE tasks.fulfillment_status = 1 and (tasks.assigner_id = $1 or forwarded_review.reviewer_id = $1) 
+								AND tasks.deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -249,10 +266,13 @@ func (r *TaskPSQL) GetTasksToReview(adminID string) ([]*entity.TaskWithDetails,
 	}
 	for rows.Next() {
 		var t entity.TaskWithDetails
-		err = rows.Scan(&t.ID, &t.Note, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline)
+		var sla taskSLA
+		err = rows.Scan(sla.scan(&t, &t.ID, &t.Note, &t.Deadline, &t.Username, &t.Request, &t.ExpectedOutcome, &t.OrderTitle, &t.OrderDescription, &t.OrderDeadline,
+			&t.Status)...)
 		if err != nil {
 			return nil, err
 		}
+		sla.apply(&t)
 		/*
 			t.Messages, err = r.GetReviewMessages(t.ID)
 			if err != nil {
@@ -269,11 +289,52 @@ func (r *TaskPSQL) GetTasksToReview(adminID string) ([]*entity.TaskWithDetails,
 }
 
 func (r *TaskPSQL) Delete(TaskID string) error {
-	_, err := r.db.Exec("DELETE FROM task where requirement_id = $1", TaskID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`UPDATE tasks SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`,
+		`UPDATE submissions SET deleted_at = $1 WHERE task_id = $2 AND deleted_at IS NULL`,
+		`UPDATE prerequisite SET deleted_at = $1 WHERE prerequisite = $2 AND deleted_at IS NULL`,
+		`UPDATE tasks SET allowed = num_of_prerequisite <= 1, num_of_prerequisite = num_of_prerequisite - 1 
+		 WHERE id IN (SELECT task_id FROM prerequisite WHERE deleted_at = $1 AND prerequisite = $2 AND resolved_at IS NULL)`,
+	}, time.Now(), TaskID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *TaskPSQL) Restore(id string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	var deletedAt time.Time
+	err = tx.QueryRow(`SELECT deleted_at FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL 
+					   AND requirement_id IN (SELECT id FROM requirements WHERE deleted_at IS NULL) FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	err = execAll(tx, []string{
+		`UPDATE submissions SET deleted_at = NULL WHERE deleted_at = $1 AND task_id = $2`,
+		`UPDATE tasks SET deleted_at = NULL WHERE deleted_at = $1 AND id = $2`,
+		`UPDATE prerequisite SET resolved_at = deleted_at WHERE deleted_at = $1 AND prerequisite = $2 AND resolved_at IS NULL
+		 AND task_id IN (SELECT id FROM tasks WHERE fulfillment_status <> 0)`,
+		`UPDATE tasks SET allowed = false, num_of_prerequisite = num_of_prerequisite + 1 
+		 WHERE id IN (SELECT task_id FROM prerequisite WHERE deleted_at = $1 AND prerequisite = $2 AND resolved_at IS NULL)`,
+		`UPDATE prerequisite SET deleted_at = NULL WHERE deleted_at = $1 AND prerequisite = $2`,
+	}, deletedAt, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 func (r *TaskPSQL) AddReviewer(TaskID string, NewReviewerID string) error {
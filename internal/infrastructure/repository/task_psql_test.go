@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskDelete(t *testing.T) {
+	db, fake := newFakeDB(nil)
+	err := NewTaskPSQL(db).Delete("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.log[0] != "BEGIN" || fake.log[len(fake.log)-1] != "COMMIT" {
+		t.Fatalf("statements = %q, want them in one transaction", fake.log)
+	}
+	queries, args := fake.statements("UPDATE")
+	if len(queries) != 4 {
+		t.Fatalf("got %d updates, want 4", len(queries))
+	}
+	for i := range args {
+		if args[i][0] != args[0][0] || args[i][1] != "t1" {
+			t.Errorf("%s: args = %v, want the same deletion time and task", queries[i], args[i])
+		}
+	}
+	//the release goes through the edges stamped just before it
+	edges := fake.index("UPDATE prerequisite SET deleted_at = $1")
+	release := fake.index("num_of_prerequisite = num_of_prerequisite - 1")
+	if edges < 0 || release < edges {
+		t.Errorf("edges stamped at %d, dependents released at %d", edges, release)
+	}
+	if !strings.Contains(fake.log[release], "resolved_at IS NULL") {
+		t.Errorf("release %q counts resolved edges", fake.log[release])
+	}
+}
+
+func TestTaskRestore(t *testing.T) {
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tt := []struct {
+		name     string
+		deleted  bool
+		restored bool
+		last     string
+	}{
+		{name: "deleted task", deleted: true, restored: true, last: "COMMIT"},
+		{name: "missing task", deleted: false, restored: false, last: "ROLLBACK"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db, fake := newFakeDB(func(query string) ([]string, [][]driver.Value) {
+				if !tc.deleted {
+					return nil, nil
+				}
+				return []string{"deleted_at"}, [][]driver.Value{{deletedAt}}
+			})
+			ok, err := NewTaskPSQL(db).Restore("t1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.restored {
+				t.Errorf("Restore = %v, want %v", ok, tc.restored)
+			}
+			if last := fake.log[len(fake.log)-1]; last != tc.last {
+				t.Errorf("last statement = %q, want %q", last, tc.last)
+			}
+			queries, args := fake.statements("UPDATE")
+			if !tc.deleted {
+				if len(queries) != 0 {
+					t.Errorf("ran %q for a task that isn't deleted", queries)
+				}
+				return
+			}
+			for i := range args {
+				if args[i][0] != deletedAt || args[i][1] != "t1" {
+					t.Errorf("%s: args = %v, want the deletion time and task", queries[i], args[i])
+				}
+			}
+			//dependents submitted while the task was deleted keep their edge
+			//resolved instead of being blocked again
+			resolve := fake.index("SET resolved_at = deleted_at")
+			block := fake.index("num_of_prerequisite = num_of_prerequisite + 1")
+			edges := fake.index("UPDATE prerequisite SET deleted_at = NULL")
+			if resolve < 0 || block < resolve || edges < block {
+				t.Fatalf("resolved at %d, blocked at %d, edges restored at %d", resolve, block, edges)
+			}
+			if !strings.Contains(fake.log[resolve], "fulfillment_status <> 0") {
+				t.Errorf("resolve %q doesn't pick the submitted dependents", fake.log[resolve])
+			}
+			if !strings.Contains(fake.log[block], "resolved_at IS NULL") {
+				t.Errorf("block %q counts resolved edges", fake.log[block])
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+type TemplatePSQL struct {
+	db *sql.DB
+}
+
+func NewTemplatePSQL(db *sql.DB) *TemplatePSQL {
+	return &TemplatePSQL{
+		db: db,
+	}
+}
+
+func (r *TemplatePSQL) Create(t *entity.OrderTemplate) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return t.ID, err
+	}
+	_, err = tx.Exec(`INSERT INTO order_templates (id, name, title, description, deadline_offset, created_by, created_at) 
+					  values($1,$2,$3,$4,$5,$6,$7)`,
+		t.ID, t.Name, t.Title, t.Description, int64(t.DeadlineOffset/time.Second), t.CreatedBy, t.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return t.ID, err
+	}
+	for position, req := range t.Requirements {
+		_, err = tx.Exec(`INSERT INTO template_requirements (template_id, position, request, expected_outcome) values($1,$2,$3,$4)`,
+			t.ID, position, req.Request, req.ExpectedOutcome)
+		if err != nil {
+			tx.Rollback()
+			return t.ID, err
+		}
+		for _, task := range req.Tasks {
+			_, err = tx.Exec(`INSERT INTO template_tasks (template_id, task_key, requirement_position, user_id, note, deadline_offset) 
+							  values($1,$2,$3,$4,$5,$6)`,
+				t.ID, task.Key, position, task.UserID, task.Note, int64(task.DeadlineOffset/time.Second))
+			if err != nil {
+				tx.Rollback()
+				return t.ID, err
+			}
+			for _, prerequisite := range task.Prerequisites {
+				_, err = tx.Exec(`INSERT INTO template_task_prerequisites (template_id, task_key, prerequisite_key) values($1,$2,$3)`,
+					t.ID, task.Key, prerequisite)
+				if err != nil {
+					tx.Rollback()
+					return t.ID, err
+				}
+			}
+		}
+	}
+	return t.ID, tx.Commit()
+}
+
+func (r *TemplatePSQL) Get(id string) (*entity.OrderTemplate, error) {
+	var t entity.OrderTemplate
+	var offset int64
+	err := r.db.QueryRow(`SELECT id, name, title, description, deadline_offset, created_by, created_at 
+						  FROM order_templates WHERE id = $1`, id).
+		Scan(&t.ID, &t.Name, &t.Title, &t.Description, &offset, &t.CreatedBy, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.DeadlineOffset = time.Duration(offset) * time.Second
+
+	rows, err := r.db.Query(`SELECT request, expected_outcome FROM template_requirements 
+							 WHERE template_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var req entity.TemplateRequirement
+		err = rows.Scan(&req.Request, &req.ExpectedOutcome)
+		if err != nil {
+			return nil, err
+		}
+		t.Requirements = append(t.Requirements, req)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prerequisites, err := r.prerequisites(id)
+	if err != nil {
+		return nil, err
+	}
+	taskRows, err := r.db.Query(`SELECT task_key, requirement_position, user_id, note, deadline_offset FROM template_tasks 
+								 WHERE template_id = $1 ORDER BY requirement_position, task_key`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer taskRows.Close()
+	for taskRows.Next() {
+		var task entity.TemplateTask
+		var position int
+		err = taskRows.Scan(&task.Key, &position, &task.UserID, &task.Note, &offset)
+		if err != nil {
+			return nil, err
+		}
+		task.DeadlineOffset = time.Duration(offset) * time.Second
+		task.Prerequisites = prerequisites[task.Key]
+		if position < len(t.Requirements) {
+			t.Requirements[position].Tasks = append(t.Requirements[position].Tasks, task)
+		}
+	}
+	return &t, taskRows.Err()
+}
+
+func (r *TemplatePSQL) prerequisites(id string) (map[string][]string, error) {
+	rows, err := r.db.Query(`SELECT task_key, prerequisite_key FROM template_task_prerequisites WHERE template_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prerequisites := map[string][]string{}
+	for rows.Next() {
+		var key, prerequisite string
+		err = rows.Scan(&key, &prerequisite)
+		if err != nil {
+			return nil, err
+		}
+		prerequisites[key] = append(prerequisites[key], prerequisite)
+	}
+	return prerequisites, rows.Err()
+}
+
+func (r *TemplatePSQL) List() ([]*entity.OrderTemplate, error) {
+	rows, err := r.db.Query(`SELECT id, name, title, description, deadline_offset, created_by, created_at 
+							 FROM order_templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var templates []*entity.OrderTemplate
+	for rows.Next() {
+		var t entity.OrderTemplate
+		var offset int64
+		err = rows.Scan(&t.ID, &t.Name, &t.Title, &t.Description, &offset, &t.CreatedBy, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.DeadlineOffset = time.Duration(offset) * time.Second
+		templates = append(templates, &t)
+	}
+	return templates, rows.Err()
+}
+
+func (r *TemplatePSQL) CreateOrder(o *entity.Orders, reqs []*entity.Requirements, tasks [][]*entity.Task) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO orders (id, title, description, deadline, status, priority, sla_policy, created_at, customer_id) 
+					  values($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		o.ID, o.Title, o.Description, o.Deadline, o.Status, o.Priority, nullString(o.SLAPolicy), o.CreatedAt, nullString(o.CustomerID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i, req := range reqs {
+		err = tx.QueryRow(`INSERT INTO requirements (request, expected_outcome, order_id, status) values($1,$2,$3,'0') RETURNING id`,
+			req.Request, req.ExpectedOutcome, req.OrderID).Scan(&req.Id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, t := range tasks[i] {
+			t.RequirementID = req.Id
+			_, err = tx.Exec(`INSERT INTO tasks (assigner_id, ID, user_id, requirement_id, note, fulfillment_status, allowed, deadline, num_of_prerequisite, total_reviewer, created_at) 
+							  values($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+				t.AssignerID, t.ID, t.UserID, t.RequirementID, t.Note, t.Status, t.Allowed, t.Deadline, t.NumOfPrerequisite, t.NumOfReviewer, t.CreatedAt)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			for _, prerequisite := range t.Prerequisites {
+				_, err = tx.Exec(`INSERT INTO prerequisite (task_id, prerequisite) values($1,$2)`, t.ID, prerequisite)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *TemplatePSQL) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		"DELETE FROM template_task_prerequisites WHERE template_id = $1",
+		"DELETE FROM template_tasks WHERE template_id = $1",
+		"DELETE FROM template_requirements WHERE template_id = $1",
+		"DELETE FROM order_templates WHERE id = $1",
+	} {
+		_, err = tx.Exec(query, id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
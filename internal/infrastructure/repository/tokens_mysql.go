@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type TokenMySQL struct {
+	db *sql.DB
+}
+
+func NewTokenMySQL(db *sql.DB) *TokenMySQL {
+	return &TokenMySQL{
+		db: db,
+	}
+}
+
+func (r *TokenMySQL) Create(t *entity.RefreshToken) (string, error) {
+	stmt, err := r.db.Prepare(`
+		INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, issued_at, expires_at, used, revoked) 
+		values(?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return t.ID, err
+	}
+	_, err = stmt.Exec(
+		t.ID,
+		t.FamilyID,
+		t.UserID,
+		t.TokenHash,
+		t.IssuedAt,
+		t.ExpiresAt,
+		t.Used,
+		t.Revoked,
+	)
+	if err != nil {
+		return t.ID, err
+	}
+	err = stmt.Close()
+	if err != nil {
+		return t.ID, err
+	}
+	return t.ID, nil
+}
+
+func (r *TokenMySQL) GetByHash(tokenHash string) (*entity.RefreshToken, error) {
+	stmt, err := r.db.Prepare(`SELECT id, family_id, user_id, token_hash, issued_at, expires_at, used, revoked 
+								FROM refresh_tokens WHERE token_hash = ?`)
+	if err != nil {
+		return nil, err
+	}
+	var t entity.RefreshToken
+	row := stmt.QueryRow(tokenHash)
+	err = row.Scan(&t.ID, &t.FamilyID, &t.UserID, &t.TokenHash, &t.IssuedAt, &t.ExpiresAt, &t.Used, &t.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TokenMySQL) MarkUsed(id string) (bool, error) {
+	result, err := r.db.Exec("UPDATE refresh_tokens SET used = true WHERE id = ? AND used = false", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *TokenMySQL) RevokeFamily(familyID string) error {
+	_, err := r.db.Exec("UPDATE refresh_tokens SET revoked = true WHERE family_id = ?", familyID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenMySQL) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO revoked_tokens (jti, expires_at) VALUES (?,?) 
+						 ON DUPLICATE KEY UPDATE jti = jti`, jti, expiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenMySQL) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	res, err := r.db.Exec(`INSERT IGNORE INTO revoked_tokens (jti, expires_at) VALUES (?,?)`, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+func (r *TokenMySQL) RevokeUser(userID string, at time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO token_revocations (user_id, revoked_at) VALUES (?,?) 
+						 ON DUPLICATE KEY UPDATE revoked_at = VALUES(revoked_at)`, userID, at)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE refresh_tokens SET revoked = true WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenMySQL) IsRevoked(jti string) (bool, error) {
+	stmt, err := r.db.Prepare("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)")
+	if err != nil {
+		return false, err
+	}
+	var revoked bool
+	row := stmt.QueryRow(jti)
+	err = row.Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+func (r *TokenMySQL) RevokedAt(userID string) (time.Time, error) {
+	stmt, err := r.db.Prepare("SELECT revoked_at FROM token_revocations WHERE user_id = ?")
+	if err != nil {
+		return time.Time{}, err
+	}
+	var revokedAt time.Time
+	row := stmt.QueryRow(userID)
+	err = row.Scan(&revokedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return revokedAt, nil
+}
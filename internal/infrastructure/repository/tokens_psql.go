@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type TokenPSQL struct {
+	db *sql.DB
+}
+
+func NewTokenPSQL(db *sql.DB) *TokenPSQL {
+	return &TokenPSQL{
+		db: db,
+	}
+}
+
+func (r *TokenPSQL) Create(t *entity.RefreshToken) (string, error) {
+	stmt, err := r.db.Prepare(`
+		INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, issued_at, expires_at, used, revoked) 
+		values($1,$2,$3,$4,$5,$6,$7,$8)`)
+	if err != nil {
+		return t.ID, err
+	}
+	_, err = stmt.Exec(
+		t.ID,
+		t.FamilyID,
+		t.UserID,
+		t.TokenHash,
+		t.IssuedAt,
+		t.ExpiresAt,
+		t.Used,
+		t.Revoked,
+	)
+	if err != nil {
+		return t.ID, err
+	}
+	err = stmt.Close()
+	if err != nil {
+		return t.ID, err
+	}
+	return t.ID, nil
+}
+
+func (r *TokenPSQL) GetByHash(tokenHash string) (*entity.RefreshToken, error) {
+	stmt, err := r.db.Prepare(`SELECT id, family_id, user_id, token_hash, issued_at, expires_at, used, revoked 
+								FROM refresh_tokens WHERE token_hash = $1`)
+	if err != nil {
+		return nil, err
+	}
+	var t entity.RefreshToken
+	row := stmt.QueryRow(tokenHash)
+	err = row.Scan(&t.ID, &t.FamilyID, &t.UserID, &t.TokenHash, &t.IssuedAt, &t.ExpiresAt, &t.Used, &t.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TokenPSQL) MarkUsed(id string) (bool, error) {
+	result, err := r.db.Exec("UPDATE refresh_tokens SET used = true WHERE id = $1 AND used = false", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *TokenPSQL) RevokeFamily(familyID string) error {
+	_, err := r.db.Exec("UPDATE refresh_tokens SET revoked = true WHERE family_id = $1", familyID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenPSQL) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1,$2) 
+						 ON CONFLICT (jti) DO NOTHING`, jti, expiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenPSQL) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	res, err := r.db.Exec(`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1,$2) 
+						   ON CONFLICT (jti) DO NOTHING`, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+func (r *TokenPSQL) RevokeUser(userID string, at time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO token_revocations (user_id, revoked_at) VALUES ($1,$2) 
+						 ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at`, userID, at)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE refresh_tokens SET revoked = true WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *TokenPSQL) IsRevoked(jti string) (bool, error) {
+	stmt, err := r.db.Prepare("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)")
+	if err != nil {
+		return false, err
+	}
+	var revoked bool
+	row := stmt.QueryRow(jti)
+	err = row.Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+func (r *TokenPSQL) RevokedAt(userID string) (time.Time, error) {
+	stmt, err := r.db.Prepare("SELECT revoked_at FROM token_revocations WHERE user_id = $1")
+	if err != nil {
+		return time.Time{}, err
+	}
+	var revokedAt time.Time
+	row := stmt.QueryRow(userID)
+	err = row.Scan(&revokedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return revokedAt, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"order-validation-v2/internal/entity"
 )
@@ -20,8 +21,8 @@ func NewUserMySQL(db *sql.DB) *UserMySQL {
 func (r *UserMySQL) Create(u *entity.User) (string, error) {
 
 	stmt, err := r.db.Prepare(`
-		INSERT INTO users (id, username, email, pswd, user_role) 
-		values(?, ?,?, sha2(?,256), ?)`)
+		INSERT INTO users (id, username, email, pswd, user_role, disabled, service_account, totp_secret, totp_enabled, totp_last_step, password_changed_at) 
+		values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return u.ID, err
 	}
@@ -31,6 +32,12 @@ func (r *UserMySQL) Create(u *entity.User) (string, error) {
 		u.Email,
 		u.Password,
 		u.UserRole,
+		u.Disabled,
+		u.ServiceAccount,
+		u.TOTPSecret,
+		u.TOTPEnabled,
+		u.TOTPLastStep,
+		nullTime(u.PasswordChangedAt),
 	)
 	if err != nil {
 		return u.ID, err
@@ -43,36 +50,70 @@ func (r *UserMySQL) Create(u *entity.User) (string, error) {
 }
 
 func (r *UserMySQL) GetbyUsername(username string) (*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role from users where username = ?`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where username = ?`)
 	if err != nil {
 		return nil, err
 	}
 	var user entity.User
+	var changedAt sql.NullTime
 	row := stmt.QueryRow(username)
-	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole)
+	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	user.PasswordChangedAt = changedAt.Time
 	return &user, nil
 }
 
+//ListbyEmail returns every user with the email, which isn't unique
+func (r *UserMySQL) ListbyEmail(email string) ([]*entity.User, error) {
+	rows, err := r.db.Query(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where email = ? ORDER BY username`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []*entity.User
+	for rows.Next() {
+		var user entity.User
+		var changedAt sql.NullTime
+		err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+			&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordChangedAt = changedAt.Time
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
 func (r *UserMySQL) GetbyID(ID string) (*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email, user_role from users where ID = ?`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where ID = ?`)
 	if err != nil {
 		return nil, err
 	}
 	var user entity.User
+	var changedAt sql.NullTime
 	row := stmt.QueryRow(ID)
-	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.UserRole)
+	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	user.PasswordChangedAt = changedAt.Time
 	return &user, nil
 }
 
 func (r *UserMySQL) Update(u *entity.User) error {
-	_, err := r.db.Exec("UPDATE users SET pswd = sha2(?,256),  username = ?, email = ? , user_role = ? where id = ?",
-		u.Password, u.Username, u.Email, u.UserRole, u.ID)
+	_, err := r.db.Exec("UPDATE users SET pswd = ?,  username = ?, email = ? , user_role = ?, disabled = ?, password_changed_at = ? where id = ?",
+		u.Password, u.Username, u.Email, u.UserRole, u.Disabled, nullTime(u.PasswordChangedAt), u.ID)
 	if err != nil {
 		return err
 	}
@@ -80,7 +121,7 @@ func (r *UserMySQL) Update(u *entity.User) error {
 }
 
 func (r *UserMySQL) Search(query string) ([]*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email , user_role FROM users WHERE username like ?`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, user_role, disabled, service_account FROM users WHERE username like ?`)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +132,7 @@ func (r *UserMySQL) Search(query string) ([]*entity.User, error) {
 	}
 	for rows.Next() {
 		var u entity.User
-		err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.UserRole)
+		err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.UserRole, &u.Disabled, &u.ServiceAccount)
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +143,7 @@ func (r *UserMySQL) Search(query string) ([]*entity.User, error) {
 }
 
 func (r *UserMySQL) List() ([]*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT ID, username, email, user_role FROM users`)
+	stmt, err := r.db.Prepare(`SELECT ID, username, email, user_role, disabled, service_account FROM users`)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +155,7 @@ func (r *UserMySQL) List() ([]*entity.User, error) {
 	for rows.Next() {
 		var u entity.User
 		err = rows.Scan(&u.ID,
-			&u.Email, &u.Username, &u.UserRole)
+			&u.Email, &u.Username, &u.UserRole, &u.Disabled, &u.ServiceAccount)
 		if err != nil {
 			return nil, err
 		}
@@ -123,12 +164,28 @@ func (r *UserMySQL) List() ([]*entity.User, error) {
 	return users, nil
 }
 
-func (r *UserMySQL) Delete(ID string) error {
-	_, err := r.db.Exec("DELETE FROM users where id = ?", ID)
+//Delete removes the user along with its sessions, tokens, API keys and
+//other credentials, so nothing issued to it outlives the account
+func (r *UserMySQL) Delete(id string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`DELETE FROM api_key_permissions WHERE key_id IN (SELECT id FROM api_keys WHERE user_id = ?)`,
+		`DELETE FROM api_keys WHERE user_id = ?`,
+		`DELETE FROM sessions WHERE user_id = ?`,
+		`DELETE FROM refresh_tokens WHERE user_id = ?`,
+		`DELETE FROM token_revocations WHERE user_id = ?`,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+		`DELETE FROM password_history WHERE user_id = ?`,
+		`DELETE FROM user_identities WHERE user_id = ?`,
+		`DELETE FROM users WHERE id = ?`,
+	}, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *UserMySQL) CustomQuery(query string) (*sql.Rows, error) {
@@ -138,3 +195,103 @@ func (r *UserMySQL) CustomQuery(query string) (*sql.Rows, error) {
 	}
 	return rows, nil
 }
+func (r *UserMySQL) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO recovery_codes (user_id, code_hash) values(?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hash := range hashes {
+		_, err = stmt.Exec(userID, hash)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = stmt.Close()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *UserMySQL) UseRecoveryCode(userID string, hash string) (bool, error) {
+	res, err := r.db.Exec("UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		time.Now(), userID, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *UserMySQL) AdvanceTOTPStep(userID string, step int64) (bool, error) {
+	res, err := r.db.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *UserMySQL) SetTOTP(userID string, secret string, enabled bool, lastStep int64) error {
+	_, err := r.db.Exec("UPDATE users SET totp_secret = ?, totp_enabled = ?, totp_last_step = ? WHERE id = ?", secret, enabled, lastStep, userID)
+	return err
+}
+
+//AddPasswordHistory deletes the rows older than the keep-th most recent
+func (r *UserMySQL) AddPasswordHistory(userID string, hash string, replacedAt time.Time, keep int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO password_history (user_id, pswd, replaced_at) values(?, ?, ?)", userID, hash, replacedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	//MySQL can't select from the table it deletes from, unless through a
+	//derived table
+	_, err = tx.Exec(`DELETE FROM password_history WHERE user_id = ? AND replaced_at < 
+							(SELECT replaced_at FROM (SELECT replaced_at FROM password_history WHERE user_id = ? 
+							ORDER BY replaced_at DESC LIMIT 1 OFFSET ?) AS oldest)`, userID, userID, keep-1)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *UserMySQL) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	rows, err := r.db.Query("SELECT pswd FROM password_history WHERE user_id = ? ORDER BY replaced_at DESC LIMIT ?", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		err = rows.Scan(&hash)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
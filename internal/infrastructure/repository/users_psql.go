@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"order-validation-v2/internal/entity"
 )
@@ -18,8 +19,8 @@ func NewUserPSQL(db *sql.DB) *UserPSQL {
 
 func (r *UserPSQL) Create(u *entity.User) (string, error) {
 	stmt, err := r.db.Prepare(`
-		INSERT INTO users (id, username, email, pswd, user_role) 
-		values($1, $2, $3, sha256($4), $5)`)
+		INSERT INTO users (id, username, email, pswd, user_role, disabled, service_account, totp_secret, totp_enabled, totp_last_step, password_changed_at) 
+		values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`)
 	if err != nil {
 		return u.ID, err
 	}
@@ -29,6 +30,12 @@ func (r *UserPSQL) Create(u *entity.User) (string, error) {
 		u.Email,
 		u.Password,
 		u.UserRole,
+		u.Disabled,
+		u.ServiceAccount,
+		u.TOTPSecret,
+		u.TOTPEnabled,
+		u.TOTPLastStep,
+		nullTime(u.PasswordChangedAt),
 	)
 	if err != nil {
 		return u.ID, err
@@ -41,36 +48,70 @@ func (r *UserPSQL) Create(u *entity.User) (string, error) {
 }
 
 func (r *UserPSQL) GetbyUsername(username string) (*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role from users where username = $1`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where username = $1`)
 	if err != nil {
 		return nil, err
 	}
 	var user entity.User
+	var changedAt sql.NullTime
 	row := stmt.QueryRow(username)
-	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole)
+	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	user.PasswordChangedAt = changedAt.Time
 	return &user, nil
 }
 
+//ListbyEmail returns every user with the email, which isn't unique
+func (r *UserPSQL) ListbyEmail(email string) ([]*entity.User, error) {
+	rows, err := r.db.Query(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where email = $1 ORDER BY username`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []*entity.User
+	for rows.Next() {
+		var user entity.User
+		var changedAt sql.NullTime
+		err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+			&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordChangedAt = changedAt.Time
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
 func (r *UserPSQL) GetbyID(ID string) (*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role from users where ID = $1`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, pswd, user_role, disabled, service_account, COALESCE(totp_secret, ''), totp_enabled, totp_last_step, password_changed_at from users where ID = $1`)
 	if err != nil {
 		return nil, err
 	}
 	var user entity.User
+	var changedAt sql.NullTime
 	row := stmt.QueryRow(ID)
-	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole)
+	err = row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.UserRole, &user.Disabled, &user.ServiceAccount,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &changedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	user.PasswordChangedAt = changedAt.Time
 	return &user, nil
 }
 
 func (r *UserPSQL) Update(u *entity.User) error {
-	_, err := r.db.Exec("UPDATE users SET pswd = $1,  username = $2, email = $3, user_role = $4 where id = $5",
-		u.Password, u.Username, u.Email, u.UserRole, u.ID)
+	_, err := r.db.Exec("UPDATE users SET pswd = $1,  username = $2, email = $3, user_role = $4, disabled = $5, password_changed_at = $6 where id = $7",
+		u.Password, u.Username, u.Email, u.UserRole, u.Disabled, nullTime(u.PasswordChangedAt), u.ID)
 	if err != nil {
 		return err
 	}
@@ -78,7 +119,7 @@ func (r *UserPSQL) Update(u *entity.User) error {
 }
 
 func (r *UserPSQL) Search(query string) ([]*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT id, username, email, user_role FROM users WHERE username like $1`)
+	stmt, err := r.db.Prepare(`SELECT id, username, email, user_role, disabled, service_account FROM users WHERE username like $1`)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +130,7 @@ func (r *UserPSQL) Search(query string) ([]*entity.User, error) {
 	}
 	for rows.Next() {
 		var u entity.User
-		err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.UserRole)
+		err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.UserRole, &u.Disabled, &u.ServiceAccount)
 		if err != nil {
 			return nil, err
 		}
@@ -100,7 +141,7 @@ func (r *UserPSQL) Search(query string) ([]*entity.User, error) {
 }
 
 func (r *UserPSQL) List() ([]*entity.User, error) {
-	stmt, err := r.db.Prepare(`SELECT ID, username, email, user_role FROM users ORDER BY username`)
+	stmt, err := r.db.Prepare(`SELECT ID, username, email, user_role, disabled, service_account FROM users ORDER BY username`)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +153,7 @@ func (r *UserPSQL) List() ([]*entity.User, error) {
 	for rows.Next() {
 		var u entity.User
 		err = rows.Scan(&u.ID,
-			&u.Username, &u.Email, &u.UserRole)
+			&u.Username, &u.Email, &u.UserRole, &u.Disabled, &u.ServiceAccount)
 		if err != nil {
 			return nil, err
 		}
@@ -121,12 +162,28 @@ func (r *UserPSQL) List() ([]*entity.User, error) {
 	return users, nil
 }
 
-func (r *UserPSQL) Delete(username string) error {
-	_, err := r.db.Exec("DELETE FROM users where username = $1", username)
+//Delete removes the user along with its sessions, tokens, API keys and
+//other credentials, so nothing issued to it outlives the account
+func (r *UserPSQL) Delete(id string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	err = execAll(tx, []string{
+		`DELETE FROM api_key_permissions WHERE key_id IN (SELECT id FROM api_keys WHERE user_id = $1)`,
+		`DELETE FROM api_keys WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`DELETE FROM token_revocations WHERE user_id = $1`,
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM password_history WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM users WHERE id = $1`,
+	}, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *UserPSQL) CheckUsername(username string) (bool, error) {
@@ -142,3 +199,101 @@ func (r *UserPSQL) CheckUsername(username string) (bool, error) {
 	}
 	return true, nil
 }
+
+func (r *UserPSQL) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO recovery_codes (user_id, code_hash) values($1, $2)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hash := range hashes {
+		_, err = stmt.Exec(userID, hash)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = stmt.Close()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *UserPSQL) UseRecoveryCode(userID string, hash string) (bool, error) {
+	res, err := r.db.Exec("UPDATE recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL",
+		time.Now(), userID, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *UserPSQL) AdvanceTOTPStep(userID string, step int64) (bool, error) {
+	res, err := r.db.Exec("UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1", step, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *UserPSQL) SetTOTP(userID string, secret string, enabled bool, lastStep int64) error {
+	_, err := r.db.Exec("UPDATE users SET totp_secret = $1, totp_enabled = $2, totp_last_step = $3 WHERE id = $4", secret, enabled, lastStep, userID)
+	return err
+}
+
+//AddPasswordHistory deletes the rows older than the keep-th most recent
+func (r *UserPSQL) AddPasswordHistory(userID string, hash string, replacedAt time.Time, keep int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO password_history (user_id, pswd, replaced_at) values($1, $2, $3)", userID, hash, replacedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM password_history WHERE user_id = $1 AND replaced_at < 
+							(SELECT replaced_at FROM password_history WHERE user_id = $1 ORDER BY replaced_at DESC LIMIT 1 OFFSET $2)`, userID, keep-1)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *UserPSQL) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	rows, err := r.db.Query("SELECT pswd FROM password_history WHERE user_id = $1 ORDER BY replaced_at DESC LIMIT $2", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		err = rows.Scan(&hash)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
@@ -0,0 +1,32 @@
+package apikeys
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type Reader interface {
+	Get(id string) (*entity.APIKey, error)
+	GetByHash(keyHash string) (*entity.APIKey, error)
+	ListByUser(userID string) ([]*entity.APIKey, error)
+}
+
+type Writer interface {
+	Create(k *entity.APIKey) (string, error)
+	Revoke(id string) error
+	TouchLastUsed(id string, at time.Time) error
+}
+
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	//CreateKey returns the key itself, which is only available here
+	CreateKey(userID string, name string, permissions []entity.Permission, createdBy string, ttl time.Duration) (string, *entity.APIKey, error)
+	Authenticate(key string) (*entity.APIKey, error)
+	GetKey(id string) (*entity.APIKey, error)
+	ListKeys(userID string) ([]*entity.APIKey, error)
+	RevokeKey(id string) error
+}
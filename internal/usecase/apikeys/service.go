@@ -0,0 +1,99 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"order-validation-v2/internal/entity"
+	"strings"
+	"time"
+)
+
+const (
+	keyPrefix = "ovk_"
+	//prefixLength is how much of the key is kept in clear for listings
+	prefixLength = len(keyPrefix) + 8
+	//lastUsedResolution limits last-used writes to one per key and minute
+	lastUsedResolution = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(r Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+func (s *Service) CreateKey(userID string, name string, permissions []entity.Permission, createdBy string, ttl time.Duration) (string, *entity.APIKey, error) {
+	if len(permissions) == 0 {
+		return "", nil, errors.New("an API key needs at least one permission")
+	}
+	for _, p := range permissions {
+		if !entity.ValidPermission(p) {
+			return "", nil, fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	k := entity.NewAPIKey(userID, name, key[:prefixLength], hashKey(key), permissions, createdBy, ttl)
+	if _, err := s.repo.Create(k); err != nil {
+		return "", nil, err
+	}
+	return key, k, nil
+}
+
+func (s *Service) Authenticate(key string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.repo.GetByHash(hashKey(key))
+	if err != nil || k == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if k.Revoked || k.Expired() {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if now.Sub(k.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(k.ID, now); err != nil {
+			return nil, err
+		}
+		k.LastUsedAt = now
+	}
+	return k, nil
+}
+
+func (s *Service) GetKey(id string) (*entity.APIKey, error) {
+	k, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, errors.New("not found")
+	}
+	return k, nil
+}
+
+func (s *Service) ListKeys(userID string) ([]*entity.APIKey, error) {
+	return s.repo.ListByUser(userID)
+}
+
+func (s *Service) RevokeKey(id string) error {
+	return s.repo.Revoke(id)
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package attachments
+
+import (
+	"io"
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	Get(id string) (*entity.Attachment, error)
+	List(resource entity.Resource) ([]*entity.Attachment, error)
+	ListByResources(t entity.ResourceType, ids []string) ([]*entity.Attachment, error)
+	//ListOrphans returns the attachments whose order or requirement was
+	//purged
+	ListOrphans() ([]*entity.Attachment, error)
+}
+
+//Writer interface
+type Writer interface {
+	Create(a *entity.Attachment) (string, error)
+	Delete(id string) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	//Upload stores content and records it as an attachment of resource. The
+	//MIME type is sniffed from the content when mimeType is empty or
+	//generic.
+	Upload(resource entity.Resource, filename string, mimeType string, uploadedBy string, content io.Reader) (*entity.Attachment, error)
+	GetAttachment(id string) (*entity.Attachment, error)
+	//Open returns the attachment and its content, which the caller closes
+	Open(id string) (*entity.Attachment, io.ReadCloser, error)
+	ListAttachments(resource entity.Resource) ([]*entity.Attachment, error)
+	//ListRequirementAttachments groups the attachments of the requirements
+	//by requirement ID
+	ListRequirementAttachments(requirementIDs []int) (map[int][]*entity.Attachment, error)
+	DeleteAttachment(id string) error
+	//PurgeOrphans removes the attachments of purged orders and
+	//requirements along with their content
+	PurgeOrphans() (int, error)
+}
@@ -0,0 +1,176 @@
+package attachments
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/pkg/blobstore"
+)
+
+//MaxSize is the largest attachment accepted, in bytes
+const MaxSize = 25 << 20
+
+const maxFilenameLength = 255
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrInvalidAttachment = errors.New("invalid attachment")
+	ErrTooLarge          = fmt.Errorf("attachment is larger than %d MB", MaxSize>>20)
+)
+
+type Service struct {
+	repo  Repository
+	store blobstore.Store
+}
+
+func NewService(r Repository, s blobstore.Store) *Service {
+	return &Service{
+		repo:  r,
+		store: s,
+	}
+}
+
+//Upload streams content to the blob store while hashing and counting it,
+//so attachments are never held in memory
+func (s *Service) Upload(resource entity.Resource, filename string, mimeType string, uploadedBy string, content io.Reader) (*entity.Attachment, error) {
+	filename = strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if filename == "" || filename == "." || filename == "/" || len(filename) > maxFilenameLength {
+		return nil, fmt.Errorf("%w: filename must be 1 to %d characters", ErrInvalidAttachment, maxFilenameLength)
+	}
+	body := bufio.NewReaderSize(content, 512)
+	head, _ := body.Peek(512)
+	a := entity.NewAttachment(resource, filename, detectMimeType(mimeType, head), uploadedBy)
+
+	hash := sha256.New()
+	var size counter
+	err := s.store.Put(a.ID, io.TeeReader(io.LimitReader(body, MaxSize+1), io.MultiWriter(hash, &size)))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case size > MaxSize:
+		err = ErrTooLarge
+	case size == 0:
+		err = fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	if err != nil {
+		s.store.Delete(a.ID)
+		return nil, err
+	}
+	a.Size = int64(size)
+	a.Checksum = hex.EncodeToString(hash.Sum(nil))
+	_, err = s.repo.Create(a)
+	if err != nil {
+		s.store.Delete(a.ID)
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Service) GetAttachment(id string) (*entity.Attachment, error) {
+	a, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *Service) Open(id string) (*entity.Attachment, io.ReadCloser, error) {
+	a, err := s.GetAttachment(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(a.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("content of attachment %s: %w", a.ID, err)
+	}
+	return a, content, nil
+}
+
+func (s *Service) ListAttachments(resource entity.Resource) ([]*entity.Attachment, error) {
+	return s.repo.List(resource)
+}
+
+func (s *Service) ListRequirementAttachments(requirementIDs []int) (map[int][]*entity.Attachment, error) {
+	grouped := map[int][]*entity.Attachment{}
+	if len(requirementIDs) == 0 {
+		return grouped, nil
+	}
+	ids := make([]string, len(requirementIDs))
+	for i, id := range requirementIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	list, err := s.repo.ListByResources(entity.ResourceRequirement, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range list {
+		id, err := strconv.Atoi(a.Resource.ID)
+		if err != nil {
+			continue
+		}
+		grouped[id] = append(grouped[id], a)
+	}
+	return grouped, nil
+}
+
+//DeleteAttachment removes the record before the content, a failure in
+//between leaves an unreferenced blob rather than a dangling attachment
+func (s *Service) DeleteAttachment(id string) error {
+	a, err := s.GetAttachment(id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Delete(a.ID)
+	if err != nil {
+		return err
+	}
+	return s.store.Delete(a.ID)
+}
+
+func (s *Service) PurgeOrphans() (int, error) {
+	orphans, err := s.repo.ListOrphans()
+	if err != nil {
+		return 0, err
+	}
+	for i, a := range orphans {
+		err = s.repo.Delete(a.ID)
+		if err == nil {
+			err = s.store.Delete(a.ID)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(orphans), nil
+}
+
+//detectMimeType keeps a declared type unless it is missing, malformed or
+//the generic application/octet-stream
+func detectMimeType(declared string, head []byte) string {
+	mediaType, params, err := mime.ParseMediaType(declared)
+	if err != nil || mediaType == "application/octet-stream" {
+		return http.DetectContentType(head)
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
+
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,142 @@
+package attachments
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"order-validation-v2/internal/entity"
+	"strings"
+	"testing"
+)
+
+type fakeRepo struct {
+	Repository
+	attachments map[string]*entity.Attachment
+	err         error
+}
+
+func (r *fakeRepo) Create(a *entity.Attachment) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	r.attachments[a.ID] = a
+	return a.ID, nil
+}
+
+//fakeStore keeps blobs in memory and, like DirectoryStore, only keeps those
+//read to the end
+type fakeStore struct {
+	blobs   map[string][]byte
+	deleted []string
+}
+
+func (s *fakeStore) Put(key string, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.blobs[key] = content
+	return nil
+}
+
+func (s *fakeStore) Get(key string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(s.blobs[key])), nil
+}
+
+func (s *fakeStore) Delete(key string) error {
+	s.deleted = append(s.deleted, key)
+	delete(s.blobs, key)
+	return nil
+}
+
+var errReset = errors.New("connection reset")
+
+//failingReader returns some content then fails, like a dropped upload
+type failingReader struct{ sent bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errReset
+	}
+	r.sent = true
+	return copy(p, "partial content"), nil
+}
+
+var pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+func TestUpload(t *testing.T) {
+	repoErr := errors.New("insert failed")
+	tt := []struct {
+		name     string
+		filename string
+		mimeType string
+		content  io.Reader
+		repoErr  error
+		err      error
+		wantName string
+		wantMime string
+	}{
+		{name: "declared type kept", filename: "notes.txt", mimeType: "text/plain; charset=utf-8", content: strings.NewReader("hello"),
+			wantName: "notes.txt", wantMime: "text/plain; charset=utf-8"},
+		{name: "missing type sniffed", filename: "photo.png", content: strings.NewReader(pngHeader),
+			wantName: "photo.png", wantMime: "image/png"},
+		{name: "generic type sniffed", filename: "spec.pdf", mimeType: "application/octet-stream", content: strings.NewReader("%PDF-1.7\n"),
+			wantName: "spec.pdf", wantMime: "application/pdf"},
+		{name: "malformed type sniffed", filename: "photo.png", mimeType: "image/;;", content: strings.NewReader(pngHeader),
+			wantName: "photo.png", wantMime: "image/png"},
+		{name: "path stripped from filename", filename: `..\..\etc/report.txt`, mimeType: "text/plain", content: strings.NewReader("report"),
+			wantName: "report.txt", wantMime: "text/plain"},
+		{name: "largest size", filename: "big.bin", mimeType: "application/zip", content: io.LimitReader(zeros{}, MaxSize),
+			wantName: "big.bin", wantMime: "application/zip"},
+		{name: "too large", filename: "big.bin", content: io.LimitReader(zeros{}, MaxSize+1), err: ErrTooLarge},
+		{name: "empty", filename: "empty.txt", content: strings.NewReader(""), err: ErrInvalidAttachment},
+		{name: "no filename", filename: " ", content: strings.NewReader("hello"), err: ErrInvalidAttachment},
+		{name: "filename too long", filename: strings.Repeat("a", maxFilenameLength+1), content: strings.NewReader("hello"), err: ErrInvalidAttachment},
+		{name: "failed read", filename: "notes.txt", content: &failingReader{}, err: errReset},
+		{name: "failed insert", filename: "notes.txt", content: strings.NewReader("hello"), repoErr: repoErr, err: repoErr},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepo{attachments: map[string]*entity.Attachment{}, err: tc.repoErr}
+			store := &fakeStore{blobs: map[string][]byte{}}
+			resource := entity.Resource{Type: entity.ResourceOrder, ID: "o1"}
+			a, err := NewService(repo, store).Upload(resource, tc.filename, tc.mimeType, "u1", tc.content)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("Upload error = %v, want %v", err, tc.err)
+				}
+				//nothing is left behind in the store or the repository
+				if len(store.blobs) != 0 || len(repo.attachments) != 0 {
+					t.Errorf("kept %d blobs and %d attachments after %v", len(store.blobs), len(repo.attachments), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Filename != tc.wantName || a.MimeType != tc.wantMime {
+				t.Errorf("attachment is %q of type %q, want %q of type %q", a.Filename, a.MimeType, tc.wantName, tc.wantMime)
+			}
+			content := store.blobs[a.ID]
+			sum := sha256.Sum256(content)
+			if a.Size != int64(len(content)) || a.Checksum != hex.EncodeToString(sum[:]) {
+				t.Errorf("attachment size %d checksum %s don't match the %d bytes stored", a.Size, a.Checksum, len(content))
+			}
+			if repo.attachments[a.ID] != a || a.Resource != resource || a.UploadedBy != "u1" {
+				t.Errorf("attachment %+v wasn't recorded", a)
+			}
+		})
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,46 @@
+package certificates
+
+import (
+	"io"
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	Get(id string) (*entity.Certificate, error)
+	//GetLatest returns the last certificate issued for the order
+	GetLatest(orderID string) (*entity.Certificate, error)
+	List(orderID string) ([]*entity.Certificate, error)
+	//Evidence reads what a certificate of the order would attest as it is
+	//now, or nil when the order doesn't exist
+	Evidence(orderID string) (*entity.CertificateContent, error)
+}
+
+//Writer interface
+type Writer interface {
+	Create(c *entity.Certificate) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	//Issue certifies a validated order. Nothing new is issued while the
+	//evidence still matches the latest certificate, which is returned.
+	Issue(orderID string) (*entity.Certificate, error)
+	GetCertificate(id string) (*entity.Certificate, error)
+	GetLatest(orderID string) (*entity.Certificate, error)
+	ListCertificates(orderID string) ([]*entity.Certificate, error)
+	//WritePDF renders the certificate document, byte for byte the one
+	//whose checksum was recorded when it was issued
+	WritePDF(c *entity.Certificate, w io.Writer) error
+	//Verify checks a certificate token's signature and then the state of
+	//the certificate and of its order
+	Verify(token string) (*entity.CertificateVerification, error)
+	//VerifyDocument verifies the token embedded in a certificate PDF and
+	//that the document wasn't altered
+	VerifyDocument(document []byte) (*entity.CertificateVerification, error)
+}
@@ -0,0 +1,197 @@
+package certificates
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+//tokenKey is the info dictionary entry the token is embedded under, so an
+//uploaded certificate can be verified on its own
+const tokenKey = "/CertificateToken"
+
+//the page is A4 in points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 50
+	//lineWidth is how many characters of 10 point Helvetica fit a line
+	lineWidth = 90
+)
+
+//the fonts are the standard ones every reader has, so none is embedded
+const (
+	fontRegular = "/F1"
+	fontBold    = "/F2"
+	fontMono    = "/F3"
+)
+
+type pdfLine struct {
+	font string
+	size int
+	text string
+}
+
+//renderPDF writes the certificate as a plain PDF 1.4 document. It only
+//depends on the certificate, so rendering it again gives the same bytes.
+func renderPDF(c *entity.Certificate, w io.Writer) error {
+	pages := paginate(certificateLines(c))
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 7+2*i))
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (Validation certificate %s) /Producer (order-validation) /CreationDate (D:%s) %s (%s) >>",
+		c.ID, c.IssuedAt.UTC().Format("20060102150405Z"), tokenKey, c.Token))
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents %d 0 R "+
+			"/Resources << /Font << %s 3 0 R %s 4 0 R %s 5 0 R >> >> >>",
+			pageWidth, pageHeight, 8+2*i, fontRegular, fontBold, fontMono))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page), page))
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func certificateLines(c *entity.Certificate) []pdfLine {
+	var lines []pdfLine
+	add := func(font string, size int, text string) {
+		for _, wrapped := range wrap(text, lineLength(font, size)) {
+			lines = append(lines, pdfLine{font: font, size: size, text: wrapped})
+		}
+	}
+	add(fontBold, 18, "Validation Certificate")
+	add(fontRegular, 10, "")
+	add(fontRegular, 10, "Order: "+c.Content.OrderTitle)
+	add(fontRegular, 10, "Order ID: "+c.Content.OrderID)
+	add(fontRegular, 10, "Certificate ID: "+c.ID)
+	add(fontRegular, 10, "Issued: "+formatTime(c.IssuedAt))
+	add(fontRegular, 10, "Content digest (SHA-256):")
+	add(fontMono, 9, c.Digest)
+	for i, req := range c.Content.Requirements {
+		add(fontRegular, 10, "")
+		add(fontBold, 12, fmt.Sprintf("Requirement %d: %s", i+1, req.Request))
+		add(fontRegular, 10, "Expected outcome: "+req.ExpectedOutcome)
+		add(fontRegular, 10, "Approved: "+formatTime(req.ApprovedAt))
+		var reviewers []string
+		for _, r := range req.Reviewers {
+			reviewers = append(reviewers, r.Username)
+		}
+		add(fontRegular, 10, "Reviewers: "+strings.Join(reviewers, ", "))
+		add(fontRegular, 10, "Approved evidence (SHA-256):")
+		for _, e := range req.Evidence {
+			add(fontRegular, 10, fmt.Sprintf("Submission %s, submitted %s", e.SubmissionID, formatTime(e.SubmittedAt)))
+			add(fontMono, 9, e.SHA256)
+		}
+	}
+	add(fontRegular, 10, "")
+	add(fontBold, 12, "Signature")
+	add(fontRegular, 10, "Upload this document to /verify, or send it the token below, to check the signature and the current state of the order.")
+	add(fontMono, 9, c.Token)
+	return lines
+}
+
+//paginate lays the lines out top to bottom and returns the content stream
+//of each page
+func paginate(lines []pdfLine) []string {
+	var pages []string
+	var page strings.Builder
+	y := pageHeight - margin
+	for _, line := range lines {
+		leading := line.size + 4
+		if y-leading < margin && page.Len() > 0 {
+			pages = append(pages, page.String())
+			page.Reset()
+			y = pageHeight - margin
+		}
+		y -= leading
+		if line.text != "" {
+			fmt.Fprintf(&page, "BT %s %d Tf %d %d Td (%s) Tj ET\n", line.font, line.size, margin, y, escape(line.text))
+		}
+	}
+	return append(pages, page.String())
+}
+
+func lineLength(font string, size int) int {
+	if font == fontMono {
+		//Courier glyphs are 0.6 em wide
+		return (pageWidth - 2*margin) * 10 / (6 * size)
+	}
+	return lineWidth * 10 / size
+}
+
+//wrap breaks text into lines of at most width characters, between words
+//where it can
+func wrap(text string, width int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+//escape makes text safe inside a PDF string. The standard fonts only cover
+//Latin-1, anything else is replaced.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
+
+//formatTime leaves unset times empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2/Jan/2006 15:04:05") + " UTC"
+}
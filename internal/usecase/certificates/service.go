@@ -0,0 +1,227 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/pkg/keys"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//TokenUse marks certificate tokens so no other token of the server passes
+//as one
+const TokenUse = "certificate"
+
+//tokenTTL keeps certificates verifiable for as long as anyone is likely to
+//ask. They are signed by the long-lived document keys, whose rotated keys
+//keep verifying, never by the access token keys.
+const tokenTTL = 10 * 365 * 24 * time.Hour
+
+//the names of the checks of a verification
+const (
+	CheckSignature   = "signature"
+	CheckRecord      = "record"
+	CheckDocument    = "document"
+	CheckOrderStatus = "order_status"
+	CheckEvidence    = "evidence"
+	CheckLatest      = "latest"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrNotValidated = errors.New("order is not validated")
+)
+
+type Service struct {
+	repo   Repository
+	orders orders.UseCase
+	keys   *keys.Manager
+	//mu keeps concurrent syncs of an order from issuing it twice
+	mu sync.Mutex
+}
+
+func NewService(r Repository, o orders.UseCase, k *keys.Manager) *Service {
+	return &Service{
+		repo:   r,
+		orders: o,
+		keys:   k,
+	}
+}
+
+func (s *Service) Issue(orderID string) (*entity.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, err := s.orders.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != entity.OrderValidated {
+		return nil, fmt.Errorf("%w: order is %s", ErrNotValidated, o.Status)
+	}
+	content, err := s.repo.Evidence(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, orders.ErrNotFound
+	}
+	digest, err := content.Digest()
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.repo.GetLatest(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Digest == digest {
+		return latest, nil
+	}
+	c := entity.NewCertificate(*content, digest)
+	c.Token, err = s.keys.Sign(jwt.MapClaims{
+		"token_use": TokenUse,
+		"jti":       c.ID,
+		"order_id":  c.Content.OrderID,
+		"digest":    c.Digest,
+	}, tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	err = s.WritePDF(c, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.DocumentChecksum = hex.EncodeToString(hash.Sum(nil))
+	err = s.repo.Create(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Service) GetCertificate(id string) (*entity.Certificate, error) {
+	c, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Service) GetLatest(orderID string) (*entity.Certificate, error) {
+	c, err := s.repo.GetLatest(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Service) ListCertificates(orderID string) ([]*entity.Certificate, error) {
+	return s.repo.List(orderID)
+}
+
+func (s *Service) WritePDF(c *entity.Certificate, w io.Writer) error {
+	return renderPDF(c, w)
+}
+
+func (s *Service) Verify(token string) (*entity.CertificateVerification, error) {
+	v := &entity.CertificateVerification{}
+	claims, err := s.keys.Parse(token)
+	if err != nil || claims["token_use"] != TokenUse {
+		v.Add(CheckSignature, false, "signed by this server")
+		return v, nil
+	}
+	v.Add(CheckSignature, true, "signed by this server")
+	id, _ := claims["jti"].(string)
+	c, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.Token != token || c.Digest != claims["digest"] {
+		v.Add(CheckRecord, false, "the signed digest matches the recorded content")
+		return v, nil
+	}
+	v.Certificate = c
+	digest, err := c.Content.Digest()
+	if err != nil {
+		return nil, err
+	}
+	v.Add(CheckRecord, digest == c.Digest, "the signed digest matches the recorded content")
+	return v, s.checkOrder(v, c)
+}
+
+func (s *Service) VerifyDocument(document []byte) (*entity.CertificateVerification, error) {
+	token := extractToken(document)
+	if token == "" {
+		v := &entity.CertificateVerification{}
+		v.Add(CheckSignature, false, "signed by this server")
+		return v, nil
+	}
+	v, err := s.Verify(token)
+	if err != nil || v.Certificate == nil {
+		return v, err
+	}
+	sum := sha256.Sum256(document)
+	v.Add(CheckDocument, hex.EncodeToString(sum[:]) == v.Certificate.DocumentChecksum, "the document is the one issued")
+	return v, nil
+}
+
+//checkOrder compares the certificate with the order as it is now
+func (s *Service) checkOrder(v *entity.CertificateVerification, c *entity.Certificate) error {
+	o, err := s.orders.GetOrder(c.Content.OrderID)
+	if errors.Is(err, orders.ErrNotFound) {
+		v.Add(CheckOrderStatus, false, "the order no longer exists")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	validated := o.Status == entity.OrderValidated || o.Status == entity.OrderArchived
+	v.Add(CheckOrderStatus, validated, fmt.Sprintf("the order is %s", o.Status))
+	content, err := s.repo.Evidence(c.Content.OrderID)
+	if err != nil {
+		return err
+	}
+	current := ""
+	if content != nil {
+		current, err = content.Digest()
+		if err != nil {
+			return err
+		}
+	}
+	v.Add(CheckEvidence, current == c.Digest, "the approved evidence is unchanged")
+	latest, err := s.repo.GetLatest(c.Content.OrderID)
+	if err != nil {
+		return err
+	}
+	v.Add(CheckLatest, latest != nil && latest.ID == c.ID, "no later certificate was issued for the order")
+	return nil
+}
+
+//extractToken reads the token from the document's info dictionary
+func extractToken(document []byte) string {
+	start := bytes.Index(document, []byte(tokenKey+" ("))
+	if start < 0 {
+		return ""
+	}
+	rest := document[start+len(tokenKey)+2:]
+	end := bytes.IndexByte(rest, ')')
+	if end < 0 {
+		return ""
+	}
+	return string(rest[:end])
+}
@@ -0,0 +1,80 @@
+package certificates
+
+import (
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/pkg/keys"
+	"testing"
+)
+
+type fakeRepo struct {
+	Repository
+	certificates []*entity.Certificate
+}
+
+func (r *fakeRepo) Evidence(orderID string) (*entity.CertificateContent, error) {
+	return &entity.CertificateContent{OrderID: orderID, OrderTitle: "Order"}, nil
+}
+
+func (r *fakeRepo) Create(c *entity.Certificate) error {
+	r.certificates = append(r.certificates, c)
+	return nil
+}
+
+func (r *fakeRepo) Get(id string) (*entity.Certificate, error) {
+	for _, c := range r.certificates {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepo) GetLatest(orderID string) (*entity.Certificate, error) {
+	if len(r.certificates) == 0 {
+		return nil, nil
+	}
+	return r.certificates[len(r.certificates)-1], nil
+}
+
+type fakeOrders struct {
+	orders.UseCase
+}
+
+func (fakeOrders) GetOrder(id string) (*entity.Orders, error) {
+	return &entity.Orders{ID: id, Status: entity.OrderValidated}, nil
+}
+
+func TestVerifyAfterKeyRotations(t *testing.T) {
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := keys.NewLongLivedManager(key, "issuer", "audience")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(&fakeRepo{}, fakeOrders{}, manager)
+	c, err := s.Issue("o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		next, err := keys.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = manager.Rotate(next)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err := s.Verify(c.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid() {
+		t.Errorf("Verify() checks = %+v, want all passed after two rotations", v.Checks)
+	}
+}
@@ -0,0 +1,45 @@
+package comments
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	Get(id string) (*entity.Comment, error)
+	//ListThreads returns a page of the comments starting threads on the
+	//resource, newest first, and how many there are in all
+	ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.Comment, int, error)
+	ListReplies(threadIDs []string) ([]*entity.Comment, error)
+	//History returns the previous bodies of the comment, oldest first
+	History(commentID string) ([]*entity.CommentEdit, error)
+}
+
+//Writer interface
+type Writer interface {
+	Create(c *entity.Comment) error
+	//Update saves the comment's new body and mentions along with the edit
+	//holding its previous body
+	Update(c *entity.Comment, edit *entity.CommentEdit) error
+	Delete(c *entity.Comment, edit *entity.CommentEdit) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	//Post adds a comment starting a thread on the resource
+	Post(resource entity.Resource, authorID string, body string) (*entity.Comment, error)
+	//Reply adds a comment to the thread of the comment replied to
+	Reply(commentID string, authorID string, body string) (*entity.Comment, error)
+	GetComment(id string) (*entity.Comment, error)
+	ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.CommentThread, int, error)
+	//Edit replaces the body of the comment and returns the users it
+	//mentions that the previous body didn't
+	Edit(commentID string, editorID string, body string) (*entity.Comment, []entity.Mention, error)
+	Delete(commentID string, deletedBy string) error
+	History(commentID string) ([]*entity.CommentEdit, error)
+}
@@ -0,0 +1,42 @@
+package comments
+
+import (
+	"regexp"
+	"strings"
+)
+
+//mentionPattern matches @username where the @ doesn't follow a word
+//character, so email addresses aren't taken for mentions
+var mentionPattern = regexp.MustCompile(`(^|[^\w@\\])@([A-Za-z0-9_.\-]+)`)
+
+//inlineCode matches Markdown code spans
+var inlineCode = regexp.MustCompile("`+[^`]*`+")
+
+//parseMentions returns the usernames mentioned in a Markdown body, in the
+//order they first appear. Mentions inside code blocks and code spans are
+//text, as is an escaped \@.
+func parseMentions(body string) []string {
+	seen := map[string]bool{}
+	var usernames []string
+	fenced := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		line = inlineCode.ReplaceAllString(line, " ")
+		for _, match := range mentionPattern.FindAllStringSubmatch(line, -1) {
+			//a mention ending a sentence doesn't take the full stop
+			username := strings.TrimRight(match[2], ".")
+			if username != "" && !seen[username] {
+				seen[username] = true
+				usernames = append(usernames, username)
+			}
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,32 @@
+package comments
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "mentions in order, once each", body: "@bob and @alice, then @bob again", want: []string{"bob", "alice"}},
+		{name: "trailing full stop", body: "Ask @bob.", want: []string{"bob"}},
+		{name: "dotted username", body: "cc @jane.doe.", want: []string{"jane.doe"}},
+		{name: "email address", body: "write to bob@example.com"},
+		{name: "escaped at sign", body: `not a mention: \@bob`},
+		{name: "inline code span", body: "run `@bob` or ``x @alice``, not @carol", want: []string{"carol"}},
+		{name: "fenced code", body: "```\n@bob\n```\n~~~go\n@alice\n~~~\n@carol", want: []string{"carol"}},
+		{name: "indented code", body: "    @bob\n\t@alice\n @carol", want: []string{"carol"}},
+		{name: "at sign alone", body: "meet @ noon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
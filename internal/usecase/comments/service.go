@@ -0,0 +1,231 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/user"
+)
+
+//MaxBodyLength is the longest comment accepted, in characters
+const MaxBodyLength = 10000
+
+//maxMentions bounds the users one comment notifies
+const maxMentions = 20
+
+//the page size of thread lists
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrInvalidComment = errors.New("invalid comment")
+	ErrNotAuthor      = errors.New("only the author can edit a comment")
+	ErrDeleted        = errors.New("comment has been deleted")
+)
+
+type Service struct {
+	repo  Repository
+	users user.UseCase
+}
+
+func NewService(r Repository, u user.UseCase) *Service {
+	return &Service{
+		repo:  r,
+		users: u,
+	}
+}
+
+func (s *Service) Post(resource entity.Resource, authorID string, body string) (*entity.Comment, error) {
+	return s.create(resource, "", authorID, body)
+}
+
+//Reply attaches replies to replies to the thread they are in, threads are
+//a single level deep
+func (s *Service) Reply(commentID string, authorID string, body string) (*entity.Comment, error) {
+	parent, err := s.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Deleted() {
+		return nil, ErrDeleted
+	}
+	threadID := parent.ThreadID
+	if threadID == "" {
+		threadID = parent.ID
+	}
+	return s.create(parent.Resource, threadID, authorID, body)
+}
+
+func (s *Service) create(resource entity.Resource, threadID string, authorID string, body string) (*entity.Comment, error) {
+	body, err := normalizeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	c := entity.NewComment(resource, threadID, authorID, body)
+	author, err := s.users.GetUserbyID(authorID)
+	if err == nil {
+		c.AuthorName = author.Username
+	}
+	c.Mentions = s.resolveMentions(body)
+	err = s.repo.Create(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Service) GetComment(id string) (*entity.Comment, error) {
+	c, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Service) ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.CommentThread, int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	roots, total, err := s.repo.ListThreads(resource, PageLimit(limit), offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	threads := []*entity.CommentThread{}
+	if len(roots) == 0 {
+		return threads, total, nil
+	}
+	byID := map[string]*entity.CommentThread{}
+	var ids []string
+	for _, c := range roots {
+		t := &entity.CommentThread{Comment: c}
+		threads = append(threads, t)
+		byID[c.ID] = t
+		ids = append(ids, c.ID)
+	}
+	replies, err := s.repo.ListReplies(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, c := range replies {
+		if t, ok := byID[c.ThreadID]; ok {
+			t.Replies = append(t.Replies, c)
+		}
+	}
+	return threads, total, nil
+}
+
+func (s *Service) Edit(commentID string, editorID string, body string) (*entity.Comment, []entity.Mention, error) {
+	c, err := s.GetComment(commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Deleted() {
+		return nil, nil, ErrDeleted
+	}
+	if c.AuthorID != editorID {
+		return nil, nil, ErrNotAuthor
+	}
+	body, err = normalizeBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body == c.Body {
+		return c, nil, nil
+	}
+	edit := &entity.CommentEdit{CommentID: c.ID, Body: c.Body, EditedBy: editorID, EditedAt: time.Now()}
+	previous := map[string]bool{}
+	for _, m := range c.Mentions {
+		previous[m.UserID] = true
+	}
+	c.Body = body
+	c.EditedAt = edit.EditedAt
+	c.Mentions = s.resolveMentions(body)
+	err = s.repo.Update(c, edit)
+	if err != nil {
+		return nil, nil, err
+	}
+	var added []entity.Mention
+	for _, m := range c.Mentions {
+		if !previous[m.UserID] {
+			added = append(added, m)
+		}
+	}
+	return c, added, nil
+}
+
+//Delete keeps the comment in its thread, so the replies still read in
+//order, and its body in the history
+func (s *Service) Delete(commentID string, deletedBy string) error {
+	c, err := s.GetComment(commentID)
+	if err != nil {
+		return err
+	}
+	if c.Deleted() {
+		return ErrDeleted
+	}
+	edit := &entity.CommentEdit{CommentID: c.ID, Body: c.Body, EditedBy: deletedBy, EditedAt: time.Now()}
+	c.Body = ""
+	c.Mentions = nil
+	c.DeletedAt = edit.EditedAt
+	return s.repo.Delete(c, edit)
+}
+
+func (s *Service) History(commentID string) ([]*entity.CommentEdit, error) {
+	_, err := s.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.History(commentID)
+}
+
+//resolveMentions looks the mentioned usernames up. A name that isn't a
+//user, or can't be looked up, stays plain text.
+func (s *Service) resolveMentions(body string) []entity.Mention {
+	var mentions []entity.Mention
+	for _, username := range parseMentions(body) {
+		if len(mentions) == maxMentions {
+			break
+		}
+		u, err := s.users.GetUserbyUsername(username)
+		if err != nil || u.Disabled {
+			continue
+		}
+		mentions = append(mentions, entity.Mention{UserID: u.ID, Username: u.Username})
+	}
+	return mentions
+}
+
+//PageLimit is the number of threads a page holds when limit are asked for
+func PageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+func normalizeBody(body string) (string, error) {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		return "", fmt.Errorf("%w: body is empty", ErrInvalidComment)
+	}
+	if !utf8.ValidString(body) || strings.ContainsRune(body, 0) {
+		return "", fmt.Errorf("%w: body is not valid text", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return "", fmt.Errorf("%w: body is longer than %d characters", ErrInvalidComment, MaxBodyLength)
+	}
+	return body, nil
+}
@@ -0,0 +1,167 @@
+package comments
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/user"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	comments map[string]*entity.Comment
+	edits    []*entity.CommentEdit
+	//limit and offset are those ListThreads was called with
+	limit, offset int
+}
+
+func newFakeRepo(comments ...*entity.Comment) *fakeRepo {
+	r := &fakeRepo{comments: map[string]*entity.Comment{}}
+	for _, c := range comments {
+		r.comments[c.ID] = c
+	}
+	return r
+}
+
+func (r *fakeRepo) Get(id string) (*entity.Comment, error) {
+	return r.comments[id], nil
+}
+
+func (r *fakeRepo) Update(c *entity.Comment, edit *entity.CommentEdit) error {
+	r.edits = append(r.edits, edit)
+	return nil
+}
+
+func (r *fakeRepo) Delete(c *entity.Comment, edit *entity.CommentEdit) error {
+	r.edits = append(r.edits, edit)
+	return nil
+}
+
+func (r *fakeRepo) ListThreads(resource entity.Resource, limit int, offset int) ([]*entity.Comment, int, error) {
+	r.limit, r.offset = limit, offset
+	return []*entity.Comment{r.comments["c1"]}, 1, nil
+}
+
+func (r *fakeRepo) ListReplies(threadIDs []string) ([]*entity.Comment, error) {
+	var replies []*entity.Comment
+	for _, c := range r.comments {
+		if c.ThreadID != "" {
+			replies = append(replies, c)
+		}
+	}
+	return replies, nil
+}
+
+type fakeUsers struct {
+	user.UseCase
+}
+
+func (fakeUsers) GetUserbyUsername(username string) (*entity.User, error) {
+	if username == "ghost" {
+		return nil, user.ErrNotFound
+	}
+	return &entity.User{ID: "id-" + username, Username: username}, nil
+}
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name        string
+		editorID    string
+		body        string
+		deleted     bool
+		wantErr     error
+		wantAdded   []entity.Mention
+		wantHistory bool
+	}{
+		{name: "author edits", editorID: "author", body: "now with @alice, @bob and @ghost", wantAdded: []entity.Mention{{UserID: "id-bob", Username: "bob"}}, wantHistory: true},
+		{name: "moderator can't edit", editorID: "moderator", body: "rewritten", wantErr: ErrNotAuthor},
+		{name: "deleted comment", editorID: "author", body: "back", deleted: true, wantErr: ErrDeleted},
+		{name: "unchanged body", editorID: "author", body: " hi @alice \r\n"},
+		{name: "empty body", editorID: "author", body: "  ", wantErr: ErrInvalidComment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &entity.Comment{ID: "c1", AuthorID: "author", Body: "hi @alice", Mentions: []entity.Mention{{UserID: "id-alice", Username: "alice"}}}
+			if tt.deleted {
+				c.Body, c.Mentions = "", nil
+				c.DeletedAt = time.Now()
+			}
+			repo := newFakeRepo(c)
+			s := NewService(repo, fakeUsers{})
+
+			_, added, err := s.Edit("c1", tt.editorID, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Edit() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("Edit() added mentions %v, want %v", added, tt.wantAdded)
+			}
+			if (len(repo.edits) > 0) != tt.wantHistory {
+				t.Fatalf("Edit() saved %d edits", len(repo.edits))
+			}
+			if tt.wantHistory && repo.edits[0].Body != "hi @alice" {
+				t.Errorf("history keeps %q, want the previous body", repo.edits[0].Body)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := &entity.Comment{ID: "c1", AuthorID: "author", Body: "hi @alice", Mentions: []entity.Mention{{UserID: "id-alice", Username: "alice"}}}
+	repo := newFakeRepo(c)
+	s := NewService(repo, fakeUsers{})
+
+	err := s.Delete("c1", "moderator")
+	if err != nil {
+		t.Fatalf("Delete() by a moderator error = %v", err)
+	}
+	if !c.Deleted() || c.Body != "" || c.Mentions != nil {
+		t.Errorf("Delete() left %+v", c)
+	}
+	if len(repo.edits) != 1 || repo.edits[0].Body != "hi @alice" || repo.edits[0].EditedBy != "moderator" {
+		t.Errorf("Delete() history = %+v", repo.edits)
+	}
+	err = s.Delete("c1", "author")
+	if err != ErrDeleted {
+		t.Errorf("Delete() twice error = %v, want ErrDeleted", err)
+	}
+	err = s.Delete("c2", "author")
+	if err != ErrNotFound {
+		t.Errorf("Delete() of a missing comment error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListThreads(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      int
+		offset     int
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "default page", wantLimit: DefaultLimit},
+		{name: "negative limit", limit: -5, wantLimit: DefaultLimit},
+		{name: "limit within bounds", limit: 5, offset: 10, wantLimit: 5, wantOffset: 10},
+		{name: "limit above the maximum", limit: MaxLimit + 1, wantLimit: MaxLimit},
+		{name: "negative offset", limit: 5, offset: -1, wantLimit: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(&entity.Comment{ID: "c1"}, &entity.Comment{ID: "c2", ThreadID: "c1"})
+			s := NewService(repo, fakeUsers{})
+
+			threads, total, err := s.ListThreads(entity.Resource{Type: entity.ResourceOrder, ID: "o1"}, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repo.limit != tt.wantLimit || repo.offset != tt.wantOffset {
+				t.Errorf("ListThreads() read limit %d offset %d, want %d and %d", repo.limit, repo.offset, tt.wantLimit, tt.wantOffset)
+			}
+			if total != 1 || len(threads) != 1 || len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != "c2" {
+				t.Errorf("ListThreads() = %+v, %d", threads, total)
+			}
+		})
+	}
+}
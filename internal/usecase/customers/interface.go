@@ -0,0 +1,43 @@
+package customers
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+//Reader interface
+type Reader interface {
+	Get(id string) (*entity.Customer, error)
+	List() ([]*entity.Customer, error)
+	GetShareLink(id string) (*entity.ShareLink, error)
+	ListShareLinks(orderID string) ([]*entity.ShareLink, error)
+}
+
+//Writer interface
+type Writer interface {
+	Create(c *entity.Customer) (string, error)
+	//Update replaces the customer's name and contacts
+	Update(c *entity.Customer) error
+	CreateShareLink(l *entity.ShareLink) (string, error)
+	RevokeShareLink(id string) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	CreateCustomer(name string, contacts []entity.Contact) (string, error)
+	GetCustomer(id string) (*entity.Customer, error)
+	ListCustomers() ([]*entity.Customer, error)
+	UpdateCustomer(c *entity.Customer) error
+	ListCustomerOrders(id string) ([]*entity.Orders, error)
+	CreateShareLink(orderID string, createdBy string, ttl time.Duration) (*entity.ShareLink, error)
+	GetShareLink(id string) (*entity.ShareLink, error)
+	ListShareLinks(orderID string) ([]*entity.ShareLink, error)
+	RevokeShareLink(id string) error
+	//ResolveShareLink returns the link if it is neither revoked nor expired
+	ResolveShareLink(id string) (*entity.ShareLink, error)
+}
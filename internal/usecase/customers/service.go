@@ -0,0 +1,152 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+)
+
+const (
+	DefaultShareLinkTTL = 14 * 24 * time.Hour
+	//MaxShareLinkTTL keeps every link expiring, a customer needing access
+	//for longer gets a new one
+	MaxShareLinkTTL = 90 * 24 * time.Hour
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidCustomer = errors.New("invalid customer")
+	ErrNoCustomer      = errors.New("order has no customer to share it with")
+	ErrInvalidTTL      = errors.New("invalid share link lifetime")
+	ErrLinkInactive    = errors.New("share link has expired or was revoked")
+)
+
+type Service struct {
+	repo   Repository
+	orders orders.UseCase
+}
+
+func NewService(r Repository, o orders.UseCase) *Service {
+	return &Service{
+		repo:   r,
+		orders: o,
+	}
+}
+
+func (s *Service) CreateCustomer(name string, contacts []entity.Contact) (string, error) {
+	c := entity.NewCustomer(strings.TrimSpace(name), contacts)
+	err := validate(c)
+	if err != nil {
+		return "", err
+	}
+	return s.repo.Create(c)
+}
+
+func (s *Service) GetCustomer(id string) (*entity.Customer, error) {
+	c, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Service) ListCustomers() ([]*entity.Customer, error) {
+	return s.repo.List()
+}
+
+func (s *Service) UpdateCustomer(c *entity.Customer) error {
+	c.Name = strings.TrimSpace(c.Name)
+	err := validate(c)
+	if err != nil {
+		return err
+	}
+	return s.repo.Update(c)
+}
+
+func (s *Service) ListCustomerOrders(id string) ([]*entity.Orders, error) {
+	_, err := s.GetCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.orders.ListCustomerOrders(id)
+}
+
+//CreateShareLink opens the order to its customer for ttl, or
+//DefaultShareLinkTTL when ttl is zero
+func (s *Service) CreateShareLink(orderID string, createdBy string, ttl time.Duration) (*entity.ShareLink, error) {
+	if ttl == 0 {
+		ttl = DefaultShareLinkTTL
+	}
+	if ttl < 0 || ttl > MaxShareLinkTTL {
+		return nil, fmt.Errorf("%w: share links last up to %s", ErrInvalidTTL, MaxShareLinkTTL)
+	}
+	o, err := s.orders.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.CustomerID == "" {
+		return nil, ErrNoCustomer
+	}
+	l := entity.NewShareLink(o.CustomerID, o.ID, createdBy, ttl)
+	_, err = s.repo.CreateShareLink(l)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *Service) ListShareLinks(orderID string) ([]*entity.ShareLink, error) {
+	return s.repo.ListShareLinks(orderID)
+}
+
+func (s *Service) GetShareLink(id string) (*entity.ShareLink, error) {
+	l, err := s.repo.GetShareLink(id)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, ErrNotFound
+	}
+	return l, nil
+}
+
+func (s *Service) RevokeShareLink(id string) error {
+	_, err := s.GetShareLink(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeShareLink(id)
+}
+
+func (s *Service) ResolveShareLink(id string) (*entity.ShareLink, error) {
+	l, err := s.repo.GetShareLink(id)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || !l.Active() {
+		return nil, ErrLinkInactive
+	}
+	return l, nil
+}
+
+func validate(c *entity.Customer) error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+	for i, contact := range c.Contacts {
+		if strings.TrimSpace(contact.Name) == "" {
+			return fmt.Errorf("%w: contact %d has no name", ErrInvalidCustomer, i+1)
+		}
+		if contact.Email != "" && !strings.Contains(contact.Email, "@") {
+			return fmt.Errorf("%w: %q is not an email address", ErrInvalidCustomer, contact.Email)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package customers
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	links map[string]*entity.ShareLink
+}
+
+func (r *fakeRepo) CreateShareLink(l *entity.ShareLink) (string, error) {
+	r.links[l.ID] = l
+	return l.ID, nil
+}
+
+func (r *fakeRepo) GetShareLink(id string) (*entity.ShareLink, error) {
+	return r.links[id], nil
+}
+
+type fakeOrders struct {
+	orders.UseCase
+	orders map[string]*entity.Orders
+}
+
+func (o *fakeOrders) GetOrder(id string) (*entity.Orders, error) {
+	order, ok := o.orders[id]
+	if !ok {
+		return nil, orders.ErrNotFound
+	}
+	return order, nil
+}
+
+func newTestService() (*Service, *fakeRepo) {
+	repo := &fakeRepo{links: map[string]*entity.ShareLink{}}
+	s := NewService(repo, &fakeOrders{orders: map[string]*entity.Orders{
+		"o1": {ID: "o1", CustomerID: "c1"},
+		"o2": {ID: "o2"},
+	}})
+	return s, repo
+}
+
+func TestCreateShareLink(t *testing.T) {
+	tt := []struct {
+		name    string
+		orderID string
+		ttl     time.Duration
+		want    time.Duration
+		err     error
+	}{
+		{name: "default lifetime", orderID: "o1", ttl: 0, want: DefaultShareLinkTTL},
+		{name: "one hour", orderID: "o1", ttl: time.Hour, want: time.Hour},
+		{name: "longest lifetime", orderID: "o1", ttl: MaxShareLinkTTL, want: MaxShareLinkTTL},
+		{name: "past the longest lifetime", orderID: "o1", ttl: MaxShareLinkTTL + time.Second, err: ErrInvalidTTL},
+		{name: "negative lifetime", orderID: "o1", ttl: -time.Hour, err: ErrInvalidTTL},
+		{name: "order without customer", orderID: "o2", ttl: time.Hour, err: ErrNoCustomer},
+		{name: "missing order", orderID: "o3", ttl: time.Hour, err: orders.ErrNotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, repo := newTestService()
+			l, err := s.CreateShareLink(tc.orderID, "u1", tc.ttl)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("CreateShareLink error = %v, want %v", err, tc.err)
+			}
+			if err != nil {
+				if len(repo.links) != 0 {
+					t.Errorf("stored a link despite %v", err)
+				}
+				return
+			}
+			if got := l.ExpiresAt.Sub(l.CreatedAt); got != tc.want {
+				t.Errorf("link lasts %s, want %s", got, tc.want)
+			}
+			if l.CustomerID != "c1" || l.CreatedBy != "u1" {
+				t.Errorf("link = %+v, want it for customer c1 created by u1", l)
+			}
+			if repo.links[l.ID] != l {
+				t.Errorf("link %s wasn't stored", l.ID)
+			}
+		})
+	}
+}
+
+func TestResolveShareLink(t *testing.T) {
+	now := time.Now()
+	tt := []struct {
+		name string
+		link *entity.ShareLink
+		err  error
+	}{
+		{name: "active", link: &entity.ShareLink{ID: "l1", ExpiresAt: now.Add(time.Hour)}},
+		{name: "revoked", link: &entity.ShareLink{ID: "l1", ExpiresAt: now.Add(time.Hour), Revoked: true}, err: ErrLinkInactive},
+		{name: "expired", link: &entity.ShareLink{ID: "l1", ExpiresAt: now.Add(-time.Second)}, err: ErrLinkInactive},
+		{name: "missing", err: ErrLinkInactive},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, repo := newTestService()
+			if tc.link != nil {
+				repo.links[tc.link.ID] = tc.link
+			}
+			l, err := s.ResolveShareLink("l1")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("ResolveShareLink error = %v, want %v", err, tc.err)
+			}
+			if err == nil && l != tc.link {
+				t.Errorf("ResolveShareLink = %+v, want %+v", l, tc.link)
+			}
+		})
+	}
+}
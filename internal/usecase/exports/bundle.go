@@ -0,0 +1,192 @@
+package exports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+//The bundle types are the JSON export, the print view renders them too
+
+type bundleOrder struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Deadline    string `json:"deadline"`
+	Status      string `json:"status"`
+	Priority    string `json:"priority"`
+	SLAPolicy   string `json:"sla_policy"`
+	CustomerID  string `json:"customer_id"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type bundleRequirement struct {
+	ID              int          `json:"id"`
+	Request         string       `json:"request"`
+	ExpectedOutcome string       `json:"outcome"`
+	Status          string       `json:"status"`
+	Tasks           []bundleTask `json:"tasks"`
+}
+
+type bundleTask struct {
+	ID               string             `json:"id"`
+	AssignedUser     string             `json:"assigned_user"`
+	AssignedUsername string             `json:"assigned_username"`
+	Note             string             `json:"note"`
+	Status           string             `json:"status"`
+	Deadline         string             `json:"deadline"`
+	CreatedAt        string             `json:"created_at"`
+	ReviewedAt       string             `json:"reviewed_at,omitempty"`
+	Submissions      []bundleSubmission `json:"submissions"`
+	Reviews          []bundleReview     `json:"reviews"`
+}
+
+type bundleSubmission struct {
+	ID          string `json:"id"`
+	SubmittedAt string `json:"submitted_at"`
+	Message     string `json:"message"`
+	Images      int    `json:"images"`
+}
+
+type bundleReview struct {
+	UserName string `json:"from"`
+	Message  string `json:"message"`
+}
+
+func newBundleOrder(o *entity.Orders) bundleOrder {
+	return bundleOrder{
+		ID:          o.ID,
+		Title:       o.Title,
+		Description: o.Description,
+		Deadline:    formatTime(o.Deadline),
+		Status:      string(o.Status),
+		Priority:    string(o.Priority),
+		SLAPolicy:   o.SLAPolicy,
+		CustomerID:  o.CustomerID,
+		CreatedAt:   formatTime(o.CreatedAt),
+	}
+}
+
+func newBundleRequirement(r *entity.Requirements) *bundleRequirement {
+	return &bundleRequirement{
+		ID:              r.Id,
+		Request:         r.Request,
+		ExpectedOutcome: r.ExpectedOutcome,
+		Status:          requirementStatus(r.Status),
+		Tasks:           []bundleTask{},
+	}
+}
+
+func newBundleTask(t *entity.ExportTask) bundleTask {
+	task := bundleTask{
+		ID:               t.ID,
+		AssignedUser:     t.UserID,
+		AssignedUsername: t.Username,
+		Note:             t.Note,
+		Status:           taskStatus(t.Status),
+		Deadline:         formatTime(t.Deadline),
+		CreatedAt:        formatTime(t.CreatedAt),
+		ReviewedAt:       formatTime(t.ReviewedAt),
+		Submissions:      []bundleSubmission{},
+		Reviews:          []bundleReview{},
+	}
+	for _, s := range t.Submissions {
+		task.Submissions = append(task.Submissions, bundleSubmission{
+			ID:          s.ID,
+			SubmittedAt: formatTime(s.SubmittedAt),
+			Message:     s.Message,
+			Images:      s.Images,
+		})
+	}
+	for _, m := range t.Reviews {
+		task.Reviews = append(task.Reviews, bundleReview{UserName: m.Username, Message: m.Message})
+	}
+	return task
+}
+
+//jsonEncoder writes {"exported_at": ..., "orders": [...]}. Each order is
+//opened and closed by hand so its requirements can be written one by one,
+//a requirement is written once the rows move past it.
+type jsonEncoder struct {
+	w           io.Writer
+	orders      int
+	written     int
+	requirement *bundleRequirement
+}
+
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) begin(exportedAt time.Time) error {
+	_, err := fmt.Fprintf(e.w, `{"exported_at":%q,"orders":[`, formatTime(exportedAt))
+	return err
+}
+
+func (e *jsonEncoder) beginOrder(o *entity.Orders) error {
+	b, err := json.Marshal(newBundleOrder(o))
+	if err != nil {
+		return err
+	}
+	if e.orders > 0 {
+		_, err = e.w.Write([]byte(","))
+		if err != nil {
+			return err
+		}
+	}
+	e.orders++
+	e.written = 0
+	//reopen the marshalled order to add its requirements
+	_, err = e.w.Write(append(b[:len(b)-1], []byte(`,"requirements":[`)...))
+	return err
+}
+
+func (e *jsonEncoder) row(row *entity.ExportRow) error {
+	if e.requirement != nil && e.requirement.ID != row.Requirement.Id {
+		err := e.flush()
+		if err != nil {
+			return err
+		}
+	}
+	if e.requirement == nil {
+		e.requirement = newBundleRequirement(row.Requirement)
+	}
+	if row.Task != nil {
+		e.requirement.Tasks = append(e.requirement.Tasks, newBundleTask(row.Task))
+	}
+	return nil
+}
+
+func (e *jsonEncoder) flush() error {
+	if e.requirement == nil {
+		return nil
+	}
+	b, err := json.Marshal(e.requirement)
+	if err != nil {
+		return err
+	}
+	if e.written > 0 {
+		b = append([]byte(","), b...)
+	}
+	e.written++
+	e.requirement = nil
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) endOrder() error {
+	err := e.flush()
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write([]byte("]}"))
+	return err
+}
+
+func (e *jsonEncoder) end() error {
+	_, err := e.w.Write([]byte("]}\n"))
+	return err
+}
@@ -0,0 +1,91 @@
+package exports
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+var csvHeader = []string{
+	"order_id", "order_title", "order_status", "order_priority", "order_deadline", "customer_id",
+	"requirement_id", "request", "outcome", "requirement_status",
+	"task_id", "assigned_username", "task_status", "task_deadline", "submissions", "last_submitted_at", "reviewed_at", "reviews",
+}
+
+//csvEncoder writes one record per task, and one per requirement without
+//tasks. An order without requirements still gets a record of its own.
+type csvEncoder struct {
+	w     *csv.Writer
+	order []string
+	rows  int
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) begin(exportedAt time.Time) error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) beginOrder(o *entity.Orders) error {
+	e.order = []string{o.ID, o.Title, string(o.Status), string(o.Priority), formatTime(o.Deadline), o.CustomerID}
+	e.rows = 0
+	return nil
+}
+
+func (e *csvEncoder) row(row *entity.ExportRow) error {
+	e.rows++
+	r := row.Requirement
+	record := append(append([]string{}, e.order...), strconv.Itoa(r.Id), r.Request, r.ExpectedOutcome, requirementStatus(r.Status))
+	if t := row.Task; t != nil {
+		var lastSubmission time.Time
+		for _, s := range t.Submissions {
+			if s.SubmittedAt.After(lastSubmission) {
+				lastSubmission = s.SubmittedAt
+			}
+		}
+		var reviews []string
+		for _, m := range t.Reviews {
+			reviews = append(reviews, m.Username+": "+m.Message)
+		}
+		record = append(record, t.ID, t.Username, taskStatus(t.Status), formatTime(t.Deadline),
+			strconv.Itoa(len(t.Submissions)), formatTime(lastSubmission), formatTime(t.ReviewedAt), strings.Join(reviews, "\n"))
+	}
+	return e.write(record)
+}
+
+func (e *csvEncoder) endOrder() error {
+	if e.rows == 0 {
+		err := e.write(e.order)
+		if err != nil {
+			return err
+		}
+	}
+	//flush per order so a large export reaches the client as it goes
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+//write pads the record to the header and escapes cells spreadsheets would
+//evaluate as formulas
+func (e *csvEncoder) write(record []string) error {
+	for len(record) < len(csvHeader) {
+		record = append(record, "")
+	}
+	for i, cell := range record {
+		if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+			record[i] = "'" + cell
+		}
+	}
+	return e.w.Write(record)
+}
@@ -0,0 +1,116 @@
+package exports
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+var printTemplates = template.Must(template.New("print").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Orders</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 4px; text-align: left; vertical-align: top; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2px 1em; }
+dt { font-weight: bold; }
+ul { margin: 0; padding-left: 1.2em; }
+.exported { color: #666; }
+@media print { section.order { page-break-after: always; } }
+</style>
+</head>
+<body>
+<p class="exported">Exported {{.}}</p>
+{{end}}
+
+{{define "order"}}<section class="order">
+<h1>{{.Title}}</h1>
+<p>{{.Description}}</p>
+<dl>
+<dt>Order</dt><dd>{{.ID}}</dd>
+<dt>Status</dt><dd>{{.Status}}</dd>
+<dt>Priority</dt><dd>{{.Priority}}</dd>
+<dt>Deadline</dt><dd>{{.Deadline}}</dd>
+{{if .CustomerID}}<dt>Customer</dt><dd>{{.CustomerID}}</dd>{{end}}
+{{if .SLAPolicy}}<dt>SLA policy</dt><dd>{{.SLAPolicy}}</dd>{{end}}
+</dl>
+<table>
+<thead><tr><th>Requirement</th><th>Expected outcome</th><th>Status</th><th>Assigned to</th><th>Task status</th><th>Deadline</th><th>Submissions</th><th>Reviews</th></tr></thead>
+<tbody>
+{{end}}
+
+{{define "row"}}<tr>
+<td>{{.Requirement.Request}}</td><td>{{.Requirement.ExpectedOutcome}}</td><td>{{.Requirement.Status}}</td>
+{{with .Task}}<td>{{.AssignedUsername}}</td><td>{{.Status}}</td><td>{{.Deadline}}</td>
+<td><ul>{{range .Submissions}}<li>{{.SubmittedAt}}: {{.Message}}{{if .Images}} ({{.Images}} images){{end}}</li>{{end}}</ul></td>
+<td><ul>{{range .Reviews}}<li>{{.UserName}}: {{.Message}}</li>{{end}}</ul></td>
+{{else}}<td colspan="5">No task assigned</td>{{end}}
+</tr>
+{{end}}
+
+{{define "empty"}}<tr><td colspan="8">No requirements</td></tr>
+{{end}}
+
+{{define "endorder"}}</tbody>
+</table>
+</section>
+{{end}}
+
+{{define "tail"}}</body>
+</html>
+{{end}}
+`))
+
+type printRow struct {
+	Requirement *bundleRequirement
+	Task        *bundleTask
+}
+
+//htmlEncoder renders the print view, one table row per task
+type htmlEncoder struct {
+	w    io.Writer
+	rows int
+}
+
+func newHTMLEncoder(w io.Writer) *htmlEncoder {
+	return &htmlEncoder{w: w}
+}
+
+func (e *htmlEncoder) begin(exportedAt time.Time) error {
+	return printTemplates.ExecuteTemplate(e.w, "head", formatTime(exportedAt))
+}
+
+func (e *htmlEncoder) beginOrder(o *entity.Orders) error {
+	e.rows = 0
+	return printTemplates.ExecuteTemplate(e.w, "order", newBundleOrder(o))
+}
+
+func (e *htmlEncoder) row(row *entity.ExportRow) error {
+	e.rows++
+	data := printRow{Requirement: newBundleRequirement(row.Requirement)}
+	if row.Task != nil {
+		task := newBundleTask(row.Task)
+		data.Task = &task
+	}
+	return printTemplates.ExecuteTemplate(e.w, "row", data)
+}
+
+func (e *htmlEncoder) endOrder() error {
+	if e.rows == 0 {
+		err := printTemplates.ExecuteTemplate(e.w, "empty", nil)
+		if err != nil {
+			return err
+		}
+	}
+	return printTemplates.ExecuteTemplate(e.w, "endorder", nil)
+}
+
+func (e *htmlEncoder) end() error {
+	return printTemplates.ExecuteTemplate(e.w, "tail", nil)
+}
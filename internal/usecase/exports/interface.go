@@ -0,0 +1,24 @@
+package exports
+
+import (
+	"io"
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	//EachRow calls fn with the rows of the order as they are read, ordered
+	//by requirement. It stops at the first error fn returns.
+	EachRow(orderID string, fn func(row *entity.ExportRow) error) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+}
+
+type UseCase interface {
+	//Export writes the orders to w one row at a time, so only a single
+	//requirement is held in memory however large the orders are
+	Export(format Format, orders []*entity.Orders, w io.Writer) error
+}
@@ -0,0 +1,125 @@
+package exports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	//FormatHTML is a page meant for printing, one order per page
+	FormatHTML Format = "html"
+)
+
+var ErrInvalidFormat = errors.New("invalid export format")
+
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSON, FormatHTML:
+		return f, true
+	}
+	return "", false
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/json"
+}
+
+//encoder writes an export piece by piece: begin, then for each order
+//beginOrder, its rows and endOrder, then end
+type encoder interface {
+	begin(exportedAt time.Time) error
+	beginOrder(o *entity.Orders) error
+	row(row *entity.ExportRow) error
+	endOrder() error
+	end() error
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(r Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+func (s *Service) Export(format Format, orders []*entity.Orders, w io.Writer) error {
+	var enc encoder
+	switch format {
+	case FormatCSV:
+		enc = newCSVEncoder(w)
+	case FormatJSON:
+		enc = newJSONEncoder(w)
+	case FormatHTML:
+		enc = newHTMLEncoder(w)
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+	err := enc.begin(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		err = enc.beginOrder(o)
+		if err != nil {
+			return err
+		}
+		err = s.repo.EachRow(o.ID, enc.row)
+		if err != nil {
+			return err
+		}
+		err = enc.endOrder()
+		if err != nil {
+			return err
+		}
+	}
+	return enc.end()
+}
+
+func requirementStatus(s entity.Status) string {
+	switch s {
+	case entity.NotAssigned:
+		return "not_assigned"
+	case entity.Assigned:
+		return "assigned"
+	case entity.AssignedAndFinished:
+		return "finished"
+	}
+	return fmt.Sprintf("unknown (%d)", s)
+}
+
+func taskStatus(s entity.Status) string {
+	switch s {
+	case entity.Unfinished:
+		return "unfinished"
+	case entity.InReview:
+		return "in_review"
+	case entity.Finished:
+		return "finished"
+	}
+	return fmt.Sprintf("unknown (%d)", s)
+}
+
+//formatTime leaves unset times empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2/Jan/2006 15:04:05")
+}
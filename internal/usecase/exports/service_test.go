@@ -0,0 +1,137 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"order-validation-v2/internal/entity"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	rows map[string][]*entity.ExportRow
+}
+
+func (r *fakeRepo) EachRow(orderID string, fn func(row *entity.ExportRow) error) error {
+	for _, row := range r.rows[orderID] {
+		err := fn(row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	deadline  = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	submitted = time.Date(2024, 2, 20, 9, 30, 0, 0, time.UTC)
+	reviewed  = time.Date(2024, 2, 21, 10, 0, 0, 0, time.UTC)
+)
+
+//exportFixture is an order with a requirement of two tasks and one without
+//tasks, followed by an order without requirements
+func exportFixture() ([]*entity.Orders, *fakeRepo) {
+	orders := []*entity.Orders{
+		{ID: "o1", Title: "Garden", Description: "Back garden", Deadline: deadline, Status: entity.OrderInProgress,
+			Priority: entity.PriorityHigh, CustomerID: "c1", CreatedAt: submitted},
+		{ID: "o2", Title: "=HYPERLINK(\"x\")", Deadline: deadline, Status: entity.OrderOpen, Priority: entity.PriorityNormal, CreatedAt: submitted},
+	}
+	fence := &entity.Requirements{Id: 1, Request: "Paint the fence", ExpectedOutcome: "White fence", OrderID: "o1", Status: entity.Assigned}
+	gate := &entity.Requirements{Id: 2, Request: "-fix the gate", ExpectedOutcome: "Gate closes", OrderID: "o1", Status: entity.NotAssigned}
+	repo := &fakeRepo{rows: map[string][]*entity.ExportRow{
+		"o1": {
+			{Requirement: fence, Task: &entity.ExportTask{ID: "t1", UserID: "u1", Username: "ann", Status: entity.Finished, Deadline: deadline,
+				CreatedAt: submitted, ReviewedAt: reviewed,
+				Submissions: []entity.SubmissionMetadata{
+					{ID: "s1", SubmittedAt: submitted.Add(-time.Hour), Message: "first coat", Images: 1},
+					{ID: "s2", SubmittedAt: submitted, Message: "second coat", Images: 2},
+				},
+				Reviews: []entity.Message{{Username: "bob", Message: "streaky"}, {Username: "bob", Message: "good"}}}},
+			{Requirement: fence, Task: &entity.ExportTask{ID: "t2", UserID: "u2", Username: "cid", Status: entity.Unfinished, Deadline: deadline, CreatedAt: submitted}},
+			{Requirement: gate},
+		},
+	}}
+	return orders, repo
+}
+
+func TestExportCSV(t *testing.T) {
+	orders, repo := exportFixture()
+	var out bytes.Buffer
+	err := NewService(repo).Export(FormatCSV, orders, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := []string{"o1", "Garden", "in_progress", "high", "1/Mar/2024 12:00:00", "c1"}
+	fence := append(append([]string{}, order...), "1", "Paint the fence", "White fence", "assigned")
+	want := [][]string{
+		csvHeader,
+		append(append([]string{}, fence...), "t1", "ann", "finished", "1/Mar/2024 12:00:00", "2", "20/Feb/2024 09:30:00", "21/Feb/2024 10:00:00", "bob: streaky\nbob: good"),
+		append(append([]string{}, fence...), "t2", "cid", "unfinished", "1/Mar/2024 12:00:00", "0", "", "", ""),
+		//spreadsheet formulas are escaped
+		append(append([]string{}, order...), "2", "'-fix the gate", "Gate closes", "not_assigned", "", "", "", "", "", "", "", ""),
+		{"o2", "'=HYPERLINK(\"x\")", "open", "normal", "1/Mar/2024 12:00:00", "", "", "", "", "", "", "", "", "", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q\nwant %q", records, want)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	orders, repo := exportFixture()
+	var out bytes.Buffer
+	err := NewService(repo).Export(FormatJSON, orders, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ExportedAt string `json:"exported_at"`
+		Orders     []struct {
+			bundleOrder
+			Requirements []bundleRequirement `json:"requirements"`
+		} `json:"orders"`
+	}
+	err = json.Unmarshal(out.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("invalid JSON %s: %v", out.Bytes(), err)
+	}
+	if _, err := time.Parse("2/Jan/2006 15:04:05", got.ExportedAt); err != nil {
+		t.Errorf("exported_at %q: %v", got.ExportedAt, err)
+	}
+	if len(got.Orders) != 2 {
+		t.Fatalf("got %d orders, want 2", len(got.Orders))
+	}
+	wantOrder := bundleOrder{ID: "o1", Title: "Garden", Description: "Back garden", Deadline: "1/Mar/2024 12:00:00", Status: "in_progress",
+		Priority: "high", CustomerID: "c1", CreatedAt: "20/Feb/2024 09:30:00"}
+	if got.Orders[0].bundleOrder != wantOrder {
+		t.Errorf("order = %+v, want %+v", got.Orders[0].bundleOrder, wantOrder)
+	}
+	wantRequirements := []bundleRequirement{
+		{ID: 1, Request: "Paint the fence", ExpectedOutcome: "White fence", Status: "assigned", Tasks: []bundleTask{
+			{ID: "t1", AssignedUser: "u1", AssignedUsername: "ann", Status: "finished", Deadline: "1/Mar/2024 12:00:00",
+				CreatedAt: "20/Feb/2024 09:30:00", ReviewedAt: "21/Feb/2024 10:00:00",
+				Submissions: []bundleSubmission{
+					{ID: "s1", SubmittedAt: "20/Feb/2024 08:30:00", Message: "first coat", Images: 1},
+					{ID: "s2", SubmittedAt: "20/Feb/2024 09:30:00", Message: "second coat", Images: 2},
+				},
+				Reviews: []bundleReview{{UserName: "bob", Message: "streaky"}, {UserName: "bob", Message: "good"}}},
+			{ID: "t2", AssignedUser: "u2", AssignedUsername: "cid", Status: "unfinished", Deadline: "1/Mar/2024 12:00:00",
+				CreatedAt: "20/Feb/2024 09:30:00", Submissions: []bundleSubmission{}, Reviews: []bundleReview{}},
+		}},
+		{ID: 2, Request: "-fix the gate", ExpectedOutcome: "Gate closes", Status: "not_assigned", Tasks: []bundleTask{}},
+	}
+	if !reflect.DeepEqual(got.Orders[0].Requirements, wantRequirements) {
+		t.Errorf("requirements = %+v\nwant %+v", got.Orders[0].Requirements, wantRequirements)
+	}
+	//JSON needs no formula escaping, and an order without requirements
+	//still has its list
+	if got.Orders[1].Title != "=HYPERLINK(\"x\")" || got.Orders[1].Requirements == nil || len(got.Orders[1].Requirements) != 0 {
+		t.Errorf("second order = %+v", got.Orders[1])
+	}
+}
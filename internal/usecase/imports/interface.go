@@ -0,0 +1,26 @@
+package imports
+
+import (
+	"io"
+	"order-validation-v2/internal/entity"
+)
+
+//Writer interface
+type Writer interface {
+	//Load inserts the orders and their requirements with their labels and
+	//revisions in one transaction, and sets the IDs of the requirements
+	Load(orders []*entity.ImportedOrder) error
+}
+
+//Repository interface
+type Repository interface {
+	Writer
+}
+
+type UseCase interface {
+	//Import reads and validates every order of the file. The orders are
+	//loaded only when all of them are valid and dryRun is false, errors in
+	//the file are reported rather than returned. The revisions of loaded
+	//orders are recorded as authorID's, authorless when it is empty.
+	Import(format Format, r io.Reader, dryRun bool, authorID string) (*entity.ImportReport, error)
+}
@@ -0,0 +1,194 @@
+package imports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSON:
+		return f, true
+	}
+	return "", false
+}
+
+//record is an order as written in the file, before validation. Tags and
+//fields can only be given in JSON.
+type record struct {
+	Ref          string              `json:"ref"`
+	Title        string              `json:"title"`
+	Description  string              `json:"description"`
+	Deadline     string              `json:"deadline"`
+	Status       string              `json:"status"`
+	Priority     string              `json:"priority"`
+	SLAPolicy    string              `json:"sla_policy"`
+	CustomerID   string              `json:"customer_id"`
+	Tags         []string            `json:"tags"`
+	Fields       map[string]string   `json:"fields"`
+	Requirements []requirementRecord `json:"requirements"`
+	row          int
+}
+
+type requirementRecord struct {
+	Request         string            `json:"request"`
+	ExpectedOutcome string            `json:"outcome"`
+	Tags            []string          `json:"tags"`
+	Fields          map[string]string `json:"fields"`
+	row             int
+	//field prefixes the names of the requirement's fields in errors
+	field string
+}
+
+//csvColumns are the columns a CSV file may have, in any order. Only title
+//is required. There are no columns for tags and custom fields, they are
+//set after the import or imported from JSON.
+var csvColumns = []string{"ref", "title", "description", "deadline", "status", "priority", "sla_policy", "customer_id", "request", "outcome"}
+
+//parseCSV reads one requirement per record. Records sharing a ref belong to
+//the same order, which takes its columns from its first record; a record
+//without ref is an order of its own. Rows count records, the header being
+//row 1.
+func parseCSV(r io.Reader) ([]*record, []entity.ImportError) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, []entity.ImportError{{Row: 1, Message: fmt.Sprintf("can't read header: %s", err.Error())}}
+	}
+	columns := map[string]int{}
+	var errs []entity.ImportError
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !contains(csvColumns, name) {
+			errs = append(errs, entity.ImportError{Row: 1, Field: name, Message: "unknown column, expected one of " + strings.Join(csvColumns, ", ")})
+			continue
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		errs = append(errs, entity.ImportError{Row: 1, Field: "title", Message: "missing column"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var records []*record
+	byRef := map[string]*record{}
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, entity.ImportError{Row: row, Message: err.Error()})
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			break
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		rec := &record{
+			Ref:         value("ref"),
+			Title:       value("title"),
+			Description: value("description"),
+			Deadline:    value("deadline"),
+			Status:      value("status"),
+			Priority:    value("priority"),
+			SLAPolicy:   value("sla_policy"),
+			CustomerID:  value("customer_id"),
+			row:         row,
+		}
+		order, ok := byRef[rec.Ref]
+		if !ok || rec.Ref == "" {
+			order = rec
+			records = append(records, order)
+			if rec.Ref != "" {
+				byRef[rec.Ref] = order
+			}
+		} else {
+			errs = append(errs, conflicts(order, rec)...)
+		}
+		if value("request") != "" || value("outcome") != "" {
+			order.Requirements = append(order.Requirements, requirementRecord{
+				Request:         value("request"),
+				ExpectedOutcome: value("outcome"),
+				row:             row,
+			})
+		}
+	}
+	return records, errs
+}
+
+//conflicts reports the order columns of a later record of an order that
+//differ from its first record. Empty columns are fine.
+func conflicts(first *record, later *record) []entity.ImportError {
+	var errs []entity.ImportError
+	for _, c := range []struct {
+		field        string
+		first, value string
+	}{
+		{"title", first.Title, later.Title},
+		{"description", first.Description, later.Description},
+		{"deadline", first.Deadline, later.Deadline},
+		{"status", first.Status, later.Status},
+		{"priority", first.Priority, later.Priority},
+		{"sla_policy", first.SLAPolicy, later.SLAPolicy},
+		{"customer_id", first.CustomerID, later.CustomerID},
+	} {
+		if c.value != "" && c.value != c.first {
+			errs = append(errs, entity.ImportError{Row: later.row, Field: c.field,
+				Message: fmt.Sprintf("differs from row %d of order %s", first.row, first.Ref)})
+		}
+	}
+	return errs
+}
+
+//parseJSON reads a list of orders with nested requirements, shaped like the
+//body of AddNewOrder. Rows count orders from 1.
+func parseJSON(r io.Reader) ([]*record, []entity.ImportError) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var records []*record
+	err := decoder.Decode(&records)
+	if err != nil {
+		return nil, []entity.ImportError{{Message: fmt.Sprintf("invalid JSON: %s", err.Error())}}
+	}
+	for i, rec := range records {
+		if rec == nil {
+			return nil, []entity.ImportError{{Row: i + 1, Message: "order is null"}}
+		}
+		rec.row = i + 1
+		for j := range rec.Requirements {
+			rec.Requirements[j].row = rec.row
+			rec.Requirements[j].field = fmt.Sprintf("requirements[%d].", j)
+		}
+	}
+	return records, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/customers"
+	"order-validation-v2/internal/usecase/labels"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/revisions"
+	"order-validation-v2/internal/usecase/sla"
+)
+
+//MaxOrders is the most orders one file may hold
+const MaxOrders = 5000
+
+//the limits follow the columns of orders and requirements
+const (
+	maxTitleLength       = 50
+	maxDescriptionLength = 255
+	maxRequestLength     = 50
+	maxOutcomeLength     = 50
+)
+
+var ErrInvalidFormat = errors.New("invalid import format")
+
+type Service struct {
+	repo      Repository
+	sla       sla.UseCase
+	customers customers.UseCase
+	labels    labels.UseCase
+}
+
+func NewService(r Repository, s sla.UseCase, c customers.UseCase, l labels.UseCase) *Service {
+	return &Service{
+		repo:      r,
+		sla:       s,
+		customers: c,
+		labels:    l,
+	}
+}
+
+func (s *Service) Import(format Format, r io.Reader, dryRun bool, authorID string) (*entity.ImportReport, error) {
+	var records []*record
+	var errs []entity.ImportError
+	switch format {
+	case FormatCSV:
+		records, errs = parseCSV(r)
+	case FormatJSON:
+		records, errs = parseJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: %q, expected %s or %s", ErrInvalidFormat, format, FormatCSV, FormatJSON)
+	}
+	report := &entity.ImportReport{DryRun: dryRun, Errors: errs}
+	if len(records) > MaxOrders {
+		report.Errors = append(report.Errors, entity.ImportError{Message: fmt.Sprintf("file has %d orders, at most %d can be imported at once", len(records), MaxOrders)})
+		return report, nil
+	}
+	v := validator{service: s, policies: map[string]error{}, customers: map[string]error{}}
+	for _, rec := range records {
+		order, err := v.order(rec)
+		if err != nil {
+			return nil, err
+		}
+		report.Orders = append(report.Orders, order)
+	}
+	report.Errors = append(report.Errors, v.errs...)
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+	if !report.Loaded() {
+		return report, nil
+	}
+	//the first revisions are loaded with the orders, like when they are
+	//created one by one
+	for _, imported := range report.Orders {
+		imported.Revision = revisions.FirstOrderRevision(imported.Order, authorID)
+		for _, req := range imported.Requirements {
+			imported.RequirementRevisions = append(imported.RequirementRevisions, revisions.FirstRequirementRevision(req, authorID))
+		}
+	}
+	err := s.repo.Load(report.Orders)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+//validator collects the errors of every record and looks up each SLA
+//policy and customer only once
+type validator struct {
+	service   *Service
+	policies  map[string]error
+	customers map[string]error
+	errs      []entity.ImportError
+}
+
+func (v *validator) fail(row int, field string, format string, args ...interface{}) {
+	v.errs = append(v.errs, entity.ImportError{Row: row, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) length(row int, field string, value string, min int, max int) {
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n < min:
+		v.fail(row, field, "is required")
+	case n > max:
+		v.fail(row, field, "is longer than %d characters", max)
+	}
+}
+
+//order validates rec and converts it. The error is only set when a lookup
+//fails, invalid values are collected in v.errs.
+func (v *validator) order(rec *record) (*entity.ImportedOrder, error) {
+	v.length(rec.row, "title", rec.Title, 1, maxTitleLength)
+	v.length(rec.row, "description", rec.Description, 0, maxDescriptionLength)
+	var deadline time.Time
+	if rec.Deadline != "" {
+		var err error
+		deadline, err = time.Parse("2/Jan/2006 15:04:05", rec.Deadline)
+		if err != nil {
+			v.fail(rec.row, "deadline", "expects a date as 2/Jan/2006 15:04:05")
+		}
+	}
+	prepare := func(status entity.OrderStatus, priority entity.Priority) (*entity.Orders, error) {
+		return orders.PrepareOrder(rec.Title, rec.Description, deadline, status, priority, rec.SLAPolicy, rec.CustomerID)
+	}
+	//PrepareOrder stops at the first invalid value, the order is prepared
+	//again without it so the status is checked too
+	priority := entity.Priority(rec.Priority)
+	o, err := prepare(entity.OrderStatus(rec.Status), priority)
+	if errors.Is(err, orders.ErrInvalidPriority) {
+		v.fail(rec.row, "priority", "unknown priority %q", rec.Priority)
+		priority = ""
+		o, err = prepare(entity.OrderStatus(rec.Status), priority)
+	}
+	if errors.Is(err, orders.ErrInvalidTransition) {
+		v.fail(rec.row, "status", "orders start as %s or %s", entity.OrderDraft, entity.OrderOpen)
+		o, err = prepare("", priority)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rec.SLAPolicy != "" {
+		err, checked := v.policies[rec.SLAPolicy]
+		if !checked {
+			_, err = v.service.sla.GetPolicy(rec.SLAPolicy)
+			v.policies[rec.SLAPolicy] = err
+		}
+		if errors.Is(err, sla.ErrNotFound) {
+			v.fail(rec.row, "sla_policy", "unknown SLA policy %q", rec.SLAPolicy)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if rec.CustomerID != "" {
+		err, checked := v.customers[rec.CustomerID]
+		if !checked {
+			_, err = v.service.customers.GetCustomer(rec.CustomerID)
+			v.customers[rec.CustomerID] = err
+		}
+		if errors.Is(err, customers.ErrNotFound) {
+			v.fail(rec.row, "customer_id", "unknown customer %q", rec.CustomerID)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	imported := &entity.ImportedOrder{Row: rec.row, Order: o}
+	imported.Labels, err = v.labels(rec.row, "", entity.ResourceOrder, rec.Tags, rec.Fields)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range rec.Requirements {
+		v.length(req.row, req.field+"request", req.Request, 1, maxRequestLength)
+		v.length(req.row, req.field+"outcome", req.ExpectedOutcome, 0, maxOutcomeLength)
+		l, err := v.labels(req.row, req.field, entity.ResourceRequirement, req.Tags, req.Fields)
+		if err != nil {
+			return nil, err
+		}
+		imported.Requirements = append(imported.Requirements, entity.NewRequirement(req.Request, req.ExpectedOutcome, o.ID))
+		imported.RequirementLabels = append(imported.RequirementLabels, l)
+	}
+	return imported, nil
+}
+
+//labels validates the tags and fields of an order or requirement, field
+//prefixes the names reported like for requirementRecord
+func (v *validator) labels(row int, field string, t entity.ResourceType, tags []string, fields map[string]string) (*entity.Labels, error) {
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	l := &entity.Labels{Tags: tags, Fields: fields}
+	err := v.service.labels.Validate(t, l)
+	if errors.Is(err, labels.ErrInvalidLabel) {
+		v.fail(row, field+"labels", "%s", err.Error())
+		return l, nil
+	}
+	return l, err
+}
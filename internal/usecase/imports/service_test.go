@@ -0,0 +1,136 @@
+package imports
+
+import (
+	"fmt"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/customers"
+	"order-validation-v2/internal/usecase/labels"
+	"order-validation-v2/internal/usecase/sla"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeRepo struct {
+	Repository
+	loaded []*entity.ImportedOrder
+}
+
+func (r *fakeRepo) Load(orders []*entity.ImportedOrder) error {
+	r.loaded = orders
+	id := 0
+	for _, imported := range orders {
+		for _, req := range imported.Requirements {
+			id++
+			req.Id = id
+		}
+	}
+	return nil
+}
+
+type fakeSLA struct {
+	sla.UseCase
+}
+
+func (fakeSLA) GetPolicy(name string) (*entity.SLAPolicy, error) {
+	if name != "standard" {
+		return nil, sla.ErrNotFound
+	}
+	return &entity.SLAPolicy{}, nil
+}
+
+type fakeCustomers struct {
+	customers.UseCase
+}
+
+func (fakeCustomers) GetCustomer(id string) (*entity.Customer, error) {
+	if id != "c1" {
+		return nil, customers.ErrNotFound
+	}
+	return &entity.Customer{}, nil
+}
+
+type fakeLabelRepo struct {
+	labels.Repository
+}
+
+func (r *fakeLabelRepo) ListFields() ([]*entity.CustomField, error) {
+	return []*entity.CustomField{{Name: "budget", Type: entity.FieldNumber, Resources: []entity.ResourceType{entity.ResourceOrder}}}, nil
+}
+
+func TestImportValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		file   string
+		//want lists the row and field of every error reported
+		want []string
+	}{
+		{name: "valid order", format: FormatJSON, file: `[{"title": "a", "status": "draft", "priority": "high", "sla_policy": "standard", "customer_id": "c1", "tags": ["X"], "fields": {"budget": "10"},
+			"requirements": [{"request": "r", "tags": ["y"]}]}]`},
+		{name: "unknown priority and status", format: FormatJSON, file: `[{"title": "a", "status": "validated", "priority": "asap"}]`, want: []string{"1 priority", "1 status"}},
+		{name: "invalid status alone", format: FormatJSON, file: `[{"title": "a", "status": "closed", "priority": "low"}]`, want: []string{"1 status"}},
+		{name: "unknown policy and customer", format: FormatJSON, file: `[{"title": "a", "sla_policy": "gold", "customer_id": "c2"}]`, want: []string{"1 sla_policy", "1 customer_id"}},
+		{name: "missing title and long outcome", format: FormatJSON, file: `[{"requirements": [{"request": "r", "outcome": "` + strings.Repeat("o", 51) + `"}]}]`, want: []string{"1 title", "1 requirements[0].outcome"}},
+		{name: "invalid tag", format: FormatJSON, file: `[{"title": "a"}, {"title": "b", "tags": ["a,b"]}]`, want: []string{"2 labels"}},
+		{name: "field not on requirements", format: FormatJSON, file: `[{"title": "a", "requirements": [{"request": "r", "fields": {"budget": "1"}}]}]`, want: []string{"1 requirements[0].labels"}},
+		{name: "unknown JSON key", format: FormatJSON, file: `[{"title": "a", "owner": "b"}]`, want: []string{"0 "}},
+		{name: "csv rows", format: FormatCSV, file: "ref,title,priority,request\n1,a,asap,r\n1,,,s\n", want: []string{"2 priority"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			s := NewService(repo, fakeSLA{}, fakeCustomers{}, labels.NewService(&fakeLabelRepo{}))
+
+			report, err := s.Import(tt.format, strings.NewReader(tt.file), false, "u1")
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			var got []string
+			for _, e := range report.Errors {
+				got = append(got, fmt.Sprintf("%d %s", e.Row, e.Field))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Import() errors = %v, want %v", report.Errors, tt.want)
+			}
+			if len(tt.want) > 0 {
+				if repo.loaded != nil {
+					t.Errorf("Import() wrote an invalid file")
+				}
+				return
+			}
+			if len(repo.loaded) == 0 {
+				t.Fatalf("Import() loaded nothing")
+			}
+		})
+	}
+}
+
+func TestImportLoadsRevisionsAndLabels(t *testing.T) {
+	repo := &fakeRepo{}
+	s := NewService(repo, fakeSLA{}, fakeCustomers{}, labels.NewService(&fakeLabelRepo{}))
+
+	file := `[{"title": "a", "tags": ["x"], "requirements": [{"request": "r"}, {"request": "s", "tags": ["y"]}]}, {"title": "b"}]`
+	report, err := s.Import(FormatJSON, strings.NewReader(file), false, "u1")
+	if err != nil || !report.Loaded() {
+		t.Fatalf("Import() = %+v, %v", report, err)
+	}
+	var got []string
+	for _, imported := range repo.loaded {
+		rev := imported.Revision
+		got = append(got, fmt.Sprintf("order %s by %s: %v %v", rev.Snapshot["title"], rev.AuthorID, rev.Number, imported.Labels.Tags))
+		for i, rev := range imported.RequirementRevisions {
+			got = append(got, fmt.Sprintf("requirement %s by %s: %v %v", rev.Snapshot["request"], rev.AuthorID, rev.Number, imported.RequirementLabels[i].Tags))
+		}
+	}
+	want := []string{"order a by u1: 1 [x]", "requirement r by u1: 1 []", "requirement s by u1: 1 [y]", "order b by u1: 1 []"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %v, want %v", got, want)
+	}
+
+	repo.loaded = nil
+	report, err = s.Import(FormatJSON, strings.NewReader(file), true, "u1")
+	if err != nil || report.Loaded() || repo.loaded != nil {
+		t.Errorf("dry run loaded %v, err %v", repo.loaded, err)
+	}
+}
@@ -0,0 +1,48 @@
+package labels
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	GetField(name string) (*entity.CustomField, error)
+	ListFields() ([]*entity.CustomField, error)
+	//Get returns the labels of the resources of type t with the given ids,
+	//keyed by id. Resources without labels are left out.
+	Get(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error)
+	//Find returns the ids of the resources of type t matching the filter
+	Find(t entity.ResourceType, filter entity.LabelFilter) ([]string, error)
+}
+
+//Writer interface
+type Writer interface {
+	CreateField(f *entity.CustomField) error
+	//DeleteField removes the field along with every value set for it
+	DeleteField(name string) error
+	//Set replaces the tags and field values of the resource
+	Set(resource entity.Resource, labels *entity.Labels) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	CreateField(f *entity.CustomField) error
+	ListFields() ([]*entity.CustomField, error)
+	DeleteField(name string) error
+	//Validate checks labels meant for a resource of type t and normalizes
+	//them in place
+	Validate(t entity.ResourceType, labels *entity.Labels) error
+	SetLabels(resource entity.Resource, labels *entity.Labels) error
+	//PatchedLabels returns the labels of the resource with the patch applied
+	//and validated, for SetLabels to save. The tags are replaced unless tags
+	//is nil, an empty field value removes the field.
+	PatchedLabels(resource entity.Resource, tags *[]string, fields map[string]string) (*entity.Labels, error)
+	GetLabels(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error)
+	//Match returns the set of ids of resources of type t matching the filter
+	Match(t entity.ResourceType, filter entity.LabelFilter) (map[string]bool, error)
+}
@@ -0,0 +1,185 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"order-validation-v2/internal/entity"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidField = errors.New("invalid custom field")
+	ErrFieldExists  = errors.New("custom field already exists")
+	ErrInvalidLabel = errors.New("invalid tags or custom fields")
+)
+
+//fieldName keeps names usable as field.<name> query parameters
+var fieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(r Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+func (s *Service) CreateField(f *entity.CustomField) error {
+	err := validateField(f)
+	if err != nil {
+		return err
+	}
+	existing, err := s.repo.GetField(f.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrFieldExists
+	}
+	return s.repo.CreateField(f)
+}
+
+func (s *Service) ListFields() ([]*entity.CustomField, error) {
+	return s.repo.ListFields()
+}
+
+func (s *Service) DeleteField(name string) error {
+	f, err := s.repo.GetField(name)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return ErrNotFound
+	}
+	return s.repo.DeleteField(name)
+}
+
+func (s *Service) Validate(t entity.ResourceType, labels *entity.Labels) error {
+	err := labels.NormalizeTags()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLabel, err.Error())
+	}
+	if len(labels.Fields) == 0 {
+		return nil
+	}
+	fields, err := s.fields()
+	if err != nil {
+		return err
+	}
+	for name, value := range labels.Fields {
+		f, ok := fields[name]
+		if !ok || !f.AppliesTo(t) {
+			return fmt.Errorf("%w: no custom field %s on %ss", ErrInvalidLabel, name, t)
+		}
+		labels.Fields[name], err = f.Normalize(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidLabel, err.Error())
+		}
+	}
+	return nil
+}
+
+func (s *Service) SetLabels(resource entity.Resource, labels *entity.Labels) error {
+	err := s.Validate(resource.Type, labels)
+	if err != nil {
+		return err
+	}
+	return s.repo.Set(resource, labels)
+}
+
+func (s *Service) PatchedLabels(resource entity.Resource, tags *[]string, fields map[string]string) (*entity.Labels, error) {
+	current, err := s.repo.Get(resource.Type, []string{resource.ID})
+	if err != nil {
+		return nil, err
+	}
+	labels, ok := current[resource.ID]
+	if !ok {
+		labels = &entity.Labels{}
+	}
+	if labels.Fields == nil {
+		labels.Fields = map[string]string{}
+	}
+	if tags != nil {
+		labels.Tags = *tags
+	}
+	for name, value := range fields {
+		if value == "" {
+			delete(labels.Fields, name)
+			continue
+		}
+		labels.Fields[name] = value
+	}
+	err = s.Validate(resource.Type, labels)
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (s *Service) GetLabels(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error) {
+	if len(ids) == 0 {
+		return map[string]*entity.Labels{}, nil
+	}
+	return s.repo.Get(t, ids)
+}
+
+//Match normalizes the filter like the labels it is compared with
+func (s *Service) Match(t entity.ResourceType, filter entity.LabelFilter) (map[string]bool, error) {
+	labels := &entity.Labels{Tags: filter.Tags, Fields: filter.Fields}
+	err := s.Validate(t, labels)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.repo.Find(t, entity.LabelFilter{Tags: labels.Tags, Fields: labels.Fields})
+	if err != nil {
+		return nil, err
+	}
+	matches := map[string]bool{}
+	for _, id := range ids {
+		matches[id] = true
+	}
+	return matches, nil
+}
+
+func (s *Service) fields() (map[string]*entity.CustomField, error) {
+	list, err := s.repo.ListFields()
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]*entity.CustomField{}
+	for _, f := range list {
+		fields[f.Name] = f
+	}
+	return fields, nil
+}
+
+func validateField(f *entity.CustomField) error {
+	if !fieldName.MatchString(f.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits and underscores", ErrInvalidField)
+	}
+	if _, ok := entity.ParseFieldType(string(f.Type)); !ok {
+		return fmt.Errorf("%w: unknown type %s", ErrInvalidField, f.Type)
+	}
+	if f.Type == entity.FieldEnum && len(f.Options) == 0 {
+		return fmt.Errorf("%w: enum fields need options", ErrInvalidField)
+	}
+	if f.Type != entity.FieldEnum && len(f.Options) > 0 {
+		return fmt.Errorf("%w: only enum fields take options", ErrInvalidField)
+	}
+	for _, option := range f.Options {
+		if strings.TrimSpace(option) == "" || strings.Contains(option, ",") {
+			return fmt.Errorf("%w: options must be non empty and without commas", ErrInvalidField)
+		}
+	}
+	for _, t := range f.Resources {
+		if t != entity.ResourceOrder && t != entity.ResourceRequirement && t != entity.ResourceTask {
+			return fmt.Errorf("%w: fields apply to orders, requirements or tasks, not %s", ErrInvalidField, t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package labels
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"reflect"
+	"testing"
+)
+
+type fakeRepo struct {
+	Repository
+	fields  []*entity.CustomField
+	current map[string]*entity.Labels
+}
+
+func (r fakeRepo) ListFields() ([]*entity.CustomField, error) {
+	return r.fields, nil
+}
+
+func (r fakeRepo) Get(t entity.ResourceType, ids []string) (map[string]*entity.Labels, error) {
+	return r.current, nil
+}
+
+var testFields = []*entity.CustomField{
+	{Name: "region", Type: entity.FieldEnum, Options: []string{"eu", "us"}},
+	{Name: "budget", Type: entity.FieldNumber, Resources: []entity.ResourceType{entity.ResourceOrder}},
+	{Name: "due", Type: entity.FieldDate},
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource entity.ResourceType
+		labels   entity.Labels
+		want     entity.Labels
+		wantErr  bool
+	}{
+		{name: "tags normalized", resource: entity.ResourceTask, labels: entity.Labels{Tags: []string{" Urgent", "b", "urgent"}}, want: entity.Labels{Tags: []string{"b", "urgent"}}},
+		{name: "tag with a comma", resource: entity.ResourceTask, labels: entity.Labels{Tags: []string{"a,b"}}, wantErr: true},
+		{name: "empty tag", resource: entity.ResourceTask, labels: entity.Labels{Tags: []string{" "}}, wantErr: true},
+		{name: "values normalized", resource: entity.ResourceOrder, labels: entity.Labels{Fields: map[string]string{"budget": " 1.50", "region": "eu"}}, want: entity.Labels{Fields: map[string]string{"budget": "1.5", "region": "eu"}}},
+		{name: "unknown field", resource: entity.ResourceOrder, labels: entity.Labels{Fields: map[string]string{"owner": "x"}}, wantErr: true},
+		{name: "field not on the resource type", resource: entity.ResourceTask, labels: entity.Labels{Fields: map[string]string{"budget": "1"}}, wantErr: true},
+		{name: "enum value not an option", resource: entity.ResourceTask, labels: entity.Labels{Fields: map[string]string{"region": "asia"}}, wantErr: true},
+		{name: "malformed date", resource: entity.ResourceRequirement, labels: entity.Labels{Fields: map[string]string{"due": "18/10/2026"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(fakeRepo{fields: testFields})
+
+			err := s.Validate(tt.resource, &tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidLabel) {
+					t.Errorf("Validate() error = %v, want ErrInvalidLabel", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(tt.labels, tt.want) {
+				t.Errorf("Validate() labels = %+v, want %+v", tt.labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchedLabels(t *testing.T) {
+	order := entity.Resource{Type: entity.ResourceOrder, ID: "o1"}
+	tags := func(tags ...string) *[]string { return &tags }
+	tests := []struct {
+		name    string
+		tags    *[]string
+		fields  map[string]string
+		want    *entity.Labels
+		wantErr bool
+	}{
+		{name: "nil tags keep the current", fields: map[string]string{"due": "2026-10-18"}, want: &entity.Labels{Tags: []string{"late"}, Fields: map[string]string{"region": "eu", "budget": "10", "due": "2026-10-18"}}},
+		{name: "tags replaced", tags: tags("New"), want: &entity.Labels{Tags: []string{"new"}, Fields: map[string]string{"region": "eu", "budget": "10"}}},
+		{name: "empty value removes the field", fields: map[string]string{"budget": ""}, want: &entity.Labels{Tags: []string{"late"}, Fields: map[string]string{"region": "eu"}}},
+		{name: "invalid patch", fields: map[string]string{"region": "asia"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := map[string]*entity.Labels{
+				"o1": {Tags: []string{"late"}, Fields: map[string]string{"region": "eu", "budget": "10"}},
+			}
+			s := NewService(fakeRepo{fields: testFields, current: current})
+
+			got, err := s.PatchedLabels(order, tt.tags, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PatchedLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PatchedLabels() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package loginguard
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type Reader interface {
+	GetCounter(key string) (*entity.LoginCounter, error)
+	ListAttempts(filter entity.LoginAttemptFilter) ([]*entity.LoginAttempt, error)
+	HasSucceededFrom(username string, ip string) (bool, error)
+}
+
+type Writer interface {
+	//IncrementFailures adds a failure to the counter, restarting it when the
+	//last failure is older than since, and returns the updated counter
+	IncrementFailures(key string, at time.Time, since time.Time) (*entity.LoginCounter, error)
+	Lock(key string) error
+	DeleteCounter(key string) error
+	CreateAttempt(a *entity.LoginAttempt) (int, error)
+}
+
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	Allow(username string, ip string) error
+	Fail(username string, ip string) error
+	//AllowReset returns a *ThrottledError when too many password resets
+	//were requested for the username or from the ip, and counts the request
+	//otherwise
+	AllowReset(username string, ip string) error
+	Succeed(username string, ip string) error
+	Unlock(username string) error
+	Record(attempt *entity.LoginAttempt) error
+	ListAttempts(filter entity.LoginAttemptFilter) ([]*entity.LoginAttempt, error)
+}
@@ -0,0 +1,191 @@
+package loginguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+var ErrAccountLocked = errors.New("account locked")
+
+//ThrottledError tells the caller how long to wait before the next attempt
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter)
+}
+
+type Policy struct {
+	//FreeAttempts failures are allowed before backoff starts
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	//LockAfter consecutive failures lock the account until an admin unlocks it
+	LockAfter int
+	//IPFreeAttempts is higher than FreeAttempts since offices share an IP
+	IPFreeAttempts int
+	//Window after which counters of inactive keys start over
+	Window time.Duration
+}
+
+var DefaultPolicy = Policy{
+	FreeAttempts:   3,
+	BaseDelay:      time.Second,
+	MaxDelay:       15 * time.Minute,
+	LockAfter:      10,
+	IPFreeAttempts: 20,
+	Window:         24 * time.Hour,
+}
+
+type Service struct {
+	repo   Repository
+	policy Policy
+}
+
+func NewService(r Repository, p Policy) *Service {
+	return &Service{
+		repo:   r,
+		policy: p,
+	}
+}
+
+//Allow is checked before the password. Counters are kept per username
+//whether or not the account exists so the responses don't reveal it.
+func (s *Service) Allow(username string, ip string) error {
+	account, err := s.repo.GetCounter(accountKey(username))
+	if err != nil {
+		return err
+	}
+	if account != nil && account.Locked {
+		return ErrAccountLocked
+	}
+	wait := s.backoff(account, s.policy.FreeAttempts)
+	address, err := s.repo.GetCounter(ipKey(ip))
+	if err != nil {
+		return err
+	}
+	if w := s.backoff(address, s.policy.IPFreeAttempts); w > wait {
+		wait = w
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (s *Service) Fail(username string, ip string) error {
+	now := time.Now()
+	since := now.Add(-s.policy.Window)
+	account, err := s.repo.IncrementFailures(accountKey(username), now, since)
+	if err != nil {
+		return err
+	}
+	if _, err = s.repo.IncrementFailures(ipKey(ip), now, since); err != nil {
+		return err
+	}
+	if account.Failures >= s.policy.LockAfter {
+		return s.repo.Lock(accountKey(username))
+	}
+	return nil
+}
+
+//AllowReset throttles password reset requests like failed logins, except
+//that every request counts. They have their own counters, so they neither
+//lock the account nor slow down its logins.
+func (s *Service) AllowReset(username string, ip string) error {
+	account, err := s.repo.GetCounter(resetKey(accountKey(username)))
+	if err != nil {
+		return err
+	}
+	wait := s.backoff(account, s.policy.FreeAttempts)
+	address, err := s.repo.GetCounter(resetKey(ipKey(ip)))
+	if err != nil {
+		return err
+	}
+	if w := s.backoff(address, s.policy.IPFreeAttempts); w > wait {
+		wait = w
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	now := time.Now()
+	since := now.Add(-s.policy.Window)
+	if _, err = s.repo.IncrementFailures(resetKey(accountKey(username)), now, since); err != nil {
+		return err
+	}
+	_, err = s.repo.IncrementFailures(resetKey(ipKey(ip)), now, since)
+	return err
+}
+
+//Succeed clears the account counter. The IP counter is left alone so one
+//valid account can't be used to reset the throttle for guessing others.
+func (s *Service) Succeed(username string, ip string) error {
+	return s.repo.DeleteCounter(accountKey(username))
+}
+
+func (s *Service) Unlock(username string) error {
+	return s.repo.DeleteCounter(accountKey(username))
+}
+
+//Record stores the attempt, flagging successful logins from an address the
+//user never logged in from before and attempts made while throttled
+func (s *Service) Record(attempt *entity.LoginAttempt) error {
+	switch attempt.Outcome {
+	case entity.LoginSucceeded:
+		known, err := s.repo.HasSucceededFrom(attempt.Username, attempt.IP)
+		if err != nil {
+			return err
+		}
+		attempt.Suspicious = !known
+	case entity.LoginThrottled, entity.LoginLocked:
+		attempt.Suspicious = true
+	}
+	_, err := s.repo.CreateAttempt(attempt)
+	return err
+}
+
+func (s *Service) ListAttempts(filter entity.LoginAttemptFilter) ([]*entity.LoginAttempt, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.repo.ListAttempts(filter)
+}
+
+//backoff doubles the delay for every failure past the free attempts
+func (s *Service) backoff(c *entity.LoginCounter, free int) time.Duration {
+	if c == nil || c.Failures < free {
+		return 0
+	}
+	if time.Since(c.LastFailure) > s.policy.Window {
+		return 0
+	}
+	delay := s.policy.BaseDelay
+	for i := free; i < c.Failures && delay < s.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.policy.MaxDelay {
+		delay = s.policy.MaxDelay
+	}
+	wait := time.Until(c.LastFailure.Add(delay))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func accountKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func resetKey(key string) string {
+	return "reset:" + key
+}
@@ -0,0 +1,67 @@
+package loginguard
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	counters map[string]*entity.LoginCounter
+}
+
+func (r *fakeRepo) GetCounter(key string) (*entity.LoginCounter, error) {
+	return r.counters[key], nil
+}
+
+func (r *fakeRepo) IncrementFailures(key string, at time.Time, since time.Time) (*entity.LoginCounter, error) {
+	c, ok := r.counters[key]
+	if !ok || c.LastFailure.Before(since) {
+		c = &entity.LoginCounter{Key: key}
+		r.counters[key] = c
+	}
+	c.Failures++
+	c.LastFailure = at
+	return c, nil
+}
+
+func TestAllowReset(t *testing.T) {
+	policy := Policy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, LockAfter: 3, IPFreeAttempts: 3, Window: time.Hour}
+	tests := []struct {
+		name string
+		//requests are made in turn as username from ip, the last must be
+		//throttled when wantThrottled
+		requests      [][2]string
+		wantThrottled bool
+	}{
+		{name: "free requests", requests: [][2]string{{"alice", "10.0.0.1"}, {"alice", "10.0.0.1"}}},
+		{name: "account past the free requests", requests: [][2]string{{"alice", "10.0.0.1"}, {"Alice", "10.0.0.2"}, {"alice", "10.0.0.3"}}, wantThrottled: true},
+		{name: "address past the free requests", requests: [][2]string{{"alice", "10.0.0.1"}, {"bob", "10.0.0.1"}, {"carol", "10.0.0.1"}, {"dave", "10.0.0.1"}}, wantThrottled: true},
+		{name: "other accounts and addresses", requests: [][2]string{{"alice", "10.0.0.1"}, {"alice", "10.0.0.1"}, {"bob", "10.0.0.2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{counters: map[string]*entity.LoginCounter{}}
+			s := NewService(repo, policy)
+
+			var err error
+			for i, request := range tt.requests {
+				err = s.AllowReset(request[0], request[1])
+				if err != nil && i < len(tt.requests)-1 {
+					t.Fatalf("AllowReset() request %d error = %v", i, err)
+				}
+			}
+			var throttled *ThrottledError
+			if errors.As(err, &throttled) != tt.wantThrottled {
+				t.Fatalf("AllowReset() error = %v, wantThrottled %v", err, tt.wantThrottled)
+			}
+			//reset requests never lock the account or slow down its logins
+			err = s.Allow("alice", "10.0.0.9")
+			if err != nil {
+				t.Errorf("Allow() after resets error = %v", err)
+			}
+		})
+	}
+}
@@ -8,15 +8,36 @@ import (
 //Reader interface
 type Reader interface {
 	Get(id string) (*entity.Orders, error)
-	Search(query string) ([]*entity.Orders, error)
-	List() ([]*entity.Orders, error)
+	//Search and List return orders in any of statuses, or every order when
+	//statuses is empty
+	Search(query string, statuses []entity.OrderStatus) ([]*entity.Orders, error)
+	List(statuses []entity.OrderStatus) ([]*entity.Orders, error)
+	ListByCustomer(customerID string) ([]*entity.Orders, error)
+	//GetProgress returns the order's status and one row per task, ordered
+	//by requirement. The status is empty when the order doesn't exist.
+	GetProgress(orderID string) (entity.OrderStatus, []*entity.ProgressRow, error)
+	//ListDeleted returns the soft deleted orders, most recently deleted first
+	ListDeleted() ([]*entity.Orders, error)
 }
 
 //Writer book writer
 type Writer interface {
 	Create(e *entity.Orders) (string, error)
+	//Update saves everything but the status, which only changes through
+	//SetStatus
 	Update(e *entity.Orders) error
+	//SetStatus moves the order from one status to another and returns false
+	//when the order is no longer in status from
+	SetStatus(id string, from entity.OrderStatus, to entity.OrderStatus) (bool, error)
+	//Delete soft deletes the order along with its requirements, tasks and
+	//submissions, all stamped with the same time
 	Delete(id string) error
+	//Restore undeletes the order and what was deleted along with it. It
+	//returns false when there is no deleted order with that id.
+	Restore(id string) (bool, error)
+	//Purge permanently removes every record soft deleted before the given
+	//time in a single transaction and returns how many rows it removed
+	Purge(before time.Time) (int64, error)
 }
 
 //Repository interface
@@ -27,9 +48,17 @@ type Repository interface {
 
 type UseCase interface {
 	GetOrder(id string) (*entity.Orders, error)
-	SearchOrders(query string) ([]*entity.Orders, error)
-	ListOrders() ([]*entity.Orders, error)
-	NewOrder(title string, description string, deadline time.Time) (string, error)
+	SearchOrders(query string, statuses []entity.OrderStatus) ([]*entity.Orders, error)
+	ListOrders(statuses []entity.OrderStatus) ([]*entity.Orders, error)
+	NewOrder(title string, description string, deadline time.Time, status entity.OrderStatus, priority entity.Priority, slaPolicy string, customerID string) (string, error)
+	ListCustomerOrders(customerID string) ([]*entity.Orders, error)
 	UpdateOrder(o *entity.Orders) error
 	DeleteOrder(id string) error
+	TransitionOrder(id string, to entity.OrderStatus) (*entity.Orders, error)
+	SyncStatus(id string) (*entity.Orders, error)
+	EnsureNotClosed(id string) error
+	GetProgress(id string) (*entity.OrderProgress, error)
+	ListDeletedOrders() ([]*entity.Orders, error)
+	RestoreOrder(id string) error
+	Purge(retention time.Duration) (int64, error)
 }
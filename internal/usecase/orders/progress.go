@@ -0,0 +1,104 @@
+package orders
+
+import (
+	"math"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+//GetProgress rolls up the order's requirements and tasks, read in a single
+//query
+func (s *Service) GetProgress(id string) (*entity.OrderProgress, error) {
+	status, rows, err := s.repo.GetProgress(id)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return nil, ErrNotFound
+	}
+	return buildProgress(id, status, rows, time.Now()), nil
+}
+
+//buildProgress counts a requirement as complete by the share of its tasks
+//that are finished, requirements without tasks count as not started
+func buildProgress(id string, status entity.OrderStatus, rows []*entity.ProgressRow, now time.Time) *entity.OrderProgress {
+	p := &entity.OrderProgress{
+		OrderID: id,
+		Status:  status,
+		TaskCounts: map[entity.Status]int{
+			entity.Unfinished: 0,
+			entity.InReview:   0,
+			entity.Finished:   0,
+		},
+	}
+	index := map[int]int{}
+	prerequisites := map[string][]string{}
+	for _, row := range rows {
+		i, ok := index[row.RequirementID]
+		if !ok {
+			i = len(p.Requirements)
+			index[row.RequirementID] = i
+			p.Requirements = append(p.Requirements, entity.RequirementProgress{
+				ID:      row.RequirementID,
+				Request: row.Request,
+				Status:  row.RequirementStatus,
+			})
+		}
+		if row.TaskID == "" {
+			continue
+		}
+		req := &p.Requirements[i]
+		req.TotalTasks++
+		p.TaskCounts[row.TaskStatus]++
+		switch row.TaskStatus {
+		case entity.Finished:
+			req.FinishedTasks++
+		case entity.InReview:
+			p.OpenReviewRounds++
+			p.PendingReviewers += row.PendingReviewers
+		}
+		if row.TaskStatus != entity.Finished && !row.Deadline.IsZero() && row.Deadline.Before(now) {
+			p.OverdueTasks = append(p.OverdueTasks, row.TaskID)
+		}
+		if !row.Allowed && len(row.Prerequisites) > 0 {
+			prerequisites[row.TaskID] = row.Prerequisites
+		}
+	}
+	for _, row := range rows {
+		if waitingOn, ok := prerequisites[row.TaskID]; ok {
+			p.BlockedTasks = append(p.BlockedTasks, entity.BlockedTask{
+				TaskID:    row.TaskID,
+				WaitingOn: waitingOn,
+				Depth:     chainDepth(row.TaskID, prerequisites, map[string]bool{}),
+			})
+		}
+	}
+	if len(p.Requirements) > 0 {
+		var complete float64
+		for _, req := range p.Requirements {
+			if req.TotalTasks > 0 {
+				complete += float64(req.FinishedTasks) / float64(req.TotalTasks)
+			}
+		}
+		p.PercentComplete = math.Round(complete/float64(len(p.Requirements))*10000) / 100
+	}
+	return p
+}
+
+//chainDepth follows the prerequisites of a task, visiting guards against
+//cycles
+func chainDepth(taskID string, prerequisites map[string][]string, visiting map[string]bool) int {
+	if visiting[taskID] {
+		return 0
+	}
+	visiting[taskID] = true
+	defer delete(visiting, taskID)
+	depth := 0
+	for _, prerequisite := range prerequisites[taskID] {
+		if d := chainDepth(prerequisite, prerequisites, visiting) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
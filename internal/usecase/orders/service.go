@@ -2,27 +2,80 @@ package orders
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/tasks"
 )
 
+//DefaultRetention is how long soft deleted records are kept before Purge
+//removes them
+const DefaultRetention = 30 * 24 * time.Hour
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	ErrOrderClosed       = errors.New("order is cancelled or archived")
+	ErrInvalidPriority   = errors.New("invalid priority")
+	ErrStatusChanged     = errors.New("order status was changed concurrently")
+)
+
+//syncAttempts bounds how often SyncStatus re-reads an order whose status
+//another request changed in the meantime
+const syncAttempts = 3
+
+//Service keeps each order's status in step with its requirements: work on
+//them moves an order between Open, InProgress and Validated, while Draft,
+//Cancelled and Archived are only entered through TransitionOrder
 type Service struct {
-	repo Repository
+	repo         Repository
+	requirements requirements.UseCase
+	tasks        tasks.UseCase
 }
 
-func NewService(r Repository) *Service {
+func NewService(r Repository, req requirements.UseCase, t tasks.UseCase) *Service {
 	return &Service{
-		repo: r,
+		repo:         r,
+		requirements: req,
+		tasks:        t,
 	}
 }
 
-func (s *Service) NewOrder(title string, description string, deadline time.Time) (string, error) {
-	o := entity.NewOrder(title, description, deadline)
+//NewOrder creates an order as a draft or open, open being the default. The
+//priority defaults to normal.
+func (s *Service) NewOrder(title string, description string, deadline time.Time, status entity.OrderStatus, priority entity.Priority, slaPolicy string, customerID string) (string, error) {
+	o, err := PrepareOrder(title, description, deadline, status, priority, slaPolicy, customerID)
+	if err != nil {
+		return "", err
+	}
 	return s.repo.Create(o)
 }
 
+//PrepareOrder builds a new order without saving it, checking the status it
+//starts in and its priority. Empty values keep the defaults.
+func PrepareOrder(title string, description string, deadline time.Time, status entity.OrderStatus, priority entity.Priority, slaPolicy string, customerID string) (*entity.Orders, error) {
+	o := entity.NewOrder(title, description, deadline)
+	o.SLAPolicy = slaPolicy
+	o.CustomerID = customerID
+	if priority != "" {
+		if _, ok := entity.ParsePriority(string(priority)); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPriority, priority)
+		}
+		o.Priority = priority
+	}
+	switch status {
+	case "", entity.OrderOpen:
+	case entity.OrderDraft:
+		o.Status = entity.OrderDraft
+	default:
+		return nil, fmt.Errorf("%w: orders start as %s or %s", ErrInvalidTransition, entity.OrderDraft, entity.OrderOpen)
+	}
+	return o, nil
+}
+
 func (s *Service) GetOrder(id string) (*entity.Orders, error) {
 	o, err := s.repo.Get(id)
 
@@ -31,14 +84,14 @@ func (s *Service) GetOrder(id string) (*entity.Orders, error) {
 	}
 
 	if o == nil {
-		return nil, errors.New("not found")
+		return nil, ErrNotFound
 	}
 
 	return o, nil
 }
 
-func (s *Service) SearchOrders(query string) ([]*entity.Orders, error) {
-	orders, err := s.repo.Search(strings.ToLower(query))
+func (s *Service) SearchOrders(query string, statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	orders, err := s.repo.Search(strings.ToLower(query), statuses)
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +101,8 @@ func (s *Service) SearchOrders(query string) ([]*entity.Orders, error) {
 	return orders, nil
 }
 
-func (s *Service) ListOrders() ([]*entity.Orders, error) {
-	orders, err := s.repo.List()
+func (s *Service) ListOrders(statuses []entity.OrderStatus) ([]*entity.Orders, error) {
+	orders, err := s.repo.List(statuses)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +112,10 @@ func (s *Service) ListOrders() ([]*entity.Orders, error) {
 	return orders, nil
 }
 
+func (s *Service) ListCustomerOrders(customerID string) ([]*entity.Orders, error) {
+	return s.repo.ListByCustomer(customerID)
+}
+
 func (s *Service) DeleteOrder(id string) error {
 	_, err := s.GetOrder(id)
 	if err != nil {
@@ -67,6 +124,153 @@ func (s *Service) DeleteOrder(id string) error {
 	return s.repo.Delete(id)
 }
 
+func (s *Service) ListDeletedOrders() ([]*entity.Orders, error) {
+	return s.repo.ListDeleted()
+}
+
+//RestoreOrder brings back a deleted order with the requirements, tasks and
+//submissions deleted along with it. Those deleted on their own before the
+//order stay deleted.
+func (s *Service) RestoreOrder(id string) error {
+	restored, err := s.repo.Restore(id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//Purge permanently removes records that have been deleted for longer than
+//retention
+func (s *Service) Purge(retention time.Duration) (int64, error) {
+	if retention < 0 {
+		return 0, fmt.Errorf("retention must not be negative, got %s", retention)
+	}
+	return s.repo.Purge(time.Now().Add(-retention))
+}
+
 func (s *Service) UpdateOrder(o *entity.Orders) error {
 	return s.repo.Update(o)
 }
+
+//TransitionOrder moves an order to a status that is entered on request.
+//InProgress and Validated follow from the requirements and can't be set.
+//Publishing a draft syncs it right away, since work may already have been
+//assigned.
+func (s *Service) TransitionOrder(id string, to entity.OrderStatus) (*entity.Orders, error) {
+	o, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	if to == entity.OrderInProgress || to == entity.OrderValidated {
+		return nil, fmt.Errorf("%w: %s is set from the requirements' progress", ErrInvalidTransition, to)
+	}
+	if !o.CanTransition(to) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, to)
+	}
+	updated, err := s.repo.SetStatus(id, o.Status, to)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrStatusChanged
+	}
+	o.Status = to
+	if to == entity.OrderOpen {
+		return s.SyncStatus(id)
+	}
+	return o, nil
+}
+
+//EnsureNotClosed returns ErrOrderClosed when the order takes no new
+//requirements or tasks
+func (s *Service) EnsureNotClosed(id string) error {
+	o, err := s.GetOrder(id)
+	if err != nil {
+		return err
+	}
+	if o.Status.Closed() {
+		return ErrOrderClosed
+	}
+	return nil
+}
+
+//SyncStatus derives each requirement's status from its tasks and then the
+//order's status from its requirements. It is called whenever a task or
+//requirement of the order changes, possibly concurrently, so the status is
+//only moved on from the one it was derived from.
+func (s *Service) SyncStatus(id string) (*entity.Orders, error) {
+	reqs, err := s.requirements.GetRequirementsbyOrderId(id)
+	if err != nil {
+		return nil, err
+	}
+	orderTasks, err := s.tasks.GetTasksOnSpecificOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	tasksOf := map[int][]*entity.TaskWithDetails{}
+	for _, t := range orderTasks {
+		tasksOf[t.RequirementID] = append(tasksOf[t.RequirementID], t)
+	}
+	started, finished := 0, 0
+	for _, req := range reqs {
+		status := requirementStatus(tasksOf[req.Id])
+		if status != req.Status {
+			req.Status = status
+			err = s.requirements.UpdateRequirement(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if status != entity.NotAssigned {
+			started++
+		}
+		if status == entity.AssignedAndFinished {
+			finished++
+		}
+	}
+	next := entity.OrderOpen
+	switch {
+	case len(reqs) > 0 && finished == len(reqs):
+		next = entity.OrderValidated
+	case started > 0:
+		next = entity.OrderInProgress
+	}
+	for attempt := 0; attempt < syncAttempts; attempt++ {
+		o, err := s.GetOrder(id)
+		if err != nil {
+			return nil, err
+		}
+		if !o.Status.Tracked() || next == o.Status {
+			return o, nil
+		}
+		if !o.CanTransition(next) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, next)
+		}
+		updated, err := s.repo.SetStatus(id, o.Status, next)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			o.Status = next
+			return o, nil
+		}
+	}
+	return nil, ErrStatusChanged
+}
+
+//requirementStatus is AssignedAndFinished once every task of the
+//requirement is finished
+func requirementStatus(reqTasks []*entity.TaskWithDetails) entity.Status {
+	if len(reqTasks) == 0 {
+		return entity.NotAssigned
+	}
+	for _, t := range reqTasks {
+		if t.Status != entity.Finished {
+			return entity.Assigned
+		}
+	}
+	return entity.AssignedAndFinished
+}
@@ -0,0 +1,121 @@
+package orders
+
+import (
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/tasks"
+	"testing"
+)
+
+//fakeRepo holds one order. interfere runs before each SetStatus, standing
+//in for a request that changes the status concurrently.
+type fakeRepo struct {
+	Repository
+	order     *entity.Orders
+	interfere func(o *entity.Orders)
+}
+
+func (r *fakeRepo) Get(id string) (*entity.Orders, error) {
+	copied := *r.order
+	return &copied, nil
+}
+
+func (r *fakeRepo) SetStatus(id string, from entity.OrderStatus, to entity.OrderStatus) (bool, error) {
+	if r.interfere != nil {
+		r.interfere(r.order)
+	}
+	if r.order.Status != from {
+		return false, nil
+	}
+	r.order.Status = to
+	return true, nil
+}
+
+type fakeRequirements struct {
+	requirements.UseCase
+	reqs []*entity.Requirements
+}
+
+func (f fakeRequirements) GetRequirementsbyOrderId(orderID string) ([]*entity.Requirements, error) {
+	return f.reqs, nil
+}
+
+func (f fakeRequirements) UpdateRequirement(e *entity.Requirements) error {
+	return nil
+}
+
+type fakeTasks struct {
+	tasks.UseCase
+	tasks []*entity.TaskWithDetails
+}
+
+func (f fakeTasks) GetTasksOnSpecificOrder(orderID string) ([]*entity.TaskWithDetails, error) {
+	return f.tasks, nil
+}
+
+func TestSyncStatus(t *testing.T) {
+	finished := &entity.TaskWithDetails{ID: "t1", RequirementID: 1, Status: entity.Finished}
+	unfinished := &entity.TaskWithDetails{ID: "t2", RequirementID: 1, Status: entity.Unfinished}
+	tests := []struct {
+		name      string
+		status    entity.OrderStatus
+		tasks     []*entity.TaskWithDetails
+		interfere func(o *entity.Orders)
+		want      entity.OrderStatus
+		wantErr   error
+	}{
+		{name: "open order with work in progress", status: entity.OrderOpen, tasks: []*entity.TaskWithDetails{unfinished}, want: entity.OrderInProgress},
+		{name: "all work finished", status: entity.OrderInProgress, tasks: []*entity.TaskWithDetails{finished}, want: entity.OrderValidated},
+		{name: "validated order loses its tasks", status: entity.OrderValidated, want: entity.OrderOpen},
+		{name: "draft is not tracked", status: entity.OrderDraft, tasks: []*entity.TaskWithDetails{finished}, want: entity.OrderDraft},
+		{
+			name:   "concurrent sync reached the same status",
+			status: entity.OrderOpen,
+			tasks:  []*entity.TaskWithDetails{finished},
+			interfere: func(o *entity.Orders) {
+				o.Status = entity.OrderValidated
+			},
+			want: entity.OrderValidated,
+		},
+		{
+			name:   "order cancelled meanwhile is left alone",
+			status: entity.OrderOpen,
+			tasks:  []*entity.TaskWithDetails{unfinished},
+			interfere: func(o *entity.Orders) {
+				o.Status = entity.OrderCancelled
+			},
+			want: entity.OrderCancelled,
+		},
+		{
+			name:   "status keeps changing",
+			status: entity.OrderOpen,
+			tasks:  []*entity.TaskWithDetails{finished},
+			interfere: func(o *entity.Orders) {
+				if o.Status == entity.OrderOpen {
+					o.Status = entity.OrderInProgress
+				} else {
+					o.Status = entity.OrderOpen
+				}
+			},
+			wantErr: ErrStatusChanged,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{order: &entity.Orders{ID: "o1", Status: tt.status}, interfere: tt.interfere}
+			reqs := fakeRequirements{reqs: []*entity.Requirements{{Id: 1, OrderID: "o1"}}}
+			s := NewService(repo, reqs, fakeTasks{tasks: tt.tasks})
+
+			o, err := s.SyncStatus("o1")
+			if err != tt.wantErr {
+				t.Fatalf("SyncStatus() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if o.Status != tt.want || repo.order.Status != tt.want {
+				t.Errorf("status = %s, stored %s, want %s", o.Status, repo.order.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package policy
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Repository resolves the relationships between users and resources
+type Repository interface {
+	GetTaskRelations(taskID string) (*entity.TaskRelations, error)
+	GetSubmissionTaskID(submissionID string) (string, error)
+}
+
+//UseCase answers whether an actor may perform an action on a resource
+type UseCase interface {
+	Authorize(actor entity.Actor, action entity.Permission, resource entity.Resource) error
+}
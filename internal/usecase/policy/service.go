@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/roles"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+//Service combines the role permissions with resource relationships:
+//the role decides whether an action is allowed at all, the relationship
+//decides whether it is allowed on this particular resource. Orders and
+//requirements have no owner, so the role alone covers them. Admins skip
+//the relationship checks.
+type Service struct {
+	repo  Repository
+	roles roles.UseCase
+}
+
+func NewService(r Repository, roles roles.UseCase) *Service {
+	return &Service{
+		repo:  r,
+		roles: roles,
+	}
+}
+
+func (s *Service) Authorize(actor entity.Actor, action entity.Permission, resource entity.Resource) error {
+	allowed, err := s.roles.HasPermission(actor.Role, action)
+	if err != nil {
+		return deny(err)
+	}
+	if !allowed {
+		return deny(fmt.Errorf("role %s lacks %s", actor.Role, action))
+	}
+	if !actor.InScope(action) {
+		return deny(fmt.Errorf("API key lacks %s", action))
+	}
+	if resource.ID == "" || actor.Role == entity.RoleAdmin {
+		return nil
+	}
+	switch resource.Type {
+	case entity.ResourceTask:
+		return s.authorizeTask(actor, action, resource.ID)
+	case entity.ResourceSubmission:
+		taskID, err := s.repo.GetSubmissionTaskID(resource.ID)
+		if err != nil {
+			return deny(err)
+		}
+		return s.authorizeTask(actor, action, taskID)
+	}
+	return nil
+}
+
+func (s *Service) authorizeTask(actor entity.Actor, action entity.Permission, taskID string) error {
+	task, err := s.repo.GetTaskRelations(taskID)
+	if err != nil {
+		return deny(err)
+	}
+	switch action {
+	case entity.PermTaskWork:
+		if task.UserID != actor.UserID {
+			return deny(errors.New("task is assigned to another user"))
+		}
+	case entity.PermSubmissionReview:
+		if task.UserID == actor.UserID {
+			return deny(errors.New("can't review own task"))
+		}
+		if !task.IsReviewer(actor.UserID) {
+			return deny(errors.New("not a reviewer of the task"))
+		}
+	case entity.PermTaskAssign:
+		if task.AssignerID != actor.UserID {
+			return deny(errors.New("task was assigned by another user"))
+		}
+	}
+	return nil
+}
+
+func deny(reason error) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, reason.Error())
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/roles"
+	"testing"
+)
+
+//fakeRoles grants every role the permissions listed for it
+type fakeRoles struct {
+	roles.UseCase
+	grants map[string][]entity.Permission
+}
+
+func (f fakeRoles) HasPermission(roleName string, p entity.Permission) (bool, error) {
+	for _, granted := range f.grants[roleName] {
+		if granted == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeRepo struct {
+	Repository
+	task *entity.TaskRelations
+}
+
+func (r fakeRepo) GetTaskRelations(taskID string) (*entity.TaskRelations, error) {
+	return r.task, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	grants := map[string][]entity.Permission{
+		"worker":         {entity.PermTaskRead, entity.PermTaskWork},
+		"manager":        {entity.PermOrderRead, entity.PermOrderWrite},
+		entity.RoleAdmin: entity.AllPermissions,
+	}
+	task := entity.Resource{Type: entity.ResourceTask, ID: "t1"}
+	order := entity.Resource{Type: entity.ResourceOrder, ID: "o1"}
+	tests := []struct {
+		name     string
+		actor    entity.Actor
+		action   entity.Permission
+		resource entity.Resource
+		wantErr  bool
+	}{
+		{name: "user without key", actor: entity.Actor{UserID: "u1", Role: "worker"}, action: entity.PermTaskRead},
+		{name: "key in scope", actor: entity.Actor{UserID: "u1", Role: "worker", Scopes: []entity.Permission{entity.PermTaskRead}}, action: entity.PermTaskRead},
+		{name: "key out of scope", actor: entity.Actor{UserID: "u1", Role: "worker", Scopes: []entity.Permission{entity.PermTaskRead}}, action: entity.PermTaskWork, wantErr: true},
+		{name: "key with an empty scope", actor: entity.Actor{UserID: "u1", Role: "worker", Scopes: []entity.Permission{}}, action: entity.PermTaskRead, wantErr: true},
+		{name: "scope beyond the role", actor: entity.Actor{UserID: "u1", Role: "worker", Scopes: []entity.Permission{entity.PermUserWrite}}, action: entity.PermUserWrite, wantErr: true},
+		{name: "admin key out of scope", actor: entity.Actor{UserID: "a1", Role: entity.RoleAdmin, Scopes: []entity.Permission{entity.PermOrderRead}}, action: entity.PermUserWrite, wantErr: true},
+		{name: "key in scope on own task", actor: entity.Actor{UserID: "u1", Role: "worker", Scopes: []entity.Permission{entity.PermTaskWork}}, action: entity.PermTaskWork, resource: task},
+		{name: "key in scope on another's task", actor: entity.Actor{UserID: "u2", Role: "worker", Scopes: []entity.Permission{entity.PermTaskWork}}, action: entity.PermTaskWork, resource: task, wantErr: true},
+		{name: "order writer on any order", actor: entity.Actor{UserID: "m1", Role: "manager"}, action: entity.PermOrderWrite, resource: order},
+		{name: "order writer key without the scope", actor: entity.Actor{UserID: "m1", Role: "manager", Scopes: []entity.Permission{entity.PermOrderRead}}, action: entity.PermOrderWrite, resource: order, wantErr: true},
+		{name: "role without order writes", actor: entity.Actor{UserID: "u1", Role: "worker"}, action: entity.PermOrderWrite, resource: order, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(fakeRepo{task: &entity.TaskRelations{TaskID: "t1", UserID: "u1"}}, fakeRoles{grants: grants})
+
+			err := s.Authorize(tt.actor, tt.action, tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrForbidden) {
+				t.Errorf("Authorize() error = %v, want ErrForbidden", err)
+			}
+		})
+	}
+}
@@ -16,7 +16,11 @@ type Reader interface {
 type Writer interface {
 	Create(r *entity.Requirements) (int, error)
 	Update(r *entity.Requirements) error
+	//Delete soft deletes the requirement along with its tasks and submissions
 	Delete(id int) error
+	//Restore returns false when there is no deleted requirement with that id
+	//or its order is deleted
+	Restore(id int) (bool, error)
 }
 
 //Repository interface
@@ -34,4 +38,5 @@ type UseCase interface {
 	CreateRequirement(request string, expectedOutcome string, orderID string) (int, error)
 	UpdateRequirement(e *entity.Requirements) error
 	DeleteRequirement(id int) error
+	RestoreRequirement(id int) error
 }
@@ -7,6 +7,8 @@ import (
 	"order-validation-v2/internal/entity"
 )
 
+var ErrNotFound = errors.New("not found")
+
 type Service struct {
 	repo Repository
 }
@@ -40,7 +42,7 @@ func (s *Service) ListRequirements() ([]*entity.Requirements, error) {
 func (s *Service) DeleteRequirement(id int) error {
 	u, err := s.GetRequirementbyID(id)
 	if u == nil {
-		return errors.New("not found")
+		return ErrNotFound
 	}
 	if err != nil {
 		return err
@@ -48,6 +50,17 @@ func (s *Service) DeleteRequirement(id int) error {
 	return s.repo.Delete(id)
 }
 
+func (s *Service) RestoreRequirement(id int) error {
+	restored, err := s.repo.Restore(id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (s *Service) UpdateRequirement(e *entity.Requirements) error {
 	return s.repo.Update(e)
 }
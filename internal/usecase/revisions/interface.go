@@ -0,0 +1,33 @@
+package revisions
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	List(resource entity.Resource) ([]*entity.Revision, error)
+	Get(resource entity.Resource, number int) (*entity.Revision, error)
+	Latest(resource entity.Resource) (*entity.Revision, error)
+}
+
+//Writer only appends, revisions are never changed or removed
+type Writer interface {
+	//Create reports false when the resource already has a revision with
+	//the same number
+	Create(r *entity.Revision) (bool, error)
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	RecordOrder(before *entity.Orders, after *entity.Orders, authorID string) error
+	RecordRequirement(before *entity.Requirements, after *entity.Requirements, authorID string) error
+	ListRevisions(resource entity.Resource) ([]*entity.Revision, error)
+	Diff(resource entity.Resource, from int, to int) ([]entity.FieldChange, error)
+	Restore(resource entity.Resource, number int, authorID string) (*entity.Revision, error)
+}
@@ -0,0 +1,239 @@
+package revisions
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/requirements"
+)
+
+var (
+	ErrNotFound    = errors.New("revision not found")
+	ErrUnsupported = errors.New("revisions are only kept for orders and requirements")
+	ErrConflict    = errors.New("revision number kept being taken by concurrent changes")
+)
+
+//recordAttempts bounds how often a revision is renumbered when concurrent
+//changes take the next number first
+const recordAttempts = 5
+
+//Service keeps the content history of orders and requirements. Each
+//revision stores the full snapshot, so any two revisions can be compared
+//and any revision restored without replaying the ones in between.
+type Service struct {
+	repo         Repository
+	orders       orders.UseCase
+	requirements requirements.UseCase
+}
+
+func NewService(r Repository, o orders.UseCase, req requirements.UseCase) *Service {
+	return &Service{
+		repo:         r,
+		orders:       o,
+		requirements: req,
+	}
+}
+
+//RecordOrder stores the order as changed by authorID, before is nil when
+//the order was just created
+func (s *Service) RecordOrder(before *entity.Orders, after *entity.Orders, authorID string) error {
+	var previous map[string]string
+	if before != nil {
+		previous = orderSnapshot(before)
+	}
+	resource := entity.Resource{Type: entity.ResourceOrder, ID: after.ID}
+	_, err := s.record(resource, previous, orderSnapshot(after), authorID, 0)
+	return err
+}
+
+//RecordRequirement stores the requirement as changed by authorID, before
+//is nil when the requirement was just created
+func (s *Service) RecordRequirement(before *entity.Requirements, after *entity.Requirements, authorID string) error {
+	var previous map[string]string
+	if before != nil {
+		previous = requirementSnapshot(before)
+	}
+	resource := entity.Resource{Type: entity.ResourceRequirement, ID: strconv.Itoa(after.Id)}
+	_, err := s.record(resource, previous, requirementSnapshot(after), authorID, 0)
+	return err
+}
+
+//FirstOrderRevision is the revision RecordOrder stores for a new order, for
+//orders created where the service can't record it
+func FirstOrderRevision(o *entity.Orders, authorID string) *entity.Revision {
+	resource := entity.Resource{Type: entity.ResourceOrder, ID: o.ID}
+	return entity.NewRevision(resource, 1, authorID, orderSnapshot(o), nil)
+}
+
+//FirstRequirementRevision is the revision RecordRequirement stores for a new
+//requirement. Its resource ID is left to be set once the requirement has one.
+func FirstRequirementRevision(r *entity.Requirements, authorID string) *entity.Revision {
+	resource := entity.Resource{Type: entity.ResourceRequirement}
+	return entity.NewRevision(resource, 1, authorID, requirementSnapshot(r), nil)
+}
+
+func (s *Service) ListRevisions(resource entity.Resource) ([]*entity.Revision, error) {
+	return s.repo.List(resource)
+}
+
+func (s *Service) Diff(resource entity.Resource, from int, to int) ([]entity.FieldChange, error) {
+	older, err := s.get(resource, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.get(resource, to)
+	if err != nil {
+		return nil, err
+	}
+	return entity.DiffSnapshots(older.Snapshot, newer.Snapshot), nil
+}
+
+//Restore writes the content of revision number back and records that as a
+//new revision, history is never rewritten
+func (s *Service) Restore(resource entity.Resource, number int, authorID string) (*entity.Revision, error) {
+	rev, err := s.get(resource, number)
+	if err != nil {
+		return nil, err
+	}
+	var before, after map[string]string
+	switch resource.Type {
+	case entity.ResourceOrder:
+		o, err := s.orders.GetOrder(resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		before = orderSnapshot(o)
+		err = applyOrderSnapshot(o, rev.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		err = s.orders.UpdateOrder(o)
+		if err != nil {
+			return nil, err
+		}
+		after = orderSnapshot(o)
+	case entity.ResourceRequirement:
+		id, err := strconv.Atoi(resource.ID)
+		if err != nil {
+			return nil, ErrNotFound
+		}
+		r, err := s.requirements.GetRequirementbyID(id)
+		if err != nil {
+			return nil, err
+		}
+		before = requirementSnapshot(r)
+		r.Request = rev.Snapshot["request"]
+		r.ExpectedOutcome = rev.Snapshot["expected_outcome"]
+		err = s.requirements.UpdateRequirement(r)
+		if err != nil {
+			return nil, err
+		}
+		after = requirementSnapshot(r)
+	default:
+		return nil, ErrUnsupported
+	}
+	return s.record(resource, before, after, authorID, number)
+}
+
+func (s *Service) get(resource entity.Resource, number int) (*entity.Revision, error) {
+	rev, err := s.repo.Get(resource, number)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, ErrNotFound
+	}
+	return rev, nil
+}
+
+//record appends after as the next revision unless nothing changed. Records
+//that predate the history get their state before the change stored as an
+//authorless revision 1, so the original content is never lost. The number
+//is claimed by the insert, so a concurrent change taking it first makes
+//record diff against that change and try the following number.
+func (s *Service) record(resource entity.Resource, before map[string]string, after map[string]string, authorID string, restoredOf int) (*entity.Revision, error) {
+	for attempt := 0; attempt < recordAttempts; attempt++ {
+		rev, created, err := s.append(resource, before, after, authorID, restoredOf)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return rev, nil
+		}
+	}
+	return nil, ErrConflict
+}
+
+//append reports false when the number it picked was taken meanwhile
+func (s *Service) append(resource entity.Resource, before map[string]string, after map[string]string, authorID string, restoredOf int) (*entity.Revision, bool, error) {
+	latest, err := s.repo.Latest(resource)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest == nil && before != nil {
+		latest = entity.NewRevision(resource, 1, "", before, nil)
+		created, err := s.repo.Create(latest)
+		if err != nil || !created {
+			return nil, false, err
+		}
+	}
+	number := 1
+	var previous map[string]string
+	if latest != nil {
+		number = latest.Number + 1
+		previous = latest.Snapshot
+		if len(entity.DiffSnapshots(previous, after)) == 0 {
+			return latest, true, nil
+		}
+	}
+	rev := entity.NewRevision(resource, number, authorID, after, previous)
+	rev.RestoredOf = restoredOf
+	created, err := s.repo.Create(rev)
+	if err != nil {
+		return nil, false, err
+	}
+	return rev, created, nil
+}
+
+func orderSnapshot(o *entity.Orders) map[string]string {
+	return map[string]string{
+		"title":       o.Title,
+		"description": o.Description,
+		"deadline":    o.Deadline.Format(time.RFC3339),
+		"customer_id": o.CustomerID,
+		"priority":    string(o.Priority),
+		"sla_policy":  o.SLAPolicy,
+	}
+}
+
+func applyOrderSnapshot(o *entity.Orders, snapshot map[string]string) error {
+	deadline, err := time.Parse(time.RFC3339, snapshot["deadline"])
+	if err != nil {
+		return fmt.Errorf("revision has an invalid deadline: %w", err)
+	}
+	o.Title = snapshot["title"]
+	o.Description = snapshot["description"]
+	o.Deadline = deadline
+	//revisions recorded before orders had customers don't name one
+	if customerID, ok := snapshot["customer_id"]; ok {
+		o.CustomerID = customerID
+	}
+	if priority, ok := snapshot["priority"]; ok {
+		o.Priority = entity.Priority(priority)
+	}
+	if policy, ok := snapshot["sla_policy"]; ok {
+		o.SLAPolicy = policy
+	}
+	return nil
+}
+
+func requirementSnapshot(r *entity.Requirements) map[string]string {
+	return map[string]string{
+		"request":          r.Request,
+		"expected_outcome": r.ExpectedOutcome,
+	}
+}
@@ -0,0 +1,91 @@
+package revisions
+
+import (
+	"order-validation-v2/internal/entity"
+	"testing"
+)
+
+//fakeRepo numbers revisions like the unique constraint does and can let a
+//concurrent change claim the next number right after Latest
+type fakeRepo struct {
+	revisions  []*entity.Revision
+	concurrent []map[string]string
+}
+
+func (r *fakeRepo) List(resource entity.Resource) ([]*entity.Revision, error) {
+	return r.revisions, nil
+}
+
+func (r *fakeRepo) Get(resource entity.Resource, number int) (*entity.Revision, error) {
+	for _, rev := range r.revisions {
+		if rev.Number == number {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepo) Latest(resource entity.Resource) (*entity.Revision, error) {
+	var latest *entity.Revision
+	if len(r.revisions) > 0 {
+		latest = r.revisions[len(r.revisions)-1]
+	}
+	if len(r.concurrent) > 0 {
+		number, previous := 1, map[string]string(nil)
+		if latest != nil {
+			number, previous = latest.Number+1, latest.Snapshot
+		}
+		r.revisions = append(r.revisions, entity.NewRevision(resource, number, "other", r.concurrent[0], previous))
+		r.concurrent = r.concurrent[1:]
+	}
+	return latest, nil
+}
+
+func (r *fakeRepo) Create(rev *entity.Revision) (bool, error) {
+	for _, existing := range r.revisions {
+		if existing.Number == rev.Number {
+			return false, nil
+		}
+	}
+	r.revisions = append(r.revisions, rev)
+	return true, nil
+}
+
+func TestRecordRenumbersOnConflict(t *testing.T) {
+	resource := entity.Resource{Type: entity.ResourceRequirement, ID: "1"}
+	tests := []struct {
+		name       string
+		concurrent int
+		wantNumber int
+		wantErr    error
+	}{
+		{name: "no concurrent change", wantNumber: 2},
+		{name: "one concurrent change", concurrent: 1, wantNumber: 3},
+		{name: "changes keep colliding", concurrent: recordAttempts, wantErr: ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			repo.revisions = []*entity.Revision{entity.NewRevision(resource, 1, "", map[string]string{"request": "a"}, nil)}
+			for i := 0; i < tt.concurrent; i++ {
+				repo.concurrent = append(repo.concurrent, map[string]string{"request": "other"})
+			}
+			s := NewService(repo, nil, nil)
+
+			rev, err := s.record(resource, nil, map[string]string{"request": "b"}, "author", 0)
+			if err != tt.wantErr {
+				t.Fatalf("record() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if rev.Number != tt.wantNumber {
+				t.Errorf("number = %d, want %d", rev.Number, tt.wantNumber)
+			}
+			previous := repo.revisions[len(repo.revisions)-2].Snapshot["request"]
+			if len(rev.Changes) != 1 || rev.Changes[0].From != previous {
+				t.Errorf("changes = %+v, want a diff against %q", rev.Changes, previous)
+			}
+		})
+	}
+}
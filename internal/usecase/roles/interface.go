@@ -0,0 +1,36 @@
+package roles
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	Get(name string) (*entity.Role, error)
+	List() ([]*entity.Role, error)
+}
+
+//Writer role writer
+type Writer interface {
+	Create(r *entity.Role) (string, error)
+	Update(r *entity.Role) error
+	Delete(name string) error
+	SetRequire2FA(name string, required bool) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+//UseCase interface
+type UseCase interface {
+	GetRole(name string) (*entity.Role, error)
+	ListRoles() ([]*entity.Role, error)
+	CreateRole(name string, description string, permissions []entity.Permission) (string, error)
+	UpdateRole(r *entity.Role) error
+	DeleteRole(name string) error
+	SetRequire2FA(name string, required bool) error
+	HasPermission(roleName string, p entity.Permission) (bool, error)
+}
@@ -0,0 +1,142 @@
+package roles
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"order-validation-v2/internal/entity"
+)
+
+var (
+	ErrBuiltInRole = errors.New("built-in role can't be changed or deleted")
+	ErrRoleExists  = errors.New("role already exists")
+)
+
+//Service resolves role permissions on every authenticated request, so
+//definitions are cached and the cache is dropped whenever a role changes
+type Service struct {
+	repo  Repository
+	mu    sync.RWMutex
+	cache map[string]*entity.Role
+}
+
+func NewService(r Repository) *Service {
+	return &Service{
+		repo:  r,
+		cache: map[string]*entity.Role{},
+	}
+}
+
+//GetRole returns a copy of the cached role, so callers may change it
+//without affecting the permissions checked on other requests
+func (s *Service) GetRole(name string) (*entity.Role, error) {
+	s.mu.RLock()
+	role, ok := s.cache[name]
+	s.mu.RUnlock()
+	if ok {
+		return copyRole(role), nil
+	}
+	role, err := s.repo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("not found")
+	}
+	if role.Name == entity.RoleAdmin {
+		role.Permissions = entity.AllPermissions
+	}
+	role = copyRole(role)
+	s.mu.Lock()
+	s.cache[name] = role
+	s.mu.Unlock()
+	return copyRole(role), nil
+}
+
+func (s *Service) ListRoles() ([]*entity.Role, error) {
+	roles, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if role.Name == entity.RoleAdmin {
+			role.Permissions = entity.AllPermissions
+		}
+	}
+	return roles, nil
+}
+
+func (s *Service) CreateRole(name string, description string, permissions []entity.Permission) (string, error) {
+	if existing, _ := s.repo.Get(name); existing != nil {
+		return "", ErrRoleExists
+	}
+	if err := validatePermissions(permissions); err != nil {
+		return "", err
+	}
+	r := entity.NewRole(name, description, permissions)
+	return s.repo.Create(r)
+}
+
+func (s *Service) UpdateRole(r *entity.Role) error {
+	if r.Name == entity.RoleAdmin {
+		return ErrBuiltInRole
+	}
+	if err := validatePermissions(r.Permissions); err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return s.repo.Update(r)
+}
+
+func (s *Service) DeleteRole(name string) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return ErrBuiltInRole
+	}
+	defer s.invalidate()
+	return s.repo.Delete(name)
+}
+
+//SetRequire2FA is allowed on built-in roles too, enforcing 2FA on Admin
+//is the main reason it exists
+func (s *Service) SetRequire2FA(name string, required bool) error {
+	_, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return s.repo.SetRequire2FA(name, required)
+}
+
+func (s *Service) HasPermission(roleName string, p entity.Permission) (bool, error) {
+	role, err := s.GetRole(roleName)
+	if err != nil {
+		return false, err
+	}
+	return role.Has(p), nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cache = map[string]*entity.Role{}
+	s.mu.Unlock()
+}
+
+func copyRole(r *entity.Role) *entity.Role {
+	copied := *r
+	copied.Permissions = append([]entity.Permission(nil), r.Permissions...)
+	return &copied
+}
+
+func validatePermissions(permissions []entity.Permission) error {
+	for _, p := range permissions {
+		if !entity.ValidPermission(p) {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	return nil
+}
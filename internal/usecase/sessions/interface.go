@@ -0,0 +1,38 @@
+package sessions
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type Reader interface {
+	Get(id string) (*entity.Session, error)
+	//ListActive returns the sessions of a user that aren't revoked and were
+	//seen after since
+	ListActive(userID string, since time.Time) ([]*entity.Session, error)
+}
+
+type Writer interface {
+	Create(s *entity.Session) (string, error)
+	Touch(id string, at time.Time) error
+	Revoke(id string) error
+	RevokeUser(userID string, except string) error
+}
+
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	StartSession(userID string, ip string, userAgent string) (*entity.Session, error)
+	GetSession(id string) (*entity.Session, error)
+	ListSessions(userID string) ([]*entity.Session, error)
+	//ValidateSession reports whether the session is still active and
+	//records the activity
+	ValidateSession(id string, userID string) (bool, error)
+	RevokeSession(id string) error
+	//RevokeSessions ends every session of the user except the given one,
+	//which may be empty
+	RevokeSessions(userID string, except string) error
+}
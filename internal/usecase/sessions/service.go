@@ -0,0 +1,74 @@
+package sessions
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+//lastSeenResolution limits last-seen writes to one per session and minute
+const lastSeenResolution = time.Minute
+
+type Service struct {
+	repo Repository
+	//idleTimeout matches the refresh token lifetime, a session unused for
+	//longer can't be resumed anyway
+	idleTimeout time.Duration
+}
+
+func NewService(r Repository, idleTimeout time.Duration) *Service {
+	return &Service{
+		repo:        r,
+		idleTimeout: idleTimeout,
+	}
+}
+
+func (s *Service) StartSession(userID string, ip string, userAgent string) (*entity.Session, error) {
+	session := entity.NewSession(userID, ip, userAgent)
+	_, err := s.repo.Create(session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *Service) GetSession(id string) (*entity.Session, error) {
+	session, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, errors.New("not found")
+	}
+	return session, nil
+}
+
+func (s *Service) ListSessions(userID string) ([]*entity.Session, error) {
+	return s.repo.ListActive(userID, time.Now().Add(-s.idleTimeout))
+}
+
+func (s *Service) ValidateSession(id string, userID string) (bool, error) {
+	session, err := s.repo.Get(id)
+	if err != nil || session == nil {
+		return false, err
+	}
+	now := time.Now()
+	if session.Revoked || session.UserID != userID || now.Sub(session.LastSeenAt) > s.idleTimeout {
+		return false, nil
+	}
+	if now.Sub(session.LastSeenAt) >= lastSeenResolution {
+		err = s.repo.Touch(id, now)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *Service) RevokeSession(id string) error {
+	return s.repo.Revoke(id)
+}
+
+func (s *Service) RevokeSessions(userID string, except string) error {
+	return s.repo.RevokeUser(userID, except)
+}
@@ -0,0 +1,46 @@
+package sla
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+//Reader interface
+type Reader interface {
+	GetPolicy(name string) (*entity.SLAPolicy, error)
+	ListPolicies() ([]*entity.SLAPolicy, error)
+	//CountOrders returns how many orders use the policy, deleted ones
+	//included
+	CountOrders(name string) (int, error)
+	//GetOrder returns the order with the times of its tasks, nil when the
+	//order doesn't exist
+	GetOrder(orderID string) (*entity.OrderSLA, error)
+	//ListOrders returns every order with an SLA policy that isn't cancelled,
+	//oldest first
+	ListOrders() ([]*entity.OrderSLA, error)
+}
+
+//Writer interface
+type Writer interface {
+	CreatePolicy(p *entity.SLAPolicy) error
+	UpdatePolicy(p *entity.SLAPolicy) error
+	DeletePolicy(name string) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	CreatePolicy(p *entity.SLAPolicy) error
+	GetPolicy(name string) (*entity.SLAPolicy, error)
+	ListPolicies() ([]*entity.SLAPolicy, error)
+	UpdatePolicy(p *entity.SLAPolicy) error
+	DeletePolicy(name string) error
+	GetOrderSLA(orderID string) (*entity.OrderSLA, error)
+	//Breaches returns the orders with at least one clock past its target at
+	//now, the highest priority first
+	Breaches(now time.Time) ([]*entity.OrderSLA, error)
+}
@@ -0,0 +1,130 @@
+package sla
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrInvalidPolicy = errors.New("invalid SLA policy")
+	ErrPolicyExists  = errors.New("SLA policy already exists")
+	ErrPolicyInUse   = errors.New("SLA policy is used by orders")
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(r Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+func (s *Service) CreatePolicy(p *entity.SLAPolicy) error {
+	p.Name = strings.TrimSpace(p.Name)
+	err := validate(p)
+	if err != nil {
+		return err
+	}
+	existing, err := s.repo.GetPolicy(p.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrPolicyExists
+	}
+	return s.repo.CreatePolicy(p)
+}
+
+func (s *Service) GetPolicy(name string) (*entity.SLAPolicy, error) {
+	p, err := s.repo.GetPolicy(name)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *Service) ListPolicies() ([]*entity.SLAPolicy, error) {
+	return s.repo.ListPolicies()
+}
+
+//UpdatePolicy changes the targets of a policy, orders using it are measured
+//against the new targets from then on
+func (s *Service) UpdatePolicy(p *entity.SLAPolicy) error {
+	err := validate(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.GetPolicy(p.Name)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdatePolicy(p)
+}
+
+//DeletePolicy refuses to delete a policy orders still use
+func (s *Service) DeletePolicy(name string) error {
+	_, err := s.GetPolicy(name)
+	if err != nil {
+		return err
+	}
+	count, err := s.repo.CountOrders(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d orders", ErrPolicyInUse, count)
+	}
+	return s.repo.DeletePolicy(name)
+}
+
+func (s *Service) GetOrderSLA(orderID string) (*entity.OrderSLA, error) {
+	o, err := s.repo.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, ErrNotFound
+	}
+	return o, nil
+}
+
+func (s *Service) Breaches(now time.Time) ([]*entity.OrderSLA, error) {
+	list, err := s.repo.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	var breached []*entity.OrderSLA
+	for _, o := range list {
+		if len(o.Breaches(now)) > 0 {
+			breached = append(breached, o)
+		}
+	}
+	sort.SliceStable(breached, func(i, j int) bool {
+		return breached[i].Priority.Rank() > breached[j].Priority.Rank()
+	})
+	return breached, nil
+}
+
+func validate(p *entity.SLAPolicy) error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPolicy)
+	}
+	if p.TargetStart < 0 || p.TargetCompletion < 0 || p.ReviewTurnaround < 0 {
+		return fmt.Errorf("%w: targets must not be negative", ErrInvalidPolicy)
+	}
+	if p.TargetStart == 0 && p.TargetCompletion == 0 && p.ReviewTurnaround == 0 {
+		return fmt.Errorf("%w: at least one target is required", ErrInvalidPolicy)
+	}
+	return nil
+}
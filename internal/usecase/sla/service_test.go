@@ -0,0 +1,46 @@
+package sla
+
+import (
+	"order-validation-v2/internal/entity"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	orders []*entity.OrderSLA
+}
+
+func (r *fakeRepo) ListOrders() ([]*entity.OrderSLA, error) {
+	return r.orders, nil
+}
+
+func TestBreaches(t *testing.T) {
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	policy := &entity.SLAPolicy{Name: "standard", TargetCompletion: 12 * time.Hour}
+	order := func(id string, priority entity.Priority, age time.Duration) *entity.OrderSLA {
+		return &entity.OrderSLA{OrderID: id, Status: entity.OrderInProgress, Priority: priority, CreatedAt: now.Add(-age), Policy: policy}
+	}
+	repo := &fakeRepo{orders: []*entity.OrderSLA{
+		order("late-normal", entity.PriorityNormal, 20*time.Hour),
+		order("on-time-urgent", entity.PriorityUrgent, time.Hour),
+		order("late-low", entity.PriorityLow, 30*time.Hour),
+		order("late-urgent", entity.PriorityUrgent, 13*time.Hour),
+		order("late-normal-newer", entity.PriorityNormal, 14*time.Hour),
+		{OrderID: "no-policy", Priority: entity.PriorityUrgent, CreatedAt: now.Add(-100 * time.Hour)},
+	}}
+	breached, err := NewService(repo).Breaches(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range breached {
+		got = append(got, o.OrderID)
+	}
+	//equal priorities keep the repository's oldest first order
+	want := []string{"late-urgent", "late-normal", "late-normal-newer", "late-low"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Breaches = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,23 @@
+package sso
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+type Reader interface {
+	//GetUserID returns "" when the identity isn't linked to a user
+	GetUserID(issuer string, subject string) (string, error)
+}
+
+type Writer interface {
+	Link(issuer string, subject string, userID string) error
+}
+
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	Resolve(identity entity.ExternalIdentity) (*entity.User, error)
+}
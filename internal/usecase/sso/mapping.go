@@ -0,0 +1,49 @@
+package sso
+
+import (
+	"fmt"
+	"strings"
+)
+
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+//RoleMapping turns IdP groups into a role. Groups are checked in order and
+//the first match wins, so list the most privileged group first.
+type RoleMapping struct {
+	Groups []GroupRole
+	//DefaultRole is given to new users in no mapped group, when empty they
+	//are refused
+	DefaultRole string
+}
+
+//ParseGroupRoles reads "group=Role,group=Role" as used by OIDC_ROLE_MAPPING
+func ParseGroupRoles(s string) ([]GroupRole, error) {
+	var mapping []GroupRole
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid group mapping %q, expected group=Role", pair)
+		}
+		mapping = append(mapping, GroupRole{Group: strings.TrimSpace(parts[0]), Role: strings.TrimSpace(parts[1])})
+	}
+	return mapping, nil
+}
+
+//RoleFor returns the role of the first mapped group the user belongs to,
+//or "" if none matches
+func (m RoleMapping) RoleFor(groups []string) string {
+	for _, mapping := range m.Groups {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package sso
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/roles"
+	"order-validation-v2/internal/usecase/user"
+	"strings"
+)
+
+var (
+	ErrNoMappedRole   = errors.New("none of the user's groups maps to a role")
+	ErrNotLinkable    = errors.New("service accounts can't sign in through SSO")
+	ErrMissingClaims  = errors.New("identity has no subject")
+	ErrAmbiguousLink  = errors.New("several users share the identity's email, an administrator has to link it")
+	ErrPrivilegedLink = errors.New("users in a privileged role can't be linked by email")
+)
+
+//Options controls what a sign in may change on existing users
+type Options struct {
+	//LinkByEmail links an unknown identity to the one existing user with
+	//the same verified email. Otherwise a new user is provisioned.
+	LinkByEmail bool
+	//SyncRoles applies the group mapping on every sign in. Otherwise it
+	//only picks the role of provisioned users.
+	SyncRoles bool
+}
+
+//Service maps provider identities to users. Identities are linked to a user
+//on first sign in, to an existing user with the same verified email when
+//enabled or to a user provisioned on the spot.
+type Service struct {
+	repo    Repository
+	users   user.UseCase
+	roles   roles.UseCase
+	mapping RoleMapping
+	options Options
+}
+
+func NewService(r Repository, u user.UseCase, ro roles.UseCase, m RoleMapping, o Options) *Service {
+	return &Service{
+		repo:    r,
+		users:   u,
+		roles:   ro,
+		mapping: m,
+		options: o,
+	}
+}
+
+func (s *Service) Resolve(identity entity.ExternalIdentity) (*entity.User, error) {
+	if identity.Issuer == "" || identity.Subject == "" {
+		return nil, ErrMissingClaims
+	}
+	role := s.mapping.RoleFor(identity.Groups)
+	u, err := s.linkedUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return s.provision(identity, role)
+	}
+	if u.ServiceAccount {
+		return nil, ErrNotLinkable
+	}
+	//users in no mapped group keep the role they have
+	if s.options.SyncRoles && role != "" && role != u.UserRole {
+		u.UserRole = role
+		err = s.users.UpdateUser(u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+//linkedUser finds the user linked to the identity, linking by verified
+//email on the first sign in when enabled. It returns nil when no user
+//matches.
+func (s *Service) linkedUser(identity entity.ExternalIdentity) (*entity.User, error) {
+	userID, err := s.repo.GetUserID(identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" {
+		return s.users.GetUserbyID(userID)
+	}
+	if !s.options.LinkByEmail || identity.Email == "" || !identity.EmailVerified {
+		return nil, nil
+	}
+	users, err := s.users.ListUsersbyEmail(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	switch len(users) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, ErrAmbiguousLink
+	}
+	u := users[0]
+	if u.ServiceAccount {
+		return nil, ErrNotLinkable
+	}
+	role, err := s.roles.GetRole(u.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	if role.Privileged() {
+		return nil, ErrPrivilegedLink
+	}
+	err = s.repo.Link(identity.Issuer, identity.Subject, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *Service) provision(identity entity.ExternalIdentity, role string) (*entity.User, error) {
+	if role == "" {
+		role = s.mapping.DefaultRole
+	}
+	if role == "" {
+		return nil, ErrNoMappedRole
+	}
+	username, err := s.username(identity)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.users.CreateExternalUser(username, identity.Email, role)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Link(identity.Issuer, identity.Subject, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.users.GetUserbyID(id)
+}
+
+//username prefers the provider's username, then the email, and adds a
+//suffix derived from the subject when the name is taken
+func (s *Service) username(identity entity.ExternalIdentity) (string, error) {
+	username := identity.Username
+	if username == "" {
+		username = identity.Email
+	}
+	if username == "" {
+		username = identity.Subject
+	}
+	if len(username) > 43 {
+		username = username[:43]
+	}
+	exists, err := s.users.ValidateUsername(username)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return username, nil
+	}
+	sum := sha256.Sum256([]byte(identity.Issuer + "|" + identity.Subject))
+	return strings.TrimSuffix(username, "-") + "-" + hex.EncodeToString(sum[:])[:6], nil
+}
@@ -0,0 +1,249 @@
+package sso
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/roles"
+	"order-validation-v2/internal/usecase/user"
+	"testing"
+)
+
+type fakeRepo struct {
+	links map[string]string
+}
+
+func (r *fakeRepo) GetUserID(issuer string, subject string) (string, error) {
+	return r.links[issuer+"|"+subject], nil
+}
+
+func (r *fakeRepo) Link(issuer string, subject string, userID string) error {
+	r.links[issuer+"|"+subject] = userID
+	return nil
+}
+
+//fakeUsers implements the part of user.UseCase the service calls
+type fakeUsers struct {
+	user.UseCase
+	users       map[string]*entity.User
+	listErr     error
+	provisioned []string
+}
+
+func (u *fakeUsers) GetUserbyID(id string) (*entity.User, error) {
+	found, ok := u.users[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return found, nil
+}
+
+func (u *fakeUsers) ListUsersbyEmail(email string) ([]*entity.User, error) {
+	if u.listErr != nil {
+		return nil, u.listErr
+	}
+	var matches []*entity.User
+	for _, found := range u.users {
+		if found.Email == email {
+			matches = append(matches, found)
+		}
+	}
+	return matches, nil
+}
+
+func (u *fakeUsers) CreateExternalUser(username string, email string, role string) (string, error) {
+	created := entity.NewUser(email, username, "", role)
+	u.users[created.ID] = created
+	u.provisioned = append(u.provisioned, created.ID)
+	return created.ID, nil
+}
+
+func (u *fakeUsers) UpdateUser(updated *entity.User) error {
+	u.users[updated.ID] = updated
+	return nil
+}
+
+func (u *fakeUsers) ValidateUsername(username string) (bool, error) {
+	for _, found := range u.users {
+		if found.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeRoles struct {
+	roles.UseCase
+}
+
+func (fakeRoles) GetRole(name string) (*entity.Role, error) {
+	switch name {
+	case entity.RoleAdmin:
+		return &entity.Role{Name: name}, nil
+	case entity.RoleManager:
+		return &entity.Role{Name: name, Permissions: []entity.Permission{entity.PermOrderWrite, entity.PermUserRead}}, nil
+	case "UserAdmin":
+		return &entity.Role{Name: name, Permissions: []entity.Permission{entity.PermUserWrite}}, nil
+	}
+	return &entity.Role{Name: name}, nil
+}
+
+const issuer = "https://idp.example"
+
+func TestResolve(t *testing.T) {
+	manager := &entity.User{ID: "manager", Username: "mia", Email: "mia@example.com", UserRole: entity.RoleManager}
+	admin := &entity.User{ID: "admin", Username: "ada", Email: "ada@example.com", UserRole: entity.RoleAdmin}
+	userAdmin := &entity.User{ID: "useradmin", Username: "uma", Email: "uma@example.com", UserRole: "UserAdmin"}
+	bot := &entity.User{ID: "bot", Username: "bot", Email: "bot@example.com", UserRole: entity.RoleWorker, ServiceAccount: true}
+	twinA := &entity.User{ID: "twin-a", Username: "twin-a", Email: "twin@example.com", UserRole: entity.RoleWorker}
+	twinB := &entity.User{ID: "twin-b", Username: "twin-b", Email: "twin@example.com", UserRole: entity.RoleWorker}
+	mapping := RoleMapping{
+		Groups:      []GroupRole{{Group: "admins", Role: entity.RoleAdmin}, {Group: "staff", Role: entity.RoleWorker}},
+		DefaultRole: entity.RoleViewer,
+	}
+	linkByEmail := Options{LinkByEmail: true}
+
+	tests := []struct {
+		name      string
+		options   Options
+		mapping   RoleMapping
+		links     map[string]string
+		listErr   error
+		identity  entity.ExternalIdentity
+		wantErr   error
+		wantUser  string
+		provision bool
+		wantRole  string
+	}{
+		{
+			name:     "linked identity keeps its local role",
+			links:    map[string]string{issuer + "|sub-1": "manager"},
+			identity: entity.ExternalIdentity{Subject: "sub-1", Groups: []string{"admins"}},
+			wantUser: "manager",
+			wantRole: entity.RoleManager,
+		},
+		{
+			name:     "linked identity follows its groups when roles are synced",
+			options:  Options{SyncRoles: true},
+			links:    map[string]string{issuer + "|sub-1": "manager"},
+			identity: entity.ExternalIdentity{Subject: "sub-1", Groups: []string{"staff"}},
+			wantUser: "manager",
+			wantRole: entity.RoleWorker,
+		},
+		{
+			name:      "matching email is not linked unless enabled",
+			identity:  entity.ExternalIdentity{Subject: "sub-2", Email: "mia@example.com", EmailVerified: true},
+			provision: true,
+			wantRole:  entity.RoleViewer,
+		},
+		{
+			name:     "verified email links the one matching user",
+			options:  linkByEmail,
+			identity: entity.ExternalIdentity{Subject: "sub-2", Email: "mia@example.com", EmailVerified: true, Groups: []string{"admins"}},
+			wantUser: "manager",
+			wantRole: entity.RoleManager,
+		},
+		{
+			name:      "unverified email provisions a new user",
+			options:   linkByEmail,
+			identity:  entity.ExternalIdentity{Subject: "sub-2", Email: "mia@example.com"},
+			provision: true,
+			wantRole:  entity.RoleViewer,
+		},
+		{
+			name:     "email shared by several users is refused",
+			options:  linkByEmail,
+			identity: entity.ExternalIdentity{Subject: "sub-3", Email: "twin@example.com", EmailVerified: true},
+			wantErr:  ErrAmbiguousLink,
+		},
+		{
+			name:     "admin is never linked by email",
+			options:  linkByEmail,
+			identity: entity.ExternalIdentity{Subject: "sub-4", Email: "ada@example.com", EmailVerified: true},
+			wantErr:  ErrPrivilegedLink,
+		},
+		{
+			name:     "role able to manage users is never linked by email",
+			options:  linkByEmail,
+			identity: entity.ExternalIdentity{Subject: "sub-4", Email: "uma@example.com", EmailVerified: true},
+			wantErr:  ErrPrivilegedLink,
+		},
+		{
+			name:     "service account is not linked",
+			options:  linkByEmail,
+			identity: entity.ExternalIdentity{Subject: "sub-5", Email: "bot@example.com", EmailVerified: true},
+			wantErr:  ErrNotLinkable,
+		},
+		{
+			name:     "lookup error is returned",
+			options:  linkByEmail,
+			listErr:  errors.New("connection refused"),
+			identity: entity.ExternalIdentity{Subject: "sub-6", Email: "mia@example.com", EmailVerified: true},
+			wantErr:  errors.New("connection refused"),
+		},
+		{
+			name:      "new user gets the role of their group",
+			identity:  entity.ExternalIdentity{Subject: "sub-7", Email: "new@example.com", Groups: []string{"staff"}},
+			provision: true,
+			wantRole:  entity.RoleWorker,
+		},
+		{
+			name:     "new user in no group is refused without a default role",
+			mapping:  RoleMapping{Groups: mapping.Groups},
+			identity: entity.ExternalIdentity{Subject: "sub-8", Email: "new@example.com"},
+			wantErr:  ErrNoMappedRole,
+		},
+		{
+			name:     "identity without subject is refused",
+			identity: entity.ExternalIdentity{},
+			wantErr:  ErrMissingClaims,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{users: map[string]*entity.User{}, listErr: tt.listErr}
+			for _, u := range []*entity.User{manager, admin, userAdmin, bot, twinA, twinB} {
+				copied := *u
+				users.users[u.ID] = &copied
+			}
+			repo := &fakeRepo{links: map[string]string{}}
+			for k, v := range tt.links {
+				repo.links[k] = v
+			}
+			m := tt.mapping
+			if m.Groups == nil {
+				m = mapping
+			}
+			if tt.identity.Subject != "" {
+				tt.identity.Issuer = issuer
+			}
+			s := NewService(repo, users, fakeRoles{}, m, tt.options)
+
+			u, err := s.Resolve(tt.identity)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.links) != len(tt.links) {
+					t.Errorf("identity was linked despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if tt.provision {
+				if len(users.provisioned) != 1 || u.ID != users.provisioned[0] {
+					t.Fatalf("Resolve() = %s, want a provisioned user", u.ID)
+				}
+			} else if u.ID != tt.wantUser {
+				t.Fatalf("Resolve() = %s, want %s", u.ID, tt.wantUser)
+			}
+			if u.UserRole != tt.wantRole {
+				t.Errorf("role = %s, want %s", u.UserRole, tt.wantRole)
+			}
+			if repo.links[issuer+"|"+tt.identity.Subject] != u.ID {
+				t.Errorf("identity is not linked to %s", u.ID)
+			}
+		})
+	}
+}
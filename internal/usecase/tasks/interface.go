@@ -17,7 +17,16 @@ type Reader interface {
 type Writer interface {
 	Create(t *entity.Task) (string, error)
 	Update(t *entity.Task) error
+	//Delete soft deletes the task along with its submissions and releases
+	//the tasks waiting on it
 	Delete(id string) error
+	//Restore blocks the released tasks again, unless they were submitted in
+	//the meantime. It returns false when there is no deleted task with that
+	//id or its requirement is deleted.
+	Restore(id string) (bool, error)
+	//RemovePrerequisite resolves the edges to a submitted task and returns
+	//the tasks that were waiting on it. The edges are kept, so the plan of
+	//the order can still be read.
 	RemovePrerequisite(prerequisiteID string) ([]*entity.Task, error)
 	AddReviewer(TaskID string, NewReviewerID string) error
 	DeleteReviewer(UserID string) error
@@ -37,6 +46,7 @@ type UseCase interface {
 	GetTasksOnSpecificOrder(orderID string) ([]*entity.TaskWithDetails, error)
 	UpdateTask(t *entity.Task) error
 	DeleteTask(id string) error
+	RestoreTask(id string) error
 	CreateTask(assignerID string, requirementID int, userID string, Note string, prerequisiteTaskID []string, Deadline time.Time) (string, error)
 	RemovePrerequisite(prerequisiteTaskID string) ([]*entity.Task, error)
 	SaveTask(t *entity.Task) (string, error)
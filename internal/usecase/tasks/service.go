@@ -1,10 +1,14 @@
 package tasks
 
 import (
+	"errors"
 	"order-validation-v2/internal/entity"
+	"sort"
 	"time"
 )
 
+var ErrNotFound = errors.New("not found")
+
 type Service struct {
 	repo Repository
 }
@@ -16,11 +20,11 @@ func NewService(r Repository) *Service {
 }
 
 func (s *Service) ListAllTasks() ([]*entity.TaskWithDetails, error) {
-	return s.repo.List()
+	return sortBySLARisk(s.repo.List())
 }
 
 func (s *Service) GetTasksofUser(userID string) ([]*entity.TaskWithDetails, error) {
-	return s.repo.GetbyUserID(userID)
+	return sortBySLARisk(s.repo.GetbyUserID(userID))
 
 }
 
@@ -36,6 +40,17 @@ func (s *Service) DeleteTask(id string) error {
 	return s.repo.Delete(id)
 }
 
+func (s *Service) RestoreTask(id string) error {
+	restored, err := s.repo.Restore(id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (s *Service) CreateTask(assignerID string, requirementID int, userID string, Note string, prerequisiteTaskID []string, Deadline time.Time) (string, error) {
 	task := entity.NewTask(assignerID, requirementID, userID, Note, prerequisiteTaskID, Deadline)
 	taskID, err := s.repo.Create(task)
@@ -57,7 +72,7 @@ func (s *Service) RemovePrerequisite(prerequisiteID string) ([]*entity.Task, err
 }
 
 func (s *Service) GetTasksToReview(adminID string) ([]*entity.TaskWithDetails, error) {
-	return s.repo.GetTasksToReview(adminID)
+	return sortBySLARisk(s.repo.GetTasksToReview(adminID))
 }
 
 func (s *Service) AddReviewer(TaskID string, NewReviewerID string) error {
@@ -79,3 +94,23 @@ func (s *Service) DeleteReviewer(UserID string) error {
 func (s *Service) AddReviewMessage(TaskID string, Message entity.Message) error {
 	return s.repo.AddReviewMessage(TaskID, Message)
 }
+
+//sortBySLARisk puts the tasks closest to breaching their SLA first. Ties go
+//to the higher priority, then to the earlier deadline.
+func sortBySLARisk(list []*entity.TaskWithDetails, err error) ([]*entity.TaskWithDetails, error) {
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sort.SliceStable(list, func(i, j int) bool {
+		ri, rj := list[i].SLARisk(now), list[j].SLARisk(now)
+		if ri != rj {
+			return ri > rj
+		}
+		if pi, pj := list[i].Priority.Rank(), list[j].Priority.Rank(); pi != pj {
+			return pi > pj
+		}
+		return list[i].Deadline.Before(list[j].Deadline)
+	})
+	return list, nil
+}
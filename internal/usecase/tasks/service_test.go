@@ -0,0 +1,47 @@
+package tasks
+
+import (
+	"order-validation-v2/internal/entity"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	tasks []*entity.TaskWithDetails
+}
+
+func (r *fakeRepo) List() ([]*entity.TaskWithDetails, error) {
+	return r.tasks, nil
+}
+
+func TestListAllTasksByRisk(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(48 * time.Hour)
+	repo := &fakeRepo{tasks: []*entity.TaskWithDetails{
+		//no SLA policy, nothing at risk
+		{ID: "untracked", Status: entity.Unfinished, Priority: entity.PriorityUrgent, Deadline: deadline},
+		//half of the completion target used
+		{ID: "working", Status: entity.Unfinished, OrderCreatedAt: now.Add(-12 * time.Hour), CompletionTarget: 24 * time.Hour, Deadline: deadline},
+		//review target breached
+		{ID: "late-review", Status: entity.InReview, SubmittedAt: now.Add(-3 * time.Hour), ReviewTarget: 2 * time.Hour, Deadline: deadline},
+		//approved tasks hold up no clock
+		{ID: "approved", Status: entity.Finished, OrderCreatedAt: now.Add(-48 * time.Hour), CompletionTarget: 24 * time.Hour, Deadline: deadline},
+		//same risk as untracked, ties go to priority then deadline
+		{ID: "untracked-high", Status: entity.Unfinished, Priority: entity.PriorityHigh, Deadline: deadline},
+		{ID: "untracked-urgent-sooner", Status: entity.Unfinished, Priority: entity.PriorityUrgent, Deadline: now.Add(time.Hour)},
+	}}
+	list, err := NewService(repo).ListAllTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, task := range list {
+		got = append(got, task.ID)
+	}
+	want := []string{"late-review", "working", "untracked-urgent-sooner", "untracked", "untracked-high", "approved"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAllTasks = %v, want %v", got, want)
+	}
+}
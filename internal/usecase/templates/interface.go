@@ -0,0 +1,38 @@
+package templates
+
+import (
+	"order-validation-v2/internal/entity"
+)
+
+//Reader interface
+type Reader interface {
+	Get(id string) (*entity.OrderTemplate, error)
+	//List returns the templates without their requirements
+	List() ([]*entity.OrderTemplate, error)
+}
+
+//Writer interface
+type Writer interface {
+	Create(t *entity.OrderTemplate) (string, error)
+	Delete(id string) error
+	//CreateOrder inserts an order with its requirements and their tasks in
+	//one transaction. tasks[i] belong to reqs[i], the requirement IDs are
+	//filled in as the requirements are inserted.
+	CreateOrder(o *entity.Orders, reqs []*entity.Requirements, tasks [][]*entity.Task) error
+}
+
+//Repository interface
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	CreateTemplate(t *entity.OrderTemplate) (string, error)
+	GetTemplate(id string) (*entity.OrderTemplate, error)
+	ListTemplates() ([]*entity.OrderTemplate, error)
+	DeleteTemplate(id string) error
+	SaveOrderAsTemplate(orderID string, name string, createdBy string) (string, error)
+	Instantiate(templateID string, o Overrides, assignerID string) (string, error)
+	CloneOrder(orderID string, o Overrides, assignerID string) (string, error)
+}
@@ -0,0 +1,322 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/tasks"
+	"order-validation-v2/internal/usecase/user"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrInvalidTemplate  = errors.New("invalid template")
+	ErrDeadlineRequired = errors.New("the template has no deadline offset, a deadline is required")
+	ErrUnassignedTask   = errors.New("task has no assignee")
+	ErrUnknownAssignee  = errors.New("assignee does not exist")
+)
+
+//Overrides replace template values when an order is instantiated. Zero
+//values keep the template's.
+type Overrides struct {
+	Title       string
+	Description string
+	Deadline    time.Time
+	Status      entity.OrderStatus
+	//CustomerID, Priority and SLAPolicy default to those of the order a
+	//clone copies
+	Priority   entity.Priority
+	SLAPolicy  string
+	CustomerID string
+	//Assignees maps task keys to the users that take them over
+	Assignees map[string]string
+}
+
+type Service struct {
+	repo         Repository
+	orders       orders.UseCase
+	requirements requirements.UseCase
+	tasks        tasks.UseCase
+	users        user.UseCase
+}
+
+func NewService(r Repository, o orders.UseCase, req requirements.UseCase, t tasks.UseCase, u user.UseCase) *Service {
+	return &Service{
+		repo:         r,
+		orders:       o,
+		requirements: req,
+		tasks:        t,
+		users:        u,
+	}
+}
+
+func (s *Service) CreateTemplate(t *entity.OrderTemplate) (string, error) {
+	if strings.TrimSpace(t.Name) == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	if _, err := taskOrder(t); err != nil {
+		return "", err
+	}
+	return s.repo.Create(t)
+}
+
+func (s *Service) GetTemplate(id string) (*entity.OrderTemplate, error) {
+	t, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func (s *Service) ListTemplates() ([]*entity.OrderTemplate, error) {
+	return s.repo.List()
+}
+
+func (s *Service) DeleteTemplate(id string) error {
+	_, err := s.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *Service) SaveOrderAsTemplate(orderID string, name string, createdBy string) (string, error) {
+	t, _, err := s.fromOrder(orderID)
+	if err != nil {
+		return "", err
+	}
+	t.Name = name
+	t.CreatedBy = createdBy
+	return s.CreateTemplate(t)
+}
+
+func (s *Service) Instantiate(templateID string, o Overrides, assignerID string) (string, error) {
+	t, err := s.GetTemplate(templateID)
+	if err != nil {
+		return "", err
+	}
+	return s.instantiate(t, o, assignerID)
+}
+
+//CloneOrder copies an order's requirements and tasks into a new order. The
+//assignees are keyed by the IDs of the order's tasks.
+func (s *Service) CloneOrder(orderID string, o Overrides, assignerID string) (string, error) {
+	t, keys, err := s.fromOrder(orderID)
+	if err != nil {
+		return "", err
+	}
+	assignees := map[string]string{}
+	for taskID, userID := range o.Assignees {
+		if key, ok := keys[taskID]; ok {
+			assignees[key] = userID
+		}
+	}
+	o.Assignees = assignees
+	source, err := s.orders.GetOrder(orderID)
+	if err != nil {
+		return "", err
+	}
+	if o.CustomerID == "" {
+		o.CustomerID = source.CustomerID
+	}
+	if o.Priority == "" {
+		o.Priority = source.Priority
+	}
+	if o.SLAPolicy == "" {
+		o.SLAPolicy = source.SLAPolicy
+	}
+	return s.instantiate(t, o, assignerID)
+}
+
+//fromOrder captures an order as a template, with the keys given to its
+//tasks by ID. The order is due its remaining time from instantiation, or
+//needs a deadline when it is overdue. Prerequisites are kept whether or not
+//they were submitted.
+func (s *Service) fromOrder(orderID string) (*entity.OrderTemplate, map[string]string, error) {
+	o, err := s.orders.GetOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reqs, err := s.requirements.GetRequirementsbyOrderId(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	orderTasks, err := s.tasks.GetTasksOnSpecificOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	offset := time.Until(o.Deadline).Round(time.Hour)
+	if offset < 0 {
+		offset = 0
+	}
+	t := entity.NewOrderTemplate("", o.Title, o.Description, offset, "")
+	//the tasks are keyed in plan order, their IDs mean nothing to the
+	//orders made from the template
+	keys := map[string]string{}
+	for _, req := range reqs {
+		for _, task := range orderTasks {
+			if task.RequirementID == req.Id {
+				keys[task.ID] = fmt.Sprintf("task-%d", len(keys)+1)
+			}
+		}
+	}
+	for _, req := range reqs {
+		requirement := entity.TemplateRequirement{Request: req.Request, ExpectedOutcome: req.ExpectedOutcome}
+		for _, task := range orderTasks {
+			if task.RequirementID != req.Id {
+				continue
+			}
+			planned := entity.TemplateTask{Key: keys[task.ID], UserID: task.UserID, Note: task.Note}
+			if !task.Deadline.IsZero() && !o.Deadline.IsZero() {
+				planned.DeadlineOffset = task.Deadline.Sub(o.Deadline)
+			}
+			for _, prerequisite := range task.Prerequisites {
+				if key, ok := keys[prerequisite]; ok {
+					planned.Prerequisites = append(planned.Prerequisites, key)
+				}
+			}
+			requirement.Tasks = append(requirement.Tasks, planned)
+		}
+		t.Requirements = append(t.Requirements, requirement)
+	}
+	return t, keys, nil
+}
+
+//instantiate checks the whole plan, then creates the order with its
+//requirements and tasks in one go, so a failure leaves nothing behind
+func (s *Service) instantiate(t *entity.OrderTemplate, o Overrides, assignerID string) (string, error) {
+	planned, err := taskOrder(t)
+	if err != nil {
+		return "", err
+	}
+	deadline := o.Deadline
+	if deadline.IsZero() {
+		if t.DeadlineOffset <= 0 {
+			return "", ErrDeadlineRequired
+		}
+		deadline = time.Now().Add(t.DeadlineOffset)
+	}
+	assignee := func(task *entity.TemplateTask) string {
+		if userID, ok := o.Assignees[task.Key]; ok {
+			return userID
+		}
+		return task.UserID
+	}
+	known := map[string]bool{}
+	for _, p := range planned {
+		userID := assignee(p.task)
+		if userID == "" {
+			return "", fmt.Errorf("%w: %s", ErrUnassignedTask, p.task.Key)
+		}
+		if known[userID] {
+			continue
+		}
+		_, err := s.users.GetUserbyID(userID)
+		if err == user.ErrNotFound {
+			return "", fmt.Errorf("%w: %s for task %s", ErrUnknownAssignee, userID, p.task.Key)
+		}
+		if err != nil {
+			return "", err
+		}
+		known[userID] = true
+	}
+	title, description := t.Title, t.Description
+	if o.Title != "" {
+		title = o.Title
+	}
+	if o.Description != "" {
+		description = o.Description
+	}
+	order, err := orders.PrepareOrder(title, description, deadline, o.Status, o.Priority, o.SLAPolicy, o.CustomerID)
+	if err != nil {
+		return "", err
+	}
+	reqs := make([]*entity.Requirements, len(t.Requirements))
+	for i, req := range t.Requirements {
+		reqs[i] = entity.NewRequirement(req.Request, req.ExpectedOutcome, order.ID)
+	}
+	reqTasks := make([][]*entity.Task, len(t.Requirements))
+	taskIDs := map[string]string{}
+	for _, p := range planned {
+		var prerequisites []string
+		for _, key := range p.task.Prerequisites {
+			prerequisites = append(prerequisites, taskIDs[key])
+		}
+		task := entity.NewTask(assignerID, 0, assignee(p.task), p.task.Note, prerequisites, deadline.Add(p.task.DeadlineOffset))
+		taskIDs[p.task.Key] = task.ID
+		reqTasks[p.requirement] = append(reqTasks[p.requirement], task)
+	}
+	err = s.repo.CreateOrder(order, reqs, reqTasks)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.orders.SyncStatus(order.ID)
+	return order.ID, err
+}
+
+type plannedTask struct {
+	requirement int
+	task        *entity.TemplateTask
+}
+
+//taskOrder validates the task keys and prerequisites of a template and
+//returns its tasks with every prerequisite ahead of the tasks waiting on it
+func taskOrder(t *entity.OrderTemplate) ([]plannedTask, error) {
+	byKey := map[string]plannedTask{}
+	var keys []string
+	for i := range t.Requirements {
+		for j := range t.Requirements[i].Tasks {
+			task := &t.Requirements[i].Tasks[j]
+			if task.Key == "" {
+				return nil, fmt.Errorf("%w: task without key", ErrInvalidTemplate)
+			}
+			if _, ok := byKey[task.Key]; ok {
+				return nil, fmt.Errorf("%w: duplicate task key %s", ErrInvalidTemplate, task.Key)
+			}
+			byKey[task.Key] = plannedTask{requirement: i, task: task}
+			keys = append(keys, task.Key)
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var ordered []plannedTask
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case visiting:
+			return fmt.Errorf("%w: prerequisite cycle through %s", ErrInvalidTemplate, key)
+		case done:
+			return nil
+		}
+		state[key] = visiting
+		for _, prerequisite := range byKey[key].task.Prerequisites {
+			if _, ok := byKey[prerequisite]; !ok {
+				return fmt.Errorf("%w: %s waits for unknown task %s", ErrInvalidTemplate, key, prerequisite)
+			}
+			if err := visit(prerequisite); err != nil {
+				return err
+			}
+		}
+		state[key] = done
+		ordered = append(ordered, byKey[key])
+		return nil
+	}
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/orders"
+	"order-validation-v2/internal/usecase/requirements"
+	"order-validation-v2/internal/usecase/tasks"
+	"order-validation-v2/internal/usecase/user"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	created *entity.OrderTemplate
+	tasks   [][]*entity.Task
+}
+
+func (r *fakeRepo) Create(t *entity.OrderTemplate) (string, error) {
+	r.created = t
+	return "t1", nil
+}
+
+func (r *fakeRepo) CreateOrder(o *entity.Orders, reqs []*entity.Requirements, tasks [][]*entity.Task) error {
+	r.tasks = tasks
+	return nil
+}
+
+type fakeOrders struct {
+	orders.UseCase
+}
+
+func (fakeOrders) GetOrder(id string) (*entity.Orders, error) {
+	return &entity.Orders{ID: id, Title: "Order", Deadline: time.Now().Add(48 * time.Hour)}, nil
+}
+
+func (fakeOrders) SyncStatus(id string) (*entity.Orders, error) {
+	return &entity.Orders{ID: id}, nil
+}
+
+type fakeRequirements struct {
+	requirements.UseCase
+}
+
+func (fakeRequirements) GetRequirementsbyOrderId(orderID string) ([]*entity.Requirements, error) {
+	return []*entity.Requirements{{Id: 1, Request: "design"}, {Id: 2, Request: "build"}}, nil
+}
+
+//fakeTasks is an order whose design task was submitted, so the edge to it
+//is resolved, and whose build task also waited on a deleted task
+type fakeTasks struct {
+	tasks.UseCase
+}
+
+func (fakeTasks) GetTasksOnSpecificOrder(orderID string) ([]*entity.TaskWithDetails, error) {
+	return []*entity.TaskWithDetails{
+		{ID: "build-id", RequirementID: 2, UserID: "u2", Prerequisites: []string{"design-id", "deleted-id"}},
+		{ID: "design-id", RequirementID: 1, UserID: "u1", Status: entity.Finished},
+	}, nil
+}
+
+type fakeUsers struct {
+	user.UseCase
+}
+
+func (fakeUsers) GetUserbyID(id string) (*entity.User, error) {
+	return &entity.User{ID: id}, nil
+}
+
+func newTestService(repo *fakeRepo) *Service {
+	return NewService(repo, fakeOrders{}, fakeRequirements{}, fakeTasks{}, fakeUsers{})
+}
+
+func TestSaveOrderAsTemplate(t *testing.T) {
+	repo := &fakeRepo{}
+	_, err := newTestService(repo).SaveOrderAsTemplate("o1", "plan", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]entity.TemplateTask
+	for _, req := range repo.created.Requirements {
+		got = append(got, req.Tasks)
+	}
+	want := [][]entity.TemplateTask{
+		{{Key: "task-1", UserID: "u1"}},
+		{{Key: "task-2", UserID: "u2", Prerequisites: []string{"task-1"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("template tasks = %+v, want %+v", got, want)
+	}
+}
+
+func TestCloneOrderAssignees(t *testing.T) {
+	repo := &fakeRepo{}
+	_, err := newTestService(repo).CloneOrder("o1", Overrides{Assignees: map[string]string{"build-id": "u3"}}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	design, build := repo.tasks[0][0], repo.tasks[1][0]
+	if design.UserID != "u1" || build.UserID != "u3" {
+		t.Errorf("assignees = %s and %s, want u1 and u3", design.UserID, build.UserID)
+	}
+	if !reflect.DeepEqual(build.Prerequisites, []string{design.ID}) {
+		t.Errorf("build waits on %v, want the new design task %s", build.Prerequisites, design.ID)
+	}
+}
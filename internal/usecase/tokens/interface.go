@@ -0,0 +1,41 @@
+package tokens
+
+import (
+	"order-validation-v2/internal/entity"
+	"time"
+)
+
+type Reader interface {
+	GetByHash(tokenHash string) (*entity.RefreshToken, error)
+	IsRevoked(jti string) (bool, error)
+	//RevokedAt returns when every token of the user was last revoked, or
+	//the zero time if never
+	RevokedAt(userID string) (time.Time, error)
+}
+
+type Writer interface {
+	Create(t *entity.RefreshToken) (string, error)
+	//MarkUsed flags the token as consumed and reports false if another
+	//request consumed it first
+	MarkUsed(id string) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	//ConsumeToken revokes jti and reports false if it was already revoked
+	ConsumeToken(jti string, expiresAt time.Time) (bool, error)
+	RevokeUser(userID string, at time.Time) error
+}
+
+type Repository interface {
+	Reader
+	Writer
+}
+
+type UseCase interface {
+	IssueRefreshToken(userID string, sessionID string) (string, error)
+	RotateRefreshToken(token string) (string, *entity.RefreshToken, error)
+	RevokeRefreshToken(token string) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	ConsumeToken(jti string, expiresAt time.Time) (bool, error)
+	RevokeUser(userID string) error
+	IsRevoked(jti string, userID string, issuedAt time.Time) (bool, error)
+}
@@ -0,0 +1,143 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/sessions"
+	"time"
+)
+
+const DefaultRefreshTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session and token family revoked")
+)
+
+type Service struct {
+	repo       Repository
+	sessions   sessions.UseCase
+	refreshTTL time.Duration
+}
+
+func NewService(r Repository, se sessions.UseCase, refreshTTL time.Duration) *Service {
+	return &Service{
+		repo:       r,
+		sessions:   se,
+		refreshTTL: refreshTTL,
+	}
+}
+
+//IssueRefreshToken starts the token family of a new session, the family
+//shares the session's ID
+func (s *Service) IssueRefreshToken(userID string, sessionID string) (string, error) {
+	return s.issue(userID, sessionID)
+}
+
+//RotateRefreshToken consumes a refresh token and returns its successor and
+//the consumed token, which carries the user and the session (family).
+//Presenting an already consumed token ends the session and revokes the
+//whole family, since either the client or an attacker holds a stolen copy.
+//A token of an ended session is rejected without being consumed.
+func (s *Service) RotateRefreshToken(token string) (string, *entity.RefreshToken, error) {
+	t, err := s.repo.GetByHash(hashToken(token))
+	if err != nil || t == nil {
+		return "", nil, ErrInvalidRefreshToken
+	}
+	if t.Revoked || t.Expired() {
+		return "", nil, ErrInvalidRefreshToken
+	}
+	if t.Used {
+		return "", nil, s.reused(t)
+	}
+	active, err := s.sessions.ValidateSession(t.FamilyID, t.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !active {
+		return "", nil, ErrInvalidRefreshToken
+	}
+	ok, err := s.repo.MarkUsed(t.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return "", nil, s.reused(t)
+	}
+	next, err := s.issue(t.UserID, t.FamilyID)
+	if err != nil {
+		return "", nil, err
+	}
+	return next, t, nil
+}
+
+func (s *Service) RevokeRefreshToken(token string) error {
+	t, err := s.repo.GetByHash(hashToken(token))
+	if err != nil || t == nil {
+		return ErrInvalidRefreshToken
+	}
+	return s.repo.RevokeFamily(t.FamilyID)
+}
+
+func (s *Service) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	return s.repo.RevokeAccessToken(jti, expiresAt)
+}
+
+//ConsumeToken makes a single-use token unusable, only the first caller
+//gets true
+func (s *Service) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	return s.repo.ConsumeToken(jti, expiresAt)
+}
+
+//RevokeUser invalidates every access and refresh token issued to the user
+//up to now. The time is kept at the microsecond precision tokens and the
+//database carry.
+func (s *Service) RevokeUser(userID string) error {
+	return s.repo.RevokeUser(userID, time.Now().Truncate(time.Microsecond))
+}
+
+//IsRevoked reports whether the token was revoked itself or issued no later
+//than the last revocation of its user
+func (s *Service) IsRevoked(jti string, userID string, issuedAt time.Time) (bool, error) {
+	revoked, err := s.repo.IsRevoked(jti)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	revokedAt, err := s.repo.RevokedAt(userID)
+	if err != nil {
+		return false, err
+	}
+	return !revokedAt.IsZero() && !issuedAt.After(revokedAt), nil
+}
+
+func (s *Service) issue(userID string, familyID string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	t := entity.NewRefreshToken(userID, familyID, hashToken(token), s.refreshTTL)
+	if _, err := s.repo.Create(t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *Service) reused(t *entity.RefreshToken) error {
+	if err := s.repo.RevokeFamily(t.FamilyID); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeSession(t.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
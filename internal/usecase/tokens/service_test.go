@@ -0,0 +1,199 @@
+package tokens
+
+import (
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/internal/usecase/sessions"
+	"testing"
+	"time"
+)
+
+//fakeRepo keeps refresh tokens by hash. stolen marks a token as used by
+//another request between the lookup and MarkUsed.
+type fakeRepo struct {
+	Repository
+	revokedJTIs map[string]bool
+	revokedAt   map[string]time.Time
+	refresh     map[string]*entity.RefreshToken
+	stolen      bool
+}
+
+func (r *fakeRepo) GetByHash(tokenHash string) (*entity.RefreshToken, error) {
+	t, ok := r.refresh[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *fakeRepo) Create(t *entity.RefreshToken) (string, error) {
+	r.refresh[t.TokenHash] = t
+	return t.ID, nil
+}
+
+func (r *fakeRepo) MarkUsed(id string) (bool, error) {
+	for _, t := range r.refresh {
+		if t.ID == id {
+			if r.stolen {
+				t.Used = true
+			}
+			if t.Used {
+				return false, nil
+			}
+			t.Used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepo) RevokeFamily(familyID string) error {
+	for _, t := range r.refresh {
+		if t.FamilyID == familyID {
+			t.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) IsRevoked(jti string) (bool, error) {
+	return r.revokedJTIs[jti], nil
+}
+
+func (r *fakeRepo) RevokedAt(userID string) (time.Time, error) {
+	return r.revokedAt[userID], nil
+}
+
+func (r *fakeRepo) RevokeUser(userID string, at time.Time) error {
+	r.revokedAt[userID] = at
+	return nil
+}
+
+type fakeSessions struct {
+	sessions.UseCase
+	active map[string]bool
+}
+
+func (f *fakeSessions) ValidateSession(id string, userID string) (bool, error) {
+	return f.active[id], nil
+}
+
+func (f *fakeSessions) RevokeSession(id string) error {
+	f.active[id] = false
+	return nil
+}
+
+func TestIsRevoked(t *testing.T) {
+	revokedAt := time.Date(2026, 3, 1, 12, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "never revoked", jti: "j1", userID: "u2", issuedAt: revokedAt},
+		{name: "revoked token", jti: "revoked", userID: "u2", issuedAt: revokedAt, want: true},
+		{name: "issued a second before", jti: "j1", userID: "u1", issuedAt: revokedAt.Add(-time.Second), want: true},
+		{name: "issued earlier in the same second", jti: "j1", userID: "u1", issuedAt: revokedAt.Add(-400 * time.Millisecond), want: true},
+		{name: "issued at the revocation", jti: "j1", userID: "u1", issuedAt: revokedAt, want: true},
+		{name: "issued later in the same second", jti: "j1", userID: "u1", issuedAt: revokedAt.Add(time.Microsecond)},
+		{name: "issued after", jti: "j1", userID: "u1", issuedAt: revokedAt.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{
+				revokedJTIs: map[string]bool{"revoked": true},
+				revokedAt:   map[string]time.Time{"u1": revokedAt},
+			}
+			s := NewService(repo, &fakeSessions{}, DefaultRefreshTTL)
+
+			revoked, err := s.IsRevoked(tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevokeUserCoversTokensIssuedUpToNow(t *testing.T) {
+	repo := &fakeRepo{revokedAt: map[string]time.Time{}}
+	s := NewService(repo, &fakeSessions{}, DefaultRefreshTTL)
+	issued := time.Now().Truncate(time.Microsecond)
+
+	if err := s.RevokeUser("u1"); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := s.IsRevoked("j1", "u1", issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Errorf("token issued at %v survived the revocation at %v", issued, repo.revokedAt["u1"])
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func(t *entity.RefreshToken)
+		sessionEnded  bool
+		stolen        bool
+		wantErr       error
+		wantConsumed  bool
+		wantRevoked   bool
+		wantSessionOn bool
+	}{
+		{name: "fresh token", wantConsumed: true, wantSessionOn: true},
+		{name: "rotated token presented again", prepare: func(t *entity.RefreshToken) { t.Used = true },
+			wantErr: ErrRefreshTokenReused, wantConsumed: true, wantRevoked: true},
+		{name: "consumed by a concurrent request", stolen: true,
+			wantErr: ErrRefreshTokenReused, wantConsumed: true, wantRevoked: true},
+		{name: "ended session", sessionEnded: true, wantErr: ErrInvalidRefreshToken},
+		{name: "revoked token", prepare: func(t *entity.RefreshToken) { t.Revoked = true },
+			wantErr: ErrInvalidRefreshToken, wantRevoked: true, wantSessionOn: true},
+		{name: "expired token", prepare: func(t *entity.RefreshToken) { t.ExpiresAt = time.Now().Add(-time.Minute) },
+			wantErr: ErrInvalidRefreshToken, wantSessionOn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{refresh: map[string]*entity.RefreshToken{}, stolen: tt.stolen}
+			se := &fakeSessions{active: map[string]bool{"s1": !tt.sessionEnded}}
+			s := NewService(repo, se, DefaultRefreshTTL)
+			token, err := s.IssueRefreshToken("u1", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := repo.refresh[hashToken(token)]
+			if tt.prepare != nil {
+				tt.prepare(stored)
+			}
+
+			next, consumed, err := s.RotateRefreshToken(token)
+			if err != tt.wantErr {
+				t.Fatalf("RotateRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if consumed.FamilyID != "s1" || consumed.UserID != "u1" {
+					t.Errorf("consumed = %+v, want family s1 of u1", consumed)
+				}
+				successor := repo.refresh[hashToken(next)]
+				if successor == nil || successor.FamilyID != "s1" || successor.Used {
+					t.Errorf("successor = %+v, want an unused token of family s1", successor)
+				}
+			}
+			if stored.Used != tt.wantConsumed {
+				t.Errorf("consumed = %v, want %v", stored.Used, tt.wantConsumed)
+			}
+			if stored.Revoked != tt.wantRevoked {
+				t.Errorf("revoked = %v, want %v", stored.Revoked, tt.wantRevoked)
+			}
+			if se.active["s1"] != tt.wantSessionOn {
+				t.Errorf("session active = %v, want %v", se.active["s1"], tt.wantSessionOn)
+			}
+		})
+	}
+}
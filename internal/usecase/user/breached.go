@@ -0,0 +1,78 @@
+package user
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"sort"
+	"strings"
+)
+
+const breachedPrefixLength = 5
+
+//BreachedIndex holds a breached-password corpus as SHA-1 hashes bucketed by
+//their first five hex digits, the layout of the Have I Been Pwned range
+//files, so no plaintext password is kept in memory
+type BreachedIndex struct {
+	buckets map[string][]string
+}
+
+//LoadBreachedIndex reads one entry per line, either a plaintext password or
+//a SHA-1 hash optionally followed by ":count" as in the HIBP downloads
+func LoadBreachedIndex(path string) (*BreachedIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	index := &BreachedIndex{buckets: map[string][]string{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		index.add(breachedHash(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, bucket := range index.buckets {
+		sort.Strings(bucket)
+	}
+	return index, nil
+}
+
+func (b *BreachedIndex) add(hash string) {
+	prefix, suffix := hash[:breachedPrefixLength], hash[breachedPrefixLength:]
+	b.buckets[prefix] = append(b.buckets[prefix], suffix)
+}
+
+func (b *BreachedIndex) Contains(password string) bool {
+	hash := sha1Hex(password)
+	bucket := b.buckets[hash[:breachedPrefixLength]]
+	suffix := hash[breachedPrefixLength:]
+	i := sort.SearchStrings(bucket, suffix)
+	return i < len(bucket) && bucket[i] == suffix
+}
+
+//breachedHash returns the upper case SHA-1 of a corpus line, hashing it
+//unless it already is a hash
+func breachedHash(line string) string {
+	candidate := line
+	if i := strings.IndexByte(line, ':'); i == sha1.Size*2 {
+		candidate = line[:i]
+	}
+	if len(candidate) == sha1.Size*2 {
+		if _, err := hex.DecodeString(candidate); err == nil {
+			return strings.ToUpper(candidate)
+		}
+	}
+	return sha1Hex(line)
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -2,12 +2,14 @@ package user
 
 import (
 	"order-validation-v2/internal/entity"
+	"time"
 )
 
 //Reader interface
 type Reader interface {
 	GetbyID(ID string) (*entity.User, error)
 	GetbyUsername(username string) (*entity.User, error)
+	ListbyEmail(email string) ([]*entity.User, error)
 	Search(query string) ([]*entity.User, error)
 	List() ([]*entity.User, error)
 	CheckUsername(username string) (bool, error)
@@ -18,6 +20,21 @@ type Writer interface {
 	Create(r *entity.User) (string, error)
 	Update(r *entity.User) error
 	Delete(ID string) error
+	//AddPasswordHistory stores a replaced hash and drops all but the keep
+	//most recent
+	AddPasswordHistory(userID string, hash string, replacedAt time.Time, keep int) error
+	//GetPasswordHistory returns the most recently replaced hashes first
+	GetPasswordHistory(userID string, limit int) ([]string, error)
+	ReplaceRecoveryCodes(userID string, hashes []string) error
+	//UseRecoveryCode marks an unused code as used and reports whether one
+	//matched
+	UseRecoveryCode(userID string, hash string) (bool, error)
+	//AdvanceTOTPStep records step as the last accepted TOTP step and returns
+	//false when an equal or later step was already accepted
+	AdvanceTOTPStep(userID string, step int64) (bool, error)
+	//SetTOTP replaces the second factor state, which Update leaves alone so
+	//a stale user can't roll back the last accepted step
+	SetTOTP(userID string, secret string, enabled bool, lastStep int64) error
 }
 
 //Repository interface
@@ -29,12 +46,24 @@ type Repository interface {
 type UseCase interface {
 	GetUserbyID(id string) (*entity.User, error)
 	GetUserbyUsername(username string) (*entity.User, error)
+	ListUsersbyEmail(email string) ([]*entity.User, error)
 	SearchUser(query string) ([]*entity.User, error)
 	ListUsers() ([]*entity.User, error)
 	CreateUser(username string, email string, password string, role string) (string, error)
+	InviteUser(username string, email string, role string) (string, error)
+	CreateServiceAccount(username string, role string) (string, error)
+	CreateExternalUser(username string, email string, role string) (string, error)
 	UpdateUser(u *entity.User) error
+	SetPassword(u *entity.User, password string) error
+	ValidatePassword(u *entity.User, password string) error
+	PasswordExpired(u *entity.User) bool
 	DeleteUser(username string) error
 	Login(username string, password string) (string, string, bool, error)
 	ValidateAndRetrieveUser(userID string, password string) (bool, *entity.User, error)
 	ValidateUsername(username string) (bool, error)
+	EnrollTOTP(u *entity.User, issuer string) (string, string, error)
+	ConfirmTOTP(u *entity.User, code string) ([]string, error)
+	VerifySecondFactor(u *entity.User, code string) (bool, error)
+	RegenerateRecoveryCodes(u *entity.User) ([]string, error)
+	DisableTOTP(u *entity.User) error
 }
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//PasswordHasher hashes passwords into self-describing strings that carry
+//the algorithm and the parameters used, so they can be verified later.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	//NeedsRehash reports whether encoded was produced by a different
+	//algorithm or with weaker parameters than the hasher currently uses.
+	NeedsRehash(encoded string) bool
+}
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+//verifyPassword checks password against any hash format the service has
+//ever written, including the unsalted SHA-256 hashes produced in SQL.
+func verifyPassword(password string, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return verifyBcrypt(password, encoded)
+	case isLegacySHA256(encoded):
+		return verifyLegacySHA256(password, encoded), nil
+	}
+	return false, ErrUnknownHashFormat
+}
+
+//isLegacySHA256 matches sha256() output as stored by Postgres ("\x" prefixed)
+//and sha2(?,256) output as stored by MySQL (bare hex).
+func isLegacySHA256(encoded string) bool {
+	digest := strings.TrimPrefix(encoded, "\\x")
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+func verifyLegacySHA256(password string, encoded string) bool {
+	digest := strings.ToLower(strings.TrimPrefix(encoded, "\\x"))
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+	return subtle.ConstantTimeCompare([]byte(digest), []byte(sum)) == 1
+}
+
+//NewPasswordHasher returns the hasher for the named algorithm, defaulting
+//to argon2id
+func NewPasswordHasher(algorithm string) PasswordHasher {
+	switch strings.ToLower(algorithm) {
+	case "bcrypt":
+		return NewBcryptHasher(12)
+	default:
+		return NewArgon2idHasher(DefaultArgon2idParams)
+	}
+}
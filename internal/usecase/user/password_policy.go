@@ -0,0 +1,160 @@
+package user
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//PasswordPolicy is checked whenever a password is chosen
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	//HistorySize is how many previous passwords can't be reused, the
+	//current one included
+	HistorySize int
+	//MaxAge forces a change at the next login once a password is older,
+	//zero disables expiry
+	MaxAge time.Duration
+	//Breached is nil when no breached-password list is loaded
+	Breached *BreachedIndex
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    10,
+	MaxLength:    128,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	HistorySize:  5,
+}
+
+//Violation is one rule a password breaks
+type Violation struct {
+	Code    string
+	Message string
+}
+
+//PolicyError lists every rule a password breaks, so they can all be shown
+//next to the field at once
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password rejected: " + strings.Join(messages, ", ")
+}
+
+//Check validates the rules that only need the password itself. Reuse is
+//checked by the service, which has the history.
+func (p PasswordPolicy) Check(password string) error {
+	var violations []Violation
+	length := len([]rune(password))
+	if length < p.MinLength {
+		violations = append(violations, Violation{"too_short", fmt.Sprintf("must be at least %d characters", p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{"too_long", fmt.Sprintf("must be at most %d characters", p.MaxLength)})
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, Violation{"missing_upper", "must contain an uppercase letter"})
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, Violation{"missing_lower", "must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, Violation{"missing_digit", "must contain a digit"})
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, Violation{"missing_symbol", "must contain a symbol"})
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, Violation{"breached", "appears in a list of breached passwords"})
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+//Expired reports whether a password set at changedAt must be changed.
+//A password with an unknown change date is past any max age.
+func (p PasswordPolicy) Expired(changedAt time.Time) bool {
+	return p.MaxAge > 0 && (changedAt.IsZero() || time.Since(changedAt) > p.MaxAge)
+}
+
+//LoadPasswordPolicy starts from DefaultPasswordPolicy and applies:
+//
+//	PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH
+//	PASSWORD_REQUIRE_UPPER, _LOWER, _DIGIT, _SYMBOL   true or false
+//	PASSWORD_HISTORY                                  previous passwords kept
+//	PASSWORD_MAX_AGE                                  e.g. 2160h, 0 disables
+//	PASSWORD_BREACHED_LIST                            path of the breached list
+func LoadPasswordPolicy() (PasswordPolicy, error) {
+	p := DefaultPasswordPolicy
+	var err error
+	ints := map[string]*int{
+		"PASSWORD_MIN_LENGTH": &p.MinLength,
+		"PASSWORD_MAX_LENGTH": &p.MaxLength,
+		"PASSWORD_HISTORY":    &p.HistorySize,
+	}
+	for key, target := range ints {
+		if v := os.Getenv(key); v != "" {
+			*target, err = strconv.Atoi(v)
+			if err != nil {
+				return p, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	bools := map[string]*bool{
+		"PASSWORD_REQUIRE_UPPER":  &p.RequireUpper,
+		"PASSWORD_REQUIRE_LOWER":  &p.RequireLower,
+		"PASSWORD_REQUIRE_DIGIT":  &p.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL": &p.RequireSymbol,
+	}
+	for key, target := range bools {
+		if v := os.Getenv(key); v != "" {
+			*target, err = strconv.ParseBool(v)
+			if err != nil {
+				return p, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	if v := os.Getenv("PASSWORD_MAX_AGE"); v != "" {
+		p.MaxAge, err = time.ParseDuration(v)
+		if err != nil {
+			return p, fmt.Errorf("PASSWORD_MAX_AGE: %w", err)
+		}
+	}
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		p.Breached, err = LoadBreachedIndex(path)
+		if err != nil {
+			return p, fmt.Errorf("PASSWORD_BREACHED_LIST: %w", err)
+		}
+	}
+	return p, nil
+}
@@ -1,20 +1,35 @@
 package user
 
 import (
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"order-validation-v2/internal/entity"
 	"strings"
+	"time"
+)
+
+var (
+	ErrUserDisabled     = errors.New("user is disabled")
+	ErrNotFound         = errors.New("not found")
+	ErrWrongCredentials = errors.New("Username/Password wrong")
 )
 
 type Service struct {
-	repo Repository
+	repo   Repository
+	hasher PasswordHasher
+	policy PasswordPolicy
+	//dummyHash is verified against when the username doesn't exist, so an
+	//unknown user takes as long to reject as a wrong password
+	dummyHash string
 }
 
-func NewService(r Repository) *Service {
+func NewService(r Repository, h PasswordHasher, p PasswordPolicy) *Service {
+	dummyHash, _ := h.Hash(entity.NewUUID().String())
 	return &Service{
-		repo: r,
+		repo:      r,
+		hasher:    h,
+		policy:    p,
+		dummyHash: dummyHash,
 	}
 }
 
@@ -26,16 +41,45 @@ func (s *Service) GetUserbyID(id string) (*entity.User, error) {
 	}
 
 	if u == nil {
-		return nil, errors.New("not found")
+		return nil, ErrNotFound
 	}
 
 	return u, nil
 }
 
 func (s *Service) CreateUser(username string, email string, password string, role string) (string, error) {
-	u := entity.NewUser(email, username, password, role)
+	err := s.policy.Check(password)
+	if err != nil {
+		return "", err
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	u := entity.NewUser(email, username, hash, role)
+	u.PasswordChangedAt = time.Now()
+	return s.repo.Create(u)
+
+}
+
+//InviteUser creates a user without a password. Login fails for it until
+//the invitation is accepted and a password is set.
+func (s *Service) InviteUser(username string, email string, role string) (string, error) {
+	u := entity.NewUser(email, username, "", role)
+	return s.repo.Create(u)
+}
+
+func (s *Service) CreateServiceAccount(username string, role string) (string, error) {
+	u := entity.NewUser("", username, "", role)
+	u.ServiceAccount = true
 	return s.repo.Create(u)
+}
 
+//CreateExternalUser creates a user that signs in through SSO and so has
+//no password of its own
+func (s *Service) CreateExternalUser(username string, email string, role string) (string, error) {
+	u := entity.NewUser(email, username, "", role)
+	return s.repo.Create(u)
 }
 
 func (s *Service) GetUserbyUsername(username string) (*entity.User, error) {
@@ -46,12 +90,17 @@ func (s *Service) GetUserbyUsername(username string) (*entity.User, error) {
 	}
 
 	if u == nil {
-		return nil, errors.New("not found")
+		return nil, ErrNotFound
 	}
 
 	return u, nil
 }
 
+//ListUsersbyEmail returns every user with the email, none is not an error
+func (s *Service) ListUsersbyEmail(email string) ([]*entity.User, error) {
+	return s.repo.ListbyEmail(email)
+}
+
 func (s *Service) SearchUser(query string) ([]*entity.User, error) {
 	users, err := s.repo.Search(strings.ToLower(query))
 	if err != nil {
@@ -86,19 +135,107 @@ func (s *Service) UpdateUser(u *entity.User) error {
 	return s.repo.Update(u)
 }
 
+//SetPassword enforces the password policy, keeps the replaced hash in the
+//history and restarts the password's age
+func (s *Service) SetPassword(u *entity.User, password string) error {
+	err := s.ValidatePassword(u, password)
+	if err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if u.Password != "" && s.policy.HistorySize > 1 {
+		err = s.repo.AddPasswordHistory(u.ID, u.Password, time.Now(), s.policy.HistorySize-1)
+		if err != nil {
+			return err
+		}
+	}
+	u.Password = hash
+	u.PasswordChangedAt = time.Now()
+	return s.repo.Update(u)
+}
+
+//ValidatePassword checks a new password for u against the policy and the
+//last HistorySize passwords, returning a *PolicyError listing every
+//violation
+func (s *Service) ValidatePassword(u *entity.User, password string) error {
+	var violations []Violation
+	err := s.policy.Check(password)
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		violations = policyErr.Violations
+	} else if err != nil {
+		return err
+	}
+	reused, err := s.reused(u, password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		violations = append(violations, Violation{"reused", fmt.Sprintf("must differ from the last %d passwords", s.policy.HistorySize)})
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+//PasswordExpired reports whether u must choose a new password before
+//being let in
+func (s *Service) PasswordExpired(u *entity.User) bool {
+	return u.Password != "" && s.policy.Expired(u.PasswordChangedAt)
+}
+
+func (s *Service) reused(u *entity.User, password string) (bool, error) {
+	if s.policy.HistorySize < 1 || u.Password == "" {
+		return false, nil
+	}
+	hashes := []string{u.Password}
+	if s.policy.HistorySize > 1 {
+		previous, err := s.repo.GetPasswordHistory(u.ID, s.policy.HistorySize-1)
+		if err != nil {
+			return false, err
+		}
+		hashes = append(hashes, previous...)
+	}
+	for _, hash := range hashes {
+		ok, err := s.hasher.Verify(password, hash)
+		//a hash nothing can verify anymore can't be reused either
+		if errors.Is(err, ErrUnknownHashFormat) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (s *Service) Login(username string, password string) (string, string, bool, error) {
 	u, err := s.repo.GetbyUsername(username)
 	if err != nil {
 		return username, "", false, err
 	}
 	if u == nil {
-		return username, "", false, errors.New("Username/Password wrong")
+		s.hasher.Verify(password, s.dummyHash)
+		return username, "", false, ErrWrongCredentials
 	}
-	sum := sha256.Sum256([]byte(password))
-	if u.Password == fmt.Sprintf("\\x%x", sum) {
+	ok, err := s.verify(u, password)
+	if err != nil {
+		return username, "", false, err
+	}
+	if ok {
+		if u.Disabled {
+			return username, "", false, ErrUserDisabled
+		}
 		return u.ID, u.UserRole, true, nil
 	}
-	return username, u.UserRole, false, errors.New("Username/Password wrong")
+	return username, u.UserRole, false, ErrWrongCredentials
 
 }
 
@@ -110,13 +247,42 @@ func (s *Service) ValidateAndRetrieveUser(userID string, password string) (bool,
 	if u == nil {
 		return false, nil, errors.New("UserID not found")
 	}
-	sum := sha256.Sum256([]byte(password))
-	if u.Password == fmt.Sprintf("\\x%x", sum) {
+	ok, err := s.verify(u, password)
+	if err != nil {
+		return false, nil, err
+	}
+	if ok {
 		return true, u, nil
 	}
 	return false, nil, nil
 }
 
+//verify checks the password and, on success, transparently upgrades hashes
+//written by an older algorithm or weaker parameters. Users without a
+//password, invited or signing in through SSO, never match and take as long
+//to reject as a wrong password.
+func (s *Service) verify(u *entity.User, password string) (bool, error) {
+	if u.Password == "" {
+		s.hasher.Verify(password, s.dummyHash)
+		return false, nil
+	}
+	ok, err := s.hasher.Verify(password, u.Password)
+	if err != nil || !ok {
+		return false, err
+	}
+	if s.hasher.NeedsRehash(u.Password) {
+		//a failed upgrade must not lock the user out, the old hash is kept
+		//and the upgrade is retried on the next login. It is the same
+		//password, so neither the policy nor its age are touched.
+		hash, err := s.hasher.Hash(password)
+		if err == nil {
+			u.Password = hash
+			s.repo.Update(u)
+		}
+	}
+	return true, nil
+}
+
 func (s *Service) ValidateUsername(username string) (bool, error) {
 	return s.repo.CheckUsername(username)
 }
@@ -0,0 +1,186 @@
+package user
+
+import (
+	"errors"
+	"order-validation-v2/internal/entity"
+	"testing"
+	"time"
+)
+
+//fakeRepo keeps the users, their password history, most recent first, and
+//the state the second factor depends on. err is returned by the lookups
+//when set.
+type fakeRepo struct {
+	Repository
+	users    map[string]*entity.User
+	history  map[string][]string
+	lastStep map[string]int64
+	recovery map[string]bool
+	err      error
+}
+
+func newFakeRepo(users ...*entity.User) *fakeRepo {
+	r := &fakeRepo{users: map[string]*entity.User{}, history: map[string][]string{}, lastStep: map[string]int64{}, recovery: map[string]bool{}}
+	for _, u := range users {
+		r.users[u.ID] = u
+		r.lastStep[u.ID] = u.TOTPLastStep
+	}
+	return r
+}
+
+func (r *fakeRepo) GetbyUsername(username string) (*entity.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepo) Update(u *entity.User) error {
+	r.users[u.ID] = u
+	return nil
+}
+
+func (r *fakeRepo) AddPasswordHistory(userID string, hash string, replacedAt time.Time, keep int) error {
+	history := append([]string{hash}, r.history[userID]...)
+	if len(history) > keep {
+		history = history[:keep]
+	}
+	r.history[userID] = history
+	return nil
+}
+
+func (r *fakeRepo) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	history := r.history[userID]
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+func TestSetPassword(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	hash := func(password string) string {
+		h, err := hasher.Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	tests := []struct {
+		name     string
+		current  string
+		history  []string
+		password string
+		wantErr  bool
+	}{
+		{name: "new password", current: hash("first one"), history: []string{hash("older one")}, password: "another one"},
+		{name: "current password", current: hash("first one"), password: "first one", wantErr: true},
+		{name: "password in the history", current: hash("first one"), history: []string{hash("older one")}, password: "older one", wantErr: true},
+		{name: "password beyond the history", current: hash("first one"), history: []string{hash("older one"), hash("old one"), hash("oldest one")}, password: "oldest one"},
+		{name: "unknown hash in the history", current: hash("first one"), history: []string{"md5:0cc175b9c0f1b6a8", hash("older one")}, password: "older one", wantErr: true},
+		{name: "invited user", password: "first one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &entity.User{ID: "u1", Username: "alice", Password: tt.current}
+			repo := newFakeRepo(u)
+			repo.history["u1"] = tt.history
+			s := NewService(repo, hasher, PasswordPolicy{HistorySize: 3})
+
+			err := s.SetPassword(u, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var policyErr *PolicyError
+			if err != nil && !errors.As(err, &policyErr) {
+				t.Fatalf("SetPassword() error = %v, want a *PolicyError", err)
+			}
+			if err != nil {
+				return
+			}
+			if len(repo.history["u1"]) > 2 {
+				t.Errorf("history kept %d hashes, want at most 2", len(repo.history["u1"]))
+			}
+			if tt.current != "" && repo.history["u1"][0] != tt.current {
+				t.Errorf("history doesn't start with the replaced hash")
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errDown := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		username string
+		password string
+		disabled bool
+		invited  bool
+		repoErr  error
+		wantOK   bool
+		wantErr  error
+	}{
+		{name: "valid credentials", username: "alice", password: "correct horse", wantOK: true},
+		{name: "wrong password", username: "alice", password: "wrong", wantErr: ErrWrongCredentials},
+		{name: "unknown user", username: "bob", password: "correct horse", wantErr: ErrWrongCredentials},
+		{name: "disabled user", username: "alice", password: "correct horse", disabled: true, wantErr: ErrUserDisabled},
+		{name: "invited user", username: "alice", password: "", invited: true, wantErr: ErrWrongCredentials},
+		{name: "repository failure", username: "alice", password: "correct horse", repoErr: errDown, wantErr: errDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &entity.User{ID: "u1", Username: "alice", Password: hash, Disabled: tt.disabled}
+			if tt.invited {
+				u.Password = ""
+			}
+			repo := newFakeRepo(u)
+			repo.err = tt.repoErr
+			s := NewService(repo, hasher, PasswordPolicy{})
+
+			_, _, ok, err := s.Login(tt.username, tt.password)
+			if err != tt.wantErr {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("Login() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	maxAge := 90 * 24 * time.Hour
+	tests := []struct {
+		name      string
+		password  string
+		changedAt time.Time
+		maxAge    time.Duration
+		want      bool
+	}{
+		{name: "recent password", password: "hash", changedAt: time.Now().Add(-time.Hour), maxAge: maxAge},
+		{name: "old password", password: "hash", changedAt: time.Now().Add(-100 * 24 * time.Hour), maxAge: maxAge, want: true},
+		{name: "unknown change date", password: "hash", maxAge: maxAge, want: true},
+		{name: "no max age", password: "hash"},
+		{name: "invited user", maxAge: maxAge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(newFakeRepo(), NewBcryptHasher(4), PasswordPolicy{MaxAge: tt.maxAge})
+
+			got := s.PasswordExpired(&entity.User{Password: tt.password, PasswordChangedAt: tt.changedAt})
+			if got != tt.want {
+				t.Errorf("PasswordExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/pkg/totp"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidOTP         = errors.New("invalid one-time password")
+)
+
+//EnrollTOTP stores a new secret for the user and returns it with its
+//provisioning URI. The secret is only used once a code has been confirmed.
+func (s *Service) EnrollTOTP(u *entity.User, issuer string) (string, string, error) {
+	if u.TOTPEnabled {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	err = s.repo.SetTOTP(u.ID, secret, false, 0)
+	if err != nil {
+		return "", "", err
+	}
+	u.TOTPSecret = secret
+	u.TOTPLastStep = 0
+	return secret, totp.ProvisioningURI(issuer, u.Username, secret), nil
+}
+
+//ConfirmTOTP enables two-factor authentication after the first valid code
+//and returns the recovery codes, which are shown to the user only once
+func (s *Service) ConfirmTOTP(u *entity.User, code string) ([]string, error) {
+	if u.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	step, ok := totp.Validate(u.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidOTP
+	}
+	err := s.repo.SetTOTP(u.ID, u.TOTPSecret, true, step)
+	if err != nil {
+		return nil, err
+	}
+	u.TOTPEnabled = true
+	u.TOTPLastStep = step
+	return s.RegenerateRecoveryCodes(u)
+}
+
+//VerifySecondFactor accepts either a current TOTP code or an unused
+//recovery code
+func (s *Service) VerifySecondFactor(u *entity.User, code string) (bool, error) {
+	if !u.TOTPEnabled {
+		return false, ErrTOTPNotEnrolled
+	}
+	step, ok := totp.Validate(u.TOTPSecret, code, time.Now())
+	if ok {
+		accepted, err := s.repo.AdvanceTOTPStep(u.ID, step)
+		if err != nil || !accepted {
+			return false, err
+		}
+		u.TOTPLastStep = step
+		return true, nil
+	}
+	return s.repo.UseRecoveryCode(u.ID, hashRecoveryCode(code))
+}
+
+func (s *Service) RegenerateRecoveryCodes(u *entity.User) ([]string, error) {
+	if !u.TOTPEnabled {
+		return nil, ErrTOTPNotEnrolled
+	}
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+	err := s.repo.ReplaceRecoveryCodes(u.ID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) DisableTOTP(u *entity.User) error {
+	err := s.repo.SetTOTP(u.ID, "", false, 0)
+	if err != nil {
+		return err
+	}
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.TOTPLastStep = 0
+	return s.repo.ReplaceRecoveryCodes(u.ID, nil)
+}
+
+//newRecoveryCode returns 50 random bits formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+//hashRecoveryCode stores recovery codes as plain SHA-256, they are random
+//enough that a slow hash adds nothing
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package user
+
+import (
+	"order-validation-v2/internal/entity"
+	"order-validation-v2/pkg/totp"
+	"testing"
+	"time"
+)
+
+func (r *fakeRepo) AdvanceTOTPStep(userID string, step int64) (bool, error) {
+	if r.lastStep[userID] >= step {
+		return false, nil
+	}
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func (r *fakeRepo) UseRecoveryCode(userID string, hash string) (bool, error) {
+	if !r.recovery[hash] {
+		return false, nil
+	}
+	delete(r.recovery, hash)
+	return true, nil
+}
+
+func (r *fakeRepo) SetTOTP(userID string, secret string, enabled bool, lastStep int64) error {
+	u := r.users[userID]
+	u.TOTPSecret, u.TOTPEnabled = secret, enabled
+	r.lastStep[userID] = lastStep
+	return nil
+}
+
+func (r *fakeRepo) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	r.recovery = map[string]bool{}
+	for _, hash := range hashes {
+		r.recovery[hash] = true
+	}
+	return nil
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	stored := &entity.User{ID: "u1", Username: "alice"}
+	repo := newFakeRepo(stored)
+	s := NewService(repo, NewBcryptHasher(4), PasswordPolicy{})
+	//the caller's copy, as loaded for the request
+	u := &entity.User{ID: "u1", Username: "alice"}
+
+	secret, _, err := s.EnrollTOTP(u, "issuer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.TOTPSecret != secret || stored.TOTPEnabled {
+		t.Fatalf("EnrollTOTP() stored secret %q enabled %v", stored.TOTPSecret, stored.TOTPEnabled)
+	}
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := s.ConfirmTOTP(u, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.TOTPEnabled || repo.lastStep["u1"] != step || len(codes) != recoveryCodeCount {
+		t.Fatalf("ConfirmTOTP() stored enabled %v step %d, %d codes", stored.TOTPEnabled, repo.lastStep["u1"], len(codes))
+	}
+	if ok, _ := s.VerifySecondFactor(u, code); ok {
+		t.Error("the confirmation code was accepted again")
+	}
+
+	err = s.DisableTOTP(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.TOTPSecret != "" || stored.TOTPEnabled || repo.lastStep["u1"] != 0 || len(repo.recovery) != 0 {
+		t.Errorf("DisableTOTP() left secret %q enabled %v step %d, %d codes", stored.TOTPSecret, stored.TOTPEnabled, repo.lastStep["u1"], len(repo.recovery))
+	}
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := totp.Step(time.Now())
+	code, err := totp.Code(secret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		lastStep int64
+		code     string
+		want     bool
+	}{
+		{name: "fresh code", lastStep: current - 2, code: code, want: true},
+		{name: "code of an accepted step", lastStep: current, code: code},
+		{name: "code older than an accepted step", lastStep: current + 1, code: code},
+		{name: "unused recovery code", lastStep: current, code: "abcde-fghij", want: true},
+		{name: "wrong code", lastStep: current - 2, code: "klmno-pqrst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &entity.User{ID: "u1", TOTPSecret: secret, TOTPEnabled: true, TOTPLastStep: tt.lastStep}
+			repo := newFakeRepo(u)
+			repo.recovery[hashRecoveryCode("abcde-fghij")] = true
+			s := NewService(repo, NewBcryptHasher(4), PasswordPolicy{})
+
+			ok, err := s.VerifySecondFactor(u, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("VerifySecondFactor() = %v, want %v", ok, tt.want)
+			}
+			if tt.want {
+				again, _ := s.VerifySecondFactor(u, tt.code)
+				if again {
+					t.Error("the same code was accepted twice")
+				}
+			}
+		})
+	}
+}
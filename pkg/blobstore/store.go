@@ -0,0 +1,42 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+//Store keeps the content of files outside the database. Keys are chosen by
+//the caller and must be unique.
+type Store interface {
+	Put(key string, r io.Reader) error
+	//Get returns ErrNotFound when no blob is stored under key
+	Get(key string) (io.ReadCloser, error)
+	//Delete succeeds when no blob is stored under key
+	Delete(key string) error
+}
+
+//LoadFromEnv picks the store from BLOB_STORE:
+//
+//	directory  BLOB_DIR (default ./blobs), one file per blob
+//
+//Without BLOB_STORE blobs are written to BLOB_DIR.
+func LoadFromEnv() (Store, error) {
+	switch strings.ToLower(os.Getenv("BLOB_STORE")) {
+	case "", "directory", "dir":
+		return NewDirectoryStore(getenv("BLOB_DIR", "blobs"))
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", os.Getenv("BLOB_STORE"))
+	}
+}
+
+func getenv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
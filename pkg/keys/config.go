@@ -0,0 +1,144 @@
+package keys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIssuer      = "order-validation-v2"
+	defaultGracePeriod = 24 * time.Hour
+)
+
+var (
+	ErrNoSigningKey         = errors.New("no JWT signing key, set JWT_SIGNING_KEY or JWT_SIGNING_KEY_FILE")
+	ErrNoDocumentSigningKey = errors.New("no document signing key, set DOCUMENT_SIGNING_KEY or DOCUMENT_SIGNING_KEY_FILE")
+)
+
+//LoadFromEnv builds a Manager from the environment:
+//
+//	JWT_SIGNING_KEY / JWT_SIGNING_KEY_FILE    PEM private key used for signing
+//	JWT_SIGNING_KEY_ID                        kid, defaults to the key thumbprint
+//	JWT_PREVIOUS_KEY / JWT_PREVIOUS_KEY_FILE  PEM key of the previous rotation
+//	JWT_PREVIOUS_KEY_ID                       kid of the previous key
+//	JWT_PREVIOUS_KEY_EXPIRES                  RFC 3339 end of the grace period
+//	JWT_KEY_GRACE_PERIOD                      grace period for Rotate, default 24h
+//	JWT_ISSUER, JWT_AUDIENCE                  expected iss and aud claims
+//	JWT_EPHEMERAL_KEY                         "true" to run without a signing key
+//
+//Without a signing key it fails, unless JWT_EPHEMERAL_KEY asks for a key
+//generated at startup. Tokens signed with it die with the process, so it
+//is only fit for development.
+func LoadFromEnv() (*Manager, error) {
+	grace := defaultGracePeriod
+	if v := os.Getenv("JWT_KEY_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		grace = d
+	}
+	current, err := loadKey("JWT_SIGNING_KEY", "JWT_SIGNING_KEY_FILE", "JWT_SIGNING_KEY_ID")
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		if os.Getenv("JWT_EPHEMERAL_KEY") != "true" {
+			return nil, ErrNoSigningKey
+		}
+		current, err = GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+	issuer := getenv("JWT_ISSUER", defaultIssuer)
+	audience := getenv("JWT_AUDIENCE", issuer)
+	m, err := NewManager(current, issuer, audience, grace)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := loadKey("JWT_PREVIOUS_KEY", "JWT_PREVIOUS_KEY_FILE", "JWT_PREVIOUS_KEY_ID")
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		notAfter := time.Now().Add(grace)
+		if v := os.Getenv("JWT_PREVIOUS_KEY_EXPIRES"); v != "" {
+			notAfter, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m.Retire(previous, notAfter)
+	}
+	return m, nil
+}
+
+//LoadDocumentKeysFromEnv builds the long-lived Manager that signs
+//certificates and share links, kept apart from the access token keys so
+//their rotation doesn't void documents handed out for years:
+//
+//	DOCUMENT_SIGNING_KEY / DOCUMENT_SIGNING_KEY_FILE  PEM private key used for signing
+//	DOCUMENT_SIGNING_KEY_ID                           kid, defaults to the key thumbprint
+//	DOCUMENT_PREVIOUS_KEYS_DIR                        every <kid>.pem in it verifies for good
+//
+//The iss and aud claims are those of LoadFromEnv. JWT_EPHEMERAL_KEY stands
+//in for a missing signing key the same way.
+func LoadDocumentKeysFromEnv() (*Manager, error) {
+	current, err := loadKey("DOCUMENT_SIGNING_KEY", "DOCUMENT_SIGNING_KEY_FILE", "DOCUMENT_SIGNING_KEY_ID")
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		if os.Getenv("JWT_EPHEMERAL_KEY") != "true" {
+			return nil, ErrNoDocumentSigningKey
+		}
+		current, err = GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+	issuer := getenv("JWT_ISSUER", defaultIssuer)
+	m, err := NewLongLivedManager(current, issuer, getenv("JWT_AUDIENCE", issuer))
+	if err != nil {
+		return nil, err
+	}
+	dir := os.Getenv("DOCUMENT_PREVIOUS_KEYS_DIR")
+	if dir == "" {
+		return m, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		previous, err := LoadPEMFile(path, strings.TrimSuffix(filepath.Base(path), ".pem"))
+		if err != nil {
+			return nil, err
+		}
+		m.Retire(previous, time.Time{})
+	}
+	return m, nil
+}
+
+func loadKey(inlineVar string, fileVar string, idVar string) (*Key, error) {
+	id := os.Getenv(idVar)
+	if pem := os.Getenv(inlineVar); pem != "" {
+		return ParsePEM([]byte(pem), id)
+	}
+	if path := os.Getenv(fileVar); path != "" {
+		return LoadPEMFile(path, id)
+	}
+	return nil, nil
+}
+
+func getenv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,32 @@
+package keys
+
+import "testing"
+
+func TestLoadFromEnvRequiresSigningKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		ephemeral string
+		wantErr   error
+	}{
+		{name: "no key", wantErr: ErrNoSigningKey},
+		{name: "ephemeral key requested", ephemeral: "true"},
+		{name: "unrecognized flag value", ephemeral: "1", wantErr: ErrNoSigningKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SIGNING_KEY", "")
+			t.Setenv("JWT_SIGNING_KEY_FILE", "")
+			t.Setenv("JWT_PREVIOUS_KEY", "")
+			t.Setenv("JWT_PREVIOUS_KEY_FILE", "")
+			t.Setenv("JWT_EPHEMERAL_KEY", tt.ephemeral)
+
+			m, err := LoadFromEnv()
+			if err != tt.wantErr {
+				t.Fatalf("LoadFromEnv() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && m == nil {
+				t.Fatal("LoadFromEnv() returned no manager")
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrNoPEMBlock        = errors.New("no PEM block found")
+	ErrUnsupportedKey    = errors.New("unsupported key type, expected RSA or ECDSA P-256/P-384")
+	ErrPublicKeyOnly     = errors.New("key has no private part and can only verify")
+	ErrUnknownKeyID      = errors.New("unknown key id")
+	ErrKeyRetired        = errors.New("key has been retired")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match key")
+)
+
+//Key is a signing or verification key identified by its kid
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	//NotAfter is zero for the active key. Retired keys are accepted for
+	//verification until NotAfter, after which they are dropped, or for good
+	//when it is zero.
+	NotAfter time.Time
+}
+
+//ParsePEM reads an RSA or ECDSA key, private (PKCS#1, PKCS#8, SEC 1) or
+//public (PKIX). When id is empty the RFC 7638 thumbprint is used as kid.
+func ParsePEM(data []byte, id string) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(parsed, id)
+}
+
+//LoadPEMFile reads a key from a PEM encoded file
+func LoadPEMFile(path string, id string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePEM(data, id)
+}
+
+//NewKey wraps a parsed private or public key and picks the matching
+//signing method: RS256 for RSA, ES256/ES384 for ECDSA
+func NewKey(k interface{}, id string) (*Key, error) {
+	key := &Key{ID: id}
+	switch v := k.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public = v, &v.PublicKey
+	case *ecdsa.PrivateKey:
+		key.Private, key.Public = v, &v.PublicKey
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		key.Public = v
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		default:
+			return nil, ErrUnsupportedKey
+		}
+	}
+	if key.ID == "" {
+		key.ID = key.Thumbprint()
+	}
+	return key, nil
+}
+
+//GenerateKey creates an in-memory ES256 key. Tokens signed with it do not
+//survive a restart, so it is only meant for local development.
+func GenerateKey() (*Key, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(private, "")
+}
+
+//JWK is the public part of a key in RFC 7517 format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+//JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *Key) JWK() JWK {
+	jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeInt(pub.N, 0)
+		jwk.E = encodeInt(big.NewInt(int64(pub.E)), 0)
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encodeInt(pub.X, size)
+		jwk.Y = encodeInt(pub.Y, size)
+	}
+	return jwk
+}
+
+//Thumbprint is the RFC 7638 SHA-256 thumbprint of the public key
+func (k *Key) Thumbprint() string {
+	jwk := k.JWK()
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeInt(n *big.Int, size int) string {
+	data := n.Bytes()
+	if len(data) < size {
+		padded := make([]byte, size)
+		copy(padded[size-len(data):], data)
+		data = padded
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+//PublicKey decodes an RSA or EC (P-256/P-384) JWK, e.g. one published by an
+//identity provider
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+		x, err := decodeInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,170 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//Manager signs tokens with the active key and verifies tokens signed by
+//the active key or by a retired key that is still inside its grace period
+type Manager struct {
+	mu       sync.RWMutex
+	current  *Key
+	retired  []*Key
+	issuer   string
+	audience string
+	grace    time.Duration
+	//permanent keeps rotated keys verifying for good
+	permanent bool
+}
+
+func NewManager(current *Key, issuer string, audience string, grace time.Duration) (*Manager, error) {
+	if current.Private == nil {
+		return nil, ErrPublicKeyOnly
+	}
+	return &Manager{
+		current:  current,
+		issuer:   issuer,
+		audience: audience,
+		grace:    grace,
+	}, nil
+}
+
+//NewLongLivedManager is for tokens meant to verify for years, like
+//certificates. Rotate retires the previous key without an end, so every
+//token it signed keeps verifying.
+func NewLongLivedManager(current *Key, issuer string, audience string) (*Manager, error) {
+	m, err := NewManager(current, issuer, audience, 0)
+	if err != nil {
+		return nil, err
+	}
+	m.permanent = true
+	return m, nil
+}
+
+//Retire registers a verification-only key that is accepted until
+//notAfter, or for good when notAfter is zero
+func (m *Manager) Retire(k *Key, notAfter time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k.NotAfter = notAfter
+	m.retired = append(m.retired, k)
+}
+
+//Rotate makes next the signing key. The previous signing key keeps
+//verifying tokens for the grace period so sessions are not cut off.
+func (m *Manager) Rotate(next *Key) error {
+	if next.Private == nil {
+		return ErrPublicKeyOnly
+	}
+	m.mu.Lock()
+	previous := m.current
+	m.current = next
+	m.mu.Unlock()
+	if m.permanent {
+		m.Retire(previous, time.Time{})
+		return nil
+	}
+	m.Retire(previous, time.Now().Add(m.grace))
+	return nil
+}
+
+func (m *Manager) Issuer() string {
+	return m.issuer
+}
+
+func (m *Manager) Audience() string {
+	return m.audience
+}
+
+//Sign adds the registered claims (iss, aud, iat, nbf, exp as numeric
+//timestamps) and signs the token with the active key under its kid. iat
+//keeps microseconds so it can be compared with revocation times.
+func (m *Manager) Sign(claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	m.mu.RLock()
+	key := m.current
+	m.mu.RUnlock()
+	now := time.Now()
+	claims["iss"] = m.issuer
+	claims["aud"] = m.audience
+	claims["iat"] = float64(now.UnixNano()/int64(time.Microsecond)) / 1e6
+	claims["nbf"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+//IssuedAt returns the iat claim of a token made by Sign
+func IssuedAt(claims jwt.MapClaims) time.Time {
+	iat, _ := claims["iat"].(float64)
+	return time.Unix(0, int64(math.Round(iat*1e6))*int64(time.Microsecond))
+}
+
+//Parse verifies the signature, exp, nbf, iss and aud of a token
+func (m *Manager) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("token has no expiry")
+	}
+	if !claims.VerifyIssuer(m.issuer, true) {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if !claims.VerifyAudience(m.audience, true) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+	return claims, nil
+}
+
+func (m *Manager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := m.lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrAlgorithmMismatch
+	}
+	return key.Public, nil
+}
+
+func (m *Manager) lookup(kid string) (*Key, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if kid == m.current.ID {
+		return m.current, nil
+	}
+	for _, k := range m.retired {
+		if k.ID != kid {
+			continue
+		}
+		if !k.NotAfter.IsZero() && time.Now().After(k.NotAfter) {
+			return nil, ErrKeyRetired
+		}
+		return k, nil
+	}
+	return nil, ErrUnknownKeyID
+}
+
+//JWKS lists the public keys that currently verify tokens
+func (m *Manager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	set := JWKSet{Keys: []JWK{m.current.JWK()}}
+	now := time.Now()
+	for _, k := range m.retired {
+		if k.NotAfter.IsZero() || now.Before(k.NotAfter) {
+			set.Keys = append(set.Keys, k.JWK())
+		}
+	}
+	return set
+}
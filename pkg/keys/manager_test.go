@@ -0,0 +1,79 @@
+package keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestIssuedAtKeepsMicroseconds(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(key, "issuer", "audience", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Truncate(time.Microsecond)
+	token, err := m.Sign(jwt.MapClaims{"sub": "u1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+
+	claims, err := m.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse() rejected a fresh token: %v", err)
+	}
+	issuedAt := IssuedAt(claims)
+	if issuedAt.Before(before) || issuedAt.After(after) {
+		t.Errorf("IssuedAt() = %v, want between %v and %v", issuedAt, before, after)
+	}
+	if issuedAt.Truncate(time.Microsecond) != issuedAt {
+		t.Errorf("IssuedAt() = %v, want microsecond precision", issuedAt)
+	}
+}
+
+func TestRotatedKeysVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager func(key *Key) (*Manager, error)
+		wantErr bool
+	}{
+		{name: "access token keys expire", manager: func(key *Key) (*Manager, error) { return NewManager(key, "issuer", "audience", 0) }, wantErr: true},
+		{name: "long-lived keys stay", manager: func(key *Key) (*Manager, error) { return NewLongLivedManager(key, "issuer", "audience") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := tt.manager(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := m.Sign(jwt.MapClaims{"sub": "u1"}, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				next, err := GenerateKey()
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = m.Rotate(next)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = m.Parse(token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
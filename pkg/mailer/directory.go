@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//DirectoryMailer writes every message to an .eml file instead of sending
+//it, for local development and manual testing
+type DirectoryMailer struct {
+	dir  string
+	from string
+}
+
+func NewDirectoryMailer(dir string, from string) (*DirectoryMailer, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &DirectoryMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (m *DirectoryMailer) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), now.UnixNano()%1e9)
+	return ioutil.WriteFile(filepath.Join(m.dir, name), msg.format(m.from, now), 0600)
+}
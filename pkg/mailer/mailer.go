@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+//Mailer delivers plain text emails
+type Mailer interface {
+	Send(m Message) error
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+//format renders the message as RFC 5322 text with CRLF line endings
+func (m Message) format(from string, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+//validate rejects header injection through the recipient or subject
+func (m Message) validate() error {
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("invalid header in message to %q", m.To)
+	}
+	return nil
+}
+
+//LoadFromEnv picks the mailer from MAILER:
+//
+//	smtp       SMTP_HOST, SMTP_PORT (default 25), SMTP_USERNAME, SMTP_PASSWORD
+//	directory  MAIL_DIR (default ./mail), one .eml file per message
+//
+//MAIL_FROM sets the sender. Without MAILER mails are written to MAIL_DIR.
+func LoadFromEnv() (Mailer, error) {
+	from := getenv("MAIL_FROM", "no-reply@order-validation.local")
+	switch strings.ToLower(os.Getenv("MAILER")) {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("MAILER=smtp requires SMTP_HOST")
+		}
+		return NewSMTPMailer(host, getenv("SMTP_PORT", "25"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	case "", "directory", "dir":
+		return NewDirectoryMailer(getenv("MAIL_DIR", "mail"), from)
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", os.Getenv("MAILER"))
+	}
+}
+
+func getenv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
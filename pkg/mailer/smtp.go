@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"time"
+)
+
+//SMTPMailer sends through an SMTP relay. Authentication is skipped when no
+//username is set, which is what local stand-ins like MailHog expect.
+type SMTPMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		host: host,
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, msg.format(m.from, time.Now()))
+}
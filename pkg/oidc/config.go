@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+//LoadFromEnv configures single sign-on from the environment:
+//
+//	OIDC_ISSUER          issuer URL, SSO is disabled when empty
+//	OIDC_CLIENT_ID       client registered at the provider
+//	OIDC_CLIENT_SECRET   its secret, may be empty for public clients
+//	OIDC_REDIRECT_URL    the /login/oidc/callback URL of this service
+//	OIDC_SCOPES          space separated, default "openid email profile groups"
+//	OIDC_GROUPS_CLAIM    ID token claim with the groups, default "groups"
+func LoadFromEnv() (*Provider, error) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil, nil
+	}
+	config := Config{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(getenv("OIDC_SCOPES", "openid email profile groups")),
+		GroupsClaim:  getenv("OIDC_GROUPS_CLAIM", "groups"),
+	}
+	return NewProvider(config, nil)
+}
+
+func getenv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,265 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"order-validation-v2/pkg/keys"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrNonceMismatch = errors.New("id token nonce does not match")
+	ErrUnknownKey    = errors.New("id token signed with an unknown key")
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	//GroupsClaim is the ID token claim listing the user's groups
+	GroupsClaim string
+}
+
+//discovery is the subset of the provider metadata the login flow uses
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+//Provider runs the authorization code flow with PKCE against an OpenID
+//Connect provider and validates the ID tokens it returns
+type Provider struct {
+	config   Config
+	client   *http.Client
+	metadata discovery
+	mu       sync.RWMutex
+	keys     map[string]interface{}
+	//fetched throttles JWKS refreshes triggered by unknown kids
+	fetched time.Time
+}
+
+//NewProvider loads the provider metadata from the issuer's discovery
+//document. Any issuer works, including a local stand-in IdP.
+func NewProvider(config Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	p := &Provider{
+		config: config,
+		client: client,
+		keys:   map[string]interface{}{},
+	}
+	err := p.getJSON(strings.TrimSuffix(config.Issuer, "/")+"/.well-known/openid-configuration", &p.metadata)
+	if err != nil {
+		return nil, err
+	}
+	if p.metadata.Issuer != config.Issuer {
+		return nil, fmt.Errorf("issuer %q in discovery document does not match %q", p.metadata.Issuer, config.Issuer)
+	}
+	return p, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+func (p *Provider) GroupsClaim() string {
+	return p.config.GroupsClaim
+}
+
+//AuthCodeURL is where the user agent is sent to sign in
+func (p *Provider) AuthCodeURL(state string, nonce string, codeChallenge string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+//Exchange trades the authorization code for the ID token
+func (p *Provider) Exchange(code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequest("POST", p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, body)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	err = json.Unmarshal(body, &tokens)
+	if err != nil {
+		return "", err
+	}
+	if tokens.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return tokens.IDToken, nil
+}
+
+//VerifyIDToken checks the signature, iss, aud, azp, exp and nonce of an
+//ID token and returns its claims
+func (p *Provider) VerifyIDToken(idToken string, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384"}}
+	_, err := parser.ParseWithClaims(idToken, claims, p.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("id token has no expiry")
+	}
+	if !claims.VerifyIssuer(p.config.Issuer, true) {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if !p.audienceMatches(claims) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+	if claims["nonce"] != nonce {
+		return nil, ErrNonceMismatch
+	}
+	return claims, nil
+}
+
+//audienceMatches accepts aud as a string or a list, jwt-go only handles
+//the former
+func (p *Provider) audienceMatches(claims jwt.MapClaims) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == p.config.ClientID
+	case []interface{}:
+		found := false
+		for _, a := range aud {
+			if a == p.config.ClientID {
+				found = true
+			}
+		}
+		if len(aud) > 1 && claims["azp"] != p.config.ClientID {
+			return false
+		}
+		return found
+	}
+	return false
+}
+
+func (p *Provider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	fetched := p.fetched
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	//the provider may have rotated its keys
+	if time.Since(fetched) < time.Minute {
+		return nil, ErrUnknownKey
+	}
+	err := p.refreshKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.RLock()
+	key, ok = p.keys[kid]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (p *Provider) refreshKeys() error {
+	var set keys.JWKSet
+	err := p.getJSON(p.metadata.JWKSURI, &set)
+	if err != nil {
+		return err
+	}
+	parsed := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		parsed[jwk.Kid] = key
+	}
+	p.mu.Lock()
+	p.keys = parsed
+	p.fetched = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *Provider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+//RandomString returns a URL safe random value for state, nonce and the
+//PKCE verifier
+func RandomString() (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+//CodeChallenge is the S256 PKCE challenge of verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
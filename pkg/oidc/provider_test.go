@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"order-validation-v2/pkg/keys"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//standInIdP is a minimal provider serving discovery, JWKS and a token
+//endpoint that answers with whatever ID token the test sets
+type standInIdP struct {
+	server   *httptest.Server
+	key      *keys.Key
+	idToken  string
+	verifier string
+}
+
+func newStandInIdP(t *testing.T) *standInIdP {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keys.NewKey(private, "idp-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &standInIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(keys.JWKSet{Keys: []keys.JWK{idp.key.JWK()}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != "good-code" || r.PostForm.Get("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		idp.verifier = r.PostForm.Get("code_verifier")
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *standInIdP) sign(t *testing.T, key *keys.Key, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (idp *standInIdP) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"sub":   "subject-1",
+		"aud":   "client-1",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "nonce-1",
+		"email": "user@example.com",
+	}
+}
+
+func TestNewProviderRejectsMismatchedIssuer(t *testing.T) {
+	idp := newStandInIdP(t)
+	_, err := NewProvider(Config{Issuer: idp.server.URL + "/other"}, nil)
+	if err == nil {
+		t.Fatal("NewProvider() accepted a discovery document for another issuer")
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newStandInIdP(t)
+	p, err := NewProvider(Config{Issuer: idp.server.URL, ClientID: "client-1", RedirectURL: "https://app/callback", Scopes: []string{"openid", "email"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(p.AuthCodeURL("state-1", "nonce-1", CodeChallenge("verifier-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-1",
+		"redirect_uri":          "https://app/callback",
+		"scope":                 "openid email",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        CodeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	idp := newStandInIdP(t)
+	other, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		code    string
+		claims  func(jwt.MapClaims)
+		key     *keys.Key
+		nonce   string
+		wantErr bool
+	}{
+		{name: "valid token", code: "good-code", nonce: "nonce-1"},
+		{name: "audience list with azp", code: "good-code", nonce: "nonce-1", claims: func(c jwt.MapClaims) {
+			c["aud"] = []string{"client-1", "other"}
+			c["azp"] = "client-1"
+		}},
+		{name: "rejected code", code: "bad-code", nonce: "nonce-1", wantErr: true},
+		{name: "wrong nonce", code: "good-code", nonce: "nonce-2", wantErr: true},
+		{name: "wrong audience", code: "good-code", nonce: "nonce-1", wantErr: true, claims: func(c jwt.MapClaims) { c["aud"] = "other" }},
+		{name: "audience list without azp", code: "good-code", nonce: "nonce-1", wantErr: true, claims: func(c jwt.MapClaims) {
+			c["aud"] = []string{"client-1", "other"}
+		}},
+		{name: "wrong issuer", code: "good-code", nonce: "nonce-1", wantErr: true, claims: func(c jwt.MapClaims) { c["iss"] = "https://evil" }},
+		{name: "expired", code: "good-code", nonce: "nonce-1", wantErr: true, claims: func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+		}},
+		{name: "no expiry", code: "good-code", nonce: "nonce-1", wantErr: true, claims: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "signed with an unknown key", code: "good-code", nonce: "nonce-1", key: other, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(Config{Issuer: idp.server.URL, ClientID: "client-1", ClientSecret: "secret"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims := idp.claims()
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			key := tt.key
+			if key == nil {
+				key = idp.key
+			}
+			idp.idToken = idp.sign(t, key, claims)
+
+			idToken, err := p.Exchange(tt.code, "verifier-1")
+			if err == nil {
+				if idp.verifier != "verifier-1" {
+					t.Errorf("code_verifier = %q, want verifier-1", idp.verifier)
+				}
+				var verified jwt.MapClaims
+				verified, err = p.VerifyIDToken(idToken, tt.nonce)
+				if err == nil && verified["sub"] != "subject-1" {
+					t.Errorf("sub = %v, want subject-1", verified["sub"])
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}